@@ -1,19 +1,24 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/sgl-disasur/api/internal/delivery/http"
 	"github.com/sgl-disasur/api/internal/delivery/http/handler"
+	"github.com/sgl-disasur/api/internal/domain"
 	"github.com/sgl-disasur/api/internal/infrastructure/config"
 	"github.com/sgl-disasur/api/internal/infrastructure/database"
 	"github.com/sgl-disasur/api/internal/infrastructure/logger"
+	"github.com/sgl-disasur/api/internal/infrastructure/security"
 	"github.com/sgl-disasur/api/internal/repository/postgres"
 	"github.com/sgl-disasur/api/internal/usecase/auth"
 	"github.com/sgl-disasur/api/internal/usecase/fleet"
 	"github.com/sgl-disasur/api/internal/usecase/inventory"
 	"github.com/sgl-disasur/api/internal/usecase/orders"
+	"github.com/sgl-disasur/api/internal/usecase/products"
 	"github.com/sgl-disasur/api/internal/usecase/reception"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -43,6 +48,9 @@ import (
 func main() {
 	// 1. Cargar configuración
 	cfg := config.Load()
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
 
 	// 2. Inicializar logger
 	logger.Init(cfg.LogLevel)
@@ -60,11 +68,29 @@ func main() {
 
 	logger.Log.Info("Database connection established")
 
+	jwtKeys, err := security.LoadKeySet(cfg.JWTSigningKeyFile, cfg.JWTVerifyKeyFiles, cfg.JWTHMACSecret())
+	if err != nil {
+		log.Fatalf("Failed to load JWT keys: %v", err)
+	}
+	if kid := jwtKeys.KeyID(); kid != "" {
+		logger.Log.Infof("JWT signing key: %s", kid)
+	} else {
+		logger.Log.Info("JWT signing with HS256 shared secret")
+	}
+
 	// 4. Inicializar repositorios
 	userRepo := postgres.NewUserRepository(db.DB)
 	sessionRepo := postgres.NewSessionRepository(db.DB)
+	passwordHistoryRepo := postgres.NewPasswordHistoryRepository(db.DB)
+	loginAttemptRepo := postgres.NewLoginAttemptRepository(db.DB)
+	recoveryCodeRepo := postgres.NewRecoveryCodeRepository(db.DB)
+	rolePermissionRepo := postgres.NewRolePermissionRepository(db.DB)
+	userAssignmentRepo := postgres.NewUserAssignmentRepository(db.DB)
+	warehouseRepo := postgres.NewWarehouseRepository(db.DB)
 	auditRepo := postgres.NewAuditRepository(db.DB)
 	productRepo := postgres.NewProductRepository(db.DB)
+	productPriceRepo := postgres.NewProductPriceRepository(db.DB)
+	productPackagingRepo := postgres.NewProductPackagingRepository(db.DB)
 	supplierRepo := postgres.NewSupplierRepository(db.DB)
 	receptionOrderRepo := postgres.NewReceptionOrderRepository(db.DB)
 	receptionLineRepo := postgres.NewReceptionLineRepository(db.DB)
@@ -78,19 +104,62 @@ func main() {
 	vehicleRepo := postgres.NewVehicleRepository(db.DB)
 	driverRepo := postgres.NewDriverRepository(db.DB)
 	routeRepo := postgres.NewRouteRepository(db.DB)
+	routeStopRepo := postgres.NewRouteStopRepository(db.DB)
+	driverAbsenceRepo := postgres.NewDriverAbsenceRepository(db.DB)
+	fleetDocumentRepo := postgres.NewFleetDocumentRepository(db.DB)
+	maintenancePlanRepo := postgres.NewMaintenancePlanRepository(db.DB)
+	odometerRepo := postgres.NewOdometerReadingRepository(db.DB)
 	maintenanceRepo := postgres.NewVehicleMaintenanceRepository(db.DB)
+	fuelRepo := postgres.NewFuelLoadRepository(db.DB)
 	checklistRepo := postgres.NewPreDepartureChecklistRepository(db.DB)
+	checklistTemplateRepo := postgres.NewChecklistTemplateRepository(db.DB)
+	positionRepo := postgres.NewVehiclePositionRepository(db.DB)
 
 	// 5. Inicializar casos de uso
 	// Auth
-	loginUseCase := auth.NewLoginUseCase(
-		userRepo,
-		sessionRepo,
-		auditRepo,
-		cfg.JWTSecretKey,
-		cfg.JWTExpirationHours,
+	sessionIdle := time.Duration(cfg.SessionTimeoutMinutes) * time.Minute
+	totpRequiredRoles := make(map[domain.UserRole]bool)
+	for _, role := range cfg.TOTPRequiredRoles {
+		totpRequiredRoles[domain.UserRole(role)] = true
+	}
+	tokenConfig := auth.TokenConfig{
+		Keys:              jwtKeys,
+		AccessTTL:         time.Duration(cfg.AccessTokenMinutes) * time.Minute,
+		RefreshTTL:        time.Duration(cfg.RefreshTokenHours) * time.Hour,
+		IdleTimeout:       sessionIdle,
+		PasswordMaxAge:    time.Duration(cfg.PasswordMaxAgeDays) * 24 * time.Hour,
+		TOTPRequiredRoles: totpRequiredRoles,
+	}
+	passwordManager := auth.NewPasswordManager(
+		security.PasswordPolicy{MinLength: cfg.PasswordMinLength, MinClasses: cfg.PasswordMinClasses},
+		cfg.PasswordHistory,
+		passwordHistoryRepo,
 	)
-	registerUserUseCase := auth.NewRegisterUserUseCase(userRepo, auditRepo)
+	lockoutPolicy := domain.LockoutPolicy{
+		MaxAttempts:   cfg.LoginMaxAttempts,
+		BaseCooldown:  time.Duration(cfg.LockoutBaseMinutes) * time.Minute,
+		MaxCooldown:   time.Duration(cfg.LockoutMaxMinutes) * time.Minute,
+		IPMaxFailures: cfg.LoginIPMaxFailures,
+		IPWindow:      time.Duration(cfg.LoginIPWindowMinutes) * time.Minute,
+	}
+	loginUseCase := auth.NewLoginUseCase(userRepo, sessionRepo, auditRepo, loginAttemptRepo, recoveryCodeRepo, tokenConfig, lockoutPolicy, cfg.TOTPEncryptionKey)
+	refreshTokenUseCase := auth.NewRefreshTokenUseCase(userRepo, sessionRepo, auditRepo, tokenConfig)
+	registerUserUseCase := auth.NewRegisterUserUseCase(userRepo, auditRepo, passwordManager)
+	logoutUseCase := auth.NewLogoutUseCase(sessionRepo, auditRepo)
+	revokeSessionsUseCase := auth.NewRevokeSessionsUseCase(userRepo, sessionRepo, auditRepo)
+	listUsersUseCase := auth.NewListUsersUseCase(userRepo)
+	manageUserUseCase := auth.NewManageUserUseCase(userRepo, sessionRepo, auditRepo, recoveryCodeRepo, passwordManager)
+	changePasswordUseCase := auth.NewChangePasswordUseCase(userRepo, sessionRepo, auditRepo, loginAttemptRepo, passwordManager, tokenConfig, lockoutPolicy)
+	twoFactorUseCase := auth.NewTwoFactorUseCase(userRepo, sessionRepo, auditRepo, recoveryCodeRepo, tokenConfig, cfg.TOTPEncryptionKey)
+	permissionService := auth.NewPermissionService(rolePermissionRepo, auditRepo, time.Duration(cfg.PermissionCacheSeconds)*time.Second)
+	scopeResolver := auth.NewScopeResolver(permissionService, userAssignmentRepo)
+	userAssignmentUseCase := auth.NewUserAssignmentUseCase(userRepo, warehouseRepo, userAssignmentRepo, auditRepo)
+
+	// Products
+	importProductsUC := products.NewImportProductsUseCase(productRepo, auditRepo)
+	updateProductUC := products.NewUpdateProductUseCase(productRepo, auditRepo)
+	deactivateProductUC := products.NewDeactivateProductUseCase(productRepo, auditRepo)
+	setPackagingUC := products.NewSetPackagingUseCase(productRepo, productPackagingRepo, auditRepo)
 
 	// Reception
 	createReceptionOrderUC := reception.NewCreateReceptionOrderUseCase(
@@ -98,6 +167,8 @@ func main() {
 		receptionLineRepo,
 		supplierRepo,
 		productRepo,
+		productPackagingRepo,
+		warehouseRepo,
 		auditRepo,
 	)
 
@@ -105,20 +176,33 @@ func main() {
 		receptionOrderRepo,
 		receptionLineRepo,
 		receptionDiscrepancyRepo,
+		productRepo,
+		productPackagingRepo,
 		auditRepo,
 	)
 
 	// Inventory
 	getStockUC := inventory.NewGetStockUseCase(inventoryRepo, productRepo)
 	getFEFOLotsUC := inventory.NewGetFEFOLotsUseCase(inventoryRepo)
+	createWarehouseUC := inventory.NewCreateWarehouseUseCase(warehouseRepo, auditRepo)
 	registerDamageUC := inventory.NewRegisterDamageUseCase(inventoryRepo, inventoryMovementRepo, auditRepo)
 	cycleCountUC := inventory.NewPerformCycleCountUseCase(
 		cycleCountRepo,
 		inventoryRepo,
 		inventoryMovementRepo,
 		productRepo,
+		productPackagingRepo,
 		auditRepo,
 	)
+	scanUC := inventory.NewScanUseCase(
+		productRepo,
+		inventoryRepo,
+		receptionOrderRepo,
+		receptionLineRepo,
+		cycleCountRepo,
+		orderRepo,
+		orderLineRepo,
+	)
 
 	// Orders
 	createOrderUC := orders.NewCreateOrderUseCase(
@@ -126,19 +210,88 @@ func main() {
 		orderLineRepo,
 		customerRepo,
 		productRepo,
+		productPriceRepo,
+		productPackagingRepo,
 		inventoryRepo,
+		vehicleRepo,
 		auditRepo,
 	)
+	assignCustomerUC := orders.NewAssignCustomerUseCase(customerRepo, userRepo, auditRepo)
 
 	// Fleet
-	assignRouteUC := fleet.NewAssignRouteUseCase(routeRepo, vehicleRepo, driverRepo, orderRepo, auditRepo)
+	routePlanner := fleet.NewRoutePlanner(cfg.DepotLatitude, cfg.DepotLongitude, cfg.RouteAvgSpeedKmh, cfg.StopServiceMinutes)
+	documentValidator := fleet.NewDocumentValidator(fleetDocumentRepo)
+	driverScheduler := fleet.NewDriverScheduler(
+		driverRepo,
+		driverAbsenceRepo,
+		routeRepo,
+		documentValidator,
+		cfg.DriverMaxDailyHours,
+		cfg.DriverMaxWeeklyHours,
+	)
+	assignRouteUC := fleet.NewAssignRouteUseCase(
+		routeRepo,
+		vehicleRepo,
+		orderRepo,
+		customerRepo,
+		driverScheduler,
+		documentValidator,
+		auditRepo,
+	)
+	consolidateRouteUC := fleet.NewConsolidateRouteUseCase(
+		routeRepo,
+		vehicleRepo,
+		orderRepo,
+		customerRepo,
+		routePlanner,
+		driverScheduler,
+		documentValidator,
+		auditRepo,
+	)
+	loadPlanUC := fleet.NewLoadPlanUseCase(
+		routeRepo,
+		routeStopRepo,
+		vehicleRepo,
+		orderRepo,
+		orderLineRepo,
+		customerRepo,
+		productRepo,
+		productPackagingRepo,
+	)
 	generateInvoiceUC := fleet.NewGenerateInvoiceUseCase(routeRepo, orderRepo, orderLineRepo, customerRepo, auditRepo)
 	registerMaintenanceUC := fleet.NewRegisterMaintenanceUseCase(maintenanceRepo, vehicleRepo, auditRepo)
-	preDepartureCheckUC := fleet.NewPerformPreDepartureCheckUseCase(checklistRepo, routeRepo, vehicleRepo, auditRepo)
+	closeMaintenanceUC := fleet.NewCloseMaintenanceUseCase(maintenanceRepo, vehicleRepo, routeRepo, maintenancePlanRepo, auditRepo)
+	recordOdometerUC := fleet.NewRecordOdometerUseCase(vehicleRepo, odometerRepo)
+	maintenancePlanUC := fleet.NewSetMaintenancePlanUseCase(maintenancePlanRepo, auditRepo)
+	upcomingMaintenanceUC := fleet.NewUpcomingMaintenanceUseCase(vehicleRepo)
+	registerFuelLoadUC := fleet.NewRegisterFuelLoadUseCase(fuelRepo, vehicleRepo, auditRepo)
+	vehicleFuelUC := fleet.NewVehicleFuelUseCase(fuelRepo, vehicleRepo)
+	fleetCostReportUC := fleet.NewFleetCostReportUseCase(vehicleRepo, fuelRepo, maintenanceRepo, odometerRepo, routeRepo)
+	preDepartureCheckUC := fleet.NewPerformPreDepartureCheckUseCase(checklistRepo, checklistTemplateRepo, routeRepo, vehicleRepo, maintenanceRepo, auditRepo)
+	checklistTemplateUC := fleet.NewCreateChecklistTemplateUseCase(checklistTemplateRepo, auditRepo)
+	startRouteUC := fleet.NewStartRouteUseCase(routeRepo, routeStopRepo, orderRepo, checklistRepo, vehicleRepo, driverRepo, odometerRepo, auditRepo)
+	routeTrackingUC := fleet.NewRouteTrackingUseCase(routeRepo, routeStopRepo, vehicleRepo, positionRepo, routePlanner)
+	recordPositionsUC := fleet.NewRecordPositionsUseCase(routeRepo, driverRepo, positionRepo, routeTrackingUC)
+	setDriverScheduleUC := fleet.NewSetDriverScheduleUseCase(driverRepo, auditRepo)
+	driverAbsenceUC := fleet.NewRegisterDriverAbsenceUseCase(driverRepo, driverAbsenceRepo, auditRepo)
+	registerDocumentUC := fleet.NewRegisterDocumentUseCase(fleetDocumentRepo, driverRepo, vehicleRepo, auditRepo)
+	documentAlertsUC := fleet.NewDocumentAlertsUseCase(fleetDocumentRepo, driverRepo, vehicleRepo, cfg.DocumentAlertDays)
 
 	// 6. Inicializar handlers
-	authHandler := handler.NewAuthHandler(loginUseCase, registerUserUseCase)
-	productHandler := handler.NewProductHandler(productRepo)
+	authHandler := handler.NewAuthHandler(loginUseCase, registerUserUseCase, logoutUseCase, revokeSessionsUseCase, refreshTokenUseCase, changePasswordUseCase, twoFactorUseCase)
+	userHandler := handler.NewUserHandler(registerUserUseCase, listUsersUseCase, manageUserUseCase, userAssignmentUseCase, userRepo)
+	roleHandler := handler.NewRoleHandler(permissionService)
+	keysHandler := handler.NewKeysHandler(jwtKeys)
+	warehouseHandler := handler.NewWarehouseHandler(createWarehouseUC, warehouseRepo)
+	productHandler := handler.NewProductHandler(
+		importProductsUC,
+		updateProductUC,
+		deactivateProductUC,
+		setPackagingUC,
+		productRepo,
+		productPriceRepo,
+		productPackagingRepo,
+	)
 	receptionHandler := handler.NewReceptionHandler(
 		createReceptionOrderUC,
 		blindCountUC,
@@ -152,22 +305,49 @@ func main() {
 		getFEFOLotsUC,
 		registerDamageUC,
 		cycleCountUC,
+		scanUC,
 	)
 	orderHandler := handler.NewOrderHandler(
 		createOrderUC,
+		assignCustomerUC,
 		orderRepo,
 		orderLineRepo,
 		customerRepo,
 	)
 	fleetHandler := handler.NewFleetHandler(
 		assignRouteUC,
+		consolidateRouteUC,
+		loadPlanUC,
 		generateInvoiceUC,
 		registerMaintenanceUC,
 		preDepartureCheckUC,
+		setDriverScheduleUC,
+		driverAbsenceUC,
+		driverScheduler,
+		registerDocumentUC,
+		documentAlertsUC,
+		closeMaintenanceUC,
+		recordOdometerUC,
+		maintenancePlanUC,
+		upcomingMaintenanceUC,
+		registerFuelLoadUC,
+		vehicleFuelUC,
+		fleetCostReportUC,
+		checklistTemplateUC,
+		startRouteUC,
+		recordPositionsUC,
+		routeTrackingUC,
 		vehicleRepo,
 		driverRepo,
 		routeRepo,
+		routeStopRepo,
 		maintenanceRepo,
+		driverAbsenceRepo,
+		fleetDocumentRepo,
+		maintenancePlanRepo,
+		odometerRepo,
+		checklistRepo,
+		checklistTemplateRepo,
 	)
 
 	// File upload handler
@@ -180,20 +360,51 @@ func main() {
 	// 7. Configurar router
 	routerConfig := &http.RouterConfig{
 		AuthHandler:      authHandler,
+		UserHandler:      userHandler,
+		RoleHandler:      roleHandler,
+		KeysHandler:      keysHandler,
+		WarehouseHandler: warehouseHandler,
 		ProductHandler:   productHandler,
 		ReceptionHandler: receptionHandler,
 		InventoryHandler: inventoryHandler,
 		OrderHandler:     orderHandler,
 		FleetHandler:     fleetHandler,
 		FileHandler:      fileHandler,
-		SecretKey:        cfg.JWTSecretKey,
+		SessionRepo:      sessionRepo,
+		Permissions:      permissionService,
+		DataScope:        scopeResolver,
+		SessionIdle:      sessionIdle,
+		Keys:             jwtKeys,
 	}
 	router := http.SetupRouter(routerConfig)
+	// Sin proxies confiables ClientIP es la IP de la conexión: un X-Forwarded-For falso no evade el freno por IP
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
 
 	// Swagger Documentation
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	// 8. Iniciar servidor
+	// 8. Tareas en segundo plano
+	if cfg.SessionCleanupMinutes > 0 {
+		// Los intentos de login se conservan para auditoría, al menos lo que dura la ventana por IP
+		attemptRetention := time.Duration(cfg.AttemptRetentionDays) * 24 * time.Hour
+		if attemptRetention < lockoutPolicy.IPWindow {
+			attemptRetention = lockoutPolicy.IPWindow
+		}
+		sessionCleanup := auth.NewSessionCleanup(
+			sessionRepo,
+			loginAttemptRepo,
+			time.Duration(cfg.SessionCleanupMinutes)*time.Minute,
+			sessionIdle,
+			attemptRetention,
+		)
+		go sessionCleanup.Run(context.Background(), func(err error) {
+			logger.Log.Errorf("Session cleanup failed: %v", err)
+		})
+	}
+
+	// 9. Iniciar servidor
 	addr := fmt.Sprintf(":%s", cfg.Port)
 	logger.Log.Infof("Server starting on %s", addr)
 	logger.Log.Info("API Documentation available at /swagger/index.html")
@@ -6,7 +6,7 @@ $apiUrl = "http://localhost:8080/api/v1/auth/register"
 $jsonBody = '{
   "username": "admin",
   "email": "admin@sgl-disasur.com",
-  "password": "password123",
+  "password": "Disasur.2024",
   "role": "ADMIN_TI"
 }'
 
@@ -16,7 +16,7 @@ try {
     $response = Invoke-RestMethod -Uri $apiUrl -Method Post -Body $jsonBody -ContentType "application/json"
     Write-Host "`nUsuario creado exitosamente!" -ForegroundColor Green
     Write-Host "Username: admin" -ForegroundColor Yellow
-    Write-Host "Password: password123" -ForegroundColor Yellow
+    Write-Host "Password: Disasur.2024" -ForegroundColor Yellow
     Write-Host "`nID: $($response.user.id)" -ForegroundColor Gray
 }
 catch {
@@ -1,21 +1,43 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sgl-disasur/api/internal/domain"
 	"github.com/sgl-disasur/api/internal/usecase/auth"
 )
 
 type AuthHandler struct {
-	loginUseCase    *auth.LoginUseCase
-	registerUseCase *auth.RegisterUserUseCase
+	loginUseCase          *auth.LoginUseCase
+	registerUseCase       *auth.RegisterUserUseCase
+	logoutUseCase         *auth.LogoutUseCase
+	revokeSessionsUseCase *auth.RevokeSessionsUseCase
+	refreshUseCase        *auth.RefreshTokenUseCase
+	changePasswordUseCase *auth.ChangePasswordUseCase
+	twoFactorUseCase      *auth.TwoFactorUseCase
 }
 
-func NewAuthHandler(loginUC *auth.LoginUseCase, registerUC *auth.RegisterUserUseCase) *AuthHandler {
+func NewAuthHandler(
+	loginUC *auth.LoginUseCase,
+	registerUC *auth.RegisterUserUseCase,
+	logoutUC *auth.LogoutUseCase,
+	revokeSessionsUC *auth.RevokeSessionsUseCase,
+	refreshUC *auth.RefreshTokenUseCase,
+	changePasswordUC *auth.ChangePasswordUseCase,
+	twoFactorUC *auth.TwoFactorUseCase,
+) *AuthHandler {
 	return &AuthHandler{
-		loginUseCase:    loginUC,
-		registerUseCase: registerUC,
+		loginUseCase:          loginUC,
+		registerUseCase:       registerUC,
+		logoutUseCase:         logoutUC,
+		revokeSessionsUseCase: revokeSessionsUC,
+		refreshUseCase:        refreshUC,
+		changePasswordUseCase: changePasswordUC,
+		twoFactorUseCase:      twoFactorUC,
 	}
 }
 
@@ -26,7 +48,7 @@ type LoginRequest struct {
 
 // Login godoc
 // @Summary      Login de usuario
-// @Description  Autentica un usuario y retorna un JWT token
+// @Description  Autentica un usuario y retorna un JWT token. Si tiene verificación en dos pasos activa, retorna mfa_required y un mfa_token para /auth/2fa/verify.
 // @Tags         auth
 // @Accept       json
 // @Produce      json
@@ -34,6 +56,7 @@ type LoginRequest struct {
 // @Success      200         {object}  auth.LoginOutput
 // @Failure      400         {object}  map[string]string
 // @Failure      401         {object}  map[string]string
+// @Failure      429         {object}  map[string]string
 // @Router       /api/v1/auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req LoginRequest
@@ -50,6 +73,141 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 
 	result, err := h.loginUseCase.Execute(input)
+	if err != nil {
+		loginError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// VerifySecondFactor godoc
+// @Summary      Verificar segundo factor
+// @Description  Completa el login con el código de la app autenticadora o un código de recuperación y retorna los tokens de la sesión
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body      auth.VerifySecondFactorInput  true  "mfa_token del login y code o recovery_code"
+// @Success      200   {object}  auth.LoginOutput
+// @Failure      400   {object}  map[string]string
+// @Failure      401   {object}  map[string]string
+// @Failure      429   {object}  map[string]string
+// @Router       /api/v1/auth/2fa/verify [post]
+func (h *AuthHandler) VerifySecondFactor(c *gin.Context) {
+	var input auth.VerifySecondFactorInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+	if input.Code == "" && input.RecoveryCode == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Debe indicar code o recovery_code"})
+		return
+	}
+	input.IPAddress = c.ClientIP()
+	input.UserAgent = c.Request.UserAgent()
+
+	result, err := h.loginUseCase.VerifySecondFactor(input)
+	if err != nil {
+		loginError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// EnrollTOTP godoc
+// @Summary      Iniciar verificación en dos pasos
+// @Description  Genera el secreto TOTP y la URI otpauth:// para el código QR. Se activa al confirmar el primer código.
+// @Tags         auth
+// @Security     Bearer
+// @Produce      json
+// @Success      200  {object}  auth.TOTPEnrollOutput
+// @Failure      400  {object}  map[string]string
+// @Router       /api/v1/auth/2fa/enroll [post]
+func (h *AuthHandler) EnrollTOTP(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	result, err := h.twoFactorUseCase.Enroll(userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ConfirmTOTP godoc
+// @Summary      Confirmar verificación en dos pasos
+// @Description  Activa la verificación en dos pasos con el primer código de la app. Retorna los códigos de recuperación (se muestran una sola vez) y tokens nuevos.
+// @Tags         auth
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        body  body      auth.TOTPConfirmInput  true  "Código de la app autenticadora"
+// @Success      200   {object}  auth.TOTPConfirmOutput
+// @Failure      400   {object}  map[string]string
+// @Failure      401   {object}  map[string]string
+// @Router       /api/v1/auth/2fa/confirm [post]
+func (h *AuthHandler) ConfirmTOTP(c *gin.Context) {
+	var input auth.TOTPConfirmInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	userIDStr, _ := c.Get("user_id")
+	input.UserID, _ = uuid.Parse(userIDStr.(string))
+	input.Token = c.GetString("token")
+	input.IPAddress = c.ClientIP()
+	input.UserAgent = c.Request.UserAgent()
+
+	result, err := h.twoFactorUseCase.Confirm(input)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidTOTP) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// loginError responde 429 con Retry-After si la IP está frenada y 401 en otro caso
+func loginError(c *gin.Context, err error) {
+	var throttle *auth.ThrottleError
+	if errors.As(err, &throttle) {
+		c.Header("Retry-After", strconv.Itoa(int(throttle.RetryAfter.Seconds())+1))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+}
+
+// Refresh godoc
+// @Summary      Renovar tokens
+// @Description  Canjea el refresh token por un nuevo access token y un nuevo refresh token; el anterior queda inutilizado. Reutilizar un refresh token ya canjeado revoca toda la sesión.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body      auth.RefreshTokenInput  true  "Refresh token"
+// @Success      200   {object}  auth.LoginOutput
+// @Failure      400   {object}  map[string]string
+// @Failure      401   {object}  map[string]string
+// @Router       /api/v1/auth/refresh [post]
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var input auth.RefreshTokenInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	input.IPAddress = c.ClientIP()
+	input.UserAgent = c.Request.UserAgent()
+
+	result, err := h.refreshUseCase.Execute(input)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
@@ -60,25 +218,118 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
 // Logout godoc
 // @Summary      Logout de usuario
-// @Description  Cierra la sesión del usuario eliminando el token
+// @Description  Cierra la sesión del usuario eliminando el token; a partir de ese momento el token es rechazado
 // @Tags         auth
 // @Security     Bearer
 // @Produce      json
 // @Success      200  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
 // @Router       /api/v1/auth/logout [post]
 func (h *AuthHandler) Logout(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	input := auth.LogoutInput{
+		Token:     c.GetString("token"),
+		UserID:    userID,
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+	if err := h.logoutUseCase.Execute(input); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "Sesión cerrada exitosamente"})
 }
 
+// ChangePassword godoc
+// @Summary      Cambiar contraseña
+// @Description  Cambia la contraseña del usuario autenticado según la política vigente. Cierra todas sus sesiones y retorna tokens nuevos. Es la única operación permitida cuando el login indica password_change_required.
+// @Tags         auth
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        body  body      auth.ChangePasswordInput  true  "Contraseña actual y nueva"
+// @Success      200   {object}  auth.LoginOutput
+// @Failure      400   {object}  map[string]string
+// @Failure      401   {object}  map[string]string
+// @Failure      429   {object}  map[string]string
+// @Router       /api/v1/auth/change-password [post]
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	var input auth.ChangePasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	userIDStr, _ := c.Get("user_id")
+	input.UserID, _ = uuid.Parse(userIDStr.(string))
+	input.IPAddress = c.ClientIP()
+	input.UserAgent = c.Request.UserAgent()
+
+	result, err := h.changePasswordUseCase.Execute(input)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidCredentials) || errors.Is(err, domain.ErrAccountLocked) ||
+			errors.Is(err, domain.ErrTooManyAttempts) {
+			loginError(c, err)
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// RevokeSessions godoc
+// @Summary      Revocar sesiones de un usuario
+// @Description  Cierra todas las sesiones activas del usuario (equipo extraviado, baja o cuenta comprometida)
+// @Tags         users
+// @Security     Bearer
+// @Produce      json
+// @Param        id   path      string  true  "ID del usuario"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /api/v1/users/{id}/sessions [delete]
+func (h *AuthHandler) RevokeSessions(c *gin.Context) {
+	targetID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	input := auth.RevokeSessionsInput{
+		TargetUserID: targetID,
+		UserID:       userID,
+		IPAddress:    c.ClientIP(),
+	}
+	if err := h.revokeSessionsUseCase.Execute(input); err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Sesiones del usuario revocadas"})
+}
+
 // Register godoc
 // @Summary      Registrar usuario
-// @Description  Crea un nuevo usuario en el sistema (público para crear admin inicial)
+// @Description  Crea el administrador inicial (ADMIN_TI). Solo funciona mientras no exista ningún usuario; después las altas se hacen en POST /api/v1/users
 // @Tags         auth
 // @Accept       json
 // @Produce      json
 // @Param        user  body      auth.RegisterUserInput  true  "Datos del usuario"
 // @Success      201   {object}  auth.RegisterUserOutput
 // @Failure      400   {object}  map[string]string
+// @Failure      403   {object}  map[string]string
 // @Router       /api/v1/auth/register [post]
 func (h *AuthHandler) Register(c *gin.Context) {
 	var input auth.RegisterUserInput
@@ -91,7 +342,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 
 	result, err := h.registerUseCase.Execute(input)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(userErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sgl-disasur/api/internal/infrastructure/export"
+)
+
+// exportBatchSize es el tamaño de lote con el que se recorren los repositorios al exportar
+const exportBatchSize = 500
+
+// maxBufferedExportRows limita los reportes XLSX y PDF, que se arman completos en memoria
+const maxBufferedExportRows = 10000
+
+// errExportTooLarge se retorna cuando un reporte XLSX o PDF excede maxBufferedExportRows
+var errExportTooLarge = fmt.Errorf("el reporte supera %d filas; use format=csv o acote los filtros", maxBufferedExportRows)
+
+// exportErrorMarker inicia la última fila de un CSV que no pudo completarse
+const exportErrorMarker = "#ERROR: exportación incompleta"
+
+// exportPage obtiene un lote de filas del reporte; un lote menor a limit indica el final
+type exportPage func(limit, offset int) ([][]interface{}, error)
+
+// requestedFormat lee ?format= y responde 400 si el formato no está soportado
+func requestedFormat(c *gin.Context) (export.Format, bool) {
+	format, err := export.ParseFormat(c.Query("format"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return "", false
+	}
+	return format, true
+}
+
+// writeExport transmite el reporte por lotes en el formato solicitado.
+// CSV se escribe conforme llegan los lotes; XLSX y PDF se juntan antes de responder para
+// rechazar con error JSON los que exceden maxBufferedExportRows. Si un lote falla cuando el CSV
+// ya se está transmitiendo, el archivo termina con una fila exportErrorMarker.
+func writeExport(c *gin.Context, format export.Format, name, title string, columns []export.Column, page exportPage) {
+	// El primer lote se obtiene antes de escribir cabeceras para poder responder con error JSON
+	rows, err := page(exportBatchSize, 0)
+	if err == nil && format.Buffered() {
+		rows, err = collectExport(rows, page)
+	}
+	if errors.Is(err, errExportTooLarge) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, format.Filename(name)))
+	c.Status(http.StatusOK)
+
+	writer, err := export.NewWriter(format, c.Writer, title, columns)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	offset := 0
+	for {
+		for _, row := range rows {
+			if err := writer.WriteRow(row); err != nil {
+				_ = c.Error(err)
+				return
+			}
+		}
+
+		if format.Buffered() || len(rows) < exportBatchSize {
+			break
+		}
+
+		offset += exportBatchSize
+		if rows, err = page(exportBatchSize, offset); err != nil {
+			_ = c.Error(err)
+			_ = writer.WriteRow([]interface{}{exportErrorMarker + ": " + err.Error()})
+			_ = writer.Close()
+			return
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		_ = c.Error(err)
+	}
+}
+
+// collectExport obtiene los lotes restantes a partir del primero, hasta maxBufferedExportRows
+func collectExport(rows [][]interface{}, page exportPage) ([][]interface{}, error) {
+	batch := rows
+	for len(batch) == exportBatchSize {
+		if len(rows) > maxBufferedExportRows {
+			return nil, errExportTooLarge
+		}
+		var err error
+		if batch, err = page(exportBatchSize, len(rows)); err != nil {
+			return nil, err
+		}
+		rows = append(rows, batch...)
+	}
+	if len(rows) > maxBufferedExportRows {
+		return nil, errExportTooLarge
+	}
+	return rows, nil
+}
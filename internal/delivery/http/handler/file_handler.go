@@ -39,7 +39,7 @@ type UploadResponse struct {
 // @Accept       multipart/form-data
 // @Produce      json
 // @Param        file  formData  file  true  "Archivo a subir"
-// @Param        type  formData  string  false  "Tipo: invoice, damage_photo, vehicle_photo"
+// @Param        type  formData  string  false  "Tipo: invoice, damage_photo, vehicle_photo, fleet_document, fuel_ticket"
 // @Success      200   {object}  UploadResponse
 // @Security     Bearer
 // @Router       /api/v1/files/upload [post]
@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sgl-disasur/api/internal/domain"
+	"github.com/sgl-disasur/api/internal/usecase/fleet"
+)
+
+// StartRoute godoc
+// @Summary      Iniciar ruta
+// @Description  El chofer asignado registra la salida real y el odómetro. Requiere que el último check-list pre-salida de la ruta esté aprobado para el mismo vehículo; la ruta y sus pedidos pasan a EN_RUTA
+// @Tags         fleet
+// @Accept       json
+// @Produce      json
+// @Param        route_id  path      string                  true  "Route ID"
+// @Param        start     body      fleet.StartRouteInput   true  "Odómetro de salida"
+// @Success      200       {object}  fleet.StartRouteOutput
+// @Failure      400       {object}  map[string]string
+// @Failure      403       {object}  map[string]string
+// @Security     Bearer
+// @Router       /api/v1/fleet/routes/{route_id}/start [post]
+func (h *FleetHandler) StartRoute(c *gin.Context) {
+	routeID, err := uuid.Parse(c.Param("route_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var input fleet.StartRouteInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+	input.RouteID = routeID
+	input.UserID = userID
+
+	result, err := h.startRouteUC.Execute(input)
+	if errors.Is(err, domain.ErrForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// CreateChecklistTemplate godoc
+// @Summary      Publicar plantilla de check-list
+// @Description  Crea una nueva versión del check-list pre-salida de un tipo de vehículo con puntos BOOLEANO, ESCALA (1-5), FOTO o NUMERO y reglas bloqueantes. La versión anterior se desactiva y los check-list ya registrados la conservan
+// @Tags         fleet
+// @Accept       json
+// @Produce      json
+// @Param        template  body      fleet.CreateChecklistTemplateInput  true  "Plantilla"
+// @Success      201       {object}  domain.ChecklistTemplate
+// @Failure      400       {object}  map[string]string
+// @Security     Bearer
+// @Router       /api/v1/fleet/checklist-templates [post]
+func (h *FleetHandler) CreateChecklistTemplate(c *gin.Context) {
+	var input fleet.CreateChecklistTemplateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+	input.UserID = userID
+
+	template, err := h.checklistTemplateUC.Execute(input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, template)
+}
+
+// ListChecklistTemplates godoc
+// @Summary      Plantillas de check-list
+// @Description  Lista todas las versiones, la activa primero por tipo de vehículo
+// @Tags         fleet
+// @Produce      json
+// @Param        vehicle_type  query     string  false  "Tipo de vehículo"
+// @Success      200           {array}   domain.ChecklistTemplate
+// @Security     Bearer
+// @Router       /api/v1/fleet/checklist-templates [get]
+func (h *FleetHandler) ListChecklistTemplates(c *gin.Context) {
+	templates, err := h.templateRepo.List(domain.VehicleType(c.Query("vehicle_type")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+// GetChecklistTemplate godoc
+// @Summary      Detalle de plantilla de check-list
+// @Tags         fleet
+// @Produce      json
+// @Param        id   path      string  true  "Template ID"
+// @Success      200  {object}  domain.ChecklistTemplate
+// @Failure      404  {object}  map[string]string
+// @Security     Bearer
+// @Router       /api/v1/fleet/checklist-templates/{id} [get]
+func (h *FleetHandler) GetChecklistTemplate(c *gin.Context) {
+	templateID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	template, err := h.templateRepo.FindByID(templateID)
+	if errors.Is(err, domain.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Plantilla no encontrada"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// GetRouteChecklist godoc
+// @Summary      Check-list pre-salida de una ruta
+// @Description  Último check-list de la ruta con sus respuestas y la versión de plantilla con la que se hizo
+// @Tags         fleet
+// @Produce      json
+// @Param        route_id  path      string  true  "Route ID"
+// @Success      200       {object}  fleet.PerformCheckOutput
+// @Failure      404       {object}  map[string]string
+// @Security     Bearer
+// @Router       /api/v1/fleet/routes/{route_id}/checklist [get]
+func (h *FleetHandler) GetRouteChecklist(c *gin.Context) {
+	routeID, err := uuid.Parse(c.Param("route_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	if !requireVisible(c, h.routeRepo.Visible, routeID, "Ruta no encontrada") {
+		return
+	}
+
+	checklist, err := h.checklistRepo.FindByRouteID(routeID)
+	if errors.Is(err, domain.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "La ruta no tiene check-list"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := &fleet.PerformCheckOutput{Checklist: checklist}
+	if checklist.TemplateID != nil {
+		if result.Template, err = h.templateRepo.FindByID(*checklist.TemplateID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sgl-disasur/api/internal/domain"
+	"github.com/sgl-disasur/api/internal/usecase/fleet"
+)
+
+// RegisterDriverDocument godoc
+// @Summary      Registrar documento de chofer
+// @Description  Registra LICENCIA o CERTIFICADO_MEDICO con el archivo subido en /files/upload y su vencimiento. La licencia actualiza la vigencia del chofer
+// @Tags         fleet
+// @Accept       json
+// @Produce      json
+// @Param        id        path      string                       true  "Driver ID"
+// @Param        document  body      fleet.RegisterDocumentInput  true  "Documento"
+// @Success      201       {object}  domain.FleetDocument
+// @Failure      400       {object}  map[string]string
+// @Security     Bearer
+// @Router       /api/v1/fleet/drivers/{id}/documents [post]
+func (h *FleetHandler) RegisterDriverDocument(c *gin.Context) {
+	h.registerDocument(c, domain.OwnerDriver)
+}
+
+// RegisterVehicleDocument godoc
+// @Summary      Registrar documento de vehículo
+// @Description  Registra POLIZA_SEGURO, TARJETA_CIRCULACION o VERIFICACION con el archivo subido en /files/upload y su vencimiento
+// @Tags         fleet
+// @Accept       json
+// @Produce      json
+// @Param        id        path      string                       true  "Vehicle ID"
+// @Param        document  body      fleet.RegisterDocumentInput  true  "Documento"
+// @Success      201       {object}  domain.FleetDocument
+// @Failure      400       {object}  map[string]string
+// @Security     Bearer
+// @Router       /api/v1/fleet/vehicles/{id}/documents [post]
+func (h *FleetHandler) RegisterVehicleDocument(c *gin.Context) {
+	h.registerDocument(c, domain.OwnerVehicle)
+}
+
+func (h *FleetHandler) registerDocument(c *gin.Context, owner domain.DocumentOwner) {
+	ownerID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var input fleet.RegisterDocumentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+	input.OwnerType = owner
+	input.OwnerID = ownerID
+	input.UserID = userID
+
+	document, err := h.registerDocumentUC.Execute(input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, document)
+}
+
+// ListDriverDocuments godoc
+// @Summary      Documentos de un chofer
+// @Tags         fleet
+// @Produce      json
+// @Param        id   path      string  true  "Driver ID"
+// @Success      200  {array}   domain.FleetDocument
+// @Security     Bearer
+// @Router       /api/v1/fleet/drivers/{id}/documents [get]
+func (h *FleetHandler) ListDriverDocuments(c *gin.Context) {
+	h.listDocuments(c, domain.OwnerDriver)
+}
+
+// ListVehicleDocuments godoc
+// @Summary      Documentos de un vehículo
+// @Tags         fleet
+// @Produce      json
+// @Param        id   path      string  true  "Vehicle ID"
+// @Success      200  {array}   domain.FleetDocument
+// @Security     Bearer
+// @Router       /api/v1/fleet/vehicles/{id}/documents [get]
+func (h *FleetHandler) ListVehicleDocuments(c *gin.Context) {
+	h.listDocuments(c, domain.OwnerVehicle)
+}
+
+func (h *FleetHandler) listDocuments(c *gin.Context, owner domain.DocumentOwner) {
+	ownerID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	documents, err := h.documentRepo.FindByOwner(owner, ownerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, documents)
+}
+
+// DocumentAlerts godoc
+// @Summary      Documentos por vencer
+// @Description  Lista los documentos vigentes de choferes y vehículos vencidos o que vencen en los próximos días (por defecto DOCUMENT_ALERT_DAYS)
+// @Tags         fleet
+// @Produce      json
+// @Param        days  query     int  false  "Días de anticipación"
+// @Success      200   {array}   fleet.DocumentAlert
+// @Security     Bearer
+// @Router       /api/v1/fleet/documents/alerts [get]
+func (h *FleetHandler) DocumentAlerts(c *gin.Context) {
+	days, _ := strconv.Atoi(c.Query("days"))
+
+	alerts, err := h.documentAlertsUC.Execute(days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, alerts)
+}
@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sgl-disasur/api/internal/infrastructure/export"
+	"github.com/sgl-disasur/api/internal/usecase/fleet"
+)
+
+var fleetCostColumns = []export.Column{
+	{Header: "Placas", Width: 12},
+	{Header: "Tipo", Width: 12},
+	{Header: "Km", Width: 10},
+	{Header: "Litros", Width: 10},
+	{Header: "Km/l", Width: 8},
+	{Header: "Anomalías", Width: 8},
+	{Header: "Combustible", Width: 12},
+	{Header: "Mantenimiento", Width: 12},
+	{Header: "Total", Width: 12},
+	{Header: "Costo/km", Width: 10},
+	{Header: "Pedidos", Width: 8},
+	{Header: "Costo/pedido", Width: 10},
+}
+
+// RegisterFuelLoad godoc
+// @Summary      Registrar carga de combustible
+// @Description  Registra litros, costo, odómetro, estación y foto del ticket. Calcula el rendimiento (km/l) desde la carga anterior y marca la carga como anómala si se desvía más de 35% del rendimiento esperado
+// @Tags         fleet
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string                       true  "Vehicle ID"
+// @Param        load  body      fleet.RegisterFuelLoadInput  true  "Datos de la carga"
+// @Success      201   {object}  domain.FuelLoad
+// @Failure      400   {object}  map[string]string
+// @Security     Bearer
+// @Router       /api/v1/fleet/vehicles/{id}/fuel [post]
+func (h *FleetHandler) RegisterFuelLoad(c *gin.Context) {
+	vehicleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var input fleet.RegisterFuelLoadInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+	input.VehicleID = vehicleID
+	input.UserID = userID
+
+	load, err := h.registerFuelLoadUC.Execute(input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, load)
+}
+
+// VehicleFuel godoc
+// @Summary      Cargas de combustible y rendimiento de un vehículo
+// @Tags         fleet
+// @Produce      json
+// @Param        id      path      string  true   "Vehicle ID"
+// @Param        limit   query     int     false  "Límite (default 50)"
+// @Param        offset  query     int     false  "Desplazamiento"
+// @Success      200     {object}  fleet.VehicleFuelOutput
+// @Failure      404     {object}  map[string]string
+// @Security     Bearer
+// @Router       /api/v1/fleet/vehicles/{id}/fuel [get]
+func (h *FleetHandler) VehicleFuel(c *gin.Context) {
+	vehicleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	output, err := h.vehicleFuelUC.Execute(vehicleID, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+// FleetCostReport godoc
+// @Summary      Reporte de costos de flotilla
+// @Description  Combustible y mantenimiento por vehículo en el periodo, con costo por kilómetro y por pedido entregado. Exportable con format=csv|xlsx|pdf
+// @Tags         reports
+// @Produce      json
+// @Param        from    query     string  false  "Desde (YYYY-MM-DD, default hace 30 días)"
+// @Param        to      query     string  false  "Hasta (YYYY-MM-DD, inclusive)"
+// @Param        format  query     string  false  "json, csv, xlsx o pdf"
+// @Success      200     {object}  fleet.FleetCostReport
+// @Failure      400     {object}  map[string]string
+// @Security     Bearer
+// @Router       /api/v1/reports/fleet-costs [get]
+func (h *FleetHandler) FleetCostReport(c *gin.Context) {
+	today := time.Now()
+	to := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.Local).AddDate(0, 0, 1)
+	from := to.AddDate(0, 0, -30)
+
+	if v := c.Query("from"); v != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Fecha 'from' inválida (YYYY-MM-DD)"})
+			return
+		}
+		from = parsed
+	}
+	if v := c.Query("to"); v != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Fecha 'to' inválida (YYYY-MM-DD)"})
+			return
+		}
+		// Incluir el día completo
+		to = parsed.AddDate(0, 0, 1)
+	}
+
+	format, ok := requestedFormat(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.fleetCostReportUC.Execute(from, to)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if format == export.FormatJSON {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	title := fmt.Sprintf("Costos de flotilla %s a %s", from.Format("2006-01-02"), to.AddDate(0, 0, -1).Format("2006-01-02"))
+	rows := append(append([]*fleet.VehicleCost{}, report.Vehicles...), &report.Total)
+	writeExport(c, format, "costos_flotilla", title, fleetCostColumns, func(limit, offset int) ([][]interface{}, error) {
+		page := [][]interface{}{}
+		for i := offset; i < len(rows) && i < offset+limit; i++ {
+			row := rows[i]
+			page = append(page, []interface{}{
+				row.PlateNumber, row.VehicleType, row.DistanceKm, row.Liters, row.EfficiencyKmL, row.FuelAnomalies,
+				row.FuelCost, row.MaintenanceCost, row.TotalCost, row.CostPerKm, row.DeliveredOrders, row.CostPerOrder,
+			})
+		}
+		return page, nil
+	})
+}
@@ -1,44 +1,162 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/sgl-disasur/api/internal/domain"
+	"github.com/sgl-disasur/api/internal/infrastructure/export"
 	"github.com/sgl-disasur/api/internal/usecase/fleet"
 )
 
 type FleetHandler struct {
 	assignRouteUC         *fleet.AssignRouteUseCase
+	consolidateRouteUC    *fleet.ConsolidateRouteUseCase
+	loadPlanUC            *fleet.LoadPlanUseCase
 	generateInvoiceUC     *fleet.GenerateInvoiceUseCase
 	registerMaintenanceUC *fleet.RegisterMaintenanceUseCase
 	preDepartureCheckUC   *fleet.PerformPreDepartureCheckUseCase
+	setDriverScheduleUC   *fleet.SetDriverScheduleUseCase
+	driverAbsenceUC       *fleet.RegisterDriverAbsenceUseCase
+	driverScheduler       *fleet.DriverScheduler
+	registerDocumentUC    *fleet.RegisterDocumentUseCase
+	documentAlertsUC      *fleet.DocumentAlertsUseCase
+	closeMaintenanceUC    *fleet.CloseMaintenanceUseCase
+	recordOdometerUC      *fleet.RecordOdometerUseCase
+	maintenancePlanUC     *fleet.SetMaintenancePlanUseCase
+	upcomingMaintenanceUC *fleet.UpcomingMaintenanceUseCase
+	registerFuelLoadUC    *fleet.RegisterFuelLoadUseCase
+	vehicleFuelUC         *fleet.VehicleFuelUseCase
+	fleetCostReportUC     *fleet.FleetCostReportUseCase
+	checklistTemplateUC   *fleet.CreateChecklistTemplateUseCase
+	startRouteUC          *fleet.StartRouteUseCase
+	recordPositionsUC     *fleet.RecordPositionsUseCase
+	routeTrackingUC       *fleet.RouteTrackingUseCase
 	vehicleRepo           domain.VehicleRepository
 	driverRepo            domain.DriverRepository
 	routeRepo             domain.RouteRepository
+	routeStopRepo         domain.RouteStopRepository
 	maintenanceRepo       domain.VehicleMaintenanceRepository
+	absenceRepo           domain.DriverAbsenceRepository
+	documentRepo          domain.FleetDocumentRepository
+	maintenancePlanRepo   domain.MaintenancePlanRepository
+	odometerRepo          domain.OdometerReadingRepository
+	checklistRepo         domain.PreDepartureChecklistRepository
+	templateRepo          domain.ChecklistTemplateRepository
+}
+
+var vehicleColumns = []export.Column{
+	{Header: "Placas", Width: 12},
+	{Header: "Tipo", Width: 12},
+	{Header: "Marca", Width: 12},
+	{Header: "Modelo", Width: 12},
+	{Header: "Año", Width: 6},
+	{Header: "Capacidad (kg)", Width: 10},
+	{Header: "Capacidad (m³)", Width: 10},
+	{Header: "Estado", Width: 12},
+	{Header: "Próximo mantenimiento", Width: 14},
+}
+
+var routeColumns = []export.Column{
+	{Header: "Folio", Width: 16},
+	{Header: "Pedido", Width: 18},
+	{Header: "Vehículo", Width: 12},
+	{Header: "Chofer", Width: 20},
+	{Header: "Tipo", Width: 9},
+	{Header: "Salida", Width: 15},
+	{Header: "Llegada estimada", Width: 15},
+	{Header: "Estado", Width: 12},
+}
+
+var loadSheetColumns = []export.Column{
+	{Header: "Orden", Width: 6},
+	{Header: "Parada", Width: 6},
+	{Header: "Pedido", Width: 14},
+	{Header: "Cliente", Width: 20},
+	{Header: "SKU", Width: 12},
+	{Header: "Producto", Width: 22},
+	{Header: "Empaque", Width: 8},
+	{Header: "Piezas", Width: 6},
+	{Header: "Posición X/Y/Z (cm)", Width: 14},
+	{Header: "Medidas (cm)", Width: 14},
+	{Header: "Peso (kg)", Width: 8},
+	{Header: "Sobre", Width: 6},
+	{Header: "Frágil", Width: 6},
 }
 
 func NewFleetHandler(
 	assignRouteUC *fleet.AssignRouteUseCase,
+	consolidateRouteUC *fleet.ConsolidateRouteUseCase,
+	loadPlanUC *fleet.LoadPlanUseCase,
 	generateInvoiceUC *fleet.GenerateInvoiceUseCase,
 	registerMaintenanceUC *fleet.RegisterMaintenanceUseCase,
 	preDepartureCheckUC *fleet.PerformPreDepartureCheckUseCase,
+	setDriverScheduleUC *fleet.SetDriverScheduleUseCase,
+	driverAbsenceUC *fleet.RegisterDriverAbsenceUseCase,
+	driverScheduler *fleet.DriverScheduler,
+	registerDocumentUC *fleet.RegisterDocumentUseCase,
+	documentAlertsUC *fleet.DocumentAlertsUseCase,
+	closeMaintenanceUC *fleet.CloseMaintenanceUseCase,
+	recordOdometerUC *fleet.RecordOdometerUseCase,
+	maintenancePlanUC *fleet.SetMaintenancePlanUseCase,
+	upcomingMaintenanceUC *fleet.UpcomingMaintenanceUseCase,
+	registerFuelLoadUC *fleet.RegisterFuelLoadUseCase,
+	vehicleFuelUC *fleet.VehicleFuelUseCase,
+	fleetCostReportUC *fleet.FleetCostReportUseCase,
+	checklistTemplateUC *fleet.CreateChecklistTemplateUseCase,
+	startRouteUC *fleet.StartRouteUseCase,
+	recordPositionsUC *fleet.RecordPositionsUseCase,
+	routeTrackingUC *fleet.RouteTrackingUseCase,
 	vehicleRepo domain.VehicleRepository,
 	driverRepo domain.DriverRepository,
 	routeRepo domain.RouteRepository,
+	routeStopRepo domain.RouteStopRepository,
 	maintenanceRepo domain.VehicleMaintenanceRepository,
+	absenceRepo domain.DriverAbsenceRepository,
+	documentRepo domain.FleetDocumentRepository,
+	maintenancePlanRepo domain.MaintenancePlanRepository,
+	odometerRepo domain.OdometerReadingRepository,
+	checklistRepo domain.PreDepartureChecklistRepository,
+	templateRepo domain.ChecklistTemplateRepository,
 ) *FleetHandler {
 	return &FleetHandler{
 		assignRouteUC:         assignRouteUC,
+		consolidateRouteUC:    consolidateRouteUC,
+		loadPlanUC:            loadPlanUC,
 		generateInvoiceUC:     generateInvoiceUC,
 		registerMaintenanceUC: registerMaintenanceUC,
 		preDepartureCheckUC:   preDepartureCheckUC,
+		setDriverScheduleUC:   setDriverScheduleUC,
+		driverAbsenceUC:       driverAbsenceUC,
+		driverScheduler:       driverScheduler,
+		registerDocumentUC:    registerDocumentUC,
+		documentAlertsUC:      documentAlertsUC,
+		closeMaintenanceUC:    closeMaintenanceUC,
+		recordOdometerUC:      recordOdometerUC,
+		maintenancePlanUC:     maintenancePlanUC,
+		upcomingMaintenanceUC: upcomingMaintenanceUC,
+		registerFuelLoadUC:    registerFuelLoadUC,
+		vehicleFuelUC:         vehicleFuelUC,
+		fleetCostReportUC:     fleetCostReportUC,
+		checklistTemplateUC:   checklistTemplateUC,
+		startRouteUC:          startRouteUC,
+		recordPositionsUC:     recordPositionsUC,
+		routeTrackingUC:       routeTrackingUC,
 		vehicleRepo:           vehicleRepo,
 		driverRepo:            driverRepo,
 		routeRepo:             routeRepo,
+		routeStopRepo:         routeStopRepo,
 		maintenanceRepo:       maintenanceRepo,
+		absenceRepo:           absenceRepo,
+		documentRepo:          documentRepo,
+		maintenancePlanRepo:   maintenancePlanRepo,
+		odometerRepo:          odometerRepo,
+		checklistRepo:         checklistRepo,
+		templateRepo:          templateRepo,
 	}
 }
 
@@ -72,6 +190,130 @@ func (h *FleetHandler) AssignRoute(c *gin.Context) {
 	c.JSON(http.StatusCreated, result)
 }
 
+// ConsolidateRoute godoc
+// @Summary      Consolidar pedidos en una ruta
+// @Description  Agrupa pedidos CONFIRMADO o EN_PREPARACION en un vehículo dentro de su capacidad (peso y volumen) y secuencia las paradas (vecino más cercano + 2-opt) con hora estimada de llegada. Sin order_ids consolida automáticamente por cercanía
+// @Tags         fleet
+// @Accept       json
+// @Produce      json
+// @Param        route  body      fleet.ConsolidateRouteInput  true  "Pedidos, vehículo y salida"
+// @Success      201    {object}  fleet.ConsolidateRouteOutput
+// @Failure      400    {object}  map[string]string
+// @Security     Bearer
+// @Router       /api/v1/fleet/routes/consolidate [post]
+func (h *FleetHandler) ConsolidateRoute(c *gin.Context) {
+	var input fleet.ConsolidateRouteInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+	input.UserID = userID
+
+	result, err := h.consolidateRouteUC.Execute(input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+// GetRouteStops godoc
+// @Summary      Paradas de una ruta
+// @Description  Lista las paradas en orden de visita con distancia y hora estimada de llegada
+// @Tags         fleet
+// @Produce      json
+// @Param        route_id  path      string  true  "Route ID"
+// @Success      200       {array}   domain.RouteStop
+// @Security     Bearer
+// @Router       /api/v1/fleet/routes/{route_id}/stops [get]
+func (h *FleetHandler) GetRouteStops(c *gin.Context) {
+	routeID, err := uuid.Parse(c.Param("route_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	if !requireVisible(c, h.routeRepo.Visible, routeID, "Ruta no encontrada") {
+		return
+	}
+
+	stops, err := h.routeStopRepo.FindByRouteID(routeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, stops)
+}
+
+// GetLoadPlan godoc
+// @Summary      Plan de carga de una ruta
+// @Description  Calcula la posición y el orden de carga de cada bulto: la última parada va al fondo, los pesados abajo y sobre cada bulto no se excede el peso que soporta (frágiles 0.5x, resto 4x su peso). Con format=pdf/xlsx/csv imprime la hoja de carga.
+// @Tags         fleet
+// @Produce      json
+// @Param        route_id  path      string  true   "Route ID"
+// @Param        format    query     string  false  "Formato: json (default), csv, xlsx, pdf"
+// @Success      200       {object}  fleet.LoadPlanOutput
+// @Security     Bearer
+// @Router       /api/v1/fleet/routes/{route_id}/load-plan [get]
+func (h *FleetHandler) GetLoadPlan(c *gin.Context) {
+	routeID, err := uuid.Parse(c.Param("route_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	if !requireVisible(c, h.routeRepo.Visible, routeID, "Ruta no encontrada") {
+		return
+	}
+
+	format, ok := requestedFormat(c)
+	if !ok {
+		return
+	}
+
+	plan, err := h.loadPlanUC.Execute(routeID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if format == export.FormatJSON {
+		c.JSON(http.StatusOK, plan)
+		return
+	}
+
+	title := fmt.Sprintf("Hoja de carga %s - %s", plan.RouteNumber, plan.PlateNumber)
+	writeExport(c, format, "hoja_carga_"+plan.RouteNumber, title, loadSheetColumns, func(limit, offset int) ([][]interface{}, error) {
+		rows := [][]interface{}{}
+		items := append(append([]*fleet.LoadItem{}, plan.Items...), plan.Unplaced...)
+		for i := offset; i < len(items) && i < offset+limit; i++ {
+			item := items[i]
+			sequence, position, stackedOn := "-", item.UnplacedCause, ""
+			if item.LoadSequence > 0 {
+				sequence = fmt.Sprint(item.LoadSequence)
+				position = fmt.Sprintf("%.0f / %.0f / %.0f", item.XCm, item.YCm, item.ZCm)
+			}
+			if item.StackedOn > 0 {
+				stackedOn = fmt.Sprint(item.StackedOn)
+			}
+			fragile := "No"
+			if item.IsFragile {
+				fragile = "Sí"
+			}
+			rows = append(rows, []interface{}{
+				sequence, item.StopSequence, item.OrderNumber, item.CustomerName, item.SKU, item.ProductName,
+				string(item.Unit), item.Pieces, position,
+				fmt.Sprintf("%.0f x %.0f x %.0f", item.LengthCm, item.WidthCm, item.HeightCm),
+				item.WeightKg, stackedOn, fragile,
+			})
+		}
+		return rows, nil
+	})
+}
+
 // GenerateInvoice godoc
 // @Summary      Generar remisión (HU-11)
 // @Description  Genera PDF de remisión para una ruta
@@ -106,12 +348,12 @@ func (h *FleetHandler) GenerateInvoice(c *gin.Context) {
 
 // RegisterMaintenance godoc
 // @Summary      Registrar mantenimiento (HU-16)
-// @Description  Registra mantenimiento de vehículo
+// @Description  Abre una orden de taller: el vehículo queda EN_TALLER hasta cerrarla en /fleet/vehicles/maintenance/{id}/close
 // @Tags         fleet
 // @Accept       json
 // @Produce      json
 // @Param        maintenance  body      fleet.RegisterMaintenanceInput  true  "Datos del mantenimiento"
-// @Success      200          {object}  map[string]string
+// @Success      200          {object}  map[string]interface{}
 // @Security     Bearer
 // @Router       /api/v1/fleet/vehicles/maintenance [post]
 func (h *FleetHandler) RegisterMaintenance(c *gin.Context) {
@@ -125,22 +367,28 @@ func (h *FleetHandler) RegisterMaintenance(c *gin.Context) {
 	userID, _ := uuid.Parse(userIDStr.(string))
 	input.UserID = userID
 
-	if err := h.registerMaintenanceUC.Execute(input); err != nil {
+	maintenance, err := h.registerMaintenanceUC.Execute(input)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Mantenimiento registrado exitosamente"})
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Mantenimiento registrado exitosamente",
+		"maintenance": maintenance,
+	})
 }
 
 // PerformPreDepartureCheck godoc
 // @Summary      Check-list pre-salida (HU-17)
-// @Description  Registra check-list de seguridad antes de partir
+// @Description  Registra check-list de seguridad antes de partir con la plantilla activa del tipo de vehículo (answers por código). Si falla un punto bloqueante responde 422 y abre un mantenimiento correctivo
 // @Tags         fleet
 // @Accept       json
 // @Produce      json
 // @Param        check  body      fleet.PerformCheckInput  true  "Datos del check-list"
-// @Success      200    {object}  map[string]string
+// @Success      200    {object}  fleet.PerformCheckOutput
+// @Failure      400    {object}  map[string]string
+// @Failure      422    {object}  map[string]interface{}
 // @Security     Bearer
 // @Router       /api/v1/fleet/routes/pre-departure-check [post]
 func (h *FleetHandler) PerformPreDepartureCheck(c *gin.Context) {
@@ -154,12 +402,20 @@ func (h *FleetHandler) PerformPreDepartureCheck(c *gin.Context) {
 	userID, _ := uuid.Parse(userIDStr.(string))
 	input.UserID = userID
 
-	if err := h.preDepartureCheckUC.Execute(input); err != nil {
+	result, err := h.preDepartureCheckUC.Execute(input)
+	if errors.Is(err, domain.ErrChecklistFailed) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "result": result})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Check-list completado. Vehículo listo para partir"})
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Check-list completado. Vehículo listo para partir",
+		"result":  result,
+	})
 }
 
 // ListVehicles godoc
@@ -167,10 +423,34 @@ func (h *FleetHandler) PerformPreDepartureCheck(c *gin.Context) {
 // @Description  Obtiene listado de vehículos de la flota
 // @Tags         fleet
 // @Produce      json
+// @Param        format  query     string  false  "Formato: json (default), csv, xlsx, pdf"
 // @Success      200  {array}   domain.Vehicle
 // @Security     Bearer
 // @Router       /api/v1/fleet/vehicles [get]
 func (h *FleetHandler) ListVehicles(c *gin.Context) {
+	format, ok := requestedFormat(c)
+	if !ok {
+		return
+	}
+
+	if format != export.FormatJSON {
+		writeExport(c, format, "vehiculos", "Vehículos", vehicleColumns, func(limit, offset int) ([][]interface{}, error) {
+			vehicles, err := h.vehicleRepo.List(nil, limit, offset)
+			if err != nil {
+				return nil, err
+			}
+			rows := make([][]interface{}, 0, len(vehicles))
+			for _, v := range vehicles {
+				rows = append(rows, []interface{}{
+					v.PlateNumber, v.VehicleType, v.Brand, v.Model, v.Year, v.CapacityKg,
+					v.CapacityM3, v.Status, v.NextMaintenanceDate,
+				})
+			}
+			return rows, nil
+		})
+		return
+	}
+
 	vehicles, err := h.vehicleRepo.List(nil, 100, 0)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -198,16 +478,185 @@ func (h *FleetHandler) ListDrivers(c *gin.Context) {
 	c.JSON(http.StatusOK, drivers)
 }
 
+// DriverAvailability godoc
+// @Summary      Disponibilidad de choferes
+// @Description  Evalúa a los choferes activos para una salida: turno, descanso semanal, descansos y licencias registrados, clase de licencia y horas de manejo diarias/semanales. Los elegibles aparecen primero, ordenados por horas restantes
+// @Tags         fleet
+// @Produce      json
+// @Param        route_type  query     string  false  "LOCAL (default) o FORANEA"
+// @Param        departure   query     string  true   "Salida (RFC3339)"
+// @Param        arrival     query     string  false  "Llegada estimada (RFC3339)"
+// @Success      200         {array}   fleet.DriverCandidate
+// @Failure      400         {object}  map[string]string
+// @Security     Bearer
+// @Router       /api/v1/fleet/drivers/availability [get]
+func (h *FleetHandler) DriverAvailability(c *gin.Context) {
+	window := fleet.RouteWindow{RouteType: domain.RouteType(c.DefaultQuery("route_type", string(domain.RouteLocal)))}
+	if window.RouteType != domain.RouteLocal && window.RouteType != domain.RouteForanea {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "route_type inválido. Use: LOCAL, FORANEA"})
+		return
+	}
+
+	departure, err := time.Parse(time.RFC3339, c.Query("departure"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "departure inválida. Use RFC3339"})
+		return
+	}
+	window.Departure = departure
+
+	if value := c.Query("arrival"); value != "" {
+		if window.Arrival, err = time.Parse(time.RFC3339, value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "arrival inválida. Use RFC3339"})
+			return
+		}
+	}
+
+	candidates, err := h.driverScheduler.Rank(window)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, candidates)
+}
+
+// SetDriverSchedule godoc
+// @Summary      Configurar turno de chofer
+// @Description  Define la clase de licencia (LOCAL/FORANEA), el turno (HH:MM) y los días de descanso semanal (0=domingo ... 6=sábado)
+// @Tags         fleet
+// @Accept       json
+// @Produce      json
+// @Param        id        path      string                        true  "Driver ID"
+// @Param        schedule  body      fleet.SetDriverScheduleInput  true  "Licencia y turno"
+// @Success      200       {object}  domain.Driver
+// @Failure      400       {object}  map[string]string
+// @Security     Bearer
+// @Router       /api/v1/fleet/drivers/{id}/schedule [put]
+func (h *FleetHandler) SetDriverSchedule(c *gin.Context) {
+	driverID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var input fleet.SetDriverScheduleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+	input.DriverID = driverID
+	input.UserID = userID
+
+	driver, err := h.setDriverScheduleUC.Execute(input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, driver)
+}
+
+// ListDriverAbsences godoc
+// @Summary      Descansos y licencias de un chofer
+// @Tags         fleet
+// @Produce      json
+// @Param        id   path      string  true  "Driver ID"
+// @Success      200  {array}   domain.DriverAbsence
+// @Security     Bearer
+// @Router       /api/v1/fleet/drivers/{id}/absences [get]
+func (h *FleetHandler) ListDriverAbsences(c *gin.Context) {
+	driverID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	absences, err := h.absenceRepo.FindByDriver(driverID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, absences)
+}
+
+// RegisterDriverAbsence godoc
+// @Summary      Registrar descanso o licencia
+// @Description  Registra un DESCANSO o LICENCIA del chofer entre dos fechas (inclusive); durante el rango no se le asignan rutas
+// @Tags         fleet
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                            true  "Driver ID"
+// @Param        absence  body      fleet.RegisterDriverAbsenceInput  true  "Tipo y rango de fechas"
+// @Success      201      {object}  domain.DriverAbsence
+// @Failure      400      {object}  map[string]string
+// @Security     Bearer
+// @Router       /api/v1/fleet/drivers/{id}/absences [post]
+func (h *FleetHandler) RegisterDriverAbsence(c *gin.Context) {
+	driverID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var input fleet.RegisterDriverAbsenceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+	input.DriverID = driverID
+	input.UserID = userID
+
+	absence, err := h.driverAbsenceUC.Execute(input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, absence)
+}
+
 // ListRoutes godoc
 // @Summary      Listar rutas
 // @Description  Obtiene listado de rutas/viajes
 // @Tags         fleet
 // @Produce      json
+// @Param        format  query     string  false  "Formato: json (default), csv, xlsx, pdf"
 // @Success      200  {array}   domain.Route
 // @Security     Bearer
 // @Router       /api/v1/fleet/routes [get]
 func (h *FleetHandler) ListRoutes(c *gin.Context) {
-	routes, err := h.routeRepo.List(nil, 50, 0)
+	format, ok := requestedFormat(c)
+	if !ok {
+		return
+	}
+
+	scope := dataScope(c)
+	if format != export.FormatJSON {
+		writeExport(c, format, "rutas", "Rutas", routeColumns, func(limit, offset int) ([][]interface{}, error) {
+			routes, err := h.routeRepo.ListForReport(scope, limit, offset)
+			if err != nil {
+				return nil, err
+			}
+			rows := make([][]interface{}, 0, len(routes))
+			for _, r := range routes {
+				rows = append(rows, []interface{}{
+					r.RouteNumber, r.OrderNumber, r.PlateNumber, r.DriverName, r.RouteType,
+					r.DepartureDate, r.EstimatedArrival, r.Status,
+				})
+			}
+			return rows, nil
+		})
+		return
+	}
+
+	routes, err := h.routeRepo.List(nil, scope, 50, 0)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sgl-disasur/api/internal/usecase/fleet"
+)
+
+// CloseMaintenance godoc
+// @Summary      Cerrar mantenimiento
+// @Description  Cierra la orden de taller, programa el próximo servicio según el plan del tipo de vehículo (km o meses) y regresa el vehículo a DISPONIBLE
+// @Tags         fleet
+// @Accept       json
+// @Produce      json
+// @Param        id     path      string                       true  "Maintenance ID"
+// @Param        close  body      fleet.CloseMaintenanceInput  true  "Costo final y odómetro"
+// @Success      200    {object}  fleet.CloseMaintenanceOutput
+// @Failure      400    {object}  map[string]string
+// @Security     Bearer
+// @Router       /api/v1/fleet/vehicles/maintenance/{id}/close [post]
+func (h *FleetHandler) CloseMaintenance(c *gin.Context) {
+	maintenanceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var input fleet.CloseMaintenanceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+	input.MaintenanceID = maintenanceID
+	input.UserID = userID
+
+	result, err := h.closeMaintenanceUC.Execute(input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// UpcomingMaintenance godoc
+// @Summary      Mantenimientos próximos
+// @Description  Lista los vehículos con servicio vencido (bloqueados para rutas) o que vence en los próximos días o kilómetros
+// @Tags         fleet
+// @Produce      json
+// @Param        days  query     int     false  "Días de anticipación (default 15)"
+// @Param        km    query     number  false  "Kilómetros de anticipación (default 1000)"
+// @Success      200   {array}   fleet.UpcomingMaintenance
+// @Security     Bearer
+// @Router       /api/v1/fleet/vehicles/maintenance/upcoming [get]
+func (h *FleetHandler) UpcomingMaintenance(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "15"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days inválido"})
+		return
+	}
+	km, err := strconv.ParseFloat(c.DefaultQuery("km", "1000"), 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "km inválido"})
+		return
+	}
+
+	upcoming, err := h.upcomingMaintenanceUC.Execute(days, km)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, upcoming)
+}
+
+// RecordOdometer godoc
+// @Summary      Registrar lectura de odómetro
+// @Tags         fleet
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                     true  "Vehicle ID"
+// @Param        reading  body      fleet.RecordOdometerInput  true  "Lectura en km"
+// @Success      200      {object}  domain.Vehicle
+// @Failure      400      {object}  map[string]string
+// @Security     Bearer
+// @Router       /api/v1/fleet/vehicles/{id}/odometer [post]
+func (h *FleetHandler) RecordOdometer(c *gin.Context) {
+	vehicleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var input fleet.RecordOdometerInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+	input.VehicleID = vehicleID
+	input.UserID = userID
+
+	vehicle, err := h.recordOdometerUC.Execute(input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, vehicle)
+}
+
+// ListOdometerReadings godoc
+// @Summary      Lecturas de odómetro de un vehículo
+// @Tags         fleet
+// @Produce      json
+// @Param        id   path      string  true  "Vehicle ID"
+// @Success      200  {array}   domain.OdometerReading
+// @Security     Bearer
+// @Router       /api/v1/fleet/vehicles/{id}/odometer [get]
+func (h *FleetHandler) ListOdometerReadings(c *gin.Context) {
+	vehicleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	readings, err := h.odometerRepo.FindByVehicle(vehicleID, 100, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, readings)
+}
+
+// ListMaintenancePlans godoc
+// @Summary      Planes de mantenimiento preventivo
+// @Tags         fleet
+// @Produce      json
+// @Success      200  {array}   domain.MaintenancePlan
+// @Security     Bearer
+// @Router       /api/v1/fleet/maintenance-plans [get]
+func (h *FleetHandler) ListMaintenancePlans(c *gin.Context) {
+	plans, err := h.maintenancePlanRepo.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, plans)
+}
+
+// SetMaintenancePlan godoc
+// @Summary      Configurar plan de mantenimiento
+// @Description  Define cada cuántos km o meses (lo que ocurra primero) requiere servicio preventivo un tipo de vehículo. Aplica desde el siguiente cierre de mantenimiento
+// @Tags         fleet
+// @Accept       json
+// @Produce      json
+// @Param        plan  body      fleet.SetMaintenancePlanInput  true  "Plan"
+// @Success      200   {object}  domain.MaintenancePlan
+// @Failure      400   {object}  map[string]string
+// @Security     Bearer
+// @Router       /api/v1/fleet/maintenance-plans [put]
+func (h *FleetHandler) SetMaintenancePlan(c *gin.Context) {
+	var input fleet.SetMaintenancePlanInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+	input.UserID = userID
+
+	plan, err := h.maintenancePlanUC.Execute(input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sgl-disasur/api/internal/domain"
+	"github.com/sgl-disasur/api/internal/usecase/fleet"
+)
+
+// RecordPositions godoc
+// @Summary      Registrar posiciones GPS
+// @Description  Recibe una o varias posiciones (app del chofer o telemetría) de una ruta EN_RUTA y retorna el avance y la ETA recalculada
+// @Tags         fleet
+// @Accept       json
+// @Produce      json
+// @Param        route_id   path      string                      true  "Route ID"
+// @Param        positions  body      fleet.RecordPositionsInput  true  "Posiciones"
+// @Success      200        {object}  fleet.RouteTracking
+// @Failure      400        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Security     Bearer
+// @Router       /api/v1/fleet/routes/{route_id}/positions [post]
+func (h *FleetHandler) RecordPositions(c *gin.Context) {
+	routeID, err := uuid.Parse(c.Param("route_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var input fleet.RecordPositionsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+	input.RouteID = routeID
+	input.UserID = userID
+	input.Role = domain.UserRole(c.GetString("user_role"))
+
+	tracking, err := h.recordPositionsUC.Execute(input)
+	if errors.Is(err, domain.ErrForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tracking)
+}
+
+// GetRouteTracking godoc
+// @Summary      Seguimiento de una ruta
+// @Description  Posición actual, recorrido, avance y ETA revisada contra la llegada estimada
+// @Tags         fleet
+// @Produce      json
+// @Param        route_id  path      string  true   "Route ID"
+// @Param        trail     query     int     false  "Posiciones del recorrido (default 200, máximo 2000)"
+// @Success      200       {object}  fleet.RouteTracking
+// @Failure      404       {object}  map[string]string
+// @Security     Bearer
+// @Router       /api/v1/fleet/routes/{route_id}/tracking [get]
+func (h *FleetHandler) GetRouteTracking(c *gin.Context) {
+	routeID, err := uuid.Parse(c.Param("route_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	if !requireVisible(c, h.routeRepo.Visible, routeID, "Ruta no encontrada") {
+		return
+	}
+	trail, err := strconv.Atoi(c.DefaultQuery("trail", "200"))
+	if err != nil || trail < 0 || trail > 2000 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "trail inválido (0 a 2000)"})
+		return
+	}
+
+	tracking, err := h.routeTrackingUC.Execute(routeID, trail)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tracking)
+}
+
+// FleetPositions godoc
+// @Summary      Posiciones de la flota en ruta
+// @Description  Última posición, avance y ETA de cada vehículo EN_RUTA, los más retrasados primero
+// @Tags         fleet
+// @Produce      json
+// @Success      200  {array}   fleet.RouteTracking
+// @Security     Bearer
+// @Router       /api/v1/fleet/tracking [get]
+func (h *FleetHandler) FleetPositions(c *gin.Context) {
+	trackings, err := h.routeTrackingUC.FleetPositions()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, trackings)
+}
@@ -1,11 +1,13 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/sgl-disasur/api/internal/domain"
+	"github.com/sgl-disasur/api/internal/infrastructure/export"
 	"github.com/sgl-disasur/api/internal/usecase/inventory"
 )
 
@@ -14,6 +16,28 @@ type InventoryHandler struct {
 	getFEFOLotsUC    *inventory.GetFEFOLotsUseCase
 	registerDamageUC *inventory.RegisterDamageUseCase
 	cycleCountUC     *inventory.PerformCycleCountUseCase
+	scanUC           *inventory.ScanUseCase
+}
+
+var stockColumns = []export.Column{
+	{Header: "SKU", Width: 12},
+	{Header: "Producto", Width: 30},
+	{Header: "Marca", Width: 12},
+	{Header: "Categoría", Width: 15},
+	{Header: "Stock total", Width: 10},
+	{Header: "Disponible", Width: 10},
+	{Header: "Reservado", Width: 10},
+	{Header: "Stock bajo", Width: 10},
+	{Header: "Alerta caducidad", Width: 12},
+}
+
+var fefoColumns = []export.Column{
+	{Header: "Lote", Width: 15},
+	{Header: "Cantidad", Width: 10},
+	{Header: "Caducidad", Width: 12},
+	{Header: "Días para caducar", Width: 12},
+	{Header: "Ubicación", Width: 15},
+	{Header: "Alerta", Width: 30},
 }
 
 func NewInventoryHandler(
@@ -21,12 +45,14 @@ func NewInventoryHandler(
 	getFEFOLotsUC *inventory.GetFEFOLotsUseCase,
 	registerDamageUC *inventory.RegisterDamageUseCase,
 	cycleCountUC *inventory.PerformCycleCountUseCase,
+	scanUC *inventory.ScanUseCase,
 ) *InventoryHandler {
 	return &InventoryHandler{
 		getStockUC:       getStockUC,
 		getFEFOLotsUC:    getFEFOLotsUC,
 		registerDamageUC: registerDamageUC,
 		cycleCountUC:     cycleCountUC,
+		scanUC:           scanUC,
 	}
 }
 
@@ -37,10 +63,16 @@ func NewInventoryHandler(
 // @Produce      json
 // @Param        brand     query     string  false  "Filtrar por marca"
 // @Param        category  query     string  false  "Filtrar por categoría"
+// @Param        format    query     string  false  "Formato: json (default), csv, xlsx, pdf"
 // @Success      200       {array}   inventory.StockItem
 // @Security     Bearer
 // @Router       /api/v1/inventory/stock [get]
 func (h *InventoryHandler) GetStock(c *gin.Context) {
+	format, ok := requestedFormat(c)
+	if !ok {
+		return
+	}
+
 	var brand *domain.Brand
 	var category *string
 
@@ -52,8 +84,27 @@ func (h *InventoryHandler) GetStock(c *gin.Context) {
 	if cat := c.Query("category"); cat != "" {
 		category = &cat
 	}
+	scope := dataScope(c)
+
+	if format != export.FormatJSON {
+		writeExport(c, format, "stock", "Monitor de stock", stockColumns, func(limit, offset int) ([][]interface{}, error) {
+			items, err := h.getStockUC.ExecutePage(brand, category, scope, limit, offset)
+			if err != nil {
+				return nil, err
+			}
+			rows := make([][]interface{}, 0, len(items))
+			for _, item := range items {
+				rows = append(rows, []interface{}{
+					item.SKU, item.ProductName, item.Brand, item.Category, item.TotalStock,
+					item.AvailableStock, item.ReservedStock, item.LowStockAlert, item.ExpirationWarning,
+				})
+			}
+			return rows, nil
+		})
+		return
+	}
 
-	stock, err := h.getStockUC.Execute(brand, category)
+	stock, err := h.getStockUC.Execute(brand, category, scope)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -68,10 +119,16 @@ func (h *InventoryHandler) GetStock(c *gin.Context) {
 // @Tags         inventory
 // @Produce      json
 // @Param        product_id  path      string  true  "Product ID"
+// @Param        format      query     string  false  "Formato: json (default), csv, xlsx, pdf"
 // @Success      200         {array}   inventory.FEFOLot
 // @Security     Bearer
 // @Router       /api/v1/inventory/fefo/{product_id} [get]
 func (h *InventoryHandler) GetFEFOLots(c *gin.Context) {
+	format, ok := requestedFormat(c)
+	if !ok {
+		return
+	}
+
 	productIDStr := c.Param("product_id")
 	productID, err := uuid.Parse(productIDStr)
 	if err != nil {
@@ -79,12 +136,30 @@ func (h *InventoryHandler) GetFEFOLots(c *gin.Context) {
 		return
 	}
 
-	lots, err := h.getFEFOLotsUC.Execute(productID)
+	lots, err := h.getFEFOLotsUC.Execute(productID, dataScope(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	if format != export.FormatJSON {
+		// Los lotes de un producto ya están en memoria: se exportan en un solo lote
+		writeExport(c, format, "lotes_fefo", "Lotes FEFO", fefoColumns, func(limit, offset int) ([][]interface{}, error) {
+			if offset > 0 {
+				return nil, nil
+			}
+			rows := make([][]interface{}, 0, len(lots))
+			for _, lot := range lots {
+				rows = append(rows, []interface{}{
+					lot.LotNumber, lot.Quantity, lot.ExpirationDate, lot.DaysUntilExpiry,
+					lot.WarehouseLocation, lot.ExpirationAlert,
+				})
+			}
+			return rows, nil
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, lots)
 }
 
@@ -96,6 +171,7 @@ func (h *InventoryHandler) GetFEFOLots(c *gin.Context) {
 // @Produce      json
 // @Param        damage  body      inventory.RegisterDamageInput  true  "Datos de la merma"
 // @Success      200     {object}  map[string]string
+// @Failure      403     {object}  map[string]string
 // @Security     Bearer
 // @Router       /api/v1/inventory/damages [post]
 func (h *InventoryHandler) RegisterDamage(c *gin.Context) {
@@ -108,8 +184,13 @@ func (h *InventoryHandler) RegisterDamage(c *gin.Context) {
 	userIDStr, _ := c.Get("user_id")
 	userID, _ := uuid.Parse(userIDStr.(string))
 	input.UserID = userID
+	input.Scope = dataScope(c)
 
 	output, err := h.registerDamageUC.Execute(input)
+	if errors.Is(err, domain.ErrForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -144,6 +225,7 @@ func (h *InventoryHandler) GenerateCycleCounts(c *gin.Context) {
 // @Produce      json
 // @Param        count  body      inventory.PerformCountInput  true  "Datos del conteo"
 // @Success      200    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
 // @Security     Bearer
 // @Router       /api/v1/inventory/cycle-counts/perform [post]
 func (h *InventoryHandler) PerformCycleCount(c *gin.Context) {
@@ -156,11 +238,51 @@ func (h *InventoryHandler) PerformCycleCount(c *gin.Context) {
 	userIDStr, _ := c.Get("user_id")
 	userID, _ := uuid.Parse(userIDStr.(string))
 	input.UserID = userID
+	input.Scope = dataScope(c)
 
-	if err := h.cycleCountUC.PerformCount(input); err != nil {
+	err := h.cycleCountUC.PerformCount(input)
+	if errors.Is(err, domain.ErrForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Conteo registrado exitosamente"})
 }
+
+// Scan godoc
+// @Summary      Escanear código de barras
+// @Description  Interpreta la lectura del escáner (EAN-13/UPC o GS1-128 con AI 01, 10, 17, 30), resuelve producto y lote, y retorna el contexto de la tarea: línea de conteo ciego, conteo cíclico o surtido
+// @Tags         inventory
+// @Accept       json
+// @Produce      json
+// @Param        scan  body      inventory.ScanInput  true  "Lectura del escáner y tarea en curso"
+// @Success      200   {object}  inventory.ScanOutput
+// @Failure      400   {object}  map[string]string
+// @Failure      403   {object}  map[string]string
+// @Security     Bearer
+// @Router       /api/v1/inventory/scan [post]
+func (h *InventoryHandler) Scan(c *gin.Context) {
+	var input inventory.ScanInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	input.Scope = dataScope(c)
+
+	result, err := h.scanUC.Execute(input)
+	if errors.Is(err, domain.ErrForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
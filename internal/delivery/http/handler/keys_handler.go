@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sgl-disasur/api/internal/infrastructure/security"
+)
+
+// KeysHandler publica las llaves con las que otros servicios validan los JWT de la API
+type KeysHandler struct {
+	keys *security.KeySet
+}
+
+func NewKeysHandler(keys *security.KeySet) *KeysHandler {
+	return &KeysHandler{keys: keys}
+}
+
+// JWKS godoc
+// @Summary      Llaves públicas JWT (JWKS)
+// @Description  Llaves públicas activas (RFC 7517); el kid del encabezado del token indica cuál usar. Con HS256 la lista está vacía.
+// @Tags         auth
+// @Produce      json
+// @Success      200  {object}  security.JWKS
+// @Router       /.well-known/jwks.json [get]
+func (h *KeysHandler) JWKS(c *gin.Context) {
+	// Los clientes pueden guardar las llaves un rato; una llave nueva se publica antes de firmar con ella
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.keys.JWKS())
+}
@@ -1,33 +1,50 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/sgl-disasur/api/internal/domain"
+	"github.com/sgl-disasur/api/internal/infrastructure/export"
 	"github.com/sgl-disasur/api/internal/usecase/orders"
 )
 
 type OrderHandler struct {
-	createOrderUC *orders.CreateOrderUseCase
-	orderRepo     domain.OrderRepository
-	orderLineRepo domain.OrderLineRepository
-	customerRepo  domain.CustomerRepository
+	createOrderUC    *orders.CreateOrderUseCase
+	assignCustomerUC *orders.AssignCustomerUseCase
+	orderRepo        domain.OrderRepository
+	orderLineRepo    domain.OrderLineRepository
+	customerRepo     domain.CustomerRepository
+}
+
+var orderColumns = []export.Column{
+	{Header: "Folio", Width: 18},
+	{Header: "Cliente", Width: 28},
+	{Header: "Estado", Width: 14},
+	{Header: "Peso (kg)", Width: 10},
+	{Header: "Volumen (m³)", Width: 10},
+	{Header: "Total", Width: 12},
+	{Header: "Vehículo sugerido", Width: 14},
+	{Header: "Frágiles", Width: 8},
+	{Header: "Fecha", Width: 16},
 }
 
 func NewOrderHandler(
 	createOrderUC *orders.CreateOrderUseCase,
+	assignCustomerUC *orders.AssignCustomerUseCase,
 	orderRepo domain.OrderRepository,
 	orderLineRepo domain.OrderLineRepository,
 	customerRepo domain.CustomerRepository,
 ) *OrderHandler {
 	return &OrderHandler{
-		createOrderUC: createOrderUC,
-		orderRepo:     orderRepo,
-		orderLineRepo: orderLineRepo,
-		customerRepo:  customerRepo,
+		createOrderUC:    createOrderUC,
+		assignCustomerUC: assignCustomerUC,
+		orderRepo:        orderRepo,
+		orderLineRepo:    orderLineRepo,
+		customerRepo:     customerRepo,
 	}
 }
 
@@ -39,6 +56,7 @@ func NewOrderHandler(
 // @Produce      json
 // @Param        order  body      orders.CreateOrderInput  true  "Datos del pedido"
 // @Success      201    {object}  orders.CreateOrderOutput
+// @Failure      403    {object}  map[string]string
 // @Security     Bearer
 // @Router       /api/v1/orders [post]
 func (h *OrderHandler) CreateOrder(c *gin.Context) {
@@ -51,8 +69,13 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	userIDStr, _ := c.Get("user_id")
 	userID, _ := uuid.Parse(userIDStr.(string))
 	input.UserID = userID
+	input.Scope = dataScope(c)
 
 	result, err := h.createOrderUC.Execute(input)
+	if errors.Is(err, domain.ErrForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -67,16 +90,41 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 // @Tags         orders
 // @Produce      json
 // @Param        status  query     string  false  "Filtrar por estado"
+// @Param        format  query     string  false  "Formato: json (default), csv, xlsx, pdf"
 // @Success      200     {array}   domain.Order
 // @Security     Bearer
 // @Router       /api/v1/orders [get]
 func (h *OrderHandler) ListOrders(c *gin.Context) {
+	format, ok := requestedFormat(c)
+	if !ok {
+		return
+	}
+
 	filters := make(map[string]interface{})
 	if status := c.Query("status"); status != "" {
 		filters["status"] = status
 	}
+	scope := dataScope(c)
 
-	orders, err := h.orderRepo.List(filters, 50, 0)
+	if format != export.FormatJSON {
+		writeExport(c, format, "pedidos", "Pedidos", orderColumns, func(limit, offset int) ([][]interface{}, error) {
+			orders, err := h.orderRepo.ListForReport(filters, scope, limit, offset)
+			if err != nil {
+				return nil, err
+			}
+			rows := make([][]interface{}, 0, len(orders))
+			for _, o := range orders {
+				rows = append(rows, []interface{}{
+					o.OrderNumber, o.CustomerName, o.Status, o.TotalWeightKg, o.TotalVolumeM3,
+					o.TotalCost, o.SuggestedVehicle, o.HasFragileItems, o.CreatedAt,
+				})
+			}
+			return rows, nil
+		})
+		return
+	}
+
+	orders, err := h.orderRepo.List(filters, scope, 50, 0)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -101,6 +149,9 @@ func (h *OrderHandler) GetOrder(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
 		return
 	}
+	if !requireVisible(c, h.orderRepo.Visible, id, "Pedido no encontrado") {
+		return
+	}
 
 	order, err := h.orderRepo.FindByID(id)
 	if err != nil {
@@ -132,6 +183,10 @@ func (h *OrderHandler) CreateCustomer(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	// El cliente que da de alta un vendedor queda asignado a él
+	if scope := dataScope(c); scope.OwnCustomersOnly() {
+		customer.AssignedTo = &scope.UserID
+	}
 
 	if err := h.customerRepo.Create(&customer); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -141,6 +196,49 @@ func (h *OrderHandler) CreateCustomer(c *gin.Context) {
 	c.JSON(http.StatusCreated, customer)
 }
 
+// AssignCustomer godoc
+// @Summary      Asignar vendedor a un cliente
+// @Description  Asigna o reasigna el VENDEDOR que atiende al cliente (él ve al cliente y sus pedidos); null quita la asignación
+// @Tags         customers
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string                      true  "ID del cliente"
+// @Param        body  body      orders.AssignCustomerInput  true  "Vendedor"
+// @Success      200   {object}  domain.Customer
+// @Failure      400   {object}  map[string]string
+// @Failure      404   {object}  map[string]string
+// @Security     Bearer
+// @Router       /api/v1/customers/{id}/assignment [put]
+func (h *OrderHandler) AssignCustomer(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var input orders.AssignCustomerInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+	userIDStr, _ := c.Get("user_id")
+	input.UserID, _ = uuid.Parse(userIDStr.(string))
+	input.CustomerID = id
+	input.IPAddress = c.ClientIP()
+
+	customer, err := h.assignCustomerUC.Execute(input)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, domain.ErrNotFound) || errors.Is(err, domain.ErrUserNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, customer)
+}
+
 // ListCustomers godoc
 // @Summary      Listar clientes
 // @Description  Obtiene catálogo de clientes activos
@@ -150,7 +248,7 @@ func (h *OrderHandler) CreateCustomer(c *gin.Context) {
 // @Security     Bearer
 // @Router       /api/v1/customers [get]
 func (h *OrderHandler) ListCustomers(c *gin.Context) {
-	customers, err := h.customerRepo.List(make(map[string]interface{}), 100, 0)
+	customers, err := h.customerRepo.List(make(map[string]interface{}), dataScope(c), 100, 0)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -174,7 +272,7 @@ func (h *OrderHandler) GetStuckOrders(c *gin.Context) {
 		fmt.Sscanf(hoursParam, "%d", &hours)
 	}
 
-	orders, err := h.orderRepo.FindStuckOrders(hours, 50, 0)
+	orders, err := h.orderRepo.FindStuckOrders(hours, dataScope(c), 50, 0)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -2,19 +2,47 @@ package handler
 
 import (
 	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/sgl-disasur/api/internal/domain"
+	"github.com/sgl-disasur/api/internal/infrastructure/export"
+	"github.com/sgl-disasur/api/internal/usecase/products"
 )
 
+// maxImportSize limita el tamaño de los archivos de catálogo (10MB)
+const maxImportSize = 10 * 1024 * 1024
+
 type ProductHandler struct {
-	productRepo domain.ProductRepository
+	importUC      *products.ImportProductsUseCase
+	updateUC      *products.UpdateProductUseCase
+	deactivateUC  *products.DeactivateProductUseCase
+	packagingUC   *products.SetPackagingUseCase
+	productRepo   domain.ProductRepository
+	priceRepo     domain.ProductPriceRepository
+	packagingRepo domain.ProductPackagingRepository
 }
 
-func NewProductHandler(productRepo domain.ProductRepository) *ProductHandler {
+func NewProductHandler(
+	importUC *products.ImportProductsUseCase,
+	updateUC *products.UpdateProductUseCase,
+	deactivateUC *products.DeactivateProductUseCase,
+	packagingUC *products.SetPackagingUseCase,
+	productRepo domain.ProductRepository,
+	priceRepo domain.ProductPriceRepository,
+	packagingRepo domain.ProductPackagingRepository,
+) *ProductHandler {
 	return &ProductHandler{
-		productRepo: productRepo,
+		importUC:      importUC,
+		updateUC:      updateUC,
+		deactivateUC:  deactivateUC,
+		packagingUC:   packagingUC,
+		productRepo:   productRepo,
+		priceRepo:     priceRepo,
+		packagingRepo: packagingRepo,
 	}
 }
 
@@ -102,3 +130,375 @@ func (h *ProductHandler) Create(c *gin.Context) {
 
 	c.JSON(http.StatusCreated, product)
 }
+
+// ReplaceProduct godoc
+// @Summary      Reemplazar producto
+// @Description  Actualiza el producto completo (name, brand y unit_price obligatorios). Un cambio de precio se registra en el historial con vigencia effective_from
+// @Tags         products
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                          true  "Product ID"
+// @Param        product  body      products.UpdateProductInput     true  "Datos del producto"
+// @Success      200      {object}  products.UpdateProductOutput
+// @Failure      400      {object}  map[string]string
+// @Security     Bearer
+// @Router       /api/v1/products/{id} [put]
+func (h *ProductHandler) Replace(c *gin.Context) {
+	h.update(c, true)
+}
+
+// PatchProduct godoc
+// @Summary      Actualizar producto parcialmente
+// @Description  Actualiza solo los campos enviados. Un cambio de precio se registra en el historial con vigencia effective_from
+// @Tags         products
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                          true  "Product ID"
+// @Param        product  body      products.UpdateProductInput     true  "Campos a actualizar"
+// @Success      200      {object}  products.UpdateProductOutput
+// @Failure      400      {object}  map[string]string
+// @Security     Bearer
+// @Router       /api/v1/products/{id} [patch]
+func (h *ProductHandler) Update(c *gin.Context) {
+	h.update(c, false)
+}
+
+func (h *ProductHandler) update(c *gin.Context, replace bool) {
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var input products.UpdateProductInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	input.ProductID = productID
+	input.Replace = replace
+	input.UserID = userID
+
+	result, err := h.updateUC.Execute(input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// DeleteProduct godoc
+// @Summary      Dar de baja producto
+// @Description  Desactiva el producto (soft-delete); el historial de pedidos y precios se conserva
+// @Tags         products
+// @Produce      json
+// @Param        id   path      string  true  "Product ID"
+// @Success      200  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Security     Bearer
+// @Router       /api/v1/products/{id} [delete]
+func (h *ProductHandler) Delete(c *gin.Context) {
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	if err := h.deactivateUC.Execute(productID, userID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Producto dado de baja"})
+}
+
+// GetPriceHistory godoc
+// @Summary      Historial de precios de un producto
+// @Description  Lista los cambios de precio del producto, del más reciente al más antiguo
+// @Tags         products
+// @Produce      json
+// @Param        id   path      string  true  "Product ID"
+// @Success      200  {array}   domain.ProductPrice
+// @Security     Bearer
+// @Router       /api/v1/products/{id}/price-history [get]
+func (h *ProductHandler) PriceHistory(c *gin.Context) {
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	prices, err := h.priceRepo.FindByProduct(productID, 100, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, prices)
+}
+
+// GetPackaging godoc
+// @Summary      Niveles de empaque de un producto
+// @Description  Lista pieza, caja y tarima con su factor de conversión a piezas. Si no hay niveles configurados solo aplica la pieza
+// @Tags         products
+// @Produce      json
+// @Param        id   path      string  true  "Product ID"
+// @Success      200  {array}   domain.ProductPackaging
+// @Security     Bearer
+// @Router       /api/v1/products/{id}/packaging [get]
+func (h *ProductHandler) GetPackaging(c *gin.Context) {
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	levels, err := h.packagingRepo.FindByProduct(productID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, levels)
+}
+
+// SetPackaging godoc
+// @Summary      Configurar niveles de empaque
+// @Description  Reemplaza los niveles de empaque (PIEZA, CAJA, TARIMA) con piezas por empaque, peso y dimensiones
+// @Tags         products
+// @Accept       json
+// @Produce      json
+// @Param        id         path      string                      true  "Product ID"
+// @Param        packaging  body      products.SetPackagingInput  true  "Niveles de empaque"
+// @Success      200        {array}   domain.ProductPackaging
+// @Failure      400        {object}  map[string]string
+// @Security     Bearer
+// @Router       /api/v1/products/{id}/packaging [put]
+func (h *ProductHandler) SetPackaging(c *gin.Context) {
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var input products.SetPackagingInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	input.ProductID = productID
+	input.UserID = userID
+
+	levels, err := h.packagingUC.Execute(input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, levels)
+}
+
+var priceChangeColumns = []export.Column{
+	{Header: "SKU", Width: 16},
+	{Header: "Precio anterior", Width: 16},
+	{Header: "Precio nuevo", Width: 16},
+	{Header: "Vigente desde", Width: 20},
+	{Header: "Modificado por", Width: 36},
+	{Header: "Registrado", Width: 20},
+}
+
+// PriceChangesReport godoc
+// @Summary      Reporte de cambios de precio
+// @Description  Cambios de precio con vigencia dentro del periodo (default: últimos 30 días)
+// @Tags         reports
+// @Produce      json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/pdf
+// @Param        from    query     string  false  "Fecha inicial (YYYY-MM-DD)"
+// @Param        to      query     string  false  "Fecha final (YYYY-MM-DD)"
+// @Param        format  query     string  false  "Formato: json (default), csv, xlsx, pdf"
+// @Success      200     {array}   domain.ProductPrice
+// @Failure      400     {object}  map[string]string
+// @Security     Bearer
+// @Router       /api/v1/reports/price-changes [get]
+func (h *ProductHandler) PriceChanges(c *gin.Context) {
+	to := time.Now()
+	from := to.AddDate(0, 0, -30)
+
+	if v := c.Query("from"); v != "" {
+		parsed, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Fecha 'from' inválida (YYYY-MM-DD)"})
+			return
+		}
+		from = parsed
+	}
+	if v := c.Query("to"); v != "" {
+		parsed, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Fecha 'to' inválida (YYYY-MM-DD)"})
+			return
+		}
+		// Incluir el día completo
+		to = parsed.Add(24*time.Hour - time.Nanosecond)
+	}
+
+	format, ok := requestedFormat(c)
+	if !ok {
+		return
+	}
+
+	if format == export.FormatJSON {
+		prices, err := h.priceRepo.ListChanges(from, to, 100, 0)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, prices)
+		return
+	}
+
+	writeExport(c, format, "cambios_precio", "Cambios de precio", priceChangeColumns,
+		func(limit, offset int) ([][]interface{}, error) {
+			prices, err := h.priceRepo.ListChanges(from, to, limit, offset)
+			if err != nil {
+				return nil, err
+			}
+			rows := make([][]interface{}, 0, len(prices))
+			for _, p := range prices {
+				rows = append(rows, []interface{}{
+					p.SKU, p.PreviousPrice, p.UnitPrice, p.EffectiveFrom, p.ChangedBy, p.CreatedAt,
+				})
+			}
+			return rows, nil
+		})
+}
+
+// ImportProducts godoc
+// @Summary      Importar catálogo de productos
+// @Description  Carga masiva CSV/XLSX con upsert por SKU. Con dry_run=true solo valida y retorna el reporte de errores por fila
+// @Tags         products
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        file     formData  file    true   "Archivo CSV o XLSX con el layout de /products/export"
+// @Param        dry_run  query     bool    false  "Solo validar, sin guardar"
+// @Param        effective_from  formData  string  false  "Vigencia de los precios de la lista (YYYY-MM-DD, default: inmediata)"
+// @Success      200      {object}  products.ImportProductsOutput
+// @Failure      400      {object}  map[string]string
+// @Failure      422      {object}  products.ImportProductsOutput
+// @Security     Bearer
+// @Router       /api/v1/products/import [post]
+func (h *ProductHandler) Import(c *gin.Context) {
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Archivo no proporcionado"})
+		return
+	}
+	defer file.Close()
+
+	if header.Size > maxImportSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Archivo demasiado grande. Máximo 10MB"})
+		return
+	}
+
+	format, err := export.ParseFormat(strings.TrimPrefix(filepath.Ext(header.Filename), "."))
+	if err != nil || (format != export.FormatCSV && format != export.FormatXLSX) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tipo de archivo no permitido. Solo: CSV, XLSX"})
+		return
+	}
+
+	var effectiveFrom *time.Time
+	if v := c.PostForm("effective_from"); v != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Fecha 'effective_from' inválida (YYYY-MM-DD)"})
+			return
+		}
+		effectiveFrom = &parsed
+	}
+
+	rows, delimiter, err := export.ReadRows(format, file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No se pudo leer el archivo: " + err.Error()})
+		return
+	}
+
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	result, err := h.importUC.Execute(products.ImportProductsInput{
+		Rows:          rows,
+		DecimalComma:  delimiter == ';',
+		DryRun:        c.Query("dry_run") == "true",
+		EffectiveFrom: effectiveFrom,
+		UserID:        userID,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(result.Errors) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, result)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ExportProducts godoc
+// @Summary      Exportar catálogo de productos
+// @Description  Descarga el catálogo completo con el mismo layout que acepta /products/import
+// @Tags         products
+// @Produce      text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/pdf
+// @Param        format    query     string  false  "Formato: csv (default), xlsx, pdf"
+// @Param        brand     query     string  false  "Filtrar por marca"
+// @Param        category  query     string  false  "Filtrar por categoría"
+// @Success      200       {file}    file
+// @Failure      400       {object}  map[string]string
+// @Security     Bearer
+// @Router       /api/v1/products/export [get]
+func (h *ProductHandler) Export(c *gin.Context) {
+	format := export.FormatCSV
+	if c.Query("format") != "" {
+		var ok bool
+		if format, ok = requestedFormat(c); !ok {
+			return
+		}
+		if format == export.FormatJSON {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "La exportación no admite JSON; use GET /api/v1/products"})
+			return
+		}
+	}
+
+	filters := make(map[string]interface{})
+	if brand := c.Query("brand"); brand != "" {
+		filters["brand"] = brand
+	}
+	if category := c.Query("category"); category != "" {
+		filters["category"] = category
+	}
+
+	writeExport(c, format, "catalogo_productos", "Catálogo de productos", products.CatalogColumns,
+		func(limit, offset int) ([][]interface{}, error) {
+			list, err := h.productRepo.List(filters, limit, offset)
+			if err != nil {
+				return nil, err
+			}
+			rows := make([][]interface{}, 0, len(list))
+			for _, p := range list {
+				rows = append(rows, products.CatalogRow(p))
+			}
+			return rows, nil
+		})
+}
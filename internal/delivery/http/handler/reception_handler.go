@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -45,6 +46,7 @@ func NewReceptionHandler(
 // @Param        order  body      reception.CreateReceptionOrderInput  true  "Datos de la orden"
 // @Success      201    {object}  domain.ReceptionOrder
 // @Failure      400    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
 // @Security     Bearer
 // @Router       /api/v1/reception/orders [post]
 func (h *ReceptionHandler) CreateOrder(c *gin.Context) {
@@ -58,8 +60,13 @@ func (h *ReceptionHandler) CreateOrder(c *gin.Context) {
 	userIDStr, _ := c.Get("user_id")
 	userID, _ := uuid.Parse(userIDStr.(string))
 	input.UserID = userID
+	input.Scope = dataScope(c)
 
 	order, err := h.createOrderUC.Execute(input)
+	if errors.Is(err, domain.ErrForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -77,6 +84,7 @@ func (h *ReceptionHandler) CreateOrder(c *gin.Context) {
 // @Param        count  body      reception.BlindCountInput  true  "Datos del conteo"
 // @Success      200    {object}  map[string]string
 // @Failure      400    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
 // @Security     Bearer
 // @Router       /api/v1/reception/blind-count [post]
 func (h *ReceptionHandler) BlindCount(c *gin.Context) {
@@ -89,8 +97,14 @@ func (h *ReceptionHandler) BlindCount(c *gin.Context) {
 	userIDStr, _ := c.Get("user_id")
 	userID, _ := uuid.Parse(userIDStr.(string))
 	input.UserID = userID
+	input.Scope = dataScope(c)
 
-	if err := h.blindCountUC.Execute(input); err != nil {
+	err := h.blindCountUC.Execute(input)
+	if errors.Is(err, domain.ErrForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -107,7 +121,7 @@ func (h *ReceptionHandler) BlindCount(c *gin.Context) {
 // @Security     Bearer
 // @Router       /api/v1/reception/orders [get]
 func (h *ReceptionHandler) ListOrders(c *gin.Context) {
-	orders, err := h.receptionRepo.List(nil, 50, 0)
+	orders, err := h.receptionRepo.List(nil, dataScope(c), 50, 0)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -132,6 +146,9 @@ func (h *ReceptionHandler) GetOrder(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
 		return
 	}
+	if !requireVisible(c, h.receptionRepo.Visible, id, "Orden no encontrada") {
+		return
+	}
 
 	order, err := h.receptionRepo.FindByID(id)
 	if err != nil {
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sgl-disasur/api/internal/domain"
+	"github.com/sgl-disasur/api/internal/usecase/auth"
+)
+
+// RoleHandler expone los permisos por rol (HU-19)
+type RoleHandler struct {
+	permissions *auth.PermissionService
+}
+
+func NewRoleHandler(permissions *auth.PermissionService) *RoleHandler {
+	return &RoleHandler{permissions: permissions}
+}
+
+// MyPermissions godoc
+// @Summary      Permisos del usuario actual
+// @Description  Retorna el rol y los permisos efectivos del usuario autenticado, para mostrar u ocultar opciones en el frontend
+// @Tags         auth
+// @Security     Bearer
+// @Produce      json
+// @Success      200  {object}  auth.RolePermissions
+// @Router       /api/v1/auth/me/permissions [get]
+func (h *RoleHandler) MyPermissions(c *gin.Context) {
+	role := domain.UserRole(c.GetString("user_role"))
+
+	permissions, err := h.permissions.ForRole(role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, auth.RolePermissions{Role: role, Permissions: permissions})
+}
+
+// ListRolePermissions godoc
+// @Summary      Matriz de permisos
+// @Description  Retorna el catálogo de permisos y los permisos asignados a cada rol
+// @Tags         roles
+// @Security     Bearer
+// @Produce      json
+// @Success      200  {object}  auth.PermissionMatrixOutput
+// @Router       /api/v1/roles/permissions [get]
+func (h *RoleHandler) List(c *gin.Context) {
+	output, err := h.permissions.Matrix()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+// UpdateRolePermissions godoc
+// @Summary      Asignar permisos a un rol
+// @Description  Reemplaza los permisos del rol. Aplica a los usuarios del rol desde su siguiente petición.
+// @Tags         roles
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        role  path      string                            true  "Rol"
+// @Param        body  body      auth.UpdateRolePermissionsInput  true  "Permisos del rol"
+// @Success      200   {object}  auth.RolePermissions
+// @Failure      400   {object}  map[string]string
+// @Failure      403   {object}  map[string]string
+// @Router       /api/v1/roles/{role}/permissions [put]
+func (h *RoleHandler) Update(c *gin.Context) {
+	var input auth.UpdateRolePermissionsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	userIDStr, _ := c.Get("user_id")
+	input.UserID, _ = uuid.Parse(userIDStr.(string))
+	input.Role = domain.UserRole(c.Param("role"))
+	input.IPAddress = c.ClientIP()
+
+	output, err := h.permissions.Update(input)
+	if err != nil {
+		if errors.Is(err, domain.ErrForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
@@ -0,0 +1,361 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sgl-disasur/api/internal/domain"
+	"github.com/sgl-disasur/api/internal/usecase/auth"
+)
+
+// UserHandler expone la administración de usuarios (módulo 0)
+type UserHandler struct {
+	registerUseCase *auth.RegisterUserUseCase
+	listUseCase     *auth.ListUsersUseCase
+	manageUseCase   *auth.ManageUserUseCase
+	assignmentUC    *auth.UserAssignmentUseCase
+	userRepo        domain.UserRepository
+}
+
+func NewUserHandler(
+	registerUC *auth.RegisterUserUseCase,
+	listUC *auth.ListUsersUseCase,
+	manageUC *auth.ManageUserUseCase,
+	assignmentUC *auth.UserAssignmentUseCase,
+	userRepo domain.UserRepository,
+) *UserHandler {
+	return &UserHandler{
+		registerUseCase: registerUC,
+		listUseCase:     listUC,
+		manageUseCase:   manageUC,
+		assignmentUC:    assignmentUC,
+		userRepo:        userRepo,
+	}
+}
+
+// ListUsers godoc
+// @Summary      Listar usuarios
+// @Description  Lista los usuarios con filtros por rol, estado y texto (username o email)
+// @Tags         users
+// @Produce      json
+// @Param        role    query     string  false  "Rol"
+// @Param        status  query     string  false  "ACTIVO, BLOQUEADO o INACTIVO"
+// @Param        search  query     string  false  "Coincidencia parcial en username o email"
+// @Param        limit   query     int     false  "Máximo de resultados (default 50, máx. 200)"
+// @Param        offset  query     int     false  "Desplazamiento"
+// @Success      200     {object}  auth.ListUsersOutput
+// @Failure      400     {object}  map[string]string
+// @Security     Bearer
+// @Router       /api/v1/users [get]
+func (h *UserHandler) List(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	output, err := h.listUseCase.Execute(auth.ListUsersInput{
+		Role:   domain.UserRole(c.Query("role")),
+		Status: domain.UserStatus(c.Query("status")),
+		Search: c.Query("search"),
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+// GetUser godoc
+// @Summary      Obtener usuario
+// @Tags         users
+// @Produce      json
+// @Param        id   path      string  true  "ID del usuario"
+// @Success      200  {object}  domain.User
+// @Failure      404  {object}  map[string]string
+// @Security     Bearer
+// @Router       /api/v1/users/{id} [get]
+func (h *UserHandler) GetByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	user, err := h.userRepo.FindByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// CreateUser godoc
+// @Summary      Crear usuario
+// @Description  Da de alta un usuario con cualquier rol (solo ADMIN_TI)
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        user  body      auth.RegisterUserInput  true  "Datos del usuario"
+// @Success      201   {object}  auth.RegisterUserOutput
+// @Failure      400   {object}  map[string]string
+// @Failure      409   {object}  map[string]string
+// @Security     Bearer
+// @Router       /api/v1/users [post]
+func (h *UserHandler) Create(c *gin.Context) {
+	var input auth.RegisterUserInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	userIDStr, _ := c.Get("user_id")
+	input.CreatedBy, _ = uuid.Parse(userIDStr.(string))
+	input.IPAddress = c.ClientIP()
+
+	result, err := h.registerUseCase.Execute(input)
+	if err != nil {
+		c.JSON(userErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+// UpdateUser godoc
+// @Summary      Actualizar usuario
+// @Description  Cambia email y/o rol. Un cambio de rol cierra las sesiones del usuario.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string                true  "ID del usuario"
+// @Param        body  body      auth.UpdateUserInput  true  "Campos a modificar"
+// @Success      200   {object}  domain.User
+// @Failure      400   {object}  map[string]string
+// @Failure      404   {object}  map[string]string
+// @Failure      409   {object}  map[string]string
+// @Security     Bearer
+// @Router       /api/v1/users/{id} [patch]
+func (h *UserHandler) Update(c *gin.Context) {
+	var input auth.UpdateUserInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+	action, ok := userAction(c)
+	if !ok {
+		return
+	}
+	input.UserActionInput = action
+
+	user, err := h.manageUseCase.Update(input)
+	if err != nil {
+		c.JSON(userErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// DeactivateUser godoc
+// @Summary      Desactivar usuario
+// @Description  Da de baja al usuario: no puede iniciar sesión y se cierran sus sesiones
+// @Tags         users
+// @Produce      json
+// @Param        id   path      string  true  "ID del usuario"
+// @Success      200  {object}  domain.User
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Security     Bearer
+// @Router       /api/v1/users/{id}/deactivate [post]
+func (h *UserHandler) Deactivate(c *gin.Context) {
+	h.runAction(c, h.manageUseCase.Deactivate)
+}
+
+// ReactivateUser godoc
+// @Summary      Reactivar usuario
+// @Tags         users
+// @Produce      json
+// @Param        id   path      string  true  "ID del usuario"
+// @Success      200  {object}  domain.User
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Security     Bearer
+// @Router       /api/v1/users/{id}/reactivate [post]
+func (h *UserHandler) Reactivate(c *gin.Context) {
+	h.runAction(c, h.manageUseCase.Reactivate)
+}
+
+// UnlockUser godoc
+// @Summary      Desbloquear cuenta
+// @Description  Desbloquea una cuenta bloqueada por intentos fallidos de login (HU-00)
+// @Tags         users
+// @Produce      json
+// @Param        id   path      string  true  "ID del usuario"
+// @Success      200  {object}  domain.User
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Security     Bearer
+// @Router       /api/v1/users/{id}/unlock [post]
+func (h *UserHandler) Unlock(c *gin.Context) {
+	h.runAction(c, h.manageUseCase.Unlock)
+}
+
+// ResetPassword godoc
+// @Summary      Restablecer contraseña
+// @Description  Asigna una contraseña nueva, desbloquea la cuenta y cierra las sesiones del usuario
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string                   true  "ID del usuario"
+// @Param        body  body      auth.ResetPasswordInput  true  "Nueva contraseña"
+// @Success      200   {object}  domain.User
+// @Failure      400   {object}  map[string]string
+// @Failure      404   {object}  map[string]string
+// @Security     Bearer
+// @Router       /api/v1/users/{id}/reset-password [post]
+func (h *UserHandler) ResetPassword(c *gin.Context) {
+	var input auth.ResetPasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+	action, ok := userAction(c)
+	if !ok {
+		return
+	}
+	input.UserActionInput = action
+
+	user, err := h.manageUseCase.ResetPassword(input)
+	if err != nil {
+		c.JSON(userErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// ResetTOTP godoc
+// @Summary      Restablecer verificación en dos pasos
+// @Description  Desactiva el TOTP y los códigos de recuperación del usuario (teléfono extraviado) y cierra sus sesiones
+// @Tags         users
+// @Produce      json
+// @Param        id   path      string  true  "ID del usuario"
+// @Success      200  {object}  domain.User
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Security     Bearer
+// @Router       /api/v1/users/{id}/2fa/reset [post]
+func (h *UserHandler) ResetTOTP(c *gin.Context) {
+	h.runAction(c, h.manageUseCase.ResetTOTP)
+}
+
+// GetAssignments godoc
+// @Summary      Almacenes y marcas del usuario
+// @Description  Asignaciones que limitan los registros que ve el usuario (los roles con data.all ven todo)
+// @Tags         users
+// @Produce      json
+// @Param        id   path      string  true  "ID del usuario"
+// @Success      200  {object}  domain.UserAssignments
+// @Failure      404  {object}  map[string]string
+// @Security     Bearer
+// @Router       /api/v1/users/{id}/assignments [get]
+func (h *UserHandler) GetAssignments(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	assignments, err := h.assignmentUC.Get(id)
+	if err != nil {
+		c.JSON(userErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, assignments)
+}
+
+// UpdateAssignments godoc
+// @Summary      Asignar almacenes y marcas
+// @Description  Reemplaza los almacenes y marcas del usuario. Una lista vacía no restringe esa dimensión.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string                       true  "ID del usuario"
+// @Param        body  body      auth.UpdateAssignmentsInput  true  "Almacenes y marcas"
+// @Success      200   {object}  domain.UserAssignments
+// @Failure      400   {object}  map[string]string
+// @Failure      404   {object}  map[string]string
+// @Security     Bearer
+// @Router       /api/v1/users/{id}/assignments [put]
+func (h *UserHandler) UpdateAssignments(c *gin.Context) {
+	var input auth.UpdateAssignmentsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+	action, ok := userAction(c)
+	if !ok {
+		return
+	}
+	input.UserActionInput = action
+
+	assignments, err := h.assignmentUC.Update(input)
+	if err != nil {
+		c.JSON(userErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, assignments)
+}
+
+func (h *UserHandler) runAction(c *gin.Context, action func(auth.UserActionInput) (*domain.User, error)) {
+	input, ok := userAction(c)
+	if !ok {
+		return
+	}
+
+	user, err := action(input)
+	if err != nil {
+		c.JSON(userErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// userAction arma la entrada con el usuario de la ruta y el administrador autenticado
+func userAction(c *gin.Context) (auth.UserActionInput, bool) {
+	targetID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return auth.UserActionInput{}, false
+	}
+
+	userIDStr, _ := c.Get("user_id")
+	userID, _ := uuid.Parse(userIDStr.(string))
+
+	return auth.UserActionInput{
+		TargetUserID: targetID,
+		UserID:       userID,
+		IPAddress:    c.ClientIP(),
+	}, true
+}
+
+func userErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrUserNotFound), errors.Is(err, domain.ErrWarehouseNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrForbidden), errors.Is(err, domain.ErrLastAdmin):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrUsernameExists), errors.Is(err, domain.ErrEmailExists):
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
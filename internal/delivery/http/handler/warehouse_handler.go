@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sgl-disasur/api/internal/domain"
+	"github.com/sgl-disasur/api/internal/usecase/inventory"
+)
+
+// WarehouseHandler expone el catálogo de almacenes usado para el alcance de datos
+type WarehouseHandler struct {
+	createUseCase *inventory.CreateWarehouseUseCase
+	warehouseRepo domain.WarehouseRepository
+}
+
+func NewWarehouseHandler(createUC *inventory.CreateWarehouseUseCase, warehouseRepo domain.WarehouseRepository) *WarehouseHandler {
+	return &WarehouseHandler{
+		createUseCase: createUC,
+		warehouseRepo: warehouseRepo,
+	}
+}
+
+// ListWarehouses godoc
+// @Summary      Listar almacenes
+// @Tags         warehouses
+// @Produce      json
+// @Success      200  {array}   domain.Warehouse
+// @Security     Bearer
+// @Router       /api/v1/warehouses [get]
+func (h *WarehouseHandler) List(c *gin.Context) {
+	warehouses, err := h.warehouseRepo.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, warehouses)
+}
+
+// CreateWarehouse godoc
+// @Summary      Crear almacén
+// @Tags         warehouses
+// @Accept       json
+// @Produce      json
+// @Param        warehouse  body      inventory.CreateWarehouseInput  true  "Código y nombre"
+// @Success      201        {object}  domain.Warehouse
+// @Failure      400        {object}  map[string]string
+// @Security     Bearer
+// @Router       /api/v1/warehouses [post]
+func (h *WarehouseHandler) Create(c *gin.Context) {
+	var input inventory.CreateWarehouseInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	userIDStr, _ := c.Get("user_id")
+	input.UserID, _ = uuid.Parse(userIDStr.(string))
+	input.IPAddress = c.ClientIP()
+
+	warehouse, err := h.createUseCase.Execute(input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, warehouse)
+}
+
+// dataScope retorna el alcance que dejó el middleware DataScope; sin él no se ve ningún registro propio
+func dataScope(c *gin.Context) domain.DataScope {
+	if scope, ok := c.Get("data_scope"); ok {
+		return scope.(domain.DataScope)
+	}
+	userIDStr := c.GetString("user_id")
+	userID, _ := uuid.Parse(userIDStr)
+	return domain.DataScope{UserID: userID, Role: domain.UserRole(c.GetString("user_role"))}
+}
+
+// requireVisible responde 404 si el registro está fuera del alcance del usuario
+func requireVisible(c *gin.Context, visible func(uuid.UUID, domain.DataScope) (bool, error), id uuid.UUID, notFound string) bool {
+	ok, err := visible(id, dataScope(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": notFound})
+		return false
+	}
+	return true
+}
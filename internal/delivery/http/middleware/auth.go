@@ -3,13 +3,33 @@ package middleware
 import (
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sgl-disasur/api/internal/domain"
 	"github.com/sgl-disasur/api/internal/infrastructure/security"
 )
 
-// AuthMiddleware verifica el token JWT
-func AuthMiddleware(secretKey string) gin.HandlerFunc {
+// SessionIdleHeader informa al cliente hasta cuándo sigue vigente la sesión si no hay actividad
+const SessionIdleHeader = "X-Session-Idle-Expires-At"
+
+// passwordChangePaths son las únicas rutas disponibles para una sesión que debe cambiar la contraseña
+var passwordChangePaths = map[string]bool{
+	"/api/v1/auth/change-password": true,
+	"/api/v1/auth/logout":          true,
+}
+
+// totpEnrollmentPaths son las únicas rutas disponibles para una sesión que debe configurar 2FA
+var totpEnrollmentPaths = map[string]bool{
+	"/api/v1/auth/2fa/enroll":  true,
+	"/api/v1/auth/2fa/confirm": true,
+	"/api/v1/auth/logout":      true,
+}
+
+// AuthMiddleware verifica el token JWT y que su sesión siga activa (no cerrada, revocada ni inactiva
+// más de idleTimeout). Cada petición válida renueva la ventana de inactividad.
+func AuthMiddleware(keys *security.KeySet, sessionRepo domain.SessionRepository, idleTimeout time.Duration) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -25,14 +45,43 @@ func AuthMiddleware(secretKey string) gin.HandlerFunc {
 			return
 		}
 
-		claims, err := security.ValidateToken(parts[1], secretKey)
-		if err != nil {
+		claims, err := security.ValidateToken(parts[1], keys)
+		// Los tokens de propósito específico (segundo factor) no dan acceso a la API
+		if err != nil || claims.Purpose != "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token inválido o expirado"})
 			c.Abort()
 			return
 		}
 
+		session, err := sessionRepo.FindByToken(parts[1])
+		if err != nil || session.IsExpired() || session.UserID.String() != claims.UserID {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Sesión expirada o cerrada"})
+			c.Abort()
+			return
+		}
+
+		now := time.Now()
+		if session.IsIdle(idleTimeout, now) {
+			_ = sessionRepo.DeleteByFamily(session.FamilyID)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": domain.ErrSessionExpired.Error() + " por inactividad"})
+			c.Abort()
+			return
+		}
+		if err := sessionRepo.Touch(session.ID, now); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+		c.Header(SessionIdleHeader, session.IdleDeadline(idleTimeout, now).Format(time.RFC3339))
+
+		if restriction := sessionRestriction(session, c.FullPath()); restriction != nil {
+			c.JSON(http.StatusForbidden, restriction)
+			c.Abort()
+			return
+		}
+
 		// Guardar información del usuario en el contexto
+		c.Set("token", parts[1])
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
 		c.Set("user_role", claims.Role)
@@ -41,25 +90,103 @@ func AuthMiddleware(secretKey string) gin.HandlerFunc {
 	}
 }
 
-// RequireRole verifica que el usuario tenga uno de los roles permitidos
-func RequireRole(allowedRoles ...string) gin.HandlerFunc {
+// sessionRestriction retorna la respuesta 403 si la sesión está limitada y la ruta no está permitida.
+// Primero se exige el cambio de contraseña y después el enrolamiento 2FA: una sesión con ambas
+// pendientes cambia la contraseña, recibe una sesión nueva y con ella configura 2FA.
+func sessionRestriction(session *domain.Session, path string) gin.H {
+	if session.PasswordChangeRequired {
+		if passwordChangePaths[path] {
+			return nil
+		}
+		return gin.H{
+			"error":                    domain.ErrPasswordChangeRequired.Error(),
+			"password_change_required": true,
+			"totp_enrollment_required": session.TOTPEnrollmentRequired,
+		}
+	}
+	if session.TOTPEnrollmentRequired && !totpEnrollmentPaths[path] {
+		return gin.H{
+			"error":                    domain.ErrTOTPEnrollmentRequired.Error(),
+			"totp_enrollment_required": true,
+		}
+	}
+	return nil
+}
+
+// PermissionChecker resuelve si un rol tiene un permiso
+type PermissionChecker interface {
+	HasPermission(role domain.UserRole, permission domain.Permission) (bool, error)
+}
+
+// RequirePermission verifica que el rol del usuario tenga el permiso (HU-19)
+func RequirePermission(checker PermissionChecker, permission domain.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("user_role")
+		if role == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "No autorizado"})
+			c.Abort()
+			return
+		}
+
+		allowed, err := checker.HasPermission(domain.UserRole(role), permission)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+		if !allowed {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":      "Acceso denegado para este rol",
+				"permission": permission,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// DataScopeResolver arma el alcance de datos del usuario autenticado
+type DataScopeResolver interface {
+	Resolve(userID uuid.UUID, role domain.UserRole) (domain.DataScope, error)
+}
+
+// DataScope deja en el contexto ("data_scope") los almacenes, marcas y registros que ve el usuario
+func DataScope(resolver DataScopeResolver) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userRole, exists := c.Get("user_role")
-		if !exists {
+		userID, err := uuid.Parse(c.GetString("user_id"))
+		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "No autorizado"})
 			c.Abort()
 			return
 		}
 
-		roleStr, ok := userRole.(string)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Rol inválido"})
+		scope, err := resolver.Resolve(userID, domain.UserRole(c.GetString("user_role")))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+
+		c.Set("data_scope", scope)
+		c.Next()
+	}
+}
+
+// RequireRole verifica que el usuario tenga uno de los roles permitidos.
+// Para autorizar operaciones usar RequirePermission; los permisos de cada rol son editables.
+func RequireRole(allowedRoles ...domain.UserRole) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("user_role")
+		if role == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "No autorizado"})
 			c.Abort()
 			return
 		}
 
-		for _, role := range allowedRoles {
-			if roleStr == role {
+		for _, allowed := range allowedRoles {
+			if domain.UserRole(role) == allowed {
 				c.Next()
 				return
 			}
@@ -77,6 +204,7 @@ func CORS() gin.HandlerFunc {
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
 		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE, PATCH")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", SessionIdleHeader)
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"testing"
+
+	"github.com/sgl-disasur/api/internal/domain"
+)
+
+func TestSessionRestriction(t *testing.T) {
+	both := &domain.Session{PasswordChangeRequired: true, TOTPEnrollmentRequired: true}
+	totpOnly := &domain.Session{TOTPEnrollmentRequired: true}
+
+	tests := []struct {
+		name    string
+		session *domain.Session
+		path    string
+		allowed bool
+	}{
+		{"ambas pendientes: cambiar contraseña", both, "/api/v1/auth/change-password", true},
+		{"ambas pendientes: logout", both, "/api/v1/auth/logout", true},
+		{"ambas pendientes: 2FA espera al cambio de contraseña", both, "/api/v1/auth/2fa/enroll", false},
+		{"ambas pendientes: resto de la API", both, "/api/v1/products", false},
+		{"solo 2FA: enrolar", totpOnly, "/api/v1/auth/2fa/enroll", true},
+		{"solo 2FA: confirmar", totpOnly, "/api/v1/auth/2fa/confirm", true},
+		{"solo 2FA: resto de la API", totpOnly, "/api/v1/products", false},
+		{"sin restricciones", &domain.Session{}, "/api/v1/products", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restriction := sessionRestriction(tt.session, tt.path)
+			if allowed := restriction == nil; allowed != tt.allowed {
+				t.Fatalf("permitido = %v, se esperaba %v (%v)", allowed, tt.allowed, restriction)
+			}
+		})
+	}
+}
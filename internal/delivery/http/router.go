@@ -1,20 +1,32 @@
 package http
 
 import (
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"github.com/sgl-disasur/api/internal/delivery/http/handler"
 	"github.com/sgl-disasur/api/internal/delivery/http/middleware"
+	"github.com/sgl-disasur/api/internal/domain"
+	"github.com/sgl-disasur/api/internal/infrastructure/security"
 )
 
 type RouterConfig struct {
 	AuthHandler      *handler.AuthHandler
+	UserHandler      *handler.UserHandler
+	RoleHandler      *handler.RoleHandler
+	KeysHandler      *handler.KeysHandler
+	WarehouseHandler *handler.WarehouseHandler
 	ProductHandler   *handler.ProductHandler
 	ReceptionHandler *handler.ReceptionHandler
 	InventoryHandler *handler.InventoryHandler
 	OrderHandler     *handler.OrderHandler
 	FleetHandler     *handler.FleetHandler
 	FileHandler      *handler.FileHandler
-	SecretKey        string
+	SessionRepo      domain.SessionRepository
+	Permissions      middleware.PermissionChecker
+	DataScope        middleware.DataScopeResolver
+	SessionIdle      time.Duration // Inactividad máxima de una sesión; 0 = sin límite
+	Keys             *security.KeySet
 }
 
 func SetupRouter(config *RouterConfig) *gin.Engine {
@@ -33,48 +45,114 @@ func SetupRouter(config *RouterConfig) *gin.Engine {
 		})
 	})
 
+	// Llaves públicas para validar los JWT desde otros servicios
+	r.GET("/.well-known/jwks.json", config.KeysHandler.JWKS)
+
 	// API v1
+	permission := func(p domain.Permission) gin.HandlerFunc {
+		return middleware.RequirePermission(config.Permissions, p)
+	}
+	// Alcance por almacén, marca y registros propios (HU-19)
+	scoped := middleware.DataScope(config.DataScope)
+
 	v1 := r.Group("/api/v1")
 	{
 		// Rutas públicas - Autenticación
 		auth := v1.Group("/auth")
 		{
 			auth.POST("/login", config.AuthHandler.Login)
-			auth.POST("/register", config.AuthHandler.Register) // Registro público
+			auth.POST("/refresh", config.AuthHandler.Refresh)
+			auth.POST("/2fa/verify", config.AuthHandler.VerifySecondFactor)
+			auth.POST("/register", config.AuthHandler.Register) // Solo el administrador inicial
 		}
 
 		// Rutas protegidas
 		protected := v1.Group("")
-		protected.Use(middleware.AuthMiddleware(config.SecretKey))
+		protected.Use(middleware.AuthMiddleware(config.Keys, config.SessionRepo, config.SessionIdle))
 		{
 			// Logout (requiere autenticación)
-			auth.POST("/logout", config.AuthHandler.Logout)
+			protected.POST("/auth/logout", config.AuthHandler.Logout)
+			protected.POST("/auth/change-password", config.AuthHandler.ChangePassword)
+			protected.POST("/auth/2fa/enroll", config.AuthHandler.EnrollTOTP)
+			protected.POST("/auth/2fa/confirm", config.AuthHandler.ConfirmTOTP)
+			protected.GET("/auth/me/permissions", config.RoleHandler.MyPermissions)
 
 			// === MÓDULO 0: USUARIOS ===
 			users := protected.Group("/users")
-			users.Use(middleware.RequireRole("ADMIN_TI", "GERENTE"))
+			users.Use(permission(domain.PermUsersRead))
 			{
-				users.GET("", func(c *gin.Context) {
-					c.JSON(200, gin.H{"message": "Lista de usuarios - TODO"})
-				})
+				users.GET("", config.UserHandler.List)
+				users.GET("/:id", config.UserHandler.GetByID)
+				users.GET("/:id/assignments", config.UserHandler.GetAssignments)
+
+				admin := users.Group("")
+				admin.Use(permission(domain.PermUsersManage))
+				admin.POST("", config.UserHandler.Create)
+				admin.PATCH("/:id", config.UserHandler.Update)
+				admin.POST("/:id/deactivate", config.UserHandler.Deactivate)
+				admin.POST("/:id/reactivate", config.UserHandler.Reactivate)
+				admin.POST("/:id/unlock", config.UserHandler.Unlock)
+				admin.POST("/:id/reset-password", config.UserHandler.ResetPassword)
+				admin.DELETE("/:id/sessions", config.AuthHandler.RevokeSessions)
+				admin.POST("/:id/2fa/reset", config.UserHandler.ResetTOTP)
+				admin.PUT("/:id/assignments", config.UserHandler.UpdateAssignments)
+			}
+
+			// Almacenes (alcance de datos)
+			warehouses := protected.Group("/warehouses")
+			warehouses.Use(permission(domain.PermUsersRead))
+			{
+				warehouses.GET("", config.WarehouseHandler.List)
+				warehouses.POST("",
+					permission(domain.PermUsersManage),
+					config.WarehouseHandler.Create)
+			}
+
+			// Permisos por rol (HU-19)
+			roles := protected.Group("/roles")
+			roles.Use(permission(domain.PermRolesManage))
+			{
+				roles.GET("/permissions", config.RoleHandler.List)
+				roles.PUT("/:role/permissions", config.RoleHandler.Update)
 			}
 
 			// === MÓDULO 1: PRODUCTOS (HU-04) ===
 			products := protected.Group("/products")
 			{
 				products.GET("", config.ProductHandler.List)
+				products.GET("/export", config.ProductHandler.Export)
 				products.GET("/:id", config.ProductHandler.GetByID)
 				products.POST("",
-					middleware.RequireRole("ADMIN_TI", "JEFE_ALMACEN"),
+					permission(domain.PermProductsManage),
 					config.ProductHandler.Create)
+				products.PUT("/:id",
+					permission(domain.PermProductsManage),
+					config.ProductHandler.Replace)
+				products.PATCH("/:id",
+					permission(domain.PermProductsManage),
+					config.ProductHandler.Update)
+				products.DELETE("/:id",
+					permission(domain.PermProductsManage),
+					config.ProductHandler.Delete)
+				products.GET("/:id/price-history", config.ProductHandler.PriceHistory)
+				products.GET("/:id/packaging", config.ProductHandler.GetPackaging)
+				products.PUT("/:id/packaging",
+					permission(domain.PermProductsManage),
+					config.ProductHandler.SetPackaging)
+
+				// Carga masiva de catálogo / listas de precios
+				products.POST("/import",
+					permission(domain.PermProductsImport),
+					config.ProductHandler.Import)
 			}
 
 			// === MÓDULO 1: RECEPCIÓN ===
 			reception := protected.Group("/reception")
+			reception.Use(scoped)
 			{
 				// HU-01: Alta de órdenes
 				reception.POST("/orders",
-					middleware.RequireRole("JEFE_ALMACEN", "SUPERVISOR"),
+					permission(domain.PermReceptionOrderCreate),
 					config.ReceptionHandler.CreateOrder)
 
 				reception.GET("/orders", config.ReceptionHandler.ListOrders)
@@ -82,17 +160,18 @@ func SetupRouter(config *RouterConfig) *gin.Engine {
 
 				// HU-02/03: Conteo ciego y validación
 				reception.POST("/blind-count",
-					middleware.RequireRole("AUXILIAR", "RECEPCIONISTA"),
+					permission(domain.PermReceptionBlindCount),
 					config.ReceptionHandler.BlindCount)
 
-				// HU-14: Devoluciones - ADMIN_TI AGREGADO
+				// HU-14: Devoluciones
 				reception.POST("/returns",
-					middleware.RequireRole("RECEPCIONISTA", "JEFE_ALMACEN", "ADMIN_TI"),
+					permission(domain.PermReceptionReturn),
 					config.ReceptionHandler.ProcessReturn)
 			}
 
 			// === MÓDULO 2: INVENTARIO ===
 			inventory := protected.Group("/inventory")
+			inventory.Use(scoped)
 			{
 				// HU-05: Monitor de stock
 				inventory.GET("/stock", config.InventoryHandler.GetStock)
@@ -102,25 +181,31 @@ func SetupRouter(config *RouterConfig) *gin.Engine {
 
 				// HU-13: Registro de mermas
 				inventory.POST("/damages",
-					middleware.RequireRole("JEFE_ALMACEN", "SUPERVISOR"),
+					permission(domain.PermInventoryDamageCreate),
 					config.InventoryHandler.RegisterDamage)
 
 				// HU-15: Conteo cíclico
 				inventory.POST("/cycle-counts/generate",
-					middleware.RequireRole("JEFE_ALMACEN"),
+					permission(domain.PermInventoryCycleCountGen),
 					config.InventoryHandler.GenerateCycleCounts)
 
 				inventory.POST("/cycle-counts/perform",
-					middleware.RequireRole("AUXILIAR", "MONTACARGUISTA"),
+					permission(domain.PermInventoryCycleCountPerform),
 					config.InventoryHandler.PerformCycleCount)
+
+				// Lectura de escáneres (EAN/UPC/GS1-128)
+				inventory.POST("/scan",
+					permission(domain.PermInventoryScan),
+					config.InventoryHandler.Scan)
 			}
 
 			// === MÓDULO 3: PEDIDOS ===
 			orders := protected.Group("/orders")
+			orders.Use(scoped)
 			{
 				// HU-07/08/09/18: Crear pedido
 				orders.POST("",
-					middleware.RequireRole("VENDEDOR", "JEFE_TRAFICO"),
+					permission(domain.PermOrdersCreate),
 					config.OrderHandler.CreateOrder)
 
 				orders.GET("", config.OrderHandler.ListOrders)
@@ -132,49 +217,122 @@ func SetupRouter(config *RouterConfig) *gin.Engine {
 
 			// Clientes
 			customers := protected.Group("/customers")
+			customers.Use(scoped)
 			{
 				customers.GET("", config.OrderHandler.ListCustomers)
 				customers.POST("",
-					middleware.RequireRole("ADMIN_TI", "JEFE_TRAFICO", "VENDEDOR"),
+					permission(domain.PermCustomersCreate),
 					config.OrderHandler.CreateCustomer)
+				customers.PUT("/:id/assignment",
+					permission(domain.PermCustomersAssign),
+					config.OrderHandler.AssignCustomer)
 			}
 
 			// === MÓDULO 4: FLOTA ===
 			fleet := protected.Group("/fleet")
+			fleet.Use(scoped)
 			{
 				// Vehículos
 				fleet.GET("/vehicles", config.FleetHandler.ListVehicles)
 
 				// HU-16: Control de mantenimiento
 				fleet.POST("/vehicles/maintenance",
-					middleware.RequireRole("FLOTA", "JEFE_TRAFICO"),
+					permission(domain.PermFleetMaintenanceManage),
 					config.FleetHandler.RegisterMaintenance)
+				fleet.POST("/vehicles/maintenance/:id/close",
+					permission(domain.PermFleetMaintenanceManage),
+					config.FleetHandler.CloseMaintenance)
+				fleet.GET("/vehicles/maintenance/upcoming", config.FleetHandler.UpcomingMaintenance)
+				fleet.GET("/vehicles/:id/odometer", config.FleetHandler.ListOdometerReadings)
+				fleet.POST("/vehicles/:id/odometer",
+					permission(domain.PermFleetOdometerRecord),
+					config.FleetHandler.RecordOdometer)
+				fleet.GET("/maintenance-plans", config.FleetHandler.ListMaintenancePlans)
+				fleet.PUT("/maintenance-plans",
+					permission(domain.PermFleetMaintenancePlanManage),
+					config.FleetHandler.SetMaintenancePlan)
+
+				// Combustible
+				fleet.GET("/vehicles/:id/fuel", config.FleetHandler.VehicleFuel)
+				fleet.POST("/vehicles/:id/fuel",
+					permission(domain.PermFleetFuelRecord),
+					config.FleetHandler.RegisterFuelLoad)
+
+				// Documentos de vehículos y choferes
+				fleet.GET("/vehicles/:id/documents", config.FleetHandler.ListVehicleDocuments)
+				fleet.POST("/vehicles/:id/documents",
+					permission(domain.PermFleetDocumentCreate),
+					config.FleetHandler.RegisterVehicleDocument)
+				fleet.GET("/drivers/:id/documents", config.FleetHandler.ListDriverDocuments)
+				fleet.POST("/drivers/:id/documents",
+					permission(domain.PermFleetDocumentCreate),
+					config.FleetHandler.RegisterDriverDocument)
+				fleet.GET("/documents/alerts",
+					permission(domain.PermFleetDocumentAlerts),
+					config.FleetHandler.DocumentAlerts)
 
 				// Choferes
 				fleet.GET("/drivers", config.FleetHandler.ListDrivers)
+				fleet.GET("/drivers/availability",
+					permission(domain.PermFleetDriverAvailability),
+					config.FleetHandler.DriverAvailability)
+				fleet.PUT("/drivers/:id/schedule",
+					permission(domain.PermFleetDriverSchedule),
+					config.FleetHandler.SetDriverSchedule)
+				fleet.GET("/drivers/:id/absences", config.FleetHandler.ListDriverAbsences)
+				fleet.POST("/drivers/:id/absences",
+					permission(domain.PermFleetDriverSchedule),
+					config.FleetHandler.RegisterDriverAbsence)
 
 				// Rutas
 				fleet.GET("/routes", config.FleetHandler.ListRoutes)
 
 				// HU-10: Asignar ruta
 				fleet.POST("/routes",
-					middleware.RequireRole("JEFE_TRAFICO"),
+					permission(domain.PermFleetRouteAssign),
 					config.FleetHandler.AssignRoute)
 
+				// Consolidación de pedidos y secuencia de paradas
+				fleet.POST("/routes/consolidate",
+					permission(domain.PermFleetRouteAssign),
+					config.FleetHandler.ConsolidateRoute)
+				fleet.GET("/routes/:route_id/stops", config.FleetHandler.GetRouteStops)
+				fleet.GET("/routes/:route_id/load-plan",
+					permission(domain.PermFleetRouteLoadPlan),
+					config.FleetHandler.GetLoadPlan)
+
 				// HU-11: Generar remisión
 				fleet.POST("/routes/:route_id/invoice",
-					middleware.RequireRole("JEFE_TRAFICO", "VENDEDOR"),
+					permission(domain.PermFleetRouteInvoice),
 					config.FleetHandler.GenerateInvoice)
 
 				// HU-17: Check-list pre-salida
 				fleet.POST("/routes/pre-departure-check",
-					middleware.RequireRole("CHOFER"),
+					permission(domain.PermFleetRouteDepart),
 					config.FleetHandler.PerformPreDepartureCheck)
+				fleet.GET("/routes/:route_id/checklist", config.FleetHandler.GetRouteChecklist)
+				fleet.POST("/routes/:route_id/start",
+					permission(domain.PermFleetRouteDepart),
+					config.FleetHandler.StartRoute)
+
+				// Seguimiento GPS
+				fleet.POST("/routes/:route_id/positions",
+					permission(domain.PermFleetTrackingRecord),
+					config.FleetHandler.RecordPositions)
+				fleet.GET("/routes/:route_id/tracking", config.FleetHandler.GetRouteTracking)
+				fleet.GET("/tracking",
+					permission(domain.PermFleetTrackingView),
+					config.FleetHandler.FleetPositions)
+				fleet.GET("/checklist-templates", config.FleetHandler.ListChecklistTemplates)
+				fleet.GET("/checklist-templates/:id", config.FleetHandler.GetChecklistTemplate)
+				fleet.POST("/checklist-templates",
+					permission(domain.PermFleetChecklistTemplate),
+					config.FleetHandler.CreateChecklistTemplate)
 			}
 
 			// === MÓDULO 6: REPORT ES ===
 			reports := protected.Group("/reports")
-			reports.Use(middleware.RequireRole("GERENTE", "ADMIN_TI"))
+			reports.Use(permission(domain.PermReportsView))
 			{
 				// HU-12: Dashboard
 				reports.GET("/dashboard", func(c *gin.Context) {
@@ -197,6 +355,12 @@ func SetupRouter(config *RouterConfig) *gin.Engine {
 				reports.GET("/stuck-orders", func(c *gin.Context) {
 					c.JSON(200, gin.H{"message": "Pedidos atorados - En desarrollo"})
 				})
+
+				// Cambios de precio por periodo
+				reports.GET("/price-changes", config.ProductHandler.PriceChanges)
+
+				// Costos de flotilla por km y por pedido entregado
+				reports.GET("/fleet-costs", config.FleetHandler.FleetCostReport)
 			}
 
 			// === FILES (UPLOAD) ===
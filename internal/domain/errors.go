@@ -12,20 +12,31 @@ var (
 	ErrInternalServer = errors.New("error interno del servidor")
 
 	// Errores de autenticación
-	ErrInvalidCredentials = errors.New("credenciales inválidas")
-	ErrAccountLocked      = errors.New("cuenta bloqueada por intentos fallidos")
-	ErrSessionExpired     = errors.New("sesión expirada")
-	ErrInvalidToken       = errors.New("token inválido")
+	ErrInvalidCredentials     = errors.New("credenciales inválidas")
+	ErrAccountLocked          = errors.New("cuenta bloqueada por intentos fallidos")
+	ErrTooManyAttempts        = errors.New("demasiados intentos fallidos desde esta dirección")
+	ErrSessionExpired         = errors.New("sesión expirada")
+	ErrInvalidToken           = errors.New("token inválido")
+	ErrRefreshTokenReused     = errors.New("refresh token reutilizado; la sesión fue revocada")
+	ErrWeakPassword           = errors.New("la contraseña no cumple la política")
+	ErrPasswordReused         = errors.New("la contraseña ya fue usada recientemente")
+	ErrPasswordChangeRequired = errors.New("debe cambiar su contraseña antes de continuar")
+	ErrInvalidTOTP            = errors.New("código de verificación inválido")
+	ErrTOTPEnrollmentRequired = errors.New("debe configurar la verificación en dos pasos antes de continuar")
 
 	// Errores de usuarios
 	ErrUserNotFound   = errors.New("usuario no encontrado")
 	ErrUsernameExists = errors.New("nombre de usuario ya existe")
 	ErrEmailExists    = errors.New("email ya existe")
+	ErrUserInactive   = errors.New("usuario inactivo")
+	ErrInvalidRole    = errors.New("rol inválido")
+	ErrLastAdmin      = errors.New("debe quedar al menos un ADMIN_TI activo")
 
 	// Errores de inventario
 	ErrInsufficientStock = errors.New("stock insuficiente")
 	ErrExpiredProduct    = errors.New("producto caducado")
 	ErrInvalidLot        = errors.New("lote inválido")
+	ErrWarehouseNotFound = errors.New("almacén no encontrado")
 
 	// Errores de pedidos
 	ErrOrderNotFound         = errors.New("pedido no encontrado")
@@ -35,4 +46,9 @@ var (
 	// Errores de flota
 	ErrVehicleNotAvailable = errors.New("vehículo no disponible")
 	ErrDriverNotAvailable  = errors.New("chofer no disponible")
+	ErrVehicleCapacity     = errors.New("la carga excede la capacidad del vehículo (peso o volumen)")
+	ErrDocumentExpired     = errors.New("documento obligatorio vencido")
+	ErrMaintenanceOverdue  = errors.New("mantenimiento preventivo vencido")
+	ErrChecklistFailed     = errors.New("check-list pre-salida con puntos bloqueantes fallidos")
+	ErrChecklistRequired   = errors.New("la ruta requiere un check-list pre-salida aprobado")
 )
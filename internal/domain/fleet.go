@@ -1,6 +1,12 @@
 package domain
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -44,9 +50,15 @@ type Vehicle struct {
 	Year                int           `json:"year,omitempty" db:"year"`
 	CapacityKg          float64       `json:"capacity_kg" db:"capacity_kg"`
 	CapacityM3          float64       `json:"capacity_m3" db:"capacity_m3"`
+	CargoLengthCm       float64       `json:"cargo_length_cm" db:"cargo_length_cm"` // Caja de carga (interior)
+	CargoWidthCm        float64       `json:"cargo_width_cm" db:"cargo_width_cm"`
+	CargoHeightCm       float64       `json:"cargo_height_cm" db:"cargo_height_cm"`
 	Status              VehicleStatus `json:"status" db:"status"`
+	OdometerKm          float64       `json:"odometer_km" db:"odometer_km"` // Última lectura del odómetro
 	LastMaintenanceDate *time.Time    `json:"last_maintenance_date,omitempty" db:"last_maintenance_date"`
 	NextMaintenanceDate *time.Time    `json:"next_maintenance_date,omitempty" db:"next_maintenance_date"`
+	LastMaintenanceKm   float64       `json:"last_maintenance_km" db:"last_maintenance_km"`
+	NextMaintenanceKm   *float64      `json:"next_maintenance_km,omitempty" db:"next_maintenance_km"`
 	IsActive            bool          `json:"is_active" db:"is_active"`
 	CreatedAt           time.Time     `json:"created_at" db:"created_at"`
 	UpdatedAt           time.Time     `json:"updated_at" db:"updated_at"`
@@ -57,6 +69,211 @@ func (v *Vehicle) IsAvailableForRoute() bool {
 	return v.Status == VehicleDisponible && v.IsActive
 }
 
+// MaintenanceOverdue indica si el vehículo rebasó la fecha o el kilometraje del próximo mantenimiento
+func (v *Vehicle) MaintenanceOverdue(at time.Time) bool {
+	if v.NextMaintenanceDate != nil && !at.Before(*v.NextMaintenanceDate) {
+		return true
+	}
+	return v.NextMaintenanceKm != nil && v.OdometerKm >= *v.NextMaintenanceKm
+}
+
+// MaintenancePlan define cada cuántos km o meses (lo que ocurra primero) requiere servicio un tipo de vehículo
+type MaintenancePlan struct {
+	ID             uuid.UUID   `json:"id" db:"id"`
+	VehicleType    VehicleType `json:"vehicle_type" db:"vehicle_type"`
+	IntervalKm     int         `json:"interval_km" db:"interval_km"`         // 0 = sin límite por km
+	IntervalMonths int         `json:"interval_months" db:"interval_months"` // 0 = sin límite por tiempo
+	CreatedAt      time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at" db:"updated_at"`
+}
+
+// DefaultMaintenancePlan se usa cuando el tipo de vehículo no tiene plan configurado
+var DefaultMaintenancePlan = MaintenancePlan{IntervalMonths: 3}
+
+// Schedule calcula la fecha y el kilometraje del próximo servicio a partir del último
+func (p MaintenancePlan) Schedule(from time.Time, odometerKm float64) (*time.Time, *float64) {
+	var nextDate *time.Time
+	var nextKm *float64
+	if p.IntervalMonths > 0 {
+		date := from.AddDate(0, p.IntervalMonths, 0)
+		nextDate = &date
+	}
+	if p.IntervalKm > 0 {
+		km := odometerKm + float64(p.IntervalKm)
+		nextKm = &km
+	}
+	return nextDate, nextKm
+}
+
+// OdometerReading representa una lectura del odómetro
+type OdometerReading struct {
+	ID         uuid.UUID `json:"id" db:"id"`
+	VehicleID  uuid.UUID `json:"vehicle_id" db:"vehicle_id"`
+	ReadingKm  float64   `json:"reading_km" db:"reading_km"`
+	Source     string    `json:"source" db:"source"` // MANUAL, MANTENIMIENTO
+	RecordedBy uuid.UUID `json:"recorded_by" db:"recorded_by"`
+	RecordedAt time.Time `json:"recorded_at" db:"recorded_at"`
+}
+
+// ReferenceFuelEfficiency es el rendimiento típico (km/l) por tipo, usado mientras el vehículo no tiene historial
+var ReferenceFuelEfficiency = map[VehicleType]float64{
+	VehicleCamioneta: 9,
+	VehicleVan:       8,
+	VehicleCamion35:  6,
+	VehicleTorton:    3.5,
+}
+
+// FuelAnomalyTolerance es la desviación máxima del rendimiento esperado antes de marcar una carga como anómala
+const FuelAnomalyTolerance = 0.35
+
+// FuelLoad representa una carga de combustible
+type FuelLoad struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	VehicleID      uuid.UUID  `json:"vehicle_id" db:"vehicle_id"`
+	DriverID       *uuid.UUID `json:"driver_id,omitempty" db:"driver_id"`
+	Liters         float64    `json:"liters" db:"liters"`
+	TotalCost      float64    `json:"total_cost" db:"total_cost"`
+	OdometerKm     float64    `json:"odometer_km" db:"odometer_km"`
+	Station        string     `json:"station,omitempty" db:"station"`
+	TicketPhotoURL string     `json:"ticket_photo_url,omitempty" db:"ticket_photo_url"`
+	DistanceKm     float64    `json:"distance_km" db:"distance_km"`                   // Recorrido desde la carga anterior
+	EfficiencyKmL  *float64   `json:"efficiency_km_l,omitempty" db:"efficiency_km_l"` // Nil en la primera carga
+	IsAnomaly      bool       `json:"is_anomaly" db:"is_anomaly"`
+	AnomalyReason  string     `json:"anomaly_reason,omitempty" db:"anomaly_reason"`
+	LoadedAt       time.Time  `json:"loaded_at" db:"loaded_at"`
+	RecordedBy     uuid.UUID  `json:"recorded_by" db:"recorded_by"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+}
+
+// PricePerLiter calcula el precio por litro de la carga
+func (f *FuelLoad) PricePerLiter() float64 {
+	if f.Liters == 0 {
+		return 0
+	}
+	return f.TotalCost / f.Liters
+}
+
+// VehicleFuelSummary resume las cargas de combustible de un vehículo en un periodo
+type VehicleFuelSummary struct {
+	VehicleID uuid.UUID `json:"vehicle_id" db:"vehicle_id"`
+	Loads     int       `json:"loads" db:"loads"`
+	Liters    float64   `json:"liters" db:"liters"`
+	Cost      float64   `json:"cost" db:"cost"`
+	Anomalies int       `json:"anomalies" db:"anomalies"`
+}
+
+// VehicleCapacity representa la capacidad de carga por peso y volumen
+type VehicleCapacity struct {
+	WeightKg float64 `json:"weight_kg"`
+	VolumeM3 float64 `json:"volume_m3"`
+}
+
+// CargoDimensions representa el espacio interior de carga (largo desde la cabina hacia la puerta)
+type CargoDimensions struct {
+	LengthCm float64 `json:"length_cm"`
+	WidthCm  float64 `json:"width_cm"`
+	HeightCm float64 `json:"height_cm"`
+}
+
+// VolumeM3 calcula el volumen interior en m³
+func (d CargoDimensions) VolumeM3() float64 {
+	return (d.LengthCm * d.WidthCm * d.HeightCm) / 1000000.0
+}
+
+// ReferenceCapacities son las capacidades típicas por tipo, de menor a mayor.
+// Se usan cuando un vehículo no tiene capturada su capacidad o no hay flota registrada.
+var ReferenceCapacities = []struct {
+	Type     VehicleType
+	Capacity VehicleCapacity
+	Cargo    CargoDimensions
+}{
+	{VehicleCamioneta, VehicleCapacity{WeightKg: 1000, VolumeM3: 5}, CargoDimensions{250, 160, 120}},
+	{VehicleVan, VehicleCapacity{WeightKg: 1500, VolumeM3: 10}, CargoDimensions{330, 170, 180}},
+	{VehicleCamion35, VehicleCapacity{WeightKg: 3500, VolumeM3: 20}, CargoDimensions{450, 220, 200}},
+	{VehicleTorton, VehicleCapacity{WeightKg: 12000, VolumeM3: 40}, CargoDimensions{750, 250, 215}},
+}
+
+// ReferenceCapacity retorna la capacidad típica del tipo de vehículo
+func ReferenceCapacity(vehicleType VehicleType) VehicleCapacity {
+	for _, ref := range ReferenceCapacities {
+		if ref.Type == vehicleType {
+			return ref.Capacity
+		}
+	}
+	return VehicleCapacity{}
+}
+
+// Capacity retorna la capacidad registrada, completando con la de referencia del tipo
+func (v *Vehicle) Capacity() VehicleCapacity {
+	capacity := VehicleCapacity{WeightKg: v.CapacityKg, VolumeM3: v.CapacityM3}
+	ref := ReferenceCapacity(v.VehicleType)
+	if capacity.WeightKg <= 0 {
+		capacity.WeightKg = ref.WeightKg
+	}
+	if capacity.VolumeM3 <= 0 {
+		capacity.VolumeM3 = ref.VolumeM3
+	}
+	return capacity
+}
+
+// Cargo retorna las dimensiones de la caja de carga, o las de referencia del tipo si no están capturadas
+func (v *Vehicle) Cargo() CargoDimensions {
+	if v.CargoLengthCm > 0 && v.CargoWidthCm > 0 && v.CargoHeightCm > 0 {
+		return CargoDimensions{LengthCm: v.CargoLengthCm, WidthCm: v.CargoWidthCm, HeightCm: v.CargoHeightCm}
+	}
+	for _, ref := range ReferenceCapacities {
+		if ref.Type == v.VehicleType {
+			return ref.Cargo
+		}
+	}
+	return CargoDimensions{}
+}
+
+// Fits verifica que la carga no exceda la capacidad por peso ni por volumen
+func (c VehicleCapacity) Fits(weightKg, volumeM3 float64) bool {
+	return c.WeightKg > 0 && c.VolumeM3 > 0 && weightKg <= c.WeightKg && volumeM3 <= c.VolumeM3
+}
+
+// Utilization calcula el porcentaje de uso de la capacidad por peso y volumen
+func (c VehicleCapacity) Utilization(weightKg, volumeM3 float64) LoadUtilization {
+	var u LoadUtilization
+	if c.WeightKg > 0 {
+		u.WeightPct = weightKg / c.WeightKg * 100
+	}
+	if c.VolumeM3 > 0 {
+		u.VolumePct = volumeM3 / c.VolumeM3 * 100
+	}
+	return u
+}
+
+// LoadUtilization representa el aprovechamiento de un vehículo (HU-18)
+type LoadUtilization struct {
+	WeightPct float64 `json:"weight_pct"`
+	VolumePct float64 `json:"volume_pct"`
+}
+
+// SmallestFittingVehicle elige el vehículo más chico donde cabe la carga por peso y volumen
+func SmallestFittingVehicle(vehicles []*Vehicle, weightKg, volumeM3 float64) *Vehicle {
+	var fitting []*Vehicle
+	for _, v := range vehicles {
+		if v.Capacity().Fits(weightKg, volumeM3) {
+			fitting = append(fitting, v)
+		}
+	}
+	if len(fitting) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(fitting, func(i, j int) bool {
+		ci, cj := fitting[i].Capacity(), fitting[j].Capacity()
+		if ci.VolumeM3 != cj.VolumeM3 {
+			return ci.VolumeM3 < cj.VolumeM3
+		}
+		return ci.WeightKg < cj.WeightKg
+	})
+	return fitting[0]
+}
+
 // Driver representa un chofer
 type Driver struct {
 	ID            uuid.UUID    `json:"id" db:"id"`
@@ -64,6 +281,10 @@ type Driver struct {
 	LicenseNumber string       `json:"license_number" db:"license_number"`
 	LicenseExpiry time.Time    `json:"license_expiry" db:"license_expiry"`
 	Phone         string       `json:"phone,omitempty" db:"phone"`
+	LicenseClass  LicenseClass `json:"license_class" db:"license_class"`
+	ShiftStart    string       `json:"shift_start,omitempty" db:"shift_start"` // HH:MM; vacío = sin turno
+	ShiftEnd      string       `json:"shift_end,omitempty" db:"shift_end"`
+	RestDays      string       `json:"rest_days,omitempty" db:"rest_days"` // Días de descanso semanal: 0=domingo ... 6=sábado, separados por coma
 	Status        DriverStatus `json:"status" db:"status"`
 	IsActive      bool         `json:"is_active" db:"is_active"`
 	CreatedAt     time.Time    `json:"created_at" db:"created_at"`
@@ -75,24 +296,282 @@ func (d *Driver) IsAvailableForRoute() bool {
 	return d.Status == DriverDisponible && d.IsActive && time.Now().Before(d.LicenseExpiry)
 }
 
+// CanDriveRouteType verifica que la clase de licencia permita el tipo de ruta
+func (d *Driver) CanDriveRouteType(routeType RouteType) bool {
+	if routeType == RouteForanea {
+		return d.LicenseClass == LicenseForanea
+	}
+	return true
+}
+
+// IsRestDay indica si la fecha cae en un día de descanso semanal del chofer
+func (d *Driver) IsRestDay(t time.Time) bool {
+	weekday := strconv.Itoa(int(t.Weekday()))
+	for _, day := range strings.Split(d.RestDays, ",") {
+		if strings.TrimSpace(day) == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// InShift indica si la hora cae dentro del turno; sin turno configurado siempre es verdadero.
+// Un turno cuyo fin es menor al inicio cruza la medianoche.
+func (d *Driver) InShift(t time.Time) bool {
+	start, errStart := time.Parse("15:04", d.ShiftStart)
+	end, errEnd := time.Parse("15:04", d.ShiftEnd)
+	if errStart != nil || errEnd != nil {
+		return true
+	}
+
+	minute := t.Hour()*60 + t.Minute()
+	from, to := start.Hour()*60+start.Minute(), end.Hour()*60+end.Minute()
+	if from <= to {
+		return minute >= from && minute <= to
+	}
+	return minute >= from || minute <= to
+}
+
+// LicenseClass representa el alcance de la licencia de conducir
+type LicenseClass string
+
+const (
+	LicenseLocal   LicenseClass = "LOCAL"   // Solo rutas locales
+	LicenseForanea LicenseClass = "FORANEA" // Rutas locales y foráneas
+)
+
+// IsValid verifica si la clase de licencia es válida
+func (c LicenseClass) IsValid() bool {
+	return c == LicenseLocal || c == LicenseForanea
+}
+
+// DriverAbsence representa un descanso o licencia del chofer en un rango de fechas
+type DriverAbsence struct {
+	ID        uuid.UUID    `json:"id" db:"id"`
+	DriverID  uuid.UUID    `json:"driver_id" db:"driver_id"`
+	Type      DriverStatus `json:"type" db:"absence_type"` // DESCANSO o LICENCIA
+	StartDate time.Time    `json:"start_date" db:"start_date"`
+	EndDate   time.Time    `json:"end_date" db:"end_date"` // Inclusive
+	Notes     string       `json:"notes,omitempty" db:"notes"`
+	CreatedBy uuid.UUID    `json:"created_by" db:"created_by"`
+	CreatedAt time.Time    `json:"created_at" db:"created_at"`
+}
+
+// Overlaps indica si la ausencia cubre algún momento entre from y to
+func (a *DriverAbsence) Overlaps(from, to time.Time) bool {
+	return !from.After(a.EndDate) && !to.Before(a.StartDate)
+}
+
+// DocumentOwner indica si un documento pertenece a un chofer o a un vehículo
+type DocumentOwner string
+
+const (
+	OwnerDriver  DocumentOwner = "DRIVER"
+	OwnerVehicle DocumentOwner = "VEHICLE"
+)
+
+// DocumentType representa el tipo de documento de flota
+type DocumentType string
+
+const (
+	DocLicencia           DocumentType = "LICENCIA"
+	DocCertificadoMedico  DocumentType = "CERTIFICADO_MEDICO"
+	DocPolizaSeguro       DocumentType = "POLIZA_SEGURO"
+	DocTarjetaCirculacion DocumentType = "TARJETA_CIRCULACION"
+	DocVerificacion       DocumentType = "VERIFICACION" // Verificación de emisiones
+)
+
+// RequiredDocuments son los documentos que deben estar vigentes para salir a ruta
+var RequiredDocuments = map[DocumentOwner][]DocumentType{
+	OwnerDriver:  {DocLicencia, DocCertificadoMedico},
+	OwnerVehicle: {DocPolizaSeguro, DocTarjetaCirculacion, DocVerificacion},
+}
+
+// Owner retorna a quién corresponde el tipo de documento ("" si el tipo no es válido)
+func (t DocumentType) Owner() DocumentOwner {
+	for owner, types := range RequiredDocuments {
+		for _, documentType := range types {
+			if documentType == t {
+				return owner
+			}
+		}
+	}
+	return ""
+}
+
+// FleetDocument representa un documento con vigencia de un chofer o vehículo
+type FleetDocument struct {
+	ID             uuid.UUID     `json:"id" db:"id"`
+	OwnerType      DocumentOwner `json:"owner_type" db:"owner_type"`
+	OwnerID        uuid.UUID     `json:"owner_id" db:"owner_id"`
+	DocumentType   DocumentType  `json:"document_type" db:"document_type"`
+	DocumentNumber string        `json:"document_number,omitempty" db:"document_number"`
+	FileURL        string        `json:"file_url" db:"file_url"` // Archivo subido en /files/upload
+	IssueDate      *time.Time    `json:"issue_date,omitempty" db:"issue_date"`
+	ExpiryDate     time.Time     `json:"expiry_date" db:"expiry_date"`
+	UploadedBy     uuid.UUID     `json:"uploaded_by" db:"uploaded_by"`
+	CreatedAt      time.Time     `json:"created_at" db:"created_at"`
+}
+
+// IsExpired indica si el documento ya no está vigente en la fecha indicada; es válido todo el día de vencimiento
+func (d *FleetDocument) IsExpired(at time.Time) bool {
+	return !at.Before(d.ExpiryDate.AddDate(0, 0, 1))
+}
+
+// DaysToExpiry retorna los días que faltan para el vencimiento (negativo si ya venció)
+func (d *FleetDocument) DaysToExpiry(now time.Time) int {
+	return int(math.Floor(d.ExpiryDate.Sub(now).Hours() / 24))
+}
+
+// Horas de manejo estimadas cuando la ruta no tiene hora de llegada
+const (
+	DefaultLocalRouteHours   = 4.0
+	DefaultForaneaRouteHours = 9.0
+)
+
+// EstimatedDrivingHours calcula las horas de manejo entre salida y llegada estimada
+func EstimatedDrivingHours(departure, arrival time.Time, routeType RouteType) float64 {
+	if !departure.IsZero() && arrival.After(departure) {
+		return arrival.Sub(departure).Hours()
+	}
+	if routeType == RouteForanea {
+		return DefaultForaneaRouteHours
+	}
+	return DefaultLocalRouteHours
+}
+
+// DayHours son las horas de manejo de una ruta en un día calendario
+type DayHours struct {
+	Day   time.Time
+	Hours float64
+}
+
+// DrivingHoursByDay reparte el tiempo en ruta desde la salida entre los días calendario de loc.
+// Cada día cuenta a lo más maxDaily horas de manejo; el resto del tiempo en ruta es descanso del chofer.
+func DrivingHoursByDay(departure time.Time, hours, maxDaily float64, loc *time.Location) []DayHours {
+	var days []DayHours
+	start := departure.In(loc)
+	end := start.Add(time.Duration(hours * float64(time.Hour)))
+	for start.Before(end) {
+		day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc)
+		next := day.AddDate(0, 0, 1)
+		if next.After(end) {
+			next = end
+		}
+		days = append(days, DayHours{Day: day, Hours: math.Min(next.Sub(start).Hours(), maxDaily)})
+		start = next
+	}
+	return days
+}
+
 // Route representa una ruta/viaje
 type Route struct {
-	ID               uuid.UUID   `json:"id" db:"id"`
-	RouteNumber      string      `json:"route_number" db:"route_number"`
-	OrderID          uuid.UUID   `json:"order_id" db:"order_id"`
-	VehicleID        uuid.UUID   `json:"vehicle_id" db:"vehicle_id"`
-	DriverID         uuid.UUID   `json:"driver_id" db:"driver_id"`
-	RouteType        RouteType   `json:"route_type" db:"route_type"`
-	DepartureDate    *time.Time  `json:"departure_date,omitempty" db:"departure_date"`
-	EstimatedArrival *time.Time  `json:"estimated_arrival,omitempty" db:"estimated_arrival"`
-	ActualArrival    *time.Time  `json:"actual_arrival,omitempty" db:"actual_arrival"`
-	Status           OrderStatus `json:"status" db:"status"`
-	InvoicePDFURL    string      `json:"invoice_pdf_url,omitempty" db:"invoice_pdf_url"`
-	AssignedBy       uuid.UUID   `json:"assigned_by" db:"assigned_by"`
-	CreatedAt        time.Time   `json:"created_at" db:"created_at"`
-	UpdatedAt        time.Time   `json:"updated_at" db:"updated_at"`
+	ID               uuid.UUID  `json:"id" db:"id"`
+	RouteNumber      string     `json:"route_number" db:"route_number"`
+	OrderID          uuid.UUID  `json:"order_id" db:"order_id"`
+	VehicleID        uuid.UUID  `json:"vehicle_id" db:"vehicle_id"`
+	DriverID         uuid.UUID  `json:"driver_id" db:"driver_id"`
+	RouteType        RouteType  `json:"route_type" db:"route_type"`
+	DepartureDate    *time.Time `json:"departure_date,omitempty" db:"departure_date"` // Salida programada
+	EstimatedArrival *time.Time `json:"estimated_arrival,omitempty" db:"estimated_arrival"`
+	// Salida real registrada por el chofer al iniciar la ruta
+	ActualDeparture     *time.Time  `json:"actual_departure,omitempty" db:"actual_departure"`
+	DepartureOdometerKm *float64    `json:"departure_odometer_km,omitempty" db:"departure_odometer_km"`
+	ActualArrival       *time.Time  `json:"actual_arrival,omitempty" db:"actual_arrival"`
+	Status              OrderStatus `json:"status" db:"status"`
+	InvoicePDFURL       string      `json:"invoice_pdf_url,omitempty" db:"invoice_pdf_url"`
+	AssignedBy          uuid.UUID   `json:"assigned_by" db:"assigned_by"`
+	CreatedAt           time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt           time.Time   `json:"updated_at" db:"updated_at"`
+}
+
+// RouteReportRow es una ruta con el folio del pedido, las placas y el chofer para los reportes
+type RouteReportRow struct {
+	Route
+	OrderNumber string `json:"order_number" db:"order_number"`
+	PlateNumber string `json:"plate_number" db:"plate_number"`
+	DriverName  string `json:"driver_name" db:"driver_name"`
+}
+
+// DrivingHours estima las horas de manejo de la ruta con la llegada real o, si no hay, la estimada
+func (r *Route) DrivingHours() float64 {
+	var departure, arrival time.Time
+	if r.DepartureDate != nil {
+		departure = *r.DepartureDate
+	}
+	if r.ActualArrival != nil {
+		arrival = *r.ActualArrival
+	} else if r.EstimatedArrival != nil {
+		arrival = *r.EstimatedArrival
+	}
+	return EstimatedDrivingHours(departure, arrival, r.RouteType)
+}
+
+// DrivingHoursByDay reparte las horas de manejo de la ruta por día calendario (ver DrivingHoursByDay)
+func (r *Route) DrivingHoursByDay(maxDaily float64, loc *time.Location) []DayHours {
+	if r.DepartureDate == nil {
+		return nil
+	}
+	return DrivingHoursByDay(*r.DepartureDate, r.DrivingHours(), maxDaily, loc)
+}
+
+// StopStatus representa el estado de una parada de la ruta
+type StopStatus string
+
+const (
+	StopPendiente StopStatus = "PENDIENTE"
+	StopEntregada StopStatus = "ENTREGADA"
+	StopFallida   StopStatus = "FALLIDA"
+)
+
+// RouteStop representa una parada (pedido/cliente) de una ruta con varias entregas
+type RouteStop struct {
+	ID               uuid.UUID  `json:"id" db:"id"`
+	RouteID          uuid.UUID  `json:"route_id" db:"route_id"`
+	OrderID          uuid.UUID  `json:"order_id" db:"order_id"`
+	CustomerID       uuid.UUID  `json:"customer_id" db:"customer_id"`
+	Sequence         int        `json:"sequence" db:"sequence"`
+	Address          string     `json:"address,omitempty" db:"address"`
+	Latitude         *float64   `json:"latitude,omitempty" db:"latitude"`
+	Longitude        *float64   `json:"longitude,omitempty" db:"longitude"`
+	DistanceKm       float64    `json:"distance_km" db:"distance_km"` // Desde la parada anterior
+	EstimatedArrival *time.Time `json:"estimated_arrival,omitempty" db:"estimated_arrival"`
+	ActualArrival    *time.Time `json:"actual_arrival,omitempty" db:"actual_arrival"`
+	Status           StopStatus `json:"status" db:"status"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// PositionSource representa el origen de una posición GPS
+type PositionSource string
+
+const (
+	PositionTelefono   PositionSource = "TELEFONO"   // App del chofer
+	PositionTelematica PositionSource = "TELEMATICA" // Dispositivo instalado en el vehículo
+)
+
+// VehiclePosition es una posición GPS del vehículo durante una ruta
+type VehiclePosition struct {
+	ID         uuid.UUID      `json:"id" db:"id"`
+	RouteID    uuid.UUID      `json:"route_id" db:"route_id"`
+	VehicleID  uuid.UUID      `json:"vehicle_id" db:"vehicle_id"`
+	Latitude   float64        `json:"latitude" db:"latitude"`
+	Longitude  float64        `json:"longitude" db:"longitude"`
+	SpeedKmh   *float64       `json:"speed_kmh,omitempty" db:"speed_kmh"`
+	Heading    *float64       `json:"heading,omitempty" db:"heading"` // Grados desde el norte
+	AccuracyM  *float64       `json:"accuracy_m,omitempty" db:"accuracy_m"`
+	Source     PositionSource `json:"source" db:"source"`
+	RecordedAt time.Time      `json:"recorded_at" db:"recorded_at"` // Hora del dispositivo
+	ReceivedAt time.Time      `json:"received_at" db:"received_at"`
 }
 
+// Tipos de mantenimiento
+const (
+	MaintenancePreventivo = "PREVENTIVO" // Reinicia el plan de mantenimiento al cerrarse
+	MaintenanceCorrectivo = "CORRECTIVO"
+	MaintenanceNeumaticos = "NEUMATICOS"
+)
+
 // VehicleMaintenance representa un registro de mantenimiento
 type VehicleMaintenance struct {
 	ID              uuid.UUID  `json:"id" db:"id"`
@@ -103,6 +582,7 @@ type VehicleMaintenance struct {
 	StartDate       time.Time  `json:"start_date" db:"start_date"`
 	EndDate         *time.Time `json:"end_date,omitempty" db:"end_date"`
 	PerformedBy     string     `json:"performed_by,omitempty" db:"performed_by"`
+	OdometerKm      float64    `json:"odometer_km" db:"odometer_km"`
 	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
 }
 
@@ -110,6 +590,7 @@ type VehicleMaintenance struct {
 type PreDepartureChecklist struct {
 	ID             uuid.UUID `json:"id" db:"id"`
 	RouteID        uuid.UUID `json:"route_id" db:"route_id"`
+	VehicleID      uuid.UUID `json:"vehicle_id" db:"vehicle_id"`
 	DriverID       uuid.UUID `json:"driver_id" db:"driver_id"`
 	TireCondition  string    `json:"tire_condition,omitempty" db:"tire_condition"`
 	FuelLevel      int       `json:"fuel_level" db:"fuel_level"`
@@ -118,6 +599,148 @@ type PreDepartureChecklist struct {
 	DamagePhotoURL string    `json:"damage_photo_url,omitempty" db:"damage_photo_url"`
 	Notes          string    `json:"notes,omitempty" db:"notes"`
 	CheckedAt      time.Time `json:"checked_at" db:"checked_at"`
+	// Check-list por plantilla; los registros anteriores usan las columnas fijas
+	TemplateID    *uuid.UUID         `json:"template_id,omitempty" db:"template_id"`
+	Passed        bool               `json:"passed" db:"passed"`
+	MaintenanceID *uuid.UUID         `json:"maintenance_id,omitempty" db:"maintenance_id"` // Mantenimiento correctivo abierto
+	Answers       []*ChecklistAnswer `json:"answers,omitempty" db:"-"`
+}
+
+// ChecklistAnswerType representa el tipo de respuesta de un punto del check-list
+type ChecklistAnswerType string
+
+const (
+	AnswerBoolean ChecklistAnswerType = "BOOLEANO" // Pasa si la respuesta es verdadera
+	AnswerScale   ChecklistAnswerType = "ESCALA"   // 1 a ChecklistScaleMax; pasa desde min_value
+	AnswerPhoto   ChecklistAnswerType = "FOTO"     // Pasa si se adjunta la foto
+	AnswerNumber  ChecklistAnswerType = "NUMERO"   // Pasa dentro de min_value y max_value
+)
+
+// ChecklistScaleMax es el valor máximo de las respuestas de escala
+const ChecklistScaleMax = 5
+
+// IsValid verifica si el tipo de respuesta es válido
+func (t ChecklistAnswerType) IsValid() bool {
+	switch t {
+	case AnswerBoolean, AnswerScale, AnswerPhoto, AnswerNumber:
+		return true
+	}
+	return false
+}
+
+// ChecklistTemplate es una versión del check-list pre-salida de un tipo de vehículo.
+// Las versiones no se modifican: cambiar los puntos crea una versión nueva y desactiva la anterior.
+type ChecklistTemplate struct {
+	ID          uuid.UUID        `json:"id" db:"id"`
+	VehicleType VehicleType      `json:"vehicle_type" db:"vehicle_type"`
+	Version     int              `json:"version" db:"version"`
+	Name        string           `json:"name" db:"name"`
+	IsActive    bool             `json:"is_active" db:"is_active"`
+	CreatedBy   *uuid.UUID       `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt   time.Time        `json:"created_at" db:"created_at"`
+	Items       []*ChecklistItem `json:"items,omitempty" db:"-"`
+}
+
+// ChecklistItem es un punto a revisar de la plantilla
+type ChecklistItem struct {
+	ID         uuid.UUID           `json:"id" db:"id"`
+	TemplateID uuid.UUID           `json:"template_id" db:"template_id"`
+	Sequence   int                 `json:"sequence" db:"sequence"`
+	Code       string              `json:"code" db:"code"`
+	Label      string              `json:"label" db:"label"`
+	AnswerType ChecklistAnswerType `json:"answer_type" db:"answer_type"`
+	Required   bool                `json:"required" db:"required"`
+	Blocking   bool                `json:"blocking" db:"blocking"` // Si falla, el vehículo no sale y pasa a taller
+	MinValue   *float64            `json:"min_value,omitempty" db:"min_value"`
+	MaxValue   *float64            `json:"max_value,omitempty" db:"max_value"`
+}
+
+// Validate verifica el tipo de respuesta y los límites del punto
+func (i *ChecklistItem) Validate() error {
+	if i.Code == "" || i.Label == "" {
+		return errors.New("cada punto requiere code y label")
+	}
+	if !i.AnswerType.IsValid() {
+		return fmt.Errorf("punto %s: tipo de respuesta inválido %s. Use: BOOLEANO, ESCALA, FOTO, NUMERO", i.Code, i.AnswerType)
+	}
+	hasLimits := i.MinValue != nil || i.MaxValue != nil
+	if (i.AnswerType == AnswerBoolean || i.AnswerType == AnswerPhoto) && hasLimits {
+		return fmt.Errorf("punto %s: min_value y max_value solo aplican a ESCALA y NUMERO", i.Code)
+	}
+	if i.MinValue != nil && i.MaxValue != nil && *i.MinValue > *i.MaxValue {
+		return fmt.Errorf("punto %s: min_value no puede ser mayor a max_value", i.Code)
+	}
+	if i.AnswerType == AnswerScale {
+		for _, limit := range []*float64{i.MinValue, i.MaxValue} {
+			if limit != nil && (*limit < 1 || *limit > ChecklistScaleMax) {
+				return fmt.Errorf("punto %s: los límites de la escala van de 1 a %d", i.Code, ChecklistScaleMax)
+			}
+		}
+	}
+	return nil
+}
+
+// Evaluate valida la respuesta y marca si el punto pasa. Una respuesta nil solo se
+// acepta en puntos no obligatorios y se retorna nil.
+func (i *ChecklistItem) Evaluate(answer *ChecklistAnswer) (*ChecklistAnswer, error) {
+	if answer == nil || answer.isEmpty() {
+		if i.Required {
+			return nil, fmt.Errorf("falta la respuesta de %s (%s)", i.Code, i.Label)
+		}
+		return nil, nil
+	}
+
+	answer.ItemID = i.ID
+	answer.Passed = true
+	switch i.AnswerType {
+	case AnswerBoolean:
+		if answer.BoolValue == nil {
+			return nil, fmt.Errorf("%s requiere una respuesta sí/no", i.Code)
+		}
+		if !*answer.BoolValue {
+			answer.fail("%s: no cumple", i.Label)
+		}
+	case AnswerPhoto:
+		if answer.PhotoURL == "" {
+			return nil, fmt.Errorf("%s requiere photo_url", i.Code)
+		}
+	case AnswerScale, AnswerNumber:
+		if answer.NumberValue == nil {
+			return nil, fmt.Errorf("%s requiere un valor numérico", i.Code)
+		}
+		value := *answer.NumberValue
+		if i.AnswerType == AnswerScale && (value < 1 || value > ChecklistScaleMax || value != math.Trunc(value)) {
+			return nil, fmt.Errorf("%s requiere un valor entero de 1 a %d", i.Code, ChecklistScaleMax)
+		}
+		if i.MinValue != nil && value < *i.MinValue {
+			answer.fail("%s: %g menor al mínimo %g", i.Label, value, *i.MinValue)
+		}
+		if i.MaxValue != nil && value > *i.MaxValue {
+			answer.fail("%s: %g mayor al máximo %g", i.Label, value, *i.MaxValue)
+		}
+	}
+	return answer, nil
+}
+
+// ChecklistAnswer es la respuesta a un punto del check-list
+type ChecklistAnswer struct {
+	ID            uuid.UUID `json:"id" db:"id"`
+	ChecklistID   uuid.UUID `json:"checklist_id" db:"checklist_id"`
+	ItemID        uuid.UUID `json:"item_id" db:"item_id"`
+	BoolValue     *bool     `json:"bool_value,omitempty" db:"bool_value"`
+	NumberValue   *float64  `json:"number_value,omitempty" db:"number_value"`
+	PhotoURL      string    `json:"photo_url,omitempty" db:"photo_url"`
+	Passed        bool      `json:"passed" db:"passed"`
+	FailureReason string    `json:"failure_reason,omitempty" db:"failure_reason"`
+}
+
+func (a *ChecklistAnswer) isEmpty() bool {
+	return a.BoolValue == nil && a.NumberValue == nil && a.PhotoURL == ""
+}
+
+func (a *ChecklistAnswer) fail(format string, args ...interface{}) {
+	a.Passed = false
+	a.FailureReason = fmt.Sprintf(format, args...)
 }
 
 // VehicleRepository define los métodos para vehículos
@@ -139,22 +762,104 @@ type DriverRepository interface {
 	List(filters map[string]interface{}, limit, offset int) ([]*Driver, error)
 }
 
+// DriverAbsenceRepository define los métodos para descansos y licencias de choferes
+type DriverAbsenceRepository interface {
+	Create(absence *DriverAbsence) error
+	FindByDriver(driverID uuid.UUID) ([]*DriverAbsence, error)
+	FindOverlapping(driverID uuid.UUID, from, to time.Time) ([]*DriverAbsence, error)
+	Delete(id uuid.UUID) error
+}
+
+// FleetDocumentRepository define los métodos para documentos de choferes y vehículos
+type FleetDocumentRepository interface {
+	Create(document *FleetDocument) error
+	FindByOwner(ownerType DocumentOwner, ownerID uuid.UUID) ([]*FleetDocument, error)
+	FindCurrent(ownerType DocumentOwner, ownerID uuid.UUID) ([]*FleetDocument, error) // El de vencimiento más lejano por tipo
+	ListExpiring(until time.Time) ([]*FleetDocument, error)                           // Documentos vigentes por tipo que vencen antes de until
+}
+
 // RouteRepository define los métodos para rutas
 type RouteRepository interface {
 	Create(route *Route) error
+	// CreateAssigned crea la ruta y sus paradas en una transacción: bloquea los pedidos de las paradas, el
+	// vehículo y el chofer, revalida sus estados y deja los pedidos LISTO y vehículo y chofer EN_RUTA
+	CreateAssigned(route *Route, stops []*RouteStop) error
 	FindByID(id uuid.UUID) (*Route, error)
 	Update(route *Route) error
-	List(filters map[string]interface{}, limit, offset int) ([]*Route, error)
+	List(filters map[string]interface{}, scope DataScope, limit, offset int) ([]*Route, error)
+	ListForReport(scope DataScope, limit, offset int) ([]*RouteReportRow, error)
+	// Visible indica si la ruta está dentro del alcance del usuario
+	Visible(id uuid.UUID, scope DataScope) (bool, error)
+	ListByDriver(driverID uuid.UUID, from, to time.Time) ([]*Route, error) // Salidas en [from, to), sin canceladas
+	CountDeliveredByVehicle(from, to time.Time) (map[uuid.UUID]int, error) // Pedidos entregados por vehículo
+	ListByStatus(status OrderStatus) ([]*Route, error)
+	CountActiveByVehicle(vehicleID uuid.UUID) (int, error) // Rutas CONFIRMADO o EN_RUTA del vehículo
+}
+
+// VehiclePositionRepository define los métodos para posiciones GPS
+type VehiclePositionRepository interface {
+	CreateBatch(positions []*VehiclePosition) error
+	FindByRoute(routeID uuid.UUID, limit int) ([]*VehiclePosition, error) // Las más recientes, en orden cronológico
+	LatestEnRuta() ([]*VehiclePosition, error)                            // Última posición de cada ruta EN_RUTA
+}
+
+// RouteStopRepository define los métodos para paradas de ruta
+type RouteStopRepository interface {
+	CreateBatch(stops []*RouteStop) error
+	FindByRouteID(routeID uuid.UUID) ([]*RouteStop, error)
+	Update(stop *RouteStop) error
 }
 
 // VehicleMaintenanceRepository define los métodos para mantenimiento
 type VehicleMaintenanceRepository interface {
 	Create(maintenance *VehicleMaintenance) error
+	FindByID(id uuid.UUID) (*VehicleMaintenance, error)
+	Update(maintenance *VehicleMaintenance) error
+	FindOpenByType(vehicleID uuid.UUID, maintenanceType string) (*VehicleMaintenance, error) // La orden abierta más reciente del tipo
+	// Open y Close guardan la lectura de odómetro (opcional), la orden y el vehículo en una transacción
+	Open(maintenance *VehicleMaintenance, vehicle *Vehicle, reading *OdometerReading) error
+	Close(maintenance *VehicleMaintenance, vehicle *Vehicle, reading *OdometerReading) error
 	FindByVehicleID(vehicleID uuid.UUID, limit, offset int) ([]*VehicleMaintenance, error)
+	CountOpen(vehicleID uuid.UUID) (int, error)                      // Mantenimientos sin fecha de cierre
+	CostByVehicle(from, to time.Time) (map[uuid.UUID]float64, error) // Costo de mantenimientos iniciados en el periodo
+}
+
+// FuelLoadRepository define los métodos para cargas de combustible
+type FuelLoadRepository interface {
+	Create(load *FuelLoad) error
+	// Register guarda la lectura de odómetro, la carga y el vehículo en una transacción
+	Register(load *FuelLoad, vehicle *Vehicle, reading *OdometerReading) error
+	FindByVehicle(vehicleID uuid.UUID, limit, offset int) ([]*FuelLoad, error) // Más recientes primero
+	FindLastBefore(vehicleID uuid.UUID, at time.Time) (*FuelLoad, error)       // Última carga hasta at
+	SummarizeByVehicle(from, to time.Time) ([]*VehicleFuelSummary, error)
+}
+
+// MaintenancePlanRepository define los métodos para planes de mantenimiento
+type MaintenancePlanRepository interface {
+	Upsert(plan *MaintenancePlan) error
+	FindByVehicleType(vehicleType VehicleType) (*MaintenancePlan, error)
+	List() ([]*MaintenancePlan, error)
+}
+
+// OdometerReadingRepository define los métodos para lecturas de odómetro
+type OdometerReadingRepository interface {
+	Create(reading *OdometerReading) error
+	FindByVehicle(vehicleID uuid.UUID, limit, offset int) ([]*OdometerReading, error)
+	DistanceByVehicle(from, to time.Time) (map[uuid.UUID]float64, error) // Km recorridos según lecturas del periodo
 }
 
 // PreDepartureChecklistRepository define los métodos para check-list
 type PreDepartureChecklistRepository interface {
 	Create(checklist *PreDepartureChecklist) error
-	FindByRouteID(routeID uuid.UUID) (*PreDepartureChecklist, error)
+	// CreateWithMaintenance guarda el check-list no aprobado, abre el mantenimiento y actualiza el vehículo en una transacción
+	CreateWithMaintenance(checklist *PreDepartureChecklist, maintenance *VehicleMaintenance, vehicle *Vehicle) error
+	FindByRouteID(routeID uuid.UUID) (*PreDepartureChecklist, error) // El más reciente, con sus respuestas
+}
+
+// ChecklistTemplateRepository define los métodos para plantillas de check-list
+type ChecklistTemplateRepository interface {
+	Create(template *ChecklistTemplate) error // Asigna la siguiente versión y desactiva la anterior
+	FindByID(id uuid.UUID) (*ChecklistTemplate, error)
+	FindActive(vehicleType VehicleType) (*ChecklistTemplate, error)
+	List(vehicleType VehicleType) ([]*ChecklistTemplate, error) // Todas las versiones; vacío = todos los tipos
 }
@@ -38,6 +38,7 @@ type Inventory struct {
 	Quantity          int         `json:"quantity" db:"quantity"`
 	Status            StockStatus `json:"status" db:"status"`
 	WarehouseLocation string      `json:"warehouse_location,omitempty" db:"warehouse_location"`
+	WarehouseID       uuid.UUID   `json:"warehouse_id" db:"warehouse_id"` // Todo lote pertenece a un almacén
 	LastMovementAt    *time.Time  `json:"last_movement_at,omitempty" db:"last_movement_at"`
 	CreatedAt         time.Time   `json:"created_at" db:"created_at"`
 	UpdatedAt         time.Time   `json:"updated_at" db:"updated_at"`
@@ -95,11 +96,13 @@ type CycleCount struct {
 type InventoryRepository interface {
 	Create(inventory *Inventory) error
 	FindByID(id uuid.UUID) (*Inventory, error)
-	FindByProduct(productID uuid.UUID) ([]*Inventory, error)
-	FindByProductFEFO(productID uuid.UUID) ([]*Inventory, error) // First Expired First Out
+	FindByProduct(productID uuid.UUID, scope DataScope) ([]*Inventory, error)
+	FindByProductFEFO(productID uuid.UUID, scope DataScope) ([]*Inventory, error) // First Expired First Out
 	Update(inventory *Inventory) error
 	ListAvailable(filters map[string]interface{}, limit, offset int) ([]*Inventory, error)
 	GetStockByProduct(productID uuid.UUID) (int, error)
+	// Visible indica si el lote está dentro del alcance del usuario
+	Visible(id uuid.UUID, scope DataScope) (bool, error)
 }
 
 // InventoryMovementRepository define los métodos para movimientos
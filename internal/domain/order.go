@@ -38,12 +38,16 @@ type Customer struct {
 	City        string    `json:"city,omitempty" db:"city"`
 	State       string    `json:"state,omitempty" db:"state"`
 	PostalCode  string    `json:"postal_code,omitempty" db:"postal_code"`
+	Latitude    *float64  `json:"latitude,omitempty" db:"latitude"` // Geocodificación para secuenciar paradas
+	Longitude   *float64  `json:"longitude,omitempty" db:"longitude"`
 	Phone       string    `json:"phone,omitempty" db:"phone"`
 	Email       string    `json:"email,omitempty" db:"email"`
 	CreditLimit float64   `json:"credit_limit" db:"credit_limit"`
 	IsActive    bool      `json:"is_active" db:"is_active"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	// AssignedTo es el vendedor que atiende al cliente; solo él ve sus pedidos (además de quien los captura)
+	AssignedTo *uuid.UUID `json:"assigned_to,omitempty" db:"assigned_to"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
 }
 
 // Order representa un pedido
@@ -65,16 +69,37 @@ type Order struct {
 	DeletedAt        *time.Time   `json:"-" db:"deleted_at"`
 }
 
-// SuggestVehicle sugiere el tipo de vehículo basado en volumen (HU-08)
+// SuggestVehicle sugiere el tipo más chico cuya capacidad de referencia cubre peso y volumen (HU-08).
+// Se usa cuando no hay vehículos registrados donde quepa el pedido.
 func (o *Order) SuggestVehicle() VehicleType {
-	if o.TotalVolumeM3 < 10 {
-		return VehicleVan
-	} else if o.TotalVolumeM3 < 20 {
-		return VehicleCamion35
+	for _, ref := range ReferenceCapacities {
+		if ref.Capacity.Fits(o.TotalWeightKg, o.TotalVolumeM3) {
+			return ref.Type
+		}
 	}
 	return VehicleTorton
 }
 
+// SuggestVehicleFrom elige el vehículo más chico de la flota donde cabe el pedido (HU-08).
+// Retorna nil si ningún vehículo tiene capacidad suficiente.
+func (o *Order) SuggestVehicleFrom(vehicles []*Vehicle) *Vehicle {
+	return SmallestFittingVehicle(vehicles, o.TotalWeightKg, o.TotalVolumeM3)
+}
+
+// RouteReadyStatuses son los estados en que un pedido puede asignarse a una ruta
+var RouteReadyStatuses = []OrderStatus{OrderConfirmado, OrderEnPreparacion}
+
+// IsReadyForRoute indica si el pedido puede asignarse a una ruta: confirmado o en preparación.
+// Al asignarse pasa a LISTO; los pedidos ya ruteados, en borrador, entregados o cancelados no se asignan.
+func (o *Order) IsReadyForRoute() bool {
+	for _, status := range RouteReadyStatuses {
+		if o.Status == status {
+			return true
+		}
+	}
+	return false
+}
+
 // GenerateLoadingAlert genera alerta de estiba si hay productos frágiles y pesados (HU-09)
 func (o *Order) GenerateLoadingAlert() string {
 	if o.HasFragileItems && o.HasHeavyItems {
@@ -86,6 +111,12 @@ func (o *Order) GenerateLoadingAlert() string {
 	return ""
 }
 
+// OrderReportRow es un pedido con el nombre del cliente para los reportes
+type OrderReportRow struct {
+	Order
+	CustomerName string `json:"customer_name" db:"customer_name"`
+}
+
 // OrderLine representa una línea de pedido
 type OrderLine struct {
 	ID          uuid.UUID  `json:"id" db:"id"`
@@ -105,8 +136,11 @@ type OrderRepository interface {
 	FindByOrderNumber(orderNumber string) (*Order, error)
 	Update(order *Order) error
 	Delete(id uuid.UUID) error
-	List(filters map[string]interface{}, limit, offset int) ([]*Order, error)
-	FindStuckOrders(hours int, limit, offset int) ([]*Order, error) // HU-24
+	List(filters map[string]interface{}, scope DataScope, limit, offset int) ([]*Order, error)
+	ListForReport(filters map[string]interface{}, scope DataScope, limit, offset int) ([]*OrderReportRow, error)
+	FindStuckOrders(hours int, scope DataScope, limit, offset int) ([]*Order, error) // HU-24
+	// Visible indica si el pedido está dentro del alcance del usuario
+	Visible(id uuid.UUID, scope DataScope) (bool, error)
 }
 
 // OrderLineRepository define los métodos para líneas de pedido
@@ -121,5 +155,5 @@ type CustomerRepository interface {
 	Create(customer *Customer) error
 	FindByID(id uuid.UUID) (*Customer, error)
 	Update(customer *Customer) error
-	List(filters map[string]interface{}, limit, offset int) ([]*Customer, error)
+	List(filters map[string]interface{}, scope DataScope, limit, offset int) ([]*Customer, error)
 }
@@ -0,0 +1,113 @@
+package domain
+
+// Permission es una acción autorizable con nombre módulo.recurso.acción.
+// Los roles reciben permisos en la tabla role_permissions, editable por ADMIN_TI.
+type Permission string
+
+const (
+	// Usuarios y permisos
+	PermUsersRead   Permission = "users.read"
+	PermUsersManage Permission = "users.manage"
+	PermRolesManage Permission = "roles.manage"
+	// PermDataAll omite el filtro por almacén, marca y registros propios
+	PermDataAll Permission = "data.all"
+
+	// Productos
+	PermProductsManage Permission = "products.manage"
+	PermProductsImport Permission = "products.import"
+
+	// Recepción
+	PermReceptionOrderCreate Permission = "reception.order.create"
+	PermReceptionBlindCount  Permission = "reception.blind_count.perform"
+	PermReceptionReturn      Permission = "reception.return.create"
+
+	// Inventario
+	PermInventoryDamageCreate      Permission = "inventory.damage.create"
+	PermInventoryCycleCountGen     Permission = "inventory.cycle_count.generate"
+	PermInventoryCycleCountPerform Permission = "inventory.cycle_count.perform"
+	PermInventoryScan              Permission = "inventory.scan"
+
+	// Pedidos y clientes
+	PermOrdersCreate    Permission = "orders.create"
+	PermCustomersCreate Permission = "customers.create"
+	PermCustomersAssign Permission = "customers.assign"
+
+	// Flota
+	PermFleetMaintenanceManage     Permission = "fleet.maintenance.manage"
+	PermFleetMaintenancePlanManage Permission = "fleet.maintenance_plan.manage"
+	PermFleetOdometerRecord        Permission = "fleet.odometer.record"
+	PermFleetFuelRecord            Permission = "fleet.fuel.record"
+	PermFleetDocumentCreate        Permission = "fleet.document.create"
+	PermFleetDocumentAlerts        Permission = "fleet.document.alerts"
+	PermFleetDriverAvailability    Permission = "fleet.driver.availability"
+	PermFleetDriverSchedule        Permission = "fleet.driver.schedule"
+	PermFleetRouteAssign           Permission = "fleet.route.assign"
+	PermFleetRouteLoadPlan         Permission = "fleet.route.load_plan"
+	PermFleetRouteInvoice          Permission = "fleet.route.invoice"
+	PermFleetRouteDepart           Permission = "fleet.route.depart"
+	PermFleetTrackingRecord        Permission = "fleet.tracking.record"
+	PermFleetTrackingView          Permission = "fleet.tracking.view"
+	PermFleetChecklistTemplate     Permission = "fleet.checklist_template.manage"
+
+	// Reportes
+	PermReportsView Permission = "reports.view"
+)
+
+// PermissionInfo describe un permiso del catálogo
+type PermissionInfo struct {
+	Name        Permission `json:"name"`
+	Description string     `json:"description"`
+}
+
+// Permissions es el catálogo de permisos que reconoce la API
+var Permissions = []PermissionInfo{
+	{PermUsersRead, "Consultar usuarios"},
+	{PermUsersManage, "Alta, edición, baja, desbloqueo y restablecimientos de usuarios"},
+	{PermRolesManage, "Editar los permisos de cada rol"},
+	{PermDataAll, "Ver los registros de todos los almacenes, marcas, clientes y rutas"},
+	{PermProductsManage, "Alta, edición, baja y empaques de productos"},
+	{PermProductsImport, "Carga masiva de catálogo y listas de precios"},
+	{PermReceptionOrderCreate, "Crear órdenes de recepción (HU-01)"},
+	{PermReceptionBlindCount, "Registrar conteo ciego (HU-02/03)"},
+	{PermReceptionReturn, "Procesar devoluciones (HU-14)"},
+	{PermInventoryDamageCreate, "Registrar mermas (HU-13)"},
+	{PermInventoryCycleCountGen, "Generar conteos cíclicos (HU-15)"},
+	{PermInventoryCycleCountPerform, "Realizar conteos cíclicos (HU-15)"},
+	{PermInventoryScan, "Leer códigos de barras para conteos y surtido"},
+	{PermOrdersCreate, "Crear pedidos (HU-07/08/09)"},
+	{PermCustomersCreate, "Crear clientes"},
+	{PermCustomersAssign, "Asignar o reasignar el vendedor de un cliente"},
+	{PermFleetMaintenanceManage, "Registrar y cerrar mantenimientos (HU-16)"},
+	{PermFleetMaintenancePlanManage, "Configurar planes de mantenimiento preventivo"},
+	{PermFleetOdometerRecord, "Registrar lecturas de odómetro"},
+	{PermFleetFuelRecord, "Registrar cargas de combustible"},
+	{PermFleetDocumentCreate, "Registrar documentos de vehículos y choferes"},
+	{PermFleetDocumentAlerts, "Consultar documentos por vencer"},
+	{PermFleetDriverAvailability, "Consultar disponibilidad de choferes"},
+	{PermFleetDriverSchedule, "Editar horarios y ausencias de choferes"},
+	{PermFleetRouteAssign, "Asignar y consolidar rutas (HU-10)"},
+	{PermFleetRouteLoadPlan, "Consultar el plan de carga"},
+	{PermFleetRouteInvoice, "Generar remisiones (HU-11)"},
+	{PermFleetRouteDepart, "Check-list pre-salida e inicio de ruta (HU-17)"},
+	{PermFleetTrackingRecord, "Enviar posiciones GPS"},
+	{PermFleetTrackingView, "Consultar la posición de la flota"},
+	{PermFleetChecklistTemplate, "Crear plantillas de check-list"},
+	{PermReportsView, "Consultar reportes (HU-12/23/24)"},
+}
+
+// IsValid verifica si el permiso está en el catálogo
+func (p Permission) IsValid() bool {
+	for _, info := range Permissions {
+		if info.Name == p {
+			return true
+		}
+	}
+	return false
+}
+
+// RolePermissionRepository define los métodos de repositorio para los permisos por rol
+type RolePermissionRepository interface {
+	ListAll() (map[UserRole][]Permission, error)
+	// Replace sustituye todos los permisos del rol
+	Replace(role UserRole, permissions []Permission) error
+}
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,6 +18,15 @@ const (
 	BrandOtros     Brand = "OTROS"
 )
 
+// IsValid verifica si la marca es una de las soportadas
+func (b Brand) IsValid() bool {
+	switch b {
+	case BrandCostena, BrandJumex, BrandPronto, BrandLaCostena, BrandOtros:
+		return true
+	}
+	return false
+}
+
 // Product representa un producto en el catálogo
 type Product struct {
 	ID        uuid.UUID  `json:"id" db:"id"`
@@ -42,6 +52,133 @@ func (p *Product) CalculateVolume() float64 {
 	return (p.LengthCm * p.WidthCm * p.HeightCm) / 1000000.0
 }
 
+// Reglas de estiba: peso que soporta encima un bulto, como múltiplo de su propio peso
+const (
+	StackLoadFactor        = 4.0
+	FragileStackLoadFactor = 0.5 // Sobre frágiles solo bultos ligeros
+)
+
+// MaxLoadOnTopKg retorna el peso máximo que puede colocarse sobre un bulto del producto
+func (p *Product) MaxLoadOnTopKg(packageWeightKg float64) float64 {
+	if p.IsFragile {
+		return packageWeightKg * FragileStackLoadFactor
+	}
+	return packageWeightKg * StackLoadFactor
+}
+
+// UnitOfMeasure representa el nivel de empaque en que se expresa una cantidad
+type UnitOfMeasure string
+
+const (
+	UnitPieza  UnitOfMeasure = "PIEZA" // Unidad base del inventario
+	UnitCaja   UnitOfMeasure = "CAJA"
+	UnitTarima UnitOfMeasure = "TARIMA"
+)
+
+// IsValid verifica si la unidad es una de las soportadas
+func (u UnitOfMeasure) IsValid() bool {
+	switch u {
+	case UnitPieza, UnitCaja, UnitTarima:
+		return true
+	}
+	return false
+}
+
+// ProductPackaging representa un nivel de empaque con su factor de conversión a piezas
+type ProductPackaging struct {
+	ID              uuid.UUID     `json:"id" db:"id"`
+	ProductID       uuid.UUID     `json:"product_id" db:"product_id"`
+	Unit            UnitOfMeasure `json:"unit" db:"unit"`
+	UnitsPerPackage int           `json:"units_per_package" db:"units_per_package"` // Piezas por empaque
+	WeightKg        float64       `json:"weight_kg" db:"weight_kg"`
+	LengthCm        float64       `json:"length_cm" db:"length_cm"`
+	WidthCm         float64       `json:"width_cm" db:"width_cm"`
+	HeightCm        float64       `json:"height_cm" db:"height_cm"`
+	CreatedAt       time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+// CalculateVolume calcula el volumen del empaque en m³
+func (p *ProductPackaging) CalculateVolume() float64 {
+	return (p.LengthCm * p.WidthCm * p.HeightCm) / 1000000.0
+}
+
+// BasePackaging retorna el nivel pieza con el peso y dimensiones del producto
+func (p *Product) BasePackaging() *ProductPackaging {
+	return &ProductPackaging{
+		ProductID:       p.ID,
+		Unit:            UnitPieza,
+		UnitsPerPackage: 1,
+		WeightKg:        p.WeightKg,
+		LengthCm:        p.LengthCm,
+		WidthCm:         p.WidthCm,
+		HeightCm:        p.HeightCm,
+	}
+}
+
+// FindPackaging busca el nivel de empaque de la unidad indicada (vacío = pieza)
+func (p *Product) FindPackaging(levels []*ProductPackaging, unit UnitOfMeasure) (*ProductPackaging, error) {
+	if unit == "" {
+		unit = UnitPieza
+	}
+	for _, level := range levels {
+		if level.Unit == unit {
+			return level, nil
+		}
+	}
+	if unit == UnitPieza {
+		return p.BasePackaging(), nil
+	}
+	return nil, fmt.Errorf("el producto %s no tiene empaque %s configurado", p.SKU, unit)
+}
+
+// PackagedQuantity es una cantidad expresada en un nivel de empaque
+type PackagedQuantity struct {
+	Product   *Product
+	Packaging *ProductPackaging
+	Quantity  int
+}
+
+// BaseUnits convierte la cantidad a piezas (unidad del inventario)
+func (q PackagedQuantity) BaseUnits() int {
+	return q.Quantity * q.Packaging.UnitsPerPackage
+}
+
+// WeightKg usa el peso del empaque si está capturado; si no, el de las piezas
+func (q PackagedQuantity) WeightKg() float64 {
+	if q.Packaging.WeightKg > 0 {
+		return q.Packaging.WeightKg * float64(q.Quantity)
+	}
+	return q.Product.WeightKg * float64(q.BaseUnits())
+}
+
+// VolumeM3 usa las dimensiones del empaque si están capturadas; si no, las de las piezas
+func (q PackagedQuantity) VolumeM3() float64 {
+	if volume := q.Packaging.CalculateVolume(); volume > 0 {
+		return volume * float64(q.Quantity)
+	}
+	return q.Product.CalculateVolume() * float64(q.BaseUnits())
+}
+
+// ProductPrice representa un precio de lista vigente a partir de una fecha
+type ProductPrice struct {
+	ID            uuid.UUID  `json:"id" db:"id"`
+	ProductID     uuid.UUID  `json:"product_id" db:"product_id"`
+	SKU           string     `json:"sku,omitempty" db:"sku"` // Solo en reportes
+	UnitPrice     float64    `json:"unit_price" db:"unit_price"`
+	PreviousPrice *float64   `json:"previous_price,omitempty" db:"previous_price"`
+	EffectiveFrom time.Time  `json:"effective_from" db:"effective_from"`
+	ChangedBy     *uuid.UUID `json:"changed_by,omitempty" db:"changed_by"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+}
+
+// ProductImport es un producto a crear o actualizar en una importación de catálogo
+type ProductImport struct {
+	Product  *Product
+	Existing bool
+	Price    *ProductPrice // Cambio de precio a registrar en el historial (nil si no cambia)
+}
+
 // Supplier representa un proveedor
 type Supplier struct {
 	ID          uuid.UUID `json:"id" db:"id"`
@@ -65,7 +202,25 @@ type ProductRepository interface {
 	Update(product *Product) error
 	Delete(id uuid.UUID) error
 	List(filters map[string]interface{}, limit, offset int) ([]*Product, error)
+	ListInScope(filters map[string]interface{}, scope DataScope, limit, offset int) ([]*Product, error) // Solo marcas del usuario y productos con lotes en sus almacenes
 	Count(filters map[string]interface{}) (int, error)
+	Import(items []*ProductImport) error                         // Aplica todas las altas, cambios y precios en una transacción
+	UpdateWithPrice(product *Product, price *ProductPrice) error // Guarda el producto y su cambio de precio (opcional) en una transacción
+	Deactivate(product *Product) error                           // Marca inactivo y da de baja (soft-delete) en una transacción
+}
+
+// ProductPriceRepository define los métodos para el historial de precios
+type ProductPriceRepository interface {
+	Create(price *ProductPrice) error
+	FindByProduct(productID uuid.UUID, limit, offset int) ([]*ProductPrice, error)
+	FindEffective(productID uuid.UUID, at time.Time) (*ProductPrice, error) // Precio vigente en una fecha
+	ListChanges(from, to time.Time, limit, offset int) ([]*ProductPrice, error)
+}
+
+// ProductPackagingRepository define los métodos para niveles de empaque
+type ProductPackagingRepository interface {
+	FindByProduct(productID uuid.UUID) ([]*ProductPackaging, error)
+	ReplaceForProduct(productID uuid.UUID, levels []*ProductPackaging) error
 }
 
 // SupplierRepository define los métodos de repositorio para proveedores
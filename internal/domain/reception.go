@@ -42,6 +42,7 @@ type ReceptionOrder struct {
 	OrderNumber    string          `json:"order_number" db:"order_number"`
 	SupplierID     uuid.UUID       `json:"supplier_id" db:"supplier_id"`
 	Brand          Brand           `json:"brand" db:"brand"`
+	WarehouseID    *uuid.UUID      `json:"warehouse_id,omitempty" db:"warehouse_id"` // Almacén que recibe
 	InvoiceNumber  string          `json:"invoice_number,omitempty" db:"invoice_number"`
 	InvoiceFileURL string          `json:"invoice_file_url,omitempty" db:"invoice_file_url"`
 	Status         ReceptionStatus `json:"status" db:"status"`
@@ -100,7 +101,9 @@ type ReceptionOrderRepository interface {
 	FindByID(id uuid.UUID) (*ReceptionOrder, error)
 	FindByOrderNumber(orderNumber string) (*ReceptionOrder, error)
 	Update(order *ReceptionOrder) error
-	List(filters map[string]interface{}, limit, offset int) ([]*ReceptionOrder, error)
+	List(filters map[string]interface{}, scope DataScope, limit, offset int) ([]*ReceptionOrder, error)
+	// Visible indica si la orden está dentro del alcance del usuario
+	Visible(id uuid.UUID, scope DataScope) (bool, error)
 }
 
 // ReceptionLineRepository define los métodos para líneas de recepción
@@ -27,6 +27,23 @@ const (
 	RoleServicioCliente UserRole = "SERVICIO_CLIENTE"
 )
 
+// Roles lista todos los roles definidos
+var Roles = []UserRole{
+	RoleAdminTI, RoleGerente, RoleJefeAlmacen, RoleAuxiliar, RoleSupervisor, RoleRecepcionista,
+	RoleVendedor, RoleJefeTrafico, RoleChofer, RoleMontacarguista, RoleCargador, RolePlanificador,
+	RoleFlota, RoleAuditor, RoleServicioCliente,
+}
+
+// IsValid verifica si el rol es uno de los definidos
+func (r UserRole) IsValid() bool {
+	for _, role := range Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
 // UserStatus define el estado de un usuario
 type UserStatus string
 
@@ -36,6 +53,11 @@ const (
 	UserStatusInactivo  UserStatus = "INACTIVO"
 )
 
+// IsValid verifica si el estado es uno de los definidos
+func (s UserStatus) IsValid() bool {
+	return s == UserStatusActivo || s == UserStatusBloqueado || s == UserStatusInactivo
+}
+
 // User representa un usuario del sistema
 type User struct {
 	ID                  uuid.UUID  `json:"id" db:"id"`
@@ -45,7 +67,14 @@ type User struct {
 	Role                UserRole   `json:"role" db:"role"`
 	Status              UserStatus `json:"status" db:"status"`
 	FailedLoginAttempts int        `json:"failed_login_attempts" db:"failed_login_attempts"`
+	LockedUntil         *time.Time `json:"locked_until,omitempty" db:"locked_until"` // Nil con BLOQUEADO = bloqueo manual
+	LockoutCount        int        `json:"lockout_count" db:"lockout_count"`         // Bloqueos desde el último login exitoso
 	LastLogin           *time.Time `json:"last_login,omitempty" db:"last_login"`
+	PasswordChangedAt   time.Time  `json:"password_changed_at" db:"password_changed_at"`
+	MustChangePassword  bool       `json:"must_change_password" db:"must_change_password"` // Alta por administrador o restablecimiento
+	TOTPSecret          string     `json:"-" db:"totp_secret"`                             // Cifrado; vacío si no ha iniciado el enrolamiento
+	TOTPEnabled         bool       `json:"totp_enabled" db:"totp_enabled"`
+	TOTPLastStep        int64      `json:"-" db:"totp_last_step"` // Último paso aceptado, evita reutilizar un código
 	CreatedAt           time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt           time.Time  `json:"updated_at" db:"updated_at"`
 	DeletedAt           *time.Time `json:"-" db:"deleted_at"`
@@ -56,24 +85,73 @@ func (u *User) IsActive() bool {
 	return u.Status == UserStatusActivo && u.DeletedAt == nil
 }
 
-// IsLocked verifica si el usuario está bloqueado
-func (u *User) IsLocked() bool {
-	return u.Status == UserStatusBloqueado || u.FailedLoginAttempts >= 3
+// PasswordChangeRequired indica si el usuario debe cambiar su contraseña antes de operar:
+// contraseña asignada por un administrador o con más de maxAge de antigüedad (0 = no vence)
+func (u *User) PasswordChangeRequired(maxAge time.Duration, at time.Time) bool {
+	return u.MustChangePassword || (maxAge > 0 && at.Sub(u.PasswordChangedAt) > maxAge)
+}
+
+// LockoutPolicy define los bloqueos por intentos fallidos de login
+type LockoutPolicy struct {
+	MaxAttempts   int           // Intentos fallidos seguidos que bloquean la cuenta
+	BaseCooldown  time.Duration // Duración del primer bloqueo; se duplica en cada bloqueo consecutivo
+	MaxCooldown   time.Duration
+	IPMaxFailures int           // Intentos fallidos desde una IP (cualquier usuario) que frenan esa IP
+	IPWindow      time.Duration // Ventana en la que se cuentan los intentos por IP
+}
+
+// Cooldown retorna la duración del bloqueo número lockoutCount (1, 2, 3...)
+func (p LockoutPolicy) Cooldown(lockoutCount int) time.Duration {
+	cooldown := p.BaseCooldown
+	for i := 1; i < lockoutCount && cooldown < p.MaxCooldown; i++ {
+		cooldown *= 2
+	}
+	if p.MaxCooldown > 0 && cooldown > p.MaxCooldown {
+		return p.MaxCooldown
+	}
+	return cooldown
+}
+
+// IsLocked verifica si el usuario está bloqueado en el momento indicado.
+// Un bloqueo sin LockedUntil solo lo levanta un administrador.
+func (u *User) IsLocked(at time.Time) bool {
+	return u.Status == UserStatusBloqueado && (u.LockedUntil == nil || at.Before(*u.LockedUntil))
+}
+
+// CooldownElapsed indica si el bloqueo temporal ya venció y la cuenta puede reactivarse
+func (u *User) CooldownElapsed(at time.Time) bool {
+	return u.Status == UserStatusBloqueado && u.LockedUntil != nil && !at.Before(*u.LockedUntil)
 }
 
-// IncrementFailedAttempts incrementa el contador de intentos fallidos
-// y bloquea la cuenta si alcanza 3 intentos
-func (u *User) IncrementFailedAttempts() {
+// IncrementFailedAttempts incrementa el contador de intentos fallidos y, al llegar al máximo,
+// bloquea la cuenta con un enfriamiento que crece en cada bloqueo consecutivo. Retorna true si bloqueó.
+func (u *User) IncrementFailedAttempts(policy LockoutPolicy, at time.Time) bool {
 	u.FailedLoginAttempts++
-	if u.FailedLoginAttempts >= 3 {
-		u.Status = UserStatusBloqueado
+	if u.FailedLoginAttempts < policy.MaxAttempts {
+		return false
 	}
+	u.FailedLoginAttempts = 0
+	u.LockoutCount++
+	lockedUntil := at.Add(policy.Cooldown(u.LockoutCount))
+	u.LockedUntil = &lockedUntil
+	u.Status = UserStatusBloqueado
+	return true
+}
+
+// ReleaseCooldown reactiva la cuenta tras el enfriamiento; conserva LockoutCount
+// para que un nuevo bloqueo dure más
+func (u *User) ReleaseCooldown() {
+	u.Status = UserStatusActivo
+	u.LockedUntil = nil
+	u.FailedLoginAttempts = 0
 }
 
 // ResetFailedAttempts resetea el contador de intentos fallidos
 // y desbloquea la cuenta si estaba bloqueada por intentos
 func (u *User) ResetFailedAttempts() {
 	u.FailedLoginAttempts = 0
+	u.LockoutCount = 0
+	u.LockedUntil = nil
 	if u.Status == UserStatusBloqueado {
 		u.Status = UserStatusActivo
 	}
@@ -88,6 +166,34 @@ type Session struct {
 	UserAgent string    `json:"user_agent" db:"user_agent"`
 	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	// LastActivityAt es la última petición autenticada con el token
+	LastActivityAt time.Time `json:"last_activity_at" db:"last_activity_at"`
+	// FamilyID agrupa las sesiones encadenadas por rotación del refresh token desde un mismo login
+	FamilyID         uuid.UUID  `json:"family_id" db:"family_id"`
+	RefreshTokenHash string     `json:"-" db:"refresh_token_hash"`
+	RefreshExpiresAt *time.Time `json:"refresh_expires_at,omitempty" db:"refresh_expires_at"`
+	RotatedAt        *time.Time `json:"rotated_at,omitempty" db:"rotated_at"` // El refresh token ya se usó
+	// PasswordChangeRequired limita la sesión a cambiar la contraseña
+	PasswordChangeRequired bool `json:"password_change_required" db:"password_change_required"`
+	// TOTPEnrollmentRequired limita la sesión a configurar la verificación en dos pasos
+	TOTPEnrollmentRequired bool `json:"totp_enrollment_required" db:"totp_enrollment_required"`
+}
+
+// RecoveryCode es un código de un solo uso para entrar sin la app autenticadora
+type RecoveryCode struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	UserID    uuid.UUID  `json:"user_id" db:"user_id"`
+	CodeHash  string     `json:"-" db:"code_hash"`
+	UsedAt    *time.Time `json:"used_at,omitempty" db:"used_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// PasswordHistory guarda los hashes de contraseñas anteriores para impedir su reutilización
+type PasswordHistory struct {
+	ID           uuid.UUID `json:"id" db:"id"`
+	UserID       uuid.UUID `json:"user_id" db:"user_id"`
+	PasswordHash string    `json:"-" db:"password_hash"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
 }
 
 // IsExpired verifica si la sesión ha expirado
@@ -95,6 +201,28 @@ func (s *Session) IsExpired() bool {
 	return time.Now().After(s.ExpiresAt)
 }
 
+// IsIdle indica si la sesión superó el tiempo de inactividad permitido (0 = sin límite)
+func (s *Session) IsIdle(timeout time.Duration, at time.Time) bool {
+	return timeout > 0 && at.Sub(s.LastActivityAt) > timeout
+}
+
+// IdleDeadline es el momento en que la sesión expira si no hay actividad desde at;
+// nunca posterior a la expiración del refresh token (o del token si no tiene)
+func (s *Session) IdleDeadline(timeout time.Duration, at time.Time) time.Time {
+	limit := s.ExpiresAt
+	if s.RefreshExpiresAt != nil {
+		limit = *s.RefreshExpiresAt
+	}
+	if timeout <= 0 {
+		return limit
+	}
+	deadline := at.Add(timeout)
+	if deadline.After(limit) {
+		return limit
+	}
+	return deadline
+}
+
 // AuditLog representa un registro de auditoría
 type AuditLog struct {
 	ID         uuid.UUID              `json:"id" db:"id"`
@@ -112,11 +240,13 @@ type AuditLog struct {
 // UserRepository define los métodos de repositorio para usuarios
 type UserRepository interface {
 	Create(user *User) error
+	CreateFirst(user *User) error // Solo si no hay usuarios; si ya existen retorna ErrAlreadyExists
 	FindByID(id uuid.UUID) (*User, error)
 	FindByUsername(username string) (*User, error)
 	FindByEmail(email string) (*User, error)
 	Update(user *User) error
 	Delete(id uuid.UUID) error
+	// List y Count aceptan los filtros "role", "status" y "search" (username o email)
 	List(filters map[string]interface{}, limit, offset int) ([]*User, error)
 	Count(filters map[string]interface{}) (int, error)
 }
@@ -125,11 +255,50 @@ type UserRepository interface {
 type SessionRepository interface {
 	Create(session *Session) error
 	FindByToken(token string) (*Session, error)
+	FindByRefreshTokenHash(hash string) (*Session, error)
+	// Rotate marca la sesión como rotada (expira su access token) y crea la siguiente de la familia;
+	// falla con ErrRefreshTokenReused si otra petición ya la rotó
+	Rotate(current *Session, next *Session) error
 	Delete(token string) error
+	DeleteByFamily(familyID uuid.UUID) error
+	Touch(id uuid.UUID, at time.Time) error
 	DeleteExpired() error
+	DeleteIdle(lastActivityBefore time.Time) error
 	DeleteByUserID(userID uuid.UUID) error
 }
 
+// PasswordHistoryRepository define los métodos de repositorio para el historial de contraseñas
+type PasswordHistoryRepository interface {
+	Create(entry *PasswordHistory) error
+	ListRecent(userID uuid.UUID, limit int) ([]*PasswordHistory, error)
+}
+
+// LoginAttempt registra un intento de login, incluso con usuarios inexistentes
+type LoginAttempt struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	Username  string    `json:"username" db:"username"`
+	IPAddress string    `json:"ip_address" db:"ip_address"`
+	Success   bool      `json:"success" db:"success"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// LoginAttemptRepository define los métodos de repositorio para intentos de login
+type LoginAttemptRepository interface {
+	Create(attempt *LoginAttempt) error
+	// CountFailuresByIP cuenta los intentos fallidos desde la IP a partir de since y retorna el más antiguo
+	CountFailuresByIP(ipAddress string, since time.Time) (int, *time.Time, error)
+	DeleteBefore(before time.Time) error
+}
+
+// RecoveryCodeRepository define los métodos de repositorio para códigos de recuperación
+type RecoveryCodeRepository interface {
+	// Replace sustituye todos los códigos del usuario
+	Replace(userID uuid.UUID, codeHashes []string) error
+	// Use marca el código como usado; retorna false si no existe o ya se usó
+	Use(userID uuid.UUID, codeHash string) (bool, error)
+	DeleteByUser(userID uuid.UUID) error
+}
+
 // AuditRepository define los métodos de repositorio para auditoría
 type AuditRepository interface {
 	Log(log AuditLog) error
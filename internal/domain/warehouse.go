@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Warehouse representa un almacén (CEDIS)
+type Warehouse struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	Code      string    `json:"code" db:"code"`
+	Name      string    `json:"name" db:"name"`
+	IsActive  bool      `json:"is_active" db:"is_active"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// UserAssignments son los almacenes y marcas asignados a un usuario
+type UserAssignments struct {
+	WarehouseIDs []uuid.UUID `json:"warehouse_ids"`
+	Brands       []Brand     `json:"brands"`
+}
+
+// DataScope limita los registros que ve un usuario. Una dimensión sin asignaciones no restringe;
+// las órdenes de recepción sin almacén solo las ve quien no tiene almacenes asignados.
+type DataScope struct {
+	UserID       uuid.UUID
+	Role         UserRole
+	All          bool // Permiso data.all: ve todo sin importar rol ni asignaciones
+	WarehouseIDs []uuid.UUID
+	Brands       []Brand
+}
+
+// FullScope no aplica restricciones; se usa en procesos internos (consolidación, asignación de lotes)
+func FullScope() DataScope {
+	return DataScope{All: true}
+}
+
+// OwnCustomersOnly indica que solo ve los clientes asignados a él y sus pedidos (VENDEDOR)
+func (s DataScope) OwnCustomersOnly() bool {
+	return !s.All && s.Role == RoleVendedor
+}
+
+// OwnRoutesOnly indica que solo ve las rutas en las que es el chofer (CHOFER)
+func (s DataScope) OwnRoutesOnly() bool {
+	return !s.All && s.Role == RoleChofer
+}
+
+// ByWarehouse indica si se filtra por almacén
+func (s DataScope) ByWarehouse() bool {
+	return !s.All && len(s.WarehouseIDs) > 0
+}
+
+// ByBrand indica si se filtra por marca
+func (s DataScope) ByBrand() bool {
+	return !s.All && len(s.Brands) > 0
+}
+
+// AllowsBrand verifica si la marca está dentro del alcance
+func (s DataScope) AllowsBrand(brand Brand) bool {
+	if !s.ByBrand() {
+		return true
+	}
+	for _, b := range s.Brands {
+		if b == brand {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsWarehouse verifica si el almacén está dentro del alcance
+func (s DataScope) AllowsWarehouse(id uuid.UUID) bool {
+	if !s.ByWarehouse() {
+		return true
+	}
+	for _, w := range s.WarehouseIDs {
+		if w == id {
+			return true
+		}
+	}
+	return false
+}
+
+// WarehouseRepository define los métodos de repositorio para almacenes
+type WarehouseRepository interface {
+	Create(warehouse *Warehouse) error
+	FindByID(id uuid.UUID) (*Warehouse, error)
+	List() ([]*Warehouse, error)
+}
+
+// UserAssignmentRepository define los métodos de repositorio para las asignaciones de usuarios
+type UserAssignmentRepository interface {
+	FindByUser(userID uuid.UUID) (*UserAssignments, error)
+	// Replace sustituye todas las asignaciones del usuario
+	Replace(userID uuid.UUID, assignments UserAssignments) error
+}
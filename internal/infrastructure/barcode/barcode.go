@@ -0,0 +1,274 @@
+package barcode
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Symbology identifica el tipo de código leído
+type Symbology string
+
+const (
+	SymbologyEAN13  Symbology = "EAN13"
+	SymbologyEAN8   Symbology = "EAN8"
+	SymbologyUPCA   Symbology = "UPCA"
+	SymbologyGTIN14 Symbology = "GTIN14"
+	SymbologyGS1128 Symbology = "GS1_128"
+	SymbologyOther  Symbology = "OTRO" // Código interno (SKU u otro texto)
+)
+
+// groupSeparator es el carácter FNC1 que los lectores envían entre campos variables
+const groupSeparator = '\x1d'
+
+var (
+	ErrEmpty         = errors.New("código vacío")
+	ErrCheckDigit    = errors.New("dígito verificador inválido")
+	ErrUnsupportedAI = errors.New("identificador de aplicación no soportado")
+)
+
+// Code es el resultado de interpretar la lectura de un escáner
+type Code struct {
+	Raw            string     `json:"raw"`
+	Symbology      Symbology  `json:"symbology"`
+	GTIN           string     `json:"gtin,omitempty"`            // AI 01 o EAN/UPC
+	LotNumber      string     `json:"lot_number,omitempty"`      // AI 10
+	ExpirationDate *time.Time `json:"expiration_date,omitempty"` // AI 17
+	Quantity       *int       `json:"quantity,omitempty"`        // AI 30
+}
+
+// applicationIdentifier describe un AI GS1: longitud fija o máxima si es variable
+type applicationIdentifier struct {
+	length   int
+	variable bool
+}
+
+var supportedAIs = map[string]applicationIdentifier{
+	"01": {length: 14},
+	"10": {length: 20, variable: true},
+	"17": {length: 6},
+	"30": {length: 8, variable: true},
+}
+
+// symbologyPrefix elimina identificadores AIM como "]C1" (GS1-128) o "]E0" (EAN)
+var symbologyPrefix = regexp.MustCompile(`^\][A-Za-z][0-9A-Za-z]`)
+
+// humanReadable detecta el formato impreso "(01)...(10)..."
+var humanReadable = regexp.MustCompile(`\((\d{2,4})\)([^(]*)`)
+
+// Parse interpreta la lectura cruda de un escáner: EAN-13/EAN-8/UPC-A/GTIN-14 o GS1-128.
+// Cualquier otro texto se devuelve como SymbologyOther para buscarlo como SKU.
+func Parse(raw string) (*Code, error) {
+	input := strings.Trim(raw, " \t\r\n")
+	if input == "" {
+		return nil, ErrEmpty
+	}
+
+	gs1 := strings.HasPrefix(input, "]C1") || strings.HasPrefix(input, "]d2") || strings.HasPrefix(input, "]Q3")
+	input = symbologyPrefix.ReplaceAllString(input, "")
+	input = strings.TrimPrefix(input, string(groupSeparator))
+
+	code := &Code{Raw: raw}
+
+	if strings.HasPrefix(input, "(") {
+		if err := parseHumanReadable(code, input); err != nil {
+			return nil, err
+		}
+		return code, nil
+	}
+
+	if isDigits(input) && !gs1 {
+		switch len(input) {
+		case 8, 12, 13, 14:
+			if !validCheckDigit(input) {
+				return nil, ErrCheckDigit
+			}
+			code.GTIN = input
+			code.Symbology = map[int]Symbology{8: SymbologyEAN8, 12: SymbologyUPCA, 13: SymbologyEAN13, 14: SymbologyGTIN14}[len(input)]
+			return code, nil
+		}
+	}
+
+	if gs1 || (strings.HasPrefix(input, "01") && len(input) > 14) {
+		if err := parseElementString(code, input); err != nil {
+			return nil, err
+		}
+		return code, nil
+	}
+
+	code.Symbology = SymbologyOther
+	return code, nil
+}
+
+// Candidates genera las variantes del GTIN con las que puede estar dado de alta el producto
+// (GTIN-14 con ceros a la izquierda, EAN-13, UPC-A).
+func (c *Code) Candidates() []string {
+	if c.GTIN == "" {
+		return []string{strings.TrimSpace(c.Raw)}
+	}
+	seen := make(map[string]bool)
+	var out []string
+	add := func(v string) {
+		if v != "" && !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+
+	add(c.GTIN)
+	trimmed := strings.TrimLeft(c.GTIN, "0")
+	for _, length := range []int{14, 13, 12, 8} {
+		if len(trimmed) <= length {
+			add(strings.Repeat("0", length-len(trimmed)) + trimmed)
+		}
+	}
+	return out
+}
+
+// parseElementString recorre la cadena de elementos GS1 (AI + dato)
+func parseElementString(code *Code, data string) error {
+	code.Symbology = SymbologyGS1128
+	for len(data) > 0 {
+		if data[0] == groupSeparator {
+			data = data[1:]
+			continue
+		}
+		if len(data) < 2 {
+			return fmt.Errorf("código GS1 incompleto")
+		}
+		ai := data[:2]
+		def, ok := supportedAIs[ai]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnsupportedAI, ai)
+		}
+		data = data[2:]
+
+		var value string
+		if def.variable {
+			end := strings.IndexByte(data, groupSeparator)
+			if end < 0 {
+				end = len(data)
+			}
+			if end > def.length {
+				return fmt.Errorf("AI %s excede %d caracteres", ai, def.length)
+			}
+			value, data = data[:end], data[end:]
+		} else {
+			if len(data) < def.length {
+				return fmt.Errorf("AI %s requiere %d dígitos", ai, def.length)
+			}
+			value, data = data[:def.length], data[def.length:]
+		}
+
+		if err := assign(code, ai, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseHumanReadable(code *Code, data string) error {
+	code.Symbology = SymbologyGS1128
+	matches := humanReadable.FindAllStringSubmatch(data, -1)
+	if len(matches) == 0 {
+		return fmt.Errorf("código GS1 inválido")
+	}
+	for _, m := range matches {
+		ai, value := m[1], strings.TrimSpace(m[2])
+		def, ok := supportedAIs[ai]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnsupportedAI, ai)
+		}
+		if (def.variable && len(value) > def.length) || (!def.variable && len(value) != def.length) {
+			return fmt.Errorf("longitud inválida para AI %s", ai)
+		}
+		if err := assign(code, ai, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func assign(code *Code, ai, value string) error {
+	switch ai {
+	case "01":
+		if !isDigits(value) || !validCheckDigit(value) {
+			return fmt.Errorf("GTIN inválido: %s", value)
+		}
+		code.GTIN = value
+	case "10":
+		code.LotNumber = value
+	case "17":
+		date, err := parseDate(value)
+		if err != nil {
+			return err
+		}
+		code.ExpirationDate = &date
+	case "30":
+		qty, err := strconv.Atoi(value)
+		if err != nil || !isDigits(value) {
+			return fmt.Errorf("cantidad inválida: %s", value)
+		}
+		code.Quantity = &qty
+	}
+	return nil
+}
+
+// parseDate interpreta YYMMDD; día 00 significa fin de mes (especificación GS1)
+func parseDate(value string) (time.Time, error) {
+	if !isDigits(value) {
+		return time.Time{}, fmt.Errorf("fecha de caducidad inválida: %s", value)
+	}
+	yy, _ := strconv.Atoi(value[0:2])
+	mm, _ := strconv.Atoi(value[2:4])
+	dd, _ := strconv.Atoi(value[4:6])
+	if mm < 1 || mm > 12 || dd > 31 {
+		return time.Time{}, fmt.Errorf("fecha de caducidad inválida: %s", value)
+	}
+
+	// Siglo según la regla GS1 de ventana deslizante (-49/+50 años)
+	current := time.Now().Year()
+	year := current/100*100 + yy
+	if diff := year - current; diff > 50 {
+		year -= 100
+	} else if diff < -49 {
+		year += 100
+	}
+
+	if dd == 0 {
+		return time.Date(year, time.Month(mm)+1, 0, 0, 0, 0, 0, time.UTC), nil
+	}
+	date := time.Date(year, time.Month(mm), dd, 0, 0, 0, 0, time.UTC)
+	if date.Day() != dd {
+		return time.Time{}, fmt.Errorf("fecha de caducidad inválida: %s", value)
+	}
+	return date, nil
+}
+
+// validCheckDigit valida el dígito verificador módulo 10 de GTIN/EAN/UPC
+func validCheckDigit(code string) bool {
+	sum := 0
+	for i := len(code) - 2; i >= 0; i-- {
+		d := int(code[i] - '0')
+		if (len(code)-2-i)%2 == 0 {
+			d *= 3
+		}
+		sum += d
+	}
+	return (10-sum%10)%10 == int(code[len(code)-1]-'0')
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -1,14 +1,22 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
 
+// Valores por defecto que solo sirven para desarrollo; en modo release la API no arranca con ellos
+const (
+	defaultJWTSecretKey      = "default-secret-key-CHANGE-IN-PRODUCTION"
+	defaultTOTPEncryptionKey = "default-totp-key-CHANGE-IN-PRODUCTION"
+)
+
 type Config struct {
 	// Database
 	DBHost     string
@@ -20,12 +28,40 @@ type Config struct {
 
 	// Security
 	JWTSecretKey          string
-	JWTExpirationHours    int
-	SessionTimeoutMinutes int
+	AccessTokenMinutes    int // Vigencia del JWT de acceso
+	RefreshTokenHours     int // Vigencia del refresh token (se renueva en cada rotación)
+	SessionTimeoutMinutes int // Inactividad máxima de una sesión; 0 = sin límite
+	SessionCleanupMinutes int // Frecuencia de limpieza de sesiones expiradas
+
+	// Llaves JWT
+	JWTSigningKeyFile string   // Llave privada PEM (RSA o Ed25519) con la que se firman los JWT; vacío = HS256 con JWTSecretKey
+	JWTVerifyKeyFiles []string // Llaves públicas PEM de rotaciones anteriores que siguen validando tokens
+
+	// Política de contraseñas
+	PasswordMinLength  int
+	PasswordMinClasses int // De 4: minúsculas, mayúsculas, dígitos y símbolos
+	PasswordHistory    int // Contraseñas anteriores que no pueden reutilizarse
+	PasswordMaxAgeDays int // 0 = no vencen
+
+	// Bloqueo por intentos fallidos de login
+	LoginMaxAttempts     int // Intentos seguidos que bloquean la cuenta
+	LockoutBaseMinutes   int // Primer bloqueo; se duplica en cada bloqueo consecutivo
+	LockoutMaxMinutes    int // Tope del bloqueo
+	LoginIPMaxFailures   int // Intentos fallidos por IP (cualquier usuario) que frenan la IP; 0 = sin límite
+	LoginIPWindowMinutes int
+	AttemptRetentionDays int // Días que se conservan los intentos en login_attempts (nunca menos que la ventana por IP)
+
+	// Verificación en dos pasos (TOTP)
+	TOTPRequiredRoles []string // Roles que deben configurarla; vacío = opcional para todos
+	TOTPEncryptionKey string   // Clave con la que se cifran los secretos en la base de datos
+
+	// Permisos por rol
+	PermissionCacheSeconds int // Vigencia en memoria de la asignación de permisos (otras instancias ven los cambios tras este tiempo)
 
 	// Server
-	Port    string
-	GinMode string
+	Port           string
+	GinMode        string
+	TrustedProxies []string // IPs o CIDR de los proxies cuyo X-Forwarded-For se acepta; vacío = IP de la conexión
 
 	// Storage
 	StorageProvider string
@@ -33,6 +69,17 @@ type Config struct {
 
 	// Logging
 	LogLevel string
+
+	// Rutas
+	DepotLatitude      float64 // Origen de las rutas (CEDIS); 0,0 = no configurado
+	DepotLongitude     float64
+	RouteAvgSpeedKmh   int
+	StopServiceMinutes int // Tiempo de descarga por parada
+
+	// Choferes
+	DriverMaxDailyHours  int // Horas de manejo por día
+	DriverMaxWeeklyHours int // Horas de manejo en 7 días
+	DocumentAlertDays    int // Anticipación de alertas de documentos por vencer
 }
 
 func Load() *Config {
@@ -51,13 +98,40 @@ func Load() *Config {
 		DBSSLMode:  getEnv("DB_SSLMODE", "disable"),
 
 		// Security
-		JWTSecretKey:          getEnv("JWT_SECRET_KEY", "default-secret-key-CHANGE-IN-PRODUCTION"),
-		JWTExpirationHours:    getEnvAsInt("JWT_EXPIRATION_HOURS", 8),
+		JWTSecretKey:          getEnv("JWT_SECRET_KEY", defaultJWTSecretKey),
+		AccessTokenMinutes:    getEnvAsInt("ACCESS_TOKEN_MINUTES", 15),
+		RefreshTokenHours:     getEnvAsInt("REFRESH_TOKEN_HOURS", 168),
 		SessionTimeoutMinutes: getEnvAsInt("SESSION_TIMEOUT_MINUTES", 30),
+		SessionCleanupMinutes: getEnvAsInt("SESSION_CLEANUP_MINUTES", 60),
+
+		// Llaves JWT
+		JWTSigningKeyFile: getEnv("JWT_SIGNING_KEY_FILE", ""),
+		JWTVerifyKeyFiles: getEnvAsList("JWT_VERIFY_KEY_FILES", ""),
+
+		// Política de contraseñas
+		PasswordMinLength:  getEnvAsInt("PASSWORD_MIN_LENGTH", 10),
+		PasswordMinClasses: getEnvAsInt("PASSWORD_MIN_CLASSES", 3),
+		PasswordHistory:    getEnvAsInt("PASSWORD_HISTORY", 5),
+		PasswordMaxAgeDays: getEnvAsInt("PASSWORD_MAX_AGE_DAYS", 90),
+
+		// Bloqueo por intentos fallidos de login
+		LoginMaxAttempts:     getEnvAsInt("LOGIN_MAX_ATTEMPTS", 3),
+		LockoutBaseMinutes:   getEnvAsInt("LOCKOUT_BASE_MINUTES", 5),
+		LockoutMaxMinutes:    getEnvAsInt("LOCKOUT_MAX_MINUTES", 1440),
+		LoginIPMaxFailures:   getEnvAsInt("LOGIN_IP_MAX_FAILURES", 20),
+		LoginIPWindowMinutes: getEnvAsInt("LOGIN_IP_WINDOW_MINUTES", 15),
+		AttemptRetentionDays: getEnvAsInt("LOGIN_ATTEMPT_RETENTION_DAYS", 30),
+
+		// 2FA
+		TOTPRequiredRoles: getEnvAsList("TOTP_REQUIRED_ROLES", "ADMIN_TI,GERENTE,AUDITOR"),
+		TOTPEncryptionKey: getEnv("TOTP_ENCRYPTION_KEY", defaultTOTPEncryptionKey),
+
+		PermissionCacheSeconds: getEnvAsInt("PERMISSION_CACHE_SECONDS", 60),
 
 		// Server
-		Port:    getEnv("PORT", "8080"),
-		GinMode: getEnv("GIN_MODE", "debug"),
+		Port:           getEnv("PORT", "8080"),
+		GinMode:        getEnv("GIN_MODE", "debug"),
+		TrustedProxies: getEnvAsList("TRUSTED_PROXIES", ""),
 
 		// Storage
 		StorageProvider: getEnv("STORAGE_PROVIDER", "local"),
@@ -65,11 +139,46 @@ func Load() *Config {
 
 		// Logging
 		LogLevel: getEnv("LOG_LEVEL", "info"),
+
+		// Rutas
+		DepotLatitude:      getEnvAsFloat("DEPOT_LATITUDE", 0),
+		DepotLongitude:     getEnvAsFloat("DEPOT_LONGITUDE", 0),
+		RouteAvgSpeedKmh:   getEnvAsInt("ROUTE_AVG_SPEED_KMH", 40),
+		StopServiceMinutes: getEnvAsInt("STOP_SERVICE_MINUTES", 20),
+
+		// Choferes
+		DriverMaxDailyHours:  getEnvAsInt("DRIVER_MAX_DAILY_HOURS", 9),
+		DriverMaxWeeklyHours: getEnvAsInt("DRIVER_MAX_WEEKLY_HOURS", 48),
+		DocumentAlertDays:    getEnvAsInt("DOCUMENT_ALERT_DAYS", 30),
 	}
 
 	return cfg
 }
 
+// Validate rechaza configuraciones inseguras en producción (GIN_MODE=release)
+func (c *Config) Validate() error {
+	if c.GinMode != "release" {
+		return nil
+	}
+	if c.JWTSigningKeyFile == "" && c.JWTSecretKey == defaultJWTSecretKey {
+		return errors.New("JWT_SECRET_KEY tiene el valor por defecto: configure JWT_SIGNING_KEY_FILE o un secreto propio")
+	}
+	// Los secretos TOTP quedarían cifrados con una clave pública en el repositorio
+	if c.TOTPEncryptionKey == defaultTOTPEncryptionKey {
+		return errors.New("TOTP_ENCRYPTION_KEY tiene el valor por defecto: configure una clave propia")
+	}
+	return nil
+}
+
+// JWTHMACSecret es el secreto HS256 con el que se firma si no hay llave asimétrica. Con llave
+// asimétrica solo se acepta si se configuró explícitamente (tokens emitidos antes de migrar).
+func (c *Config) JWTHMACSecret() string {
+	if c.JWTSigningKeyFile != "" && c.JWTSecretKey == defaultJWTSecretKey {
+		return ""
+	}
+	return c.JWTSecretKey
+}
+
 func (c *Config) GetDatabaseURL() string {
 	return fmt.Sprintf(
 		"postgres://%s:%s@%s:%s/%s?sslmode=%s",
@@ -102,3 +211,33 @@ func getEnvAsInt(key string, defaultValue int) int {
 
 	return value
 }
+
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	value, err := strconv.ParseFloat(valueStr, 64)
+	if err != nil {
+		return defaultValue
+	}
+
+	return value
+}
+
+// getEnvAsList lee una lista separada por comas; una variable definida pero vacía no aplica el default
+func getEnvAsList(key, defaultValue string) []string {
+	valueStr, ok := os.LookupEnv(key)
+	if !ok {
+		valueStr = defaultValue
+	}
+
+	var values []string
+	for _, value := range strings.Split(valueStr, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,52 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// csvWriter escribe filas directamente sobre la respuesta
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer, columns []Column) (*csvWriter, error) {
+	// BOM UTF-8 para que Excel muestre acentos correctamente
+	if _, err := w.Write([]byte("\xEF\xBB\xBF")); err != nil {
+		return nil, err
+	}
+
+	cw := &csvWriter{w: csv.NewWriter(w)}
+	headers := make([]string, len(columns))
+	for i, col := range columns {
+		headers[i] = col.Header
+	}
+	if err := cw.w.Write(headers); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+func (cw *csvWriter) WriteRow(values []interface{}) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		// Decimales sin redondear para que el archivo pueda volver a importarse
+		if f, ok := normalize(v).(float64); ok {
+			record[i] = strconv.FormatFloat(f, 'f', -1, 64)
+			continue
+		}
+		record[i] = formatText(v)
+	}
+	if err := cw.w.Write(record); err != nil {
+		return err
+	}
+	// Vaciar por fila para no acumular el reporte en memoria
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+func (cw *csvWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
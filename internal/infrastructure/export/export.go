@@ -0,0 +1,160 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Format representa un formato de exportación de reportes
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+	FormatPDF  Format = "pdf"
+)
+
+// ErrUnsupportedFormat se retorna cuando el formato solicitado no existe
+var ErrUnsupportedFormat = errors.New("formato de exportación no soportado (json, csv, xlsx, pdf)")
+
+// ParseFormat convierte el parámetro ?format= en un Format (vacío = JSON)
+func ParseFormat(value string) (Format, error) {
+	switch Format(strings.ToLower(strings.TrimSpace(value))) {
+	case "", FormatJSON:
+		return FormatJSON, nil
+	case FormatCSV:
+		return FormatCSV, nil
+	case FormatXLSX:
+		return FormatXLSX, nil
+	case FormatPDF:
+		return FormatPDF, nil
+	}
+	return "", ErrUnsupportedFormat
+}
+
+// ContentType retorna el MIME type del formato
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case FormatPDF:
+		return "application/pdf"
+	}
+	return "application/json; charset=utf-8"
+}
+
+// Buffered indica si el formato se arma completo en memoria antes de escribirse (XLSX y PDF)
+func (f Format) Buffered() bool {
+	return f == FormatXLSX || f == FormatPDF
+}
+
+// Filename genera el nombre del archivo de descarga con fecha
+func (f Format) Filename(name string) string {
+	return fmt.Sprintf("%s_%s.%s", name, time.Now().Format("20060102"), f)
+}
+
+// Column describe una columna del reporte
+type Column struct {
+	Header string  // Encabezado (en español)
+	Width  float64 // Ancho relativo de la columna; 0 = ancho por defecto
+}
+
+// Writer escribe un reporte fila por fila
+type Writer interface {
+	WriteRow(values []interface{}) error
+	Close() error
+}
+
+// NewWriter crea el escritor correspondiente al formato.
+// CSV se escribe directamente sobre w; XLSX y PDF se vuelcan a w al cerrar.
+func NewWriter(format Format, w io.Writer, title string, columns []Column) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, columns)
+	case FormatXLSX:
+		return newXLSXWriter(w, columns)
+	case FormatPDF:
+		return newPDFWriter(w, title, columns), nil
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// normalize adapta los valores de dominio a tipos imprimibles
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case bool:
+		if v {
+			return "Sí"
+		}
+		return "No"
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return *v
+	case *int:
+		if v == nil {
+			return ""
+		}
+		return *v
+	case *string:
+		if v == nil {
+			return ""
+		}
+		return *v
+	case uuid.UUID:
+		return v.String()
+	case *uuid.UUID:
+		if v == nil {
+			return ""
+		}
+		return v.String()
+	case time.Time:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return ""
+		}
+		return normalize(rv.Elem().Interface())
+	case reflect.String:
+		// Tipos de dominio basados en string (Brand, OrderStatus, ...)
+		return rv.String()
+	}
+	return value
+}
+
+// formatText convierte un valor normalizado a texto (CSV y PDF)
+func formatText(value interface{}) string {
+	switch v := normalize(value).(type) {
+	case string:
+		return v
+	case time.Time:
+		if v.Hour() == 0 && v.Minute() == 0 && v.Second() == 0 {
+			return v.Format("2006-01-02")
+		}
+		return v.Format("2006-01-02 15:04")
+	case float64:
+		return fmt.Sprintf("%.2f", v)
+	case float32:
+		return fmt.Sprintf("%.2f", v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
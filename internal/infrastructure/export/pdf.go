@@ -0,0 +1,133 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+const (
+	pdfMargin     = 10.0
+	pdfRowHeight  = 6.0
+	pdfHeaderSize = 9.0
+	pdfBodySize   = 8.0
+)
+
+// pdfWriter genera una versión imprimible del reporte (A4 horizontal)
+type pdfWriter struct {
+	out     io.Writer
+	pdf     *gofpdf.Fpdf
+	tr      func(string) string
+	columns []Column
+	widths  []float64
+	rows    int
+}
+
+func newPDFWriter(w io.Writer, title string, columns []Column) *pdfWriter {
+	pdf := gofpdf.New("L", "mm", "A4", "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(false, pdfMargin)
+
+	pw := &pdfWriter{
+		out:     w,
+		pdf:     pdf,
+		tr:      pdf.UnicodeTranslatorFromDescriptor(""), // UTF-8 -> cp1252 (acentos y ñ)
+		columns: columns,
+		widths:  columnWidths(pdf, columns),
+	}
+
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-pdfMargin)
+		pdf.SetFont("Arial", "I", 7)
+		pdf.CellFormat(0, 5, pw.tr(fmt.Sprintf("Página %d", pdf.PageNo())), "", 0, "R", false, 0, "")
+	})
+
+	pdf.AddPage()
+	pdf.SetFont("Arial", "B", 14)
+	pdf.CellFormat(0, 8, pw.tr(title), "", 1, "L", false, 0, "")
+	pdf.SetFont("Arial", "", 8)
+	pdf.CellFormat(0, 5, pw.tr("Generado: "+time.Now().Format("2006-01-02 15:04")), "", 1, "L", false, 0, "")
+	pdf.Ln(2)
+	pw.writeHeader()
+
+	return pw
+}
+
+// columnWidths reparte el ancho útil de la página según el peso de cada columna
+func columnWidths(pdf *gofpdf.Fpdf, columns []Column) []float64 {
+	pageWidth, _ := pdf.GetPageSize()
+	usable := pageWidth - 2*pdfMargin
+
+	total := 0.0
+	for _, col := range columns {
+		total += columnWeight(col)
+	}
+
+	widths := make([]float64, len(columns))
+	for i, col := range columns {
+		widths[i] = usable * columnWeight(col) / total
+	}
+	return widths
+}
+
+func columnWeight(col Column) float64 {
+	if col.Width > 0 {
+		return col.Width
+	}
+	return 15
+}
+
+func (pw *pdfWriter) writeHeader() {
+	pw.pdf.SetFont("Arial", "B", pdfHeaderSize)
+	pw.pdf.SetFillColor(217, 225, 242)
+	for i, col := range pw.columns {
+		pw.pdf.CellFormat(pw.widths[i], pdfRowHeight+1, pw.fit(col.Header, pw.widths[i]), "1", 0, "C", true, 0, "")
+	}
+	pw.pdf.Ln(-1)
+	pw.pdf.SetFont("Arial", "", pdfBodySize)
+}
+
+func (pw *pdfWriter) WriteRow(values []interface{}) error {
+	_, pageHeight := pw.pdf.GetPageSize()
+	if pw.pdf.GetY()+pdfRowHeight > pageHeight-2*pdfMargin {
+		pw.pdf.AddPage()
+		pw.writeHeader()
+	}
+
+	fill := pw.rows%2 == 1
+	pw.pdf.SetFillColor(245, 245, 245)
+	for i := range pw.columns {
+		text := ""
+		if i < len(values) {
+			text = formatText(values[i])
+		}
+		pw.pdf.CellFormat(pw.widths[i], pdfRowHeight, pw.fit(text, pw.widths[i]), "1", 0, "L", fill, 0, "")
+	}
+	pw.pdf.Ln(-1)
+	pw.rows++
+
+	return pw.pdf.Error()
+}
+
+// fit trunca el texto para que quepa en la celda
+func (pw *pdfWriter) fit(text string, width float64) string {
+	text = pw.tr(text)
+	maxWidth := width - 2*pw.pdf.GetCellMargin()
+	if pw.pdf.GetStringWidth(text) <= maxWidth {
+		return text
+	}
+	for len(text) > 0 && pw.pdf.GetStringWidth(text+"...") > maxWidth {
+		text = text[:len(text)-1]
+	}
+	return text + "..."
+}
+
+func (pw *pdfWriter) Close() error {
+	if pw.rows == 0 {
+		pw.pdf.SetFont("Arial", "I", pdfBodySize)
+		pw.pdf.CellFormat(0, pdfRowHeight, pw.tr("Sin registros"), "", 1, "L", false, 0, "")
+	}
+	return pw.pdf.Output(pw.out)
+}
@@ -0,0 +1,66 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// ReadRows lee todas las filas de un archivo CSV o XLSX (primera hoja), incluyendo encabezados.
+// También retorna el separador de campos del CSV (',' en XLSX): con ';' la coma es el separador decimal.
+func ReadRows(format Format, r io.Reader) ([][]string, rune, error) {
+	switch format {
+	case FormatCSV:
+		return readCSV(r)
+	case FormatXLSX:
+		rows, err := readXLSX(r)
+		return rows, ',', err
+	}
+	return nil, 0, errors.New("solo se pueden importar archivos CSV o XLSX")
+}
+
+func readCSV(r io.Reader) ([][]string, rune, error) {
+	br := bufio.NewReader(r)
+
+	// Ignorar BOM UTF-8 (archivos guardados desde Excel)
+	if bom, err := br.Peek(3); err == nil && bytes.Equal(bom, []byte("\xEF\xBB\xBF")) {
+		_, _ = br.Discard(3)
+	}
+
+	reader := csv.NewReader(br)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	// Excel en español exporta CSV separados por ';'
+	if firstLine, err := br.Peek(br.Buffered()); err == nil {
+		line := string(firstLine)
+		if i := strings.IndexByte(line, '\n'); i >= 0 {
+			line = line[:i]
+		}
+		if strings.Count(line, ";") > strings.Count(line, ",") {
+			reader.Comma = ';'
+		}
+	}
+
+	rows, err := reader.ReadAll()
+	return rows, reader.Comma, err
+}
+
+func readXLSX(r io.Reader) ([][]string, error) {
+	file, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	sheets := file.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, errors.New("el archivo no contiene hojas")
+	}
+	return file.GetRows(sheets[0])
+}
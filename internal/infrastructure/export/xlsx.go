@@ -0,0 +1,81 @@
+package export
+
+import (
+	"io"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const xlsxSheetName = "Reporte"
+
+// xlsxWriter usa el StreamWriter de excelize para no mantener todas las celdas en memoria
+type xlsxWriter struct {
+	out    io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+func newXLSXWriter(w io.Writer, columns []Column) (*xlsxWriter, error) {
+	file := excelize.NewFile()
+	if err := file.SetSheetName("Sheet1", xlsxSheetName); err != nil {
+		return nil, err
+	}
+
+	stream, err := file.NewStreamWriter(xlsxSheetName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Los anchos deben definirse antes de la primera fila
+	for i, col := range columns {
+		width := col.Width
+		if width == 0 {
+			width = 15
+		}
+		if err := stream.SetColWidth(i+1, i+1, width); err != nil {
+			return nil, err
+		}
+	}
+
+	headerStyle, err := file.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"#D9E1F2"}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	headers := make([]interface{}, len(columns))
+	for i, col := range columns {
+		headers[i] = excelize.Cell{StyleID: headerStyle, Value: col.Header}
+	}
+	if err := stream.SetRow("A1", headers); err != nil {
+		return nil, err
+	}
+
+	return &xlsxWriter{out: w, file: file, stream: stream, row: 1}, nil
+}
+
+func (xw *xlsxWriter) WriteRow(values []interface{}) error {
+	xw.row++
+	cell, err := excelize.CoordinatesToCellName(1, xw.row)
+	if err != nil {
+		return err
+	}
+
+	row := make([]interface{}, len(values))
+	for i, v := range values {
+		row[i] = normalize(v)
+	}
+	return xw.stream.SetRow(cell, row)
+}
+
+func (xw *xlsxWriter) Close() error {
+	defer xw.file.Close()
+
+	if err := xw.stream.Flush(); err != nil {
+		return err
+	}
+	return xw.file.Write(xw.out)
+}
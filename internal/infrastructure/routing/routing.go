@@ -0,0 +1,142 @@
+package routing
+
+import (
+	"math"
+)
+
+// earthRadiusKm es el radio medio de la Tierra usado en la fórmula de Haversine
+const earthRadiusKm = 6371.0
+
+// Point representa una coordenada geográfica
+type Point struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// DistanceKm calcula la distancia en línea recta (Haversine) entre dos puntos
+func DistanceKm(a, b Point) float64 {
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat := (b.Lat - a.Lat) * math.Pi / 180
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
+
+// Sequence ordena las paradas con vecino más cercano y mejora el recorrido con 2-opt.
+// El recorrido es abierto (no regresa al origen). Si origin es nil se prueba iniciar
+// en cada parada y se conserva el recorrido más corto.
+// Retorna los índices de stops en el orden de visita.
+func Sequence(origin *Point, stops []Point) []int {
+	if len(stops) == 0 {
+		return nil
+	}
+
+	if origin != nil {
+		order := nearestNeighbour(origin, stops, -1)
+		twoOpt(origin, stops, order)
+		return order
+	}
+
+	var best []int
+	bestDistance := math.MaxFloat64
+	for start := range stops {
+		order := nearestNeighbour(nil, stops, start)
+		twoOpt(nil, stops, order)
+		if d := PathDistance(nil, stops, order); d < bestDistance {
+			best, bestDistance = order, d
+		}
+	}
+	return best
+}
+
+// PathDistance calcula la distancia total del recorrido en km
+func PathDistance(origin *Point, stops []Point, order []int) float64 {
+	total := 0.0
+	for i := range order {
+		total += legDistance(origin, stops, order, i)
+	}
+	return total
+}
+
+// legDistance es la distancia para llegar a la parada en la posición i del recorrido
+func legDistance(origin *Point, stops []Point, order []int, i int) float64 {
+	if i == 0 {
+		if origin == nil {
+			return 0
+		}
+		return DistanceKm(*origin, stops[order[0]])
+	}
+	return DistanceKm(stops[order[i-1]], stops[order[i]])
+}
+
+func nearestNeighbour(origin *Point, stops []Point, start int) []int {
+	visited := make([]bool, len(stops))
+	order := make([]int, 0, len(stops))
+
+	var current Point
+	if start >= 0 {
+		order = append(order, start)
+		visited[start] = true
+		current = stops[start]
+	} else {
+		current = *origin
+	}
+
+	for len(order) < len(stops) {
+		next, nextDistance := -1, math.MaxFloat64
+		for i, p := range stops {
+			if visited[i] {
+				continue
+			}
+			if d := DistanceKm(current, p); d < nextDistance {
+				next, nextDistance = i, d
+			}
+		}
+		order = append(order, next)
+		visited[next] = true
+		current = stops[next]
+	}
+	return order
+}
+
+// twoOpt invierte segmentos del recorrido mientras se reduzca la distancia total
+func twoOpt(origin *Point, stops []Point, order []int) {
+	point := func(pos int) *Point {
+		if pos < 0 {
+			return origin
+		}
+		return &stops[order[pos]]
+	}
+	dist := func(a, b *Point) float64 {
+		if a == nil || b == nil {
+			return 0
+		}
+		return DistanceKm(*a, *b)
+	}
+
+	n := len(order)
+	improved := true
+	for improved {
+		improved = false
+		for i := 0; i < n-1; i++ {
+			for k := i + 1; k < n; k++ {
+				prev, first, last := point(i-1), point(i), point(k)
+				var next *Point
+				if k+1 < n {
+					next = point(k + 1)
+				}
+
+				before := dist(prev, first) + dist(last, next)
+				after := dist(prev, last) + dist(first, next)
+				if after < before-1e-9 {
+					for a, b := i, k; a < b; a, b = a+1, b-1 {
+						order[a], order[b] = order[b], order[a]
+					}
+					improved = true
+				}
+			}
+		}
+	}
+}
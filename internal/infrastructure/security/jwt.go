@@ -12,25 +12,29 @@ type Claims struct {
 	UserID   string `json:"user_id"`
 	Username string `json:"username"`
 	Role     string `json:"role"`
+	// Purpose distingue tokens de un solo propósito (ej. PurposeMFA); vacío en tokens de acceso
+	Purpose string `json:"purpose,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken genera un nuevo JWT token para un usuario
-func GenerateToken(userID uuid.UUID, username, role string, secretKey string, expirationHours int) (string, error) {
+// PurposeMFA marca el token intermedio del login en dos pasos
+const PurposeMFA = "mfa"
+
+// GenerateToken genera un nuevo JWT token para un usuario, firmado con la llave activa del KeySet
+func GenerateToken(userID uuid.UUID, username, role string, keys *KeySet, ttl time.Duration) (string, error) {
 	claims := Claims{
 		UserID:   userID.String(),
 		Username: username,
 		Role:     role,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(expirationHours) * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    "sgl-disasur-api",
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(secretKey))
+	tokenString, err := keys.signToken(claims)
 	if err != nil {
 		return "", err
 	}
@@ -38,16 +42,25 @@ func GenerateToken(userID uuid.UUID, username, role string, secretKey string, ex
 	return tokenString, nil
 }
 
-// ValidateToken valida un JWT token y retorna las claims si es válido
-func ValidateToken(tokenString, secretKey string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		// Verificar el método de firma
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("método de firma inválido")
-		}
-		return []byte(secretKey), nil
-	})
+// GenerateChallengeToken genera un token de corta vida para completar el segundo factor del login.
+// No tiene sesión asociada, por lo que el middleware lo rechaza como token de acceso.
+func GenerateChallengeToken(userID uuid.UUID, username string, keys *KeySet, ttl time.Duration) (string, error) {
+	claims := Claims{
+		UserID:   userID.String(),
+		Username: username,
+		Purpose:  PurposeMFA,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Issuer:    "sgl-disasur-api",
+		},
+	}
+	return keys.signToken(claims)
+}
 
+// ValidateToken valida un JWT token contra las llaves activas y retorna las claims si es válido
+func ValidateToken(tokenString string, keys *KeySet) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, keys.keyFunc)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,245 @@
+package security
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// minRSABits es el tamaño mínimo aceptado para llaves RSA
+const minRSABits = 2048
+
+// signingKey es una llave de firma o verificación identificada por su kid
+type signingKey struct {
+	id     string // Vacío en el secreto HS256: los tokens firmados con él no llevan kid
+	method jwt.SigningMethod
+	sign   interface{} // Llave privada o secreto; nil si solo verifica
+	verify interface{}
+	public crypto.PublicKey // nil en el secreto HS256, que no se publica
+}
+
+// KeySet firma los tokens con una llave y los valida con cualquiera de las llaves activas,
+// elegida por el kid del encabezado. Así se rota la llave de firma sin invalidar los tokens vigentes.
+type KeySet struct {
+	signing *signingKey
+	keys    map[string]*signingKey
+	ordered []*signingKey
+}
+
+// NewHMACKeySet firma y valida con un secreto compartido (HS256)
+func NewHMACKeySet(secret string) *KeySet {
+	set := &KeySet{keys: make(map[string]*signingKey)}
+	set.signing = hmacKey(secret)
+	set.add(set.signing)
+	return set
+}
+
+// LoadKeySet carga la llave privada de firma (RSA o Ed25519, PEM) y las llaves públicas de
+// verificación de rotaciones anteriores. Sin llave de firma se usa HS256 con hmacSecret;
+// con llave de firma, un hmacSecret no vacío solo valida los tokens emitidos antes de migrar.
+func LoadKeySet(signingKeyFile string, verifyKeyFiles []string, hmacSecret string) (*KeySet, error) {
+	if signingKeyFile == "" {
+		if hmacSecret == "" {
+			return nil, errors.New("se requiere una llave de firma o un secreto JWT")
+		}
+		set := NewHMACKeySet(hmacSecret)
+		for _, file := range verifyKeyFiles {
+			if err := set.loadVerifyKey(file); err != nil {
+				return nil, err
+			}
+		}
+		return set, nil
+	}
+
+	set := &KeySet{keys: make(map[string]*signingKey)}
+	key, err := readPEM(signingKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%s: se esperaba una llave privada", signingKeyFile)
+	}
+	set.signing, err = asymmetricKey(signer.Public())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", signingKeyFile, err)
+	}
+	set.signing.sign = signer
+	set.add(set.signing)
+
+	for _, file := range verifyKeyFiles {
+		if err := set.loadVerifyKey(file); err != nil {
+			return nil, err
+		}
+	}
+	if hmacSecret != "" {
+		set.add(hmacKey(hmacSecret))
+	}
+	return set, nil
+}
+
+// KeyID retorna el kid de la llave de firma (vacío con HS256)
+func (k *KeySet) KeyID() string {
+	return k.signing.id
+}
+
+func (k *KeySet) signToken(claims Claims) (string, error) {
+	token := jwt.NewWithClaims(k.signing.method, claims)
+	if k.signing.id != "" {
+		token.Header["kid"] = k.signing.id
+	}
+	return token.SignedString(k.signing.sign)
+}
+
+func (k *KeySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, errors.New("llave de firma desconocida")
+	}
+	// El algoritmo lo fija la llave, no el token
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, errors.New("método de firma inválido")
+	}
+	return key.verify, nil
+}
+
+func (k *KeySet) add(key *signingKey) {
+	if _, exists := k.keys[key.id]; exists {
+		return
+	}
+	k.keys[key.id] = key
+	k.ordered = append(k.ordered, key)
+}
+
+func (k *KeySet) loadVerifyKey(file string) error {
+	key, err := readPEM(file)
+	if err != nil {
+		return err
+	}
+	if signer, ok := key.(crypto.Signer); ok {
+		key = signer.Public()
+	}
+	verify, err := asymmetricKey(key)
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	k.add(verify)
+	return nil
+}
+
+func hmacKey(secret string) *signingKey {
+	return &signingKey{
+		method: jwt.SigningMethodHS256,
+		sign:   []byte(secret),
+		verify: []byte(secret),
+	}
+}
+
+func asymmetricKey(public crypto.PublicKey) (*signingKey, error) {
+	key := &signingKey{verify: public, public: public}
+	switch pub := public.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("la llave RSA debe tener al menos %d bits", minRSABits)
+		}
+		key.method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, errors.New("tipo de llave no soportado (use RSA o Ed25519)")
+	}
+
+	key.id = thumbprintOf(jwkOf(key))
+	return key, nil
+}
+
+// readPEM lee una llave privada (PKCS#8 o PKCS#1) o pública (PKIX o PKCS#1)
+func readPEM(file string) (interface{}, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no contiene un bloque PEM", file)
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+	return nil, fmt.Errorf("%s: bloque PEM no soportado (%s)", file, block.Type)
+}
+
+// JWK es una llave pública en formato JSON Web Key (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Kid string `json:"kid,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+// JWKS es el conjunto de llaves públicas que publica /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS retorna las llaves públicas activas; el secreto HS256 nunca se publica
+func (k *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	for _, key := range k.ordered {
+		if key.public == nil {
+			continue
+		}
+		jwk := jwkOf(key)
+		jwk.Use = "sig"
+		jwk.Alg = key.method.Alg()
+		jwk.Kid = key.id
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+func jwkOf(key *signingKey) JWK {
+	encode := base64.RawURLEncoding.EncodeToString
+	switch pub := key.public.(type) {
+	case *rsa.PublicKey:
+		return JWK{Kty: "RSA", N: encode(pub.N.Bytes()), E: encode(big.NewInt(int64(pub.E)).Bytes())}
+	case ed25519.PublicKey:
+		return JWK{Kty: "OKP", Crv: "Ed25519", X: encode(pub)}
+	}
+	return JWK{}
+}
+
+// thumbprintOf calcula el kid como JWK thumbprint (RFC 7638): estable entre reinicios e instancias
+func thumbprintOf(jwk JWK) string {
+	var canonical string
+	if jwk.Kty == "RSA" {
+		canonical = fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, jwk.E, jwk.N)
+	} else {
+		canonical = fmt.Sprintf(`{"crv":"%s","kty":"%s","x":"%s"}`, jwk.Crv, jwk.Kty, jwk.X)
+	}
+	sum := sha256.Sum256([]byte(canonical))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -1,6 +1,9 @@
 package security
 
 import (
+	"fmt"
+	"unicode"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -15,3 +18,47 @@ func CheckPasswordHash(password, hash string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	return err == nil
 }
+
+// maxPasswordBytes es el límite de bcrypt: más bytes no se pueden hashear
+const maxPasswordBytes = 72
+
+// PasswordPolicy define los requisitos de una contraseña nueva
+type PasswordPolicy struct {
+	MinLength  int
+	MinClasses int // Tipos de carácter requeridos de 4: minúsculas, mayúsculas, dígitos y símbolos
+}
+
+// Validate retorna la primera regla que la contraseña no cumple
+func (p PasswordPolicy) Validate(password string) error {
+	if len([]rune(password)) < p.MinLength {
+		return fmt.Errorf("mínimo %d caracteres", p.MinLength)
+	}
+	// Se mide en bytes: los acentos y símbolos fuera de ASCII ocupan más de uno
+	if len(password) > maxPasswordBytes {
+		return fmt.Errorf("máximo %d bytes (%d caracteres sin acentos)", maxPasswordBytes, maxPasswordBytes)
+	}
+
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+	classes := 0
+	for _, present := range []bool{lower, upper, digit, symbol} {
+		if present {
+			classes++
+		}
+	}
+	if classes < p.MinClasses {
+		return fmt.Errorf("debe combinar al menos %d de: minúsculas, mayúsculas, dígitos y símbolos", p.MinClasses)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package security
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	totpPeriod = 30 // Segundos por código (RFC 6238)
+	totpDigits = 6
+	totpSkew   = 1 // Pasos de tolerancia antes y después por desfase de reloj
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret genera un secreto TOTP de 160 bits en base32
+func GenerateTOTPSecret() (string, error) {
+	buf := make([]byte, 20)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(buf), nil
+}
+
+// TOTPProvisioningURI arma el URI otpauth:// que las apps autenticadoras leen como QR
+func TOTPProvisioningURI(issuer, account, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(totpPeriod))
+	label := url.PathEscape(issuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// TOTPCode calcula el código del paso indicado (HOTP de RFC 4226 sobre el contador de tiempo)
+func TOTPCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", errors.New("secreto TOTP inválido")
+	}
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000), nil
+}
+
+// ValidateTOTP verifica el código contra los pasos vecinos de at. Rechaza pasos iguales o
+// anteriores a lastStep para que un código no se use dos veces. Retorna el paso aceptado.
+func ValidateTOTP(secret, code string, at time.Time, lastStep int64) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != totpDigits {
+		return 0, false
+	}
+	current := at.Unix() / totpPeriod
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= lastStep {
+			continue
+		}
+		expected, err := TOTPCode(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// GenerateRecoveryCodes genera n códigos de recuperación de un solo uso (XXXXX-XXXXX)
+func GenerateRecoveryCodes(n int) ([]string, error) {
+	codes := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		buf := make([]byte, 7)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		raw := totpEncoding.EncodeToString(buf)[:10]
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+	}
+	return codes, nil
+}
+
+// HashRecoveryCode normaliza el código (mayúsculas, sin guiones ni espacios) y retorna su SHA-256
+func HashRecoveryCode(code string) string {
+	normalized := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return HashRefreshToken(normalized)
+}
+
+// EncryptSecret cifra un secreto con AES-256-GCM; la llave se deriva de passphrase
+func EncryptSecret(plaintext, passphrase string) (string, error) {
+	gcm, err := secretCipher(passphrase)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptSecret descifra un secreto cifrado con EncryptSecret
+func DecryptSecret(ciphertext, passphrase string) (string, error) {
+	gcm, err := secretCipher(passphrase)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil || len(sealed) < gcm.NonceSize() {
+		return "", errors.New("secreto cifrado inválido")
+	}
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("secreto cifrado inválido")
+	}
+	return string(plaintext), nil
+}
+
+func secretCipher(passphrase string) (cipher.AEAD, error) {
+	key := sha256.Sum256([]byte(passphrase))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -3,12 +3,21 @@ package postgres
 import (
 	"database/sql"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/sgl-disasur/api/internal/domain"
 )
 
+// fleetWriter permite escribir dentro o fuera de una transacción
+type fleetWriter interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
 type VehicleRepositoryPostgres struct {
 	db *sqlx.DB
 }
@@ -19,12 +28,14 @@ func NewVehicleRepository(db *sqlx.DB) domain.VehicleRepository {
 
 func (r *VehicleRepositoryPostgres) Create(vehicle *domain.Vehicle) error {
 	query := `
-		INSERT INTO vehicles (plate_number, vehicle_type, brand, model, year, capacity_kg, capacity_m3, status)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO vehicles (plate_number, vehicle_type, brand, model, year, capacity_kg, capacity_m3,
+		                      cargo_length_cm, cargo_width_cm, cargo_height_cm, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 		RETURNING id, created_at, updated_at
 	`
 	return r.db.QueryRow(query, vehicle.PlateNumber, vehicle.VehicleType, vehicle.Brand,
-		vehicle.Model, vehicle.Year, vehicle.CapacityKg, vehicle.CapacityM3, vehicle.Status).
+		vehicle.Model, vehicle.Year, vehicle.CapacityKg, vehicle.CapacityM3,
+		vehicle.CargoLengthCm, vehicle.CargoWidthCm, vehicle.CargoHeightCm, vehicle.Status).
 		Scan(&vehicle.ID, &vehicle.CreatedAt, &vehicle.UpdatedAt)
 }
 
@@ -42,14 +53,20 @@ func (r *VehicleRepositoryPostgres) FindByID(id uuid.UUID) (*domain.Vehicle, err
 }
 
 func (r *VehicleRepositoryPostgres) Update(vehicle *domain.Vehicle) error {
+	return updateVehicle(r.db, vehicle)
+}
+
+func updateVehicle(db fleetWriter, vehicle *domain.Vehicle) error {
 	query := `
 		UPDATE vehicles
 		SET status = $1, last_maintenance_date = $2, next_maintenance_date = $3, 
-		    is_active = $4, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $5
+		    is_active = $4, odometer_km = $5, last_maintenance_km = $6, next_maintenance_km = $7,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $8
 	`
-	result, err := r.db.Exec(query, vehicle.Status, vehicle.LastMaintenanceDate,
-		vehicle.NextMaintenanceDate, vehicle.IsActive, vehicle.ID)
+	result, err := db.Exec(query, vehicle.Status, vehicle.LastMaintenanceDate,
+		vehicle.NextMaintenanceDate, vehicle.IsActive, vehicle.OdometerKm, vehicle.LastMaintenanceKm,
+		vehicle.NextMaintenanceKm, vehicle.ID)
 	if err != nil {
 		return err
 	}
@@ -90,12 +107,13 @@ func NewDriverRepository(db *sqlx.DB) domain.DriverRepository {
 
 func (r *DriverRepositoryPostgres) Create(driver *domain.Driver) error {
 	query := `
-		INSERT INTO drivers (user_id, license_number, license_expiry, phone, status)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO drivers (user_id, license_number, license_expiry, license_class, shift_start, shift_end,
+		                     rest_days, phone, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING id, created_at, updated_at
 	`
-	return r.db.QueryRow(query, driver.UserID, driver.LicenseNumber, driver.LicenseExpiry,
-		driver.Phone, driver.Status).Scan(&driver.ID, &driver.CreatedAt, &driver.UpdatedAt)
+	return r.db.QueryRow(query, driver.UserID, driver.LicenseNumber, driver.LicenseExpiry, driver.LicenseClass,
+		driver.ShiftStart, driver.ShiftEnd, driver.RestDays, driver.Phone, driver.Status).Scan(&driver.ID, &driver.CreatedAt, &driver.UpdatedAt)
 }
 
 func (r *DriverRepositoryPostgres) FindByID(id uuid.UUID) (*domain.Driver, error) {
@@ -127,10 +145,13 @@ func (r *DriverRepositoryPostgres) FindByUserID(userID uuid.UUID) (*domain.Drive
 func (r *DriverRepositoryPostgres) Update(driver *domain.Driver) error {
 	query := `
 		UPDATE drivers
-		SET phone = $1, status = $2, is_active = $3, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $4
+		SET phone = $1, status = $2, is_active = $3, license_class = $4, shift_start = $5,
+		    shift_end = $6, rest_days = $7, license_number = $8, license_expiry = $9,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $10
 	`
-	result, err := r.db.Exec(query, driver.Phone, driver.Status, driver.IsActive, driver.ID)
+	result, err := r.db.Exec(query, driver.Phone, driver.Status, driver.IsActive, driver.LicenseClass,
+		driver.ShiftStart, driver.ShiftEnd, driver.RestDays, driver.LicenseNumber, driver.LicenseExpiry, driver.ID)
 	if err != nil {
 		return err
 	}
@@ -170,13 +191,87 @@ func NewRouteRepository(db *sqlx.DB) domain.RouteRepository {
 }
 
 func (r *RouteRepositoryPostgres) Create(route *domain.Route) error {
+	return insertRoute(r.db, route)
+}
+
+// CreateAssigned crea la ruta y sus paradas y actualiza pedidos, vehículo y chofer en una sola transacción.
+// Los bloquea con FOR UPDATE y revalida sus estados: dos asignaciones simultáneas no toman el mismo
+// pedido, vehículo o chofer.
+func (r *RouteRepositoryPostgres) CreateAssigned(route *domain.Route, stops []*domain.RouteStop) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	orderIDs := make([]string, len(stops))
+	for i, stop := range stops {
+		orderIDs[i] = stop.OrderID.String()
+	}
+	var orders []*domain.Order
+	if err := tx.Select(&orders, `SELECT * FROM orders WHERE id = ANY($1::uuid[]) AND deleted_at IS NULL ORDER BY id FOR UPDATE`,
+		pq.Array(orderIDs)); err != nil {
+		return err
+	}
+	if len(orders) != len(stops) {
+		return fmt.Errorf("%w: un pedido de la ruta ya no existe", domain.ErrOrderNotFound)
+	}
+	for _, order := range orders {
+		if !order.IsReadyForRoute() {
+			return fmt.Errorf("%w: el pedido %s está %s", domain.ErrInvalidOrderStatus, order.OrderNumber, order.Status)
+		}
+	}
+
+	var vehicle domain.Vehicle
+	if err := tx.Get(&vehicle, `SELECT * FROM vehicles WHERE id = $1 FOR UPDATE`, route.VehicleID); err != nil {
+		return err
+	}
+	if !vehicle.IsAvailableForRoute() {
+		return fmt.Errorf("%w: el vehículo %s está %s", domain.ErrVehicleNotAvailable, vehicle.PlateNumber, vehicle.Status)
+	}
+	var driver domain.Driver
+	if err := tx.Get(&driver, `SELECT * FROM drivers WHERE id = $1 FOR UPDATE`, route.DriverID); err != nil {
+		return err
+	}
+	if !driver.IsActive || driver.Status != domain.DriverDisponible {
+		return fmt.Errorf("%w: el chofer está %s", domain.ErrDriverNotAvailable, driver.Status)
+	}
+
+	if err := insertRoute(tx, route); err != nil {
+		return err
+	}
+	for _, stop := range stops {
+		stop.RouteID = route.ID
+	}
+	if err := insertRouteStops(tx, stops); err != nil {
+		return err
+	}
+
+	// El pedido pasa a EN_RUTA cuando el chofer inicia la ruta
+	if _, err := tx.Exec(`UPDATE orders SET status = $1, updated_at = CURRENT_TIMESTAMP WHERE id = ANY($2::uuid[])`,
+		domain.OrderListo, pq.Array(orderIDs)); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE vehicles SET status = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`,
+		domain.VehicleEnRuta, route.VehicleID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE drivers SET status = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`,
+		domain.DriverEnRuta, route.DriverID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func insertRoute(db fleetWriter, route *domain.Route) error {
 	query := `
 		INSERT INTO routes (route_number, order_id, vehicle_id, driver_id, route_type, 
 		                   departure_date, estimated_arrival, status, assigned_by)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING id, created_at, updated_at
 	`
-	return r.db.QueryRow(query, route.RouteNumber, route.OrderID, route.VehicleID,
+	return db.QueryRow(query, route.RouteNumber, route.OrderID, route.VehicleID,
 		route.DriverID, route.RouteType, route.DepartureDate, route.EstimatedArrival,
 		route.Status, route.AssignedBy).Scan(&route.ID, &route.CreatedAt, &route.UpdatedAt)
 }
@@ -198,11 +293,12 @@ func (r *RouteRepositoryPostgres) Update(route *domain.Route) error {
 	query := `
 		UPDATE routes
 		SET departure_date = $1, actual_arrival = $2, status = $3, 
-		    invoice_pdf_url = $4, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $5
+		    invoice_pdf_url = $4, actual_departure = $5, departure_odometer_km = $6,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $7
 	`
 	result, err := r.db.Exec(query, route.DepartureDate, route.ActualArrival,
-		route.Status, route.InvoicePDFURL, route.ID)
+		route.Status, route.InvoicePDFURL, route.ActualDeparture, route.DepartureOdometerKm, route.ID)
 	if err != nil {
 		return err
 	}
@@ -214,13 +310,330 @@ func (r *RouteRepositoryPostgres) Update(route *domain.Route) error {
 	return nil
 }
 
-func (r *RouteRepositoryPostgres) List(filters map[string]interface{}, limit, offset int) ([]*domain.Route, error) {
+func (r *RouteRepositoryPostgres) List(filters map[string]interface{}, scope domain.DataScope, limit, offset int) ([]*domain.Route, error) {
 	var routes []*domain.Route
-	query := `SELECT * FROM routes ORDER BY created_at DESC LIMIT $1 OFFSET $2`
-	err := r.db.Select(&routes, query, limit, offset)
+	where := newConditions()
+	scopeRoutes(where, scope)
+
+	query := `SELECT r.* FROM routes r WHERE ` + where.sql() + ` ORDER BY r.created_at DESC ` + where.page(limit, offset)
+	err := r.db.Select(&routes, query, where.args...)
 	return routes, err
 }
 
+func (r *RouteRepositoryPostgres) ListForReport(scope domain.DataScope, limit, offset int) ([]*domain.RouteReportRow, error) {
+	var rows []*domain.RouteReportRow
+	where := newConditions()
+	scopeRoutes(where, scope)
+
+	query := `
+		SELECT r.*, COALESCE(o.order_number, '') AS order_number, COALESCE(v.plate_number, '') AS plate_number,
+		       COALESCE(u.username, '') AS driver_name
+		FROM routes r
+		LEFT JOIN orders o ON o.id = r.order_id
+		LEFT JOIN vehicles v ON v.id = r.vehicle_id
+		LEFT JOIN drivers d ON d.id = r.driver_id
+		LEFT JOIN users u ON u.id = d.user_id
+		WHERE ` + where.sql() + ` ORDER BY r.created_at DESC ` + where.page(limit, offset)
+	err := r.db.Select(&rows, query, where.args...)
+	return rows, err
+}
+
+func (r *RouteRepositoryPostgres) Visible(id uuid.UUID, scope domain.DataScope) (bool, error) {
+	where := newConditions()
+	where.where("r.id = " + where.arg(id))
+	scopeRoutes(where, scope)
+
+	var visible bool
+	err := r.db.Get(&visible, `SELECT EXISTS (SELECT 1 FROM routes r WHERE `+where.sql()+`)`, where.args...)
+	return visible, err
+}
+
+func (r *RouteRepositoryPostgres) ListByDriver(driverID uuid.UUID, from, to time.Time) ([]*domain.Route, error) {
+	var routes []*domain.Route
+	query := `
+		SELECT * FROM routes
+		WHERE driver_id = $1 AND departure_date >= $2 AND departure_date < $3 AND status <> 'CANCELADO'
+		ORDER BY departure_date
+	`
+	err := r.db.Select(&routes, query, driverID, from, to)
+	return routes, err
+}
+
+func (r *RouteRepositoryPostgres) ListByStatus(status domain.OrderStatus) ([]*domain.Route, error) {
+	var routes []*domain.Route
+	query := `SELECT * FROM routes WHERE status = $1 ORDER BY departure_date`
+	err := r.db.Select(&routes, query, status)
+	return routes, err
+}
+
+func (r *RouteRepositoryPostgres) CountActiveByVehicle(vehicleID uuid.UUID) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM routes WHERE vehicle_id = $1 AND status IN ('CONFIRMADO', 'EN_RUTA')`
+	err := r.db.Get(&count, query, vehicleID)
+	return count, err
+}
+
+func (r *RouteRepositoryPostgres) CountDeliveredByVehicle(from, to time.Time) (map[uuid.UUID]int, error) {
+	var rows []struct {
+		VehicleID uuid.UUID `db:"vehicle_id"`
+		Orders    int       `db:"orders"`
+	}
+	// Paradas entregadas o, en rutas sin paradas, la ruta entregada
+	query := `
+		SELECT r.vehicle_id, COUNT(*) AS orders
+		FROM routes r
+		LEFT JOIN route_stops s ON s.route_id = r.id
+		WHERE COALESCE(s.actual_arrival, r.actual_arrival, r.departure_date) >= $1
+		  AND COALESCE(s.actual_arrival, r.actual_arrival, r.departure_date) < $2
+		  AND (s.status = 'ENTREGADA' OR (s.id IS NULL AND r.status = 'ENTREGADO'))
+		GROUP BY r.vehicle_id
+	`
+	if err := r.db.Select(&rows, query, from, to); err != nil {
+		return nil, err
+	}
+
+	delivered := make(map[uuid.UUID]int, len(rows))
+	for _, row := range rows {
+		delivered[row.VehicleID] = row.Orders
+	}
+	return delivered, nil
+}
+
+// VehiclePositionRepositoryPostgres implementa el repositorio de posiciones GPS
+type VehiclePositionRepositoryPostgres struct {
+	db *sqlx.DB
+}
+
+func NewVehiclePositionRepository(db *sqlx.DB) domain.VehiclePositionRepository {
+	return &VehiclePositionRepositoryPostgres{db: db}
+}
+
+func (r *VehiclePositionRepositoryPostgres) CreateBatch(positions []*domain.VehiclePosition) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO vehicle_positions (route_id, vehicle_id, latitude, longitude, speed_kmh, heading, accuracy_m,
+		                               source, recorded_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, received_at
+	`
+	for _, position := range positions {
+		err := tx.QueryRow(query, position.RouteID, position.VehicleID, position.Latitude, position.Longitude,
+			position.SpeedKmh, position.Heading, position.AccuracyM, position.Source, position.RecordedAt).
+			Scan(&position.ID, &position.ReceivedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *VehiclePositionRepositoryPostgres) FindByRoute(routeID uuid.UUID, limit int) ([]*domain.VehiclePosition, error) {
+	var positions []*domain.VehiclePosition
+	query := `
+		SELECT * FROM (
+			SELECT * FROM vehicle_positions WHERE route_id = $1
+			ORDER BY recorded_at DESC LIMIT $2
+		) recent
+		ORDER BY recorded_at
+	`
+	err := r.db.Select(&positions, query, routeID, limit)
+	return positions, err
+}
+
+func (r *VehiclePositionRepositoryPostgres) LatestEnRuta() ([]*domain.VehiclePosition, error) {
+	var positions []*domain.VehiclePosition
+	query := `
+		SELECT DISTINCT ON (p.route_id) p.* FROM vehicle_positions p
+		JOIN routes r ON r.id = p.route_id
+		WHERE r.status = 'EN_RUTA'
+		ORDER BY p.route_id, p.recorded_at DESC
+	`
+	err := r.db.Select(&positions, query)
+	return positions, err
+}
+
+// DriverAbsenceRepositoryPostgres implementa el repositorio de descansos y licencias
+type DriverAbsenceRepositoryPostgres struct {
+	db *sqlx.DB
+}
+
+func NewDriverAbsenceRepository(db *sqlx.DB) domain.DriverAbsenceRepository {
+	return &DriverAbsenceRepositoryPostgres{db: db}
+}
+
+func (r *DriverAbsenceRepositoryPostgres) Create(absence *domain.DriverAbsence) error {
+	query := `
+		INSERT INTO driver_absences (driver_id, absence_type, start_date, end_date, notes, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+	return r.db.QueryRow(query, absence.DriverID, absence.Type, absence.StartDate, absence.EndDate,
+		absence.Notes, absence.CreatedBy).Scan(&absence.ID, &absence.CreatedAt)
+}
+
+func (r *DriverAbsenceRepositoryPostgres) FindByDriver(driverID uuid.UUID) ([]*domain.DriverAbsence, error) {
+	var absences []*domain.DriverAbsence
+	query := `SELECT * FROM driver_absences WHERE driver_id = $1 ORDER BY start_date DESC`
+	err := r.db.Select(&absences, query, driverID)
+	return absences, err
+}
+
+func (r *DriverAbsenceRepositoryPostgres) FindOverlapping(driverID uuid.UUID, from, to time.Time) ([]*domain.DriverAbsence, error) {
+	var absences []*domain.DriverAbsence
+	query := `
+		SELECT * FROM driver_absences
+		WHERE driver_id = $1 AND start_date <= $3 AND end_date >= $2
+		ORDER BY start_date
+	`
+	err := r.db.Select(&absences, query, driverID, from, to)
+	return absences, err
+}
+
+func (r *DriverAbsenceRepositoryPostgres) Delete(id uuid.UUID) error {
+	result, err := r.db.Exec(`DELETE FROM driver_absences WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// FleetDocumentRepositoryPostgres implementa el repositorio de documentos de flota
+type FleetDocumentRepositoryPostgres struct {
+	db *sqlx.DB
+}
+
+func NewFleetDocumentRepository(db *sqlx.DB) domain.FleetDocumentRepository {
+	return &FleetDocumentRepositoryPostgres{db: db}
+}
+
+func (r *FleetDocumentRepositoryPostgres) Create(document *domain.FleetDocument) error {
+	query := `
+		INSERT INTO fleet_documents (owner_type, owner_id, document_type, document_number, file_url,
+		                             issue_date, expiry_date, uploaded_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`
+	return r.db.QueryRow(query, document.OwnerType, document.OwnerID, document.DocumentType,
+		document.DocumentNumber, document.FileURL, document.IssueDate, document.ExpiryDate,
+		document.UploadedBy).Scan(&document.ID, &document.CreatedAt)
+}
+
+func (r *FleetDocumentRepositoryPostgres) FindByOwner(ownerType domain.DocumentOwner, ownerID uuid.UUID) ([]*domain.FleetDocument, error) {
+	var documents []*domain.FleetDocument
+	query := `
+		SELECT * FROM fleet_documents
+		WHERE owner_type = $1 AND owner_id = $2
+		ORDER BY document_type, expiry_date DESC
+	`
+	err := r.db.Select(&documents, query, ownerType, ownerID)
+	return documents, err
+}
+
+func (r *FleetDocumentRepositoryPostgres) FindCurrent(ownerType domain.DocumentOwner, ownerID uuid.UUID) ([]*domain.FleetDocument, error) {
+	var documents []*domain.FleetDocument
+	query := `
+		SELECT DISTINCT ON (document_type) * FROM fleet_documents
+		WHERE owner_type = $1 AND owner_id = $2
+		ORDER BY document_type, expiry_date DESC
+	`
+	err := r.db.Select(&documents, query, ownerType, ownerID)
+	return documents, err
+}
+
+func (r *FleetDocumentRepositoryPostgres) ListExpiring(until time.Time) ([]*domain.FleetDocument, error) {
+	var documents []*domain.FleetDocument
+	query := `
+		SELECT * FROM (
+			SELECT DISTINCT ON (owner_type, owner_id, document_type) * FROM fleet_documents
+			ORDER BY owner_type, owner_id, document_type, expiry_date DESC
+		) current
+		WHERE expiry_date <= $1
+		ORDER BY expiry_date
+	`
+	err := r.db.Select(&documents, query, until)
+	return documents, err
+}
+
+// RouteStopRepositoryPostgres implementa el repositorio de paradas de ruta
+type RouteStopRepositoryPostgres struct {
+	db *sqlx.DB
+}
+
+func NewRouteStopRepository(db *sqlx.DB) domain.RouteStopRepository {
+	return &RouteStopRepositoryPostgres{db: db}
+}
+
+func (r *RouteStopRepositoryPostgres) CreateBatch(stops []*domain.RouteStop) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertRouteStops(tx, stops); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func insertRouteStops(db fleetWriter, stops []*domain.RouteStop) error {
+	query := `
+		INSERT INTO route_stops (route_id, order_id, customer_id, sequence, address, latitude, longitude,
+		                         distance_km, estimated_arrival, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at, updated_at
+	`
+
+	for _, stop := range stops {
+		err := db.QueryRow(query, stop.RouteID, stop.OrderID, stop.CustomerID, stop.Sequence, stop.Address,
+			stop.Latitude, stop.Longitude, stop.DistanceKm, stop.EstimatedArrival, stop.Status).
+			Scan(&stop.ID, &stop.CreatedAt, &stop.UpdatedAt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *RouteStopRepositoryPostgres) FindByRouteID(routeID uuid.UUID) ([]*domain.RouteStop, error) {
+	var stops []*domain.RouteStop
+	query := `SELECT * FROM route_stops WHERE route_id = $1 ORDER BY sequence`
+	err := r.db.Select(&stops, query, routeID)
+	return stops, err
+}
+
+func (r *RouteStopRepositoryPostgres) Update(stop *domain.RouteStop) error {
+	query := `
+		UPDATE route_stops
+		SET sequence = $1, distance_km = $2, estimated_arrival = $3, actual_arrival = $4,
+		    status = $5, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $6
+	`
+	result, err := r.db.Exec(query, stop.Sequence, stop.DistanceKm, stop.EstimatedArrival,
+		stop.ActualArrival, stop.Status, stop.ID)
+	if err != nil {
+		return err
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
 // VehicleMaintenanceRepositoryPostgres implementa el repositorio de mantenimiento
 type VehicleMaintenanceRepositoryPostgres struct {
 	db *sqlx.DB
@@ -231,14 +644,132 @@ func NewVehicleMaintenanceRepository(db *sqlx.DB) domain.VehicleMaintenanceRepos
 }
 
 func (r *VehicleMaintenanceRepositoryPostgres) Create(maintenance *domain.VehicleMaintenance) error {
+	return insertMaintenance(r.db, maintenance)
+}
+
+func insertMaintenance(db fleetWriter, maintenance *domain.VehicleMaintenance) error {
 	query := `
-		INSERT INTO vehicle_maintenance (vehicle_id, maintenance_type, description, cost, start_date, performed_by)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO vehicle_maintenance (vehicle_id, maintenance_type, description, cost, start_date,
+		                                 performed_by, odometer_km)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		RETURNING id, created_at
 	`
-	return r.db.QueryRow(query, maintenance.VehicleID, maintenance.MaintenanceType,
-		maintenance.Description, maintenance.Cost, maintenance.StartDate, maintenance.PerformedBy).
-		Scan(&maintenance.ID, &maintenance.CreatedAt)
+	return db.QueryRow(query, maintenance.VehicleID, maintenance.MaintenanceType,
+		maintenance.Description, maintenance.Cost, maintenance.StartDate, maintenance.PerformedBy,
+		maintenance.OdometerKm).Scan(&maintenance.ID, &maintenance.CreatedAt)
+}
+
+func (r *VehicleMaintenanceRepositoryPostgres) FindByID(id uuid.UUID) (*domain.VehicleMaintenance, error) {
+	var maintenance domain.VehicleMaintenance
+	query := `SELECT * FROM vehicle_maintenance WHERE id = $1`
+	err := r.db.Get(&maintenance, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return &maintenance, nil
+}
+
+func (r *VehicleMaintenanceRepositoryPostgres) Update(maintenance *domain.VehicleMaintenance) error {
+	return updateMaintenance(r.db, maintenance)
+}
+
+func updateMaintenance(db fleetWriter, maintenance *domain.VehicleMaintenance) error {
+	query := `
+		UPDATE vehicle_maintenance
+		SET description = $1, cost = $2, end_date = $3, performed_by = $4, odometer_km = $5
+		WHERE id = $6
+	`
+	result, err := db.Exec(query, maintenance.Description, maintenance.Cost, maintenance.EndDate,
+		maintenance.PerformedBy, maintenance.OdometerKm, maintenance.ID)
+	if err != nil {
+		return err
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (r *VehicleMaintenanceRepositoryPostgres) Open(maintenance *domain.VehicleMaintenance, vehicle *domain.Vehicle, reading *domain.OdometerReading) error {
+	return r.save(maintenance, vehicle, reading, insertMaintenance)
+}
+
+func (r *VehicleMaintenanceRepositoryPostgres) Close(maintenance *domain.VehicleMaintenance, vehicle *domain.Vehicle, reading *domain.OdometerReading) error {
+	return r.save(maintenance, vehicle, reading, updateMaintenance)
+}
+
+func (r *VehicleMaintenanceRepositoryPostgres) save(maintenance *domain.VehicleMaintenance, vehicle *domain.Vehicle, reading *domain.OdometerReading,
+	write func(fleetWriter, *domain.VehicleMaintenance) error) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if reading != nil {
+		if err := insertOdometerReading(tx, reading); err != nil {
+			return err
+		}
+	}
+	if err := write(tx, maintenance); err != nil {
+		return err
+	}
+	if err := updateVehicle(tx, vehicle); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *VehicleMaintenanceRepositoryPostgres) CostByVehicle(from, to time.Time) (map[uuid.UUID]float64, error) {
+	var rows []struct {
+		VehicleID uuid.UUID `db:"vehicle_id"`
+		Cost      float64   `db:"cost"`
+	}
+	query := `
+		SELECT vehicle_id, COALESCE(SUM(cost), 0) AS cost FROM vehicle_maintenance
+		WHERE start_date >= $1 AND start_date < $2
+		GROUP BY vehicle_id
+	`
+	if err := r.db.Select(&rows, query, from, to); err != nil {
+		return nil, err
+	}
+
+	costs := make(map[uuid.UUID]float64, len(rows))
+	for _, row := range rows {
+		costs[row.VehicleID] = row.Cost
+	}
+	return costs, nil
+}
+
+func (r *VehicleMaintenanceRepositoryPostgres) CountOpen(vehicleID uuid.UUID) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM vehicle_maintenance WHERE vehicle_id = $1 AND end_date IS NULL`
+	err := r.db.Get(&count, query, vehicleID)
+	return count, err
+}
+
+// FindOpenByType retorna la orden abierta más reciente del tipo indicado
+func (r *VehicleMaintenanceRepositoryPostgres) FindOpenByType(vehicleID uuid.UUID, maintenanceType string) (*domain.VehicleMaintenance, error) {
+	var maintenance domain.VehicleMaintenance
+	query := `
+		SELECT * FROM vehicle_maintenance
+		WHERE vehicle_id = $1 AND maintenance_type = $2 AND end_date IS NULL
+		ORDER BY start_date DESC
+		LIMIT 1
+	`
+	err := r.db.Get(&maintenance, query, vehicleID, maintenanceType)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return &maintenance, nil
 }
 
 func (r *VehicleMaintenanceRepositoryPostgres) FindByVehicleID(vehicleID uuid.UUID, limit, offset int) ([]*domain.VehicleMaintenance, error) {
@@ -253,6 +784,198 @@ func (r *VehicleMaintenanceRepositoryPostgres) FindByVehicleID(vehicleID uuid.UU
 	return maintenances, err
 }
 
+// MaintenancePlanRepositoryPostgres implementa el repositorio de planes de mantenimiento
+type MaintenancePlanRepositoryPostgres struct {
+	db *sqlx.DB
+}
+
+func NewMaintenancePlanRepository(db *sqlx.DB) domain.MaintenancePlanRepository {
+	return &MaintenancePlanRepositoryPostgres{db: db}
+}
+
+func (r *MaintenancePlanRepositoryPostgres) Upsert(plan *domain.MaintenancePlan) error {
+	query := `
+		INSERT INTO maintenance_plans (vehicle_type, interval_km, interval_months)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (vehicle_type) DO UPDATE
+		SET interval_km = EXCLUDED.interval_km, interval_months = EXCLUDED.interval_months,
+		    updated_at = CURRENT_TIMESTAMP
+		RETURNING id, created_at, updated_at
+	`
+	return r.db.QueryRow(query, plan.VehicleType, plan.IntervalKm, plan.IntervalMonths).
+		Scan(&plan.ID, &plan.CreatedAt, &plan.UpdatedAt)
+}
+
+func (r *MaintenancePlanRepositoryPostgres) FindByVehicleType(vehicleType domain.VehicleType) (*domain.MaintenancePlan, error) {
+	var plan domain.MaintenancePlan
+	query := `SELECT * FROM maintenance_plans WHERE vehicle_type = $1`
+	err := r.db.Get(&plan, query, vehicleType)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return &plan, nil
+}
+
+func (r *MaintenancePlanRepositoryPostgres) List() ([]*domain.MaintenancePlan, error) {
+	var plans []*domain.MaintenancePlan
+	query := `SELECT * FROM maintenance_plans ORDER BY vehicle_type`
+	err := r.db.Select(&plans, query)
+	return plans, err
+}
+
+// FuelLoadRepositoryPostgres implementa el repositorio de cargas de combustible
+type FuelLoadRepositoryPostgres struct {
+	db *sqlx.DB
+}
+
+func NewFuelLoadRepository(db *sqlx.DB) domain.FuelLoadRepository {
+	return &FuelLoadRepositoryPostgres{db: db}
+}
+
+func (r *FuelLoadRepositoryPostgres) Create(load *domain.FuelLoad) error {
+	return insertFuelLoad(r.db, load)
+}
+
+func (r *FuelLoadRepositoryPostgres) Register(load *domain.FuelLoad, vehicle *domain.Vehicle, reading *domain.OdometerReading) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertOdometerReading(tx, reading); err != nil {
+		return err
+	}
+	if err := insertFuelLoad(tx, load); err != nil {
+		return err
+	}
+	if err := updateVehicle(tx, vehicle); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func insertFuelLoad(db fleetWriter, load *domain.FuelLoad) error {
+	query := `
+		INSERT INTO fuel_loads (vehicle_id, driver_id, liters, total_cost, odometer_km, station, ticket_photo_url,
+		                        distance_km, efficiency_km_l, is_anomaly, anomaly_reason, loaded_at, recorded_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id, created_at
+	`
+	return db.QueryRow(query, load.VehicleID, load.DriverID, load.Liters, load.TotalCost, load.OdometerKm,
+		load.Station, load.TicketPhotoURL, load.DistanceKm, load.EfficiencyKmL, load.IsAnomaly,
+		load.AnomalyReason, load.LoadedAt, load.RecordedBy).Scan(&load.ID, &load.CreatedAt)
+}
+
+func (r *FuelLoadRepositoryPostgres) FindByVehicle(vehicleID uuid.UUID, limit, offset int) ([]*domain.FuelLoad, error) {
+	var loads []*domain.FuelLoad
+	query := `
+		SELECT * FROM fuel_loads
+		WHERE vehicle_id = $1
+		ORDER BY loaded_at DESC, odometer_km DESC
+		LIMIT $2 OFFSET $3
+	`
+	err := r.db.Select(&loads, query, vehicleID, limit, offset)
+	return loads, err
+}
+
+func (r *FuelLoadRepositoryPostgres) FindLastBefore(vehicleID uuid.UUID, at time.Time) (*domain.FuelLoad, error) {
+	var load domain.FuelLoad
+	query := `
+		SELECT * FROM fuel_loads
+		WHERE vehicle_id = $1 AND loaded_at <= $2
+		ORDER BY loaded_at DESC, odometer_km DESC
+		LIMIT 1
+	`
+	err := r.db.Get(&load, query, vehicleID, at)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return &load, nil
+}
+
+func (r *FuelLoadRepositoryPostgres) SummarizeByVehicle(from, to time.Time) ([]*domain.VehicleFuelSummary, error) {
+	var summaries []*domain.VehicleFuelSummary
+	query := `
+		SELECT vehicle_id, COUNT(*) AS loads, SUM(liters) AS liters, SUM(total_cost) AS cost,
+		       COUNT(*) FILTER (WHERE is_anomaly) AS anomalies
+		FROM fuel_loads
+		WHERE loaded_at >= $1 AND loaded_at < $2
+		GROUP BY vehicle_id
+	`
+	err := r.db.Select(&summaries, query, from, to)
+	return summaries, err
+}
+
+// OdometerReadingRepositoryPostgres implementa el repositorio de lecturas de odómetro
+type OdometerReadingRepositoryPostgres struct {
+	db *sqlx.DB
+}
+
+func NewOdometerReadingRepository(db *sqlx.DB) domain.OdometerReadingRepository {
+	return &OdometerReadingRepositoryPostgres{db: db}
+}
+
+func (r *OdometerReadingRepositoryPostgres) Create(reading *domain.OdometerReading) error {
+	return insertOdometerReading(r.db, reading)
+}
+
+func insertOdometerReading(db fleetWriter, reading *domain.OdometerReading) error {
+	query := `
+		INSERT INTO odometer_readings (vehicle_id, reading_km, source, recorded_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, recorded_at
+	`
+	return db.QueryRow(query, reading.VehicleID, reading.ReadingKm, reading.Source, reading.RecordedBy).
+		Scan(&reading.ID, &reading.RecordedAt)
+}
+
+func (r *OdometerReadingRepositoryPostgres) FindByVehicle(vehicleID uuid.UUID, limit, offset int) ([]*domain.OdometerReading, error) {
+	var readings []*domain.OdometerReading
+	query := `
+		SELECT * FROM odometer_readings
+		WHERE vehicle_id = $1
+		ORDER BY recorded_at DESC
+		LIMIT $2 OFFSET $3
+	`
+	err := r.db.Select(&readings, query, vehicleID, limit, offset)
+	return readings, err
+}
+
+func (r *OdometerReadingRepositoryPostgres) DistanceByVehicle(from, to time.Time) (map[uuid.UUID]float64, error) {
+	var rows []struct {
+		VehicleID  uuid.UUID `db:"vehicle_id"`
+		DistanceKm float64   `db:"distance_km"`
+	}
+	// Se mide desde la última lectura anterior al periodo (o la primera del periodo)
+	query := `
+		SELECT o.vehicle_id,
+		       MAX(o.reading_km) - COALESCE(
+		           (SELECT MAX(p.reading_km) FROM odometer_readings p
+		            WHERE p.vehicle_id = o.vehicle_id AND p.recorded_at < $1),
+		           MIN(o.reading_km)) AS distance_km
+		FROM odometer_readings o
+		WHERE o.recorded_at >= $1 AND o.recorded_at < $2
+		GROUP BY o.vehicle_id
+	`
+	if err := r.db.Select(&rows, query, from, to); err != nil {
+		return nil, err
+	}
+
+	distances := make(map[uuid.UUID]float64, len(rows))
+	for _, row := range rows {
+		distances[row.VehicleID] = row.DistanceKm
+	}
+	return distances, nil
+}
+
 // PreDepartureChecklistRepositoryPostgres implementa el repositorio de check-list
 type PreDepartureChecklistRepositoryPostgres struct {
 	db *sqlx.DB
@@ -263,20 +986,74 @@ func NewPreDepartureChecklistRepository(db *sqlx.DB) domain.PreDepartureChecklis
 }
 
 func (r *PreDepartureChecklistRepositoryPostgres) Create(checklist *domain.PreDepartureChecklist) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertChecklist(tx, checklist); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *PreDepartureChecklistRepositoryPostgres) CreateWithMaintenance(checklist *domain.PreDepartureChecklist, maintenance *domain.VehicleMaintenance, vehicle *domain.Vehicle) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertMaintenance(tx, maintenance); err != nil {
+		return err
+	}
+	if err := updateVehicle(tx, vehicle); err != nil {
+		return err
+	}
+	checklist.MaintenanceID = &maintenance.ID
+	if err := insertChecklist(tx, checklist); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// insertChecklist guarda el check-list con sus respuestas
+func insertChecklist(tx *sqlx.Tx, checklist *domain.PreDepartureChecklist) error {
 	query := `
 		INSERT INTO pre_departure_checklist (route_id, driver_id, tire_condition, fuel_level, 
-		                                    oil_level, lights_ok, damage_photo_url, notes, checked_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		                                    oil_level, lights_ok, damage_photo_url, notes, checked_at,
+		                                    template_id, passed, maintenance_id, vehicle_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 		RETURNING id
 	`
-	return r.db.QueryRow(query, checklist.RouteID, checklist.DriverID, checklist.TireCondition,
+	err := tx.QueryRow(query, checklist.RouteID, checklist.DriverID, checklist.TireCondition,
 		checklist.FuelLevel, checklist.OilLevel, checklist.LightsOk, checklist.DamagePhotoURL,
-		checklist.Notes, checklist.CheckedAt).Scan(&checklist.ID)
+		checklist.Notes, checklist.CheckedAt, checklist.TemplateID, checklist.Passed,
+		checklist.MaintenanceID, checklist.VehicleID).Scan(&checklist.ID)
+	if err != nil {
+		return err
+	}
+
+	answerQuery := `
+		INSERT INTO checklist_answers (checklist_id, item_id, bool_value, number_value, photo_url, passed, failure_reason)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`
+	for _, answer := range checklist.Answers {
+		answer.ChecklistID = checklist.ID
+		err := tx.QueryRow(answerQuery, answer.ChecklistID, answer.ItemID, answer.BoolValue, answer.NumberValue,
+			answer.PhotoURL, answer.Passed, answer.FailureReason).Scan(&answer.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (r *PreDepartureChecklistRepositoryPostgres) FindByRouteID(routeID uuid.UUID) (*domain.PreDepartureChecklist, error) {
 	var checklist domain.PreDepartureChecklist
-	query := `SELECT * FROM pre_departure_checklist WHERE route_id = $1`
+	query := `SELECT * FROM pre_departure_checklist WHERE route_id = $1 ORDER BY checked_at DESC LIMIT 1`
 	err := r.db.Get(&checklist, query, routeID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -284,5 +1061,100 @@ func (r *PreDepartureChecklistRepositoryPostgres) FindByRouteID(routeID uuid.UUI
 		}
 		return nil, err
 	}
+
+	answersQuery := `
+		SELECT a.* FROM checklist_answers a
+		JOIN checklist_template_items i ON i.id = a.item_id
+		WHERE a.checklist_id = $1
+		ORDER BY i.sequence
+	`
+	if err := r.db.Select(&checklist.Answers, answersQuery, checklist.ID); err != nil {
+		return nil, err
+	}
 	return &checklist, nil
 }
+
+// ChecklistTemplateRepositoryPostgres implementa el repositorio de plantillas de check-list
+type ChecklistTemplateRepositoryPostgres struct {
+	db *sqlx.DB
+}
+
+func NewChecklistTemplateRepository(db *sqlx.DB) domain.ChecklistTemplateRepository {
+	return &ChecklistTemplateRepositoryPostgres{db: db}
+}
+
+func (r *ChecklistTemplateRepositoryPostgres) Create(template *domain.ChecklistTemplate) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE checklist_templates SET is_active = false WHERE vehicle_type = $1 AND is_active`,
+		template.VehicleType); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO checklist_templates (vehicle_type, version, name, is_active, created_by)
+		VALUES ($1, (SELECT COALESCE(MAX(version), 0) + 1 FROM checklist_templates WHERE vehicle_type = $1), $2, true, $3)
+		RETURNING id, version, is_active, created_at
+	`
+	err = tx.QueryRow(query, template.VehicleType, template.Name, template.CreatedBy).
+		Scan(&template.ID, &template.Version, &template.IsActive, &template.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	itemQuery := `
+		INSERT INTO checklist_template_items (template_id, sequence, code, label, answer_type, required, blocking,
+		                                      min_value, max_value)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id
+	`
+	for _, item := range template.Items {
+		item.TemplateID = template.ID
+		err := tx.QueryRow(itemQuery, item.TemplateID, item.Sequence, item.Code, item.Label, item.AnswerType,
+			item.Required, item.Blocking, item.MinValue, item.MaxValue).Scan(&item.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *ChecklistTemplateRepositoryPostgres) FindByID(id uuid.UUID) (*domain.ChecklistTemplate, error) {
+	return r.findOne(`SELECT * FROM checklist_templates WHERE id = $1`, id)
+}
+
+func (r *ChecklistTemplateRepositoryPostgres) FindActive(vehicleType domain.VehicleType) (*domain.ChecklistTemplate, error) {
+	return r.findOne(`SELECT * FROM checklist_templates WHERE vehicle_type = $1 AND is_active`, vehicleType)
+}
+
+func (r *ChecklistTemplateRepositoryPostgres) findOne(query string, arg interface{}) (*domain.ChecklistTemplate, error) {
+	var template domain.ChecklistTemplate
+	if err := r.db.Get(&template, query, arg); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+
+	itemsQuery := `SELECT * FROM checklist_template_items WHERE template_id = $1 ORDER BY sequence`
+	if err := r.db.Select(&template.Items, itemsQuery, template.ID); err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (r *ChecklistTemplateRepositoryPostgres) List(vehicleType domain.VehicleType) ([]*domain.ChecklistTemplate, error) {
+	var templates []*domain.ChecklistTemplate
+	query := `
+		SELECT * FROM checklist_templates
+		WHERE $1::varchar = '' OR vehicle_type = $1::varchar
+		ORDER BY vehicle_type, version DESC
+	`
+	err := r.db.Select(&templates, query, vehicleType)
+	return templates, err
+}
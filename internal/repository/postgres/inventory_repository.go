@@ -19,13 +19,16 @@ func NewInventoryRepository(db *sqlx.DB) domain.InventoryRepository {
 }
 
 func (r *InventoryRepositoryPostgres) Create(inventory *domain.Inventory) error {
+	if inventory.WarehouseID == uuid.Nil {
+		return domain.ErrWarehouseNotFound
+	}
 	query := `
-		INSERT INTO inventory (product_id, lot_number, expiration_date, quantity, status, warehouse_location)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO inventory (product_id, lot_number, expiration_date, quantity, status, warehouse_location, warehouse_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		RETURNING id, created_at, updated_at
 	`
 	return r.db.QueryRow(query, inventory.ProductID, inventory.LotNumber, inventory.ExpirationDate,
-		inventory.Quantity, inventory.Status, inventory.WarehouseLocation).
+		inventory.Quantity, inventory.Status, inventory.WarehouseLocation, inventory.WarehouseID).
 		Scan(&inventory.ID, &inventory.CreatedAt, &inventory.UpdatedAt)
 }
 
@@ -42,27 +45,39 @@ func (r *InventoryRepositoryPostgres) FindByID(id uuid.UUID) (*domain.Inventory,
 	return &inventory, nil
 }
 
-func (r *InventoryRepositoryPostgres) FindByProduct(productID uuid.UUID) ([]*domain.Inventory, error) {
+func (r *InventoryRepositoryPostgres) FindByProduct(productID uuid.UUID, scope domain.DataScope) ([]*domain.Inventory, error) {
 	var inventories []*domain.Inventory
-	query := `SELECT * FROM inventory WHERE product_id = $1 ORDER BY expiration_date`
-	err := r.db.Select(&inventories, query, productID)
+	where := newConditions()
+	where.where("i.product_id = " + where.arg(productID))
+	scopeInventory(where, scope)
+
+	query := `SELECT i.* FROM inventory i WHERE ` + where.sql() + ` ORDER BY i.expiration_date`
+	err := r.db.Select(&inventories, query, where.args...)
 	return inventories, err
 }
 
 // FindByProductFEFO implementa HU-06: First Expired First Out
-func (r *InventoryRepositoryPostgres) FindByProductFEFO(productID uuid.UUID) ([]*domain.Inventory, error) {
+func (r *InventoryRepositoryPostgres) FindByProductFEFO(productID uuid.UUID, scope domain.DataScope) ([]*domain.Inventory, error) {
 	var inventories []*domain.Inventory
-	query := `
-		SELECT * FROM inventory 
-		WHERE product_id = $1 
-		  AND status = 'DISPONIBLE' 
-		  AND quantity > 0
-		ORDER BY expiration_date ASC NULLS LAST, created_at ASC
-	`
-	err := r.db.Select(&inventories, query, productID)
+	where := newConditions("i.status = 'DISPONIBLE'", "i.quantity > 0")
+	where.where("i.product_id = " + where.arg(productID))
+	scopeInventory(where, scope)
+
+	query := `SELECT i.* FROM inventory i WHERE ` + where.sql() + ` ORDER BY i.expiration_date ASC NULLS LAST, i.created_at ASC`
+	err := r.db.Select(&inventories, query, where.args...)
 	return inventories, err
 }
 
+func (r *InventoryRepositoryPostgres) Visible(id uuid.UUID, scope domain.DataScope) (bool, error) {
+	where := newConditions()
+	where.where("i.id = " + where.arg(id))
+	scopeInventory(where, scope)
+
+	var visible bool
+	err := r.db.Get(&visible, `SELECT EXISTS (SELECT 1 FROM inventory i WHERE `+where.sql()+`)`, where.args...)
+	return visible, err
+}
+
 func (r *InventoryRepositoryPostgres) Update(inventory *domain.Inventory) error {
 	now := time.Now()
 	inventory.LastMovementAt = &now
@@ -220,3 +235,40 @@ func (r *CycleCountRepositoryPostgres) ListPending(limit, offset int) ([]*domain
 	err := r.db.Select(&counts, query, limit, offset)
 	return counts, err
 }
+
+// WarehouseRepositoryPostgres implementa el catálogo de almacenes
+type WarehouseRepositoryPostgres struct {
+	db *sqlx.DB
+}
+
+func NewWarehouseRepository(db *sqlx.DB) domain.WarehouseRepository {
+	return &WarehouseRepositoryPostgres{db: db}
+}
+
+func (r *WarehouseRepositoryPostgres) Create(warehouse *domain.Warehouse) error {
+	query := `
+		INSERT INTO warehouses (code, name, is_active)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`
+	return r.db.QueryRow(query, warehouse.Code, warehouse.Name, warehouse.IsActive).
+		Scan(&warehouse.ID, &warehouse.CreatedAt)
+}
+
+func (r *WarehouseRepositoryPostgres) FindByID(id uuid.UUID) (*domain.Warehouse, error) {
+	var warehouse domain.Warehouse
+	err := r.db.Get(&warehouse, `SELECT * FROM warehouses WHERE id = $1`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrWarehouseNotFound
+		}
+		return nil, err
+	}
+	return &warehouse, nil
+}
+
+func (r *WarehouseRepositoryPostgres) List() ([]*domain.Warehouse, error) {
+	var warehouses []*domain.Warehouse
+	err := r.db.Select(&warehouses, `SELECT * FROM warehouses ORDER BY code`)
+	return warehouses, err
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/sgl-disasur/api/internal/domain"
 )
 
@@ -94,35 +95,70 @@ func (r *OrderRepositoryPostgres) Delete(id uuid.UUID) error {
 	return nil
 }
 
-func (r *OrderRepositoryPostgres) List(filters map[string]interface{}, limit, offset int) ([]*domain.Order, error) {
+func (r *OrderRepositoryPostgres) List(filters map[string]interface{}, scope domain.DataScope, limit, offset int) ([]*domain.Order, error) {
 	var orders []*domain.Order
-	query := `SELECT * FROM orders WHERE deleted_at IS NULL`
+	where := orderListConditions(filters, scope)
 
+	query := `SELECT o.* FROM orders o WHERE ` + where.sql() + ` ORDER BY o.created_at DESC ` + where.page(limit, offset)
+	err := r.db.Select(&orders, query, where.args...)
+	return orders, err
+}
+
+func (r *OrderRepositoryPostgres) ListForReport(filters map[string]interface{}, scope domain.DataScope, limit, offset int) ([]*domain.OrderReportRow, error) {
+	var rows []*domain.OrderReportRow
+	where := orderListConditions(filters, scope)
+
+	query := `
+		SELECT o.*, COALESCE(cust.name, '') AS customer_name
+		FROM orders o
+		LEFT JOIN customers cust ON cust.id = o.customer_id
+		WHERE ` + where.sql() + ` ORDER BY o.created_at DESC ` + where.page(limit, offset)
+	err := r.db.Select(&rows, query, where.args...)
+	return rows, err
+}
+
+// orderListConditions arma los filtros y el alcance de List y ListForReport
+func orderListConditions(filters map[string]interface{}, scope domain.DataScope) *conditions {
+	where := newConditions("o.deleted_at IS NULL")
 	if status, ok := filters["status"]; ok {
-		query += fmt.Sprintf(" AND status = '%s'", status)
+		where.where("o.status = " + where.arg(status))
 	}
-
-	query += " ORDER BY created_at DESC LIMIT $1 OFFSET $2"
-	err := r.db.Select(&orders, query, limit, offset)
-	return orders, err
+	if statuses, ok := filters["statuses"].([]domain.OrderStatus); ok {
+		values := make([]string, len(statuses))
+		for i, status := range statuses {
+			values[i] = string(status)
+		}
+		where.where("o.status::text = ANY(" + where.arg(pq.Array(values)) + "::text[])")
+	}
+	scopeOrders(where, scope)
+	return where
 }
 
 // FindStuckOrders implementa HU-24: Pedidos atorados > X horas
-func (r *OrderRepositoryPostgres) FindStuckOrders(hours int, limit, offset int) ([]*domain.Order, error) {
+func (r *OrderRepositoryPostgres) FindStuckOrders(hours int, scope domain.DataScope, limit, offset int) ([]*domain.Order, error) {
 	var orders []*domain.Order
-	query := `
-		SELECT * FROM orders
-		WHERE status IN ('EN_PREPARACION', 'CONFIRMADO')
-		  AND created_at < NOW() - INTERVAL '%d hours'
-		  AND deleted_at IS NULL
-		ORDER BY created_at ASC
-		LIMIT $1 OFFSET $2
-	`
-	query = fmt.Sprintf(query, hours)
-	err := r.db.Select(&orders, query, limit, offset)
+	where := newConditions(
+		"o.status IN ('EN_PREPARACION', 'CONFIRMADO')",
+		fmt.Sprintf("o.created_at < NOW() - INTERVAL '%d hours'", hours),
+		"o.deleted_at IS NULL",
+	)
+	scopeOrders(where, scope)
+
+	query := `SELECT o.* FROM orders o WHERE ` + where.sql() + ` ORDER BY o.created_at ASC ` + where.page(limit, offset)
+	err := r.db.Select(&orders, query, where.args...)
 	return orders, err
 }
 
+func (r *OrderRepositoryPostgres) Visible(id uuid.UUID, scope domain.DataScope) (bool, error) {
+	where := newConditions("o.deleted_at IS NULL")
+	where.where("o.id = " + where.arg(id))
+	scopeOrders(where, scope)
+
+	var visible bool
+	err := r.db.Get(&visible, `SELECT EXISTS (SELECT 1 FROM orders o WHERE `+where.sql()+`)`, where.args...)
+	return visible, err
+}
+
 // OrderLineRepositoryPostgres implementa el repositorio de líneas de pedido
 type OrderLineRepositoryPostgres struct {
 	db *sqlx.DB
@@ -184,12 +220,14 @@ func NewCustomerRepository(db *sqlx.DB) domain.CustomerRepository {
 
 func (r *CustomerRepositoryPostgres) Create(customer *domain.Customer) error {
 	query := `
-		INSERT INTO customers (name, rfc, address, city, state, postal_code, phone, email, credit_limit)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO customers (name, rfc, address, city, state, postal_code, latitude, longitude,
+		                       phone, email, credit_limit, assigned_to)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 		RETURNING id, created_at, updated_at
 	`
 	return r.db.QueryRow(query, customer.Name, customer.RFC, customer.Address, customer.City,
-		customer.State, customer.PostalCode, customer.Phone, customer.Email, customer.CreditLimit).
+		customer.State, customer.PostalCode, customer.Latitude, customer.Longitude,
+		customer.Phone, customer.Email, customer.CreditLimit, customer.AssignedTo).
 		Scan(&customer.ID, &customer.CreatedAt, &customer.UpdatedAt)
 }
 
@@ -210,11 +248,11 @@ func (r *CustomerRepositoryPostgres) Update(customer *domain.Customer) error {
 	query := `
 		UPDATE customers
 		SET name = $1, address = $2, phone = $3, email = $4, credit_limit = $5,
-		    updated_at = CURRENT_TIMESTAMP
-		WHERE id = $6
+		    latitude = $6, longitude = $7, assigned_to = $8, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $9
 	`
 	result, err := r.db.Exec(query, customer.Name, customer.Address, customer.Phone,
-		customer.Email, customer.CreditLimit, customer.ID)
+		customer.Email, customer.CreditLimit, customer.Latitude, customer.Longitude, customer.AssignedTo, customer.ID)
 	if err != nil {
 		return err
 	}
@@ -226,9 +264,12 @@ func (r *CustomerRepositoryPostgres) Update(customer *domain.Customer) error {
 	return nil
 }
 
-func (r *CustomerRepositoryPostgres) List(filters map[string]interface{}, limit, offset int) ([]*domain.Customer, error) {
+func (r *CustomerRepositoryPostgres) List(filters map[string]interface{}, scope domain.DataScope, limit, offset int) ([]*domain.Customer, error) {
 	var customers []*domain.Customer
-	query := `SELECT * FROM customers ORDER BY name LIMIT $1 OFFSET $2`
-	err := r.db.Select(&customers, query, limit, offset)
+	where := newConditions()
+	scopeCustomers(where, scope)
+
+	query := `SELECT cu.* FROM customers cu WHERE ` + where.sql() + ` ORDER BY cu.name ` + where.page(limit, offset)
+	err := r.db.Select(&customers, query, where.args...)
 	return customers, err
 }
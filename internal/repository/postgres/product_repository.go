@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
@@ -18,13 +19,23 @@ func NewProductRepository(db *sqlx.DB) domain.ProductRepository {
 	return &ProductRepositoryPostgres{db: db}
 }
 
+// productWriter permite escribir productos dentro o fuera de una transacción
+type productWriter interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
 func (r *ProductRepositoryPostgres) Create(product *domain.Product) error {
+	return insertProduct(r.db, product)
+}
+
+func insertProduct(db productWriter, product *domain.Product) error {
 	query := `
 		INSERT INTO products (sku, name, brand, category, barcode, weight_kg, length_cm, width_cm, height_cm, is_fragile, unit_price)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 		RETURNING id, created_at, updated_at
 	`
-	return r.db.QueryRow(query, product.SKU, product.Name, product.Brand, product.Category, product.Barcode,
+	return db.QueryRow(query, product.SKU, product.Name, product.Brand, product.Category, product.Barcode,
 		product.WeightKg, product.LengthCm, product.WidthCm, product.HeightCm, product.IsFragile, product.UnitPrice).
 		Scan(&product.ID, &product.CreatedAt, &product.UpdatedAt)
 }
@@ -68,7 +79,32 @@ func (r *ProductRepositoryPostgres) FindByBarcode(barcode string) (*domain.Produ
 	return &product, nil
 }
 
+// restoreOrInsertProduct reactiva el producto dado de baja con el mismo SKU (el SKU sigue siendo único)
+// o lo crea si no existe
+func restoreOrInsertProduct(db productWriter, product *domain.Product) error {
+	query := `
+		UPDATE products
+		SET name = $1, brand = $2, category = $3, barcode = $4, weight_kg = $5,
+		    length_cm = $6, width_cm = $7, height_cm = $8, is_fragile = $9, unit_price = $10,
+		    is_active = TRUE, deleted_at = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE sku = $11 AND deleted_at IS NOT NULL
+		RETURNING id, created_at, updated_at
+	`
+	err := db.QueryRow(query, product.Name, product.Brand, product.Category, product.Barcode,
+		product.WeightKg, product.LengthCm, product.WidthCm, product.HeightCm, product.IsFragile,
+		product.UnitPrice, product.SKU).Scan(&product.ID, &product.CreatedAt, &product.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return insertProduct(db, product)
+	}
+	product.IsActive = true
+	return err
+}
+
 func (r *ProductRepositoryPostgres) Update(product *domain.Product) error {
+	return updateProduct(r.db, product)
+}
+
+func updateProduct(db productWriter, product *domain.Product) error {
 	query := `
 		UPDATE products
 		SET name = $1, brand = $2, category = $3, barcode = $4, weight_kg = $5,
@@ -76,7 +112,7 @@ func (r *ProductRepositoryPostgres) Update(product *domain.Product) error {
 		    is_active = $11, updated_at = CURRENT_TIMESTAMP
 		WHERE id = $12 AND deleted_at IS NULL
 	`
-	result, err := r.db.Exec(query, product.Name, product.Brand, product.Category, product.Barcode,
+	result, err := db.Exec(query, product.Name, product.Brand, product.Category, product.Barcode,
 		product.WeightKg, product.LengthCm, product.WidthCm, product.HeightCm, product.IsFragile,
 		product.UnitPrice, product.IsActive, product.ID)
 	if err != nil {
@@ -91,8 +127,12 @@ func (r *ProductRepositoryPostgres) Update(product *domain.Product) error {
 }
 
 func (r *ProductRepositoryPostgres) Delete(id uuid.UUID) error {
+	return deleteProduct(r.db, id)
+}
+
+func deleteProduct(db productWriter, id uuid.UUID) error {
 	query := `UPDATE products SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1`
-	result, err := r.db.Exec(query, id)
+	result, err := db.Exec(query, id)
 	if err != nil {
 		return err
 	}
@@ -105,19 +145,24 @@ func (r *ProductRepositoryPostgres) Delete(id uuid.UUID) error {
 }
 
 func (r *ProductRepositoryPostgres) List(filters map[string]interface{}, limit, offset int) ([]*domain.Product, error) {
-	var products []*domain.Product
-	query := `SELECT * FROM products WHERE deleted_at IS NULL`
+	return r.ListInScope(filters, domain.DataScope{All: true}, limit, offset)
+}
 
-	// Agregar filtros
+// ListInScope pagina solo los productos dentro del alcance del usuario, así LIMIT/OFFSET
+// aplican sobre el conjunto ya filtrado
+func (r *ProductRepositoryPostgres) ListInScope(filters map[string]interface{}, scope domain.DataScope, limit, offset int) ([]*domain.Product, error) {
+	var products []*domain.Product
+	c := newConditions("p.deleted_at IS NULL")
 	if brand, ok := filters["brand"]; ok {
-		query += fmt.Sprintf(" AND brand = '%s'", brand)
+		c.where("p.brand = " + c.arg(brand))
 	}
 	if category, ok := filters["category"]; ok {
-		query += fmt.Sprintf(" AND category = '%s'", category)
+		c.where("p.category = " + c.arg(category))
 	}
+	scopeProducts(c, scope)
 
-	query += " ORDER BY created_at DESC LIMIT $1 OFFSET $2"
-	err := r.db.Select(&products, query, limit, offset)
+	query := `SELECT p.* FROM products p WHERE ` + c.sql() + ` ORDER BY p.created_at DESC ` + c.page(limit, offset)
+	err := r.db.Select(&products, query, c.args...)
 	return products, err
 }
 
@@ -128,6 +173,188 @@ func (r *ProductRepositoryPostgres) Count(filters map[string]interface{}) (int,
 	return count, err
 }
 
+// Import aplica la importación completa en una transacción: si una fila falla no se modifica nada
+func (r *ProductRepositoryPostgres) Import(items []*domain.ProductImport) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, item := range items {
+		if item.Existing {
+			err = updateProduct(tx, item.Product)
+		} else {
+			err = restoreOrInsertProduct(tx, item.Product)
+		}
+		if err != nil {
+			return fmt.Errorf("error al guardar SKU %s: %w", item.Product.SKU, err)
+		}
+
+		if item.Price != nil {
+			item.Price.ProductID = item.Product.ID
+			if err := insertProductPrice(tx, item.Price); err != nil {
+				return fmt.Errorf("error al registrar precio de SKU %s: %w", item.Product.SKU, err)
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// UpdateWithPrice guarda el producto y registra el cambio de precio en una transacción:
+// no queda historial sin producto actualizado ni al revés
+func (r *ProductRepositoryPostgres) UpdateWithPrice(product *domain.Product, price *domain.ProductPrice) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := updateProduct(tx, product); err != nil {
+		return err
+	}
+	if price != nil {
+		price.ProductID = product.ID
+		if err := insertProductPrice(tx, price); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Deactivate marca el producto inactivo y lo da de baja en una transacción
+func (r *ProductRepositoryPostgres) Deactivate(product *domain.Product) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	product.IsActive = false
+	if err := updateProduct(tx, product); err != nil {
+		return err
+	}
+	if err := deleteProduct(tx, product.ID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ProductPriceRepositoryPostgres implementa el historial de precios
+type ProductPriceRepositoryPostgres struct {
+	db *sqlx.DB
+}
+
+func NewProductPriceRepository(db *sqlx.DB) domain.ProductPriceRepository {
+	return &ProductPriceRepositoryPostgres{db: db}
+}
+
+func (r *ProductPriceRepositoryPostgres) Create(price *domain.ProductPrice) error {
+	return insertProductPrice(r.db, price)
+}
+
+func insertProductPrice(db productWriter, price *domain.ProductPrice) error {
+	query := `
+		INSERT INTO product_price_history (product_id, unit_price, previous_price, effective_from, changed_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+	return db.QueryRow(query, price.ProductID, price.UnitPrice, price.PreviousPrice,
+		price.EffectiveFrom, price.ChangedBy).Scan(&price.ID, &price.CreatedAt)
+}
+
+func (r *ProductPriceRepositoryPostgres) FindByProduct(productID uuid.UUID, limit, offset int) ([]*domain.ProductPrice, error) {
+	var prices []*domain.ProductPrice
+	query := `
+		SELECT * FROM product_price_history
+		WHERE product_id = $1
+		ORDER BY effective_from DESC, created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+	err := r.db.Select(&prices, query, productID, limit, offset)
+	return prices, err
+}
+
+// FindEffective retorna el último precio cuya vigencia inició antes de la fecha indicada
+func (r *ProductPriceRepositoryPostgres) FindEffective(productID uuid.UUID, at time.Time) (*domain.ProductPrice, error) {
+	var price domain.ProductPrice
+	query := `
+		SELECT * FROM product_price_history
+		WHERE product_id = $1 AND effective_from <= $2
+		ORDER BY effective_from DESC, created_at DESC
+		LIMIT 1
+	`
+	err := r.db.Get(&price, query, productID, at)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return &price, nil
+}
+
+func (r *ProductPriceRepositoryPostgres) ListChanges(from, to time.Time, limit, offset int) ([]*domain.ProductPrice, error) {
+	var prices []*domain.ProductPrice
+	query := `
+		SELECT h.*, p.sku FROM product_price_history h
+		JOIN products p ON p.id = h.product_id
+		WHERE h.effective_from BETWEEN $1 AND $2
+		ORDER BY h.effective_from DESC
+		LIMIT $3 OFFSET $4
+	`
+	err := r.db.Select(&prices, query, from, to, limit, offset)
+	return prices, err
+}
+
+// ProductPackagingRepositoryPostgres implementa los niveles de empaque
+type ProductPackagingRepositoryPostgres struct {
+	db *sqlx.DB
+}
+
+func NewProductPackagingRepository(db *sqlx.DB) domain.ProductPackagingRepository {
+	return &ProductPackagingRepositoryPostgres{db: db}
+}
+
+func (r *ProductPackagingRepositoryPostgres) FindByProduct(productID uuid.UUID) ([]*domain.ProductPackaging, error) {
+	var levels []*domain.ProductPackaging
+	query := `SELECT * FROM product_packaging WHERE product_id = $1 ORDER BY units_per_package`
+	err := r.db.Select(&levels, query, productID)
+	return levels, err
+}
+
+// ReplaceForProduct reemplaza todos los niveles de empaque del producto en una transacción
+func (r *ProductPackagingRepositoryPostgres) ReplaceForProduct(productID uuid.UUID, levels []*domain.ProductPackaging) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM product_packaging WHERE product_id = $1`, productID); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO product_packaging (product_id, unit, units_per_package, weight_kg, length_cm, width_cm, height_cm)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, updated_at
+	`
+	for _, level := range levels {
+		level.ProductID = productID
+		err := tx.QueryRow(query, productID, level.Unit, level.UnitsPerPackage, level.WeightKg,
+			level.LengthCm, level.WidthCm, level.HeightCm).Scan(&level.ID, &level.CreatedAt, &level.UpdatedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
 // SupplierRepositoryPostgres implementa el repositorio de proveedores
 type SupplierRepositoryPostgres struct {
 	db *sqlx.DB
@@ -19,12 +19,13 @@ func NewReceptionOrderRepository(db *sqlx.DB) domain.ReceptionOrderRepository {
 
 func (r *ReceptionOrderRepositoryPostgres) Create(order *domain.ReceptionOrder) error {
 	query := `
-		INSERT INTO reception_orders (order_number, supplier_id, brand, invoice_number, invoice_file_url, status, notes)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO reception_orders (order_number, supplier_id, brand, invoice_number, invoice_file_url, status, notes,
+		                              warehouse_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		RETURNING id, created_at, updated_at
 	`
 	return r.db.QueryRow(query, order.OrderNumber, order.SupplierID, order.Brand, order.InvoiceNumber,
-		order.InvoiceFileURL, order.Status, order.Notes).Scan(&order.ID, &order.CreatedAt, &order.UpdatedAt)
+		order.InvoiceFileURL, order.Status, order.Notes, order.WarehouseID).Scan(&order.ID, &order.CreatedAt, &order.UpdatedAt)
 }
 
 func (r *ReceptionOrderRepositoryPostgres) FindByID(id uuid.UUID) (*domain.ReceptionOrder, error) {
@@ -73,13 +74,26 @@ func (r *ReceptionOrderRepositoryPostgres) Update(order *domain.ReceptionOrder)
 	return nil
 }
 
-func (r *ReceptionOrderRepositoryPostgres) List(filters map[string]interface{}, limit, offset int) ([]*domain.ReceptionOrder, error) {
+func (r *ReceptionOrderRepositoryPostgres) List(filters map[string]interface{}, scope domain.DataScope, limit, offset int) ([]*domain.ReceptionOrder, error) {
 	var orders []*domain.ReceptionOrder
-	query := `SELECT * FROM reception_orders ORDER BY created_at DESC LIMIT $1 OFFSET $2`
-	err := r.db.Select(&orders, query, limit, offset)
+	where := newConditions()
+	scopeReceptionOrders(where, scope)
+
+	query := `SELECT ro.* FROM reception_orders ro WHERE ` + where.sql() + ` ORDER BY ro.created_at DESC ` + where.page(limit, offset)
+	err := r.db.Select(&orders, query, where.args...)
 	return orders, err
 }
 
+func (r *ReceptionOrderRepositoryPostgres) Visible(id uuid.UUID, scope domain.DataScope) (bool, error) {
+	where := newConditions()
+	where.where("ro.id = " + where.arg(id))
+	scopeReceptionOrders(where, scope)
+
+	var visible bool
+	err := r.db.Get(&visible, `SELECT EXISTS (SELECT 1 FROM reception_orders ro WHERE `+where.sql()+`)`, where.args...)
+	return visible, err
+}
+
 // ReceptionLineRepositoryPostgres implementa el repositorio de líneas de recepción
 type ReceptionLineRepositoryPostgres struct {
 	db *sqlx.DB
@@ -0,0 +1,127 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/sgl-disasur/api/internal/domain"
+)
+
+// conditions arma cláusulas WHERE parametrizadas; los placeholders continúan tras los argumentos iniciales
+type conditions struct {
+	clauses []string
+	args    []interface{}
+}
+
+func newConditions(clauses ...string) *conditions {
+	return &conditions{clauses: clauses}
+}
+
+// arg agrega un argumento y retorna su placeholder
+func (c *conditions) arg(value interface{}) string {
+	c.args = append(c.args, value)
+	return fmt.Sprintf("$%d", len(c.args))
+}
+
+func (c *conditions) where(clause string) {
+	c.clauses = append(c.clauses, clause)
+}
+
+func (c *conditions) sql() string {
+	if len(c.clauses) == 0 {
+		return "TRUE"
+	}
+	return strings.Join(c.clauses, " AND ")
+}
+
+// page agrega LIMIT/OFFSET y retorna la cláusula
+func (c *conditions) page(limit, offset int) string {
+	return fmt.Sprintf("LIMIT %s OFFSET %s", c.arg(limit), c.arg(offset))
+}
+
+func (c *conditions) warehouses(scope domain.DataScope) string {
+	ids := make([]string, len(scope.WarehouseIDs))
+	for i, id := range scope.WarehouseIDs {
+		ids[i] = id.String()
+	}
+	return c.arg(pq.Array(ids)) + "::uuid[]"
+}
+
+func (c *conditions) brands(scope domain.DataScope) string {
+	brands := make([]string, len(scope.Brands))
+	for i, brand := range scope.Brands {
+		brands[i] = string(brand)
+	}
+	return c.arg(pq.Array(brands)) + "::text[]"
+}
+
+// scopeReceptionOrders limita las órdenes de recepción (alias ro) a los almacenes y marcas del usuario
+func scopeReceptionOrders(c *conditions, scope domain.DataScope) {
+	if scope.ByWarehouse() {
+		c.where("ro.warehouse_id = ANY(" + c.warehouses(scope) + ")")
+	}
+	if scope.ByBrand() {
+		c.where("ro.brand::text = ANY(" + c.brands(scope) + ")")
+	}
+}
+
+// scopeInventory limita los lotes (alias i) a los almacenes y marcas del usuario
+func scopeInventory(c *conditions, scope domain.DataScope) {
+	if scope.ByWarehouse() {
+		c.where("i.warehouse_id = ANY(" + c.warehouses(scope) + ")")
+	}
+	if scope.ByBrand() {
+		c.where("EXISTS (SELECT 1 FROM products p WHERE p.id = i.product_id AND p.brand::text = ANY(" + c.brands(scope) + "))")
+	}
+}
+
+// scopeProducts limita los productos (alias p) a las marcas del usuario y a los que tienen lotes en sus almacenes
+func scopeProducts(c *conditions, scope domain.DataScope) {
+	if scope.ByWarehouse() {
+		c.where("EXISTS (SELECT 1 FROM inventory i WHERE i.product_id = p.id AND i.warehouse_id = ANY(" + c.warehouses(scope) + "))")
+	}
+	if scope.ByBrand() {
+		c.where("p.brand::text = ANY(" + c.brands(scope) + ")")
+	}
+}
+
+// scopeOrders limita los pedidos (alias o): el vendedor ve los de sus clientes o los que capturó,
+// el chofer los de sus rutas, y por almacén/marca los que tengan alguna línea dentro del alcance
+func scopeOrders(c *conditions, scope domain.DataScope) {
+	if scope.OwnCustomersOnly() {
+		user := c.arg(scope.UserID)
+		c.where("(o.created_by = " + user + " OR EXISTS (SELECT 1 FROM customers cu WHERE cu.id = o.customer_id AND cu.assigned_to = " + user + "))")
+	}
+	if scope.OwnRoutesOnly() {
+		user := c.arg(scope.UserID)
+		c.where(`EXISTS (
+			SELECT 1 FROM routes r JOIN drivers d ON d.id = r.driver_id
+			LEFT JOIN route_stops s ON s.route_id = r.id
+			WHERE (r.order_id = o.id OR s.order_id = o.id) AND d.user_id = ` + user + `)`)
+	}
+	if scope.ByWarehouse() {
+		c.where(`EXISTS (
+			SELECT 1 FROM order_lines l JOIN inventory i ON i.id = l.inventory_id
+			WHERE l.order_id = o.id AND i.warehouse_id = ANY(` + c.warehouses(scope) + `))`)
+	}
+	if scope.ByBrand() {
+		c.where(`EXISTS (
+			SELECT 1 FROM order_lines l JOIN products p ON p.id = l.product_id
+			WHERE l.order_id = o.id AND p.brand::text = ANY(` + c.brands(scope) + `))`)
+	}
+}
+
+// scopeCustomers limita los clientes (alias cu) a los asignados al vendedor
+func scopeCustomers(c *conditions, scope domain.DataScope) {
+	if scope.OwnCustomersOnly() {
+		c.where("cu.assigned_to = " + c.arg(scope.UserID))
+	}
+}
+
+// scopeRoutes limita las rutas (alias r) a las del chofer
+func scopeRoutes(c *conditions, scope domain.DataScope) {
+	if scope.OwnRoutesOnly() {
+		c.where("r.driver_id IN (SELECT id FROM drivers WHERE user_id = " + c.arg(scope.UserID) + ")")
+	}
+}
@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"database/sql/driver"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/sgl-disasur/api/internal/domain"
+)
+
+func TestScopeInventoryByWarehouse(t *testing.T) {
+	warehouseID := uuid.New()
+	scope := domain.DataScope{UserID: uuid.New(), Role: domain.RoleJefeAlmacen, WarehouseIDs: []uuid.UUID{warehouseID}}
+
+	where := newConditions()
+	scopeInventory(where, scope)
+
+	if got, want := where.sql(), "i.warehouse_id = ANY($1::uuid[])"; got != want {
+		t.Fatalf("condición = %q, se esperaba %q", got, want)
+	}
+	valuer, ok := where.args[0].(driver.Valuer)
+	if !ok {
+		t.Fatalf("argumento %T no es un arreglo de Postgres", where.args[0])
+	}
+	value, err := valuer.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := value, "{\""+warehouseID.String()+"\"}"; got != want {
+		t.Fatalf("almacenes = %v, se esperaba %v", got, want)
+	}
+}
+
+// La condición por almacén no coincide con NULL: la migración debe dejar cada lote existente en un almacén
+func TestDataScopeMigrationAssignsEveryLot(t *testing.T) {
+	migration, err := os.ReadFile("../../../scripts/migrations/018_data_scope.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sql := string(migration)
+
+	backfill := strings.Index(sql, "SET warehouse_id = (SELECT id FROM warehouses WHERE code = 'PRINCIPAL')")
+	notNull := strings.Index(sql, "ALTER TABLE inventory ALTER COLUMN warehouse_id SET NOT NULL")
+	if backfill < 0 || notNull < 0 || notNull < backfill {
+		t.Fatal("018 debe asignar el almacén PRINCIPAL a los lotes sin almacén y después marcar inventory.warehouse_id NOT NULL")
+	}
+	if !regexp.MustCompile(`(?s)INSERT INTO warehouses \(code, name\) VALUES \('PRINCIPAL'.*ON CONFLICT \(code\) DO NOTHING`).MatchString(sql) {
+		t.Fatal("018 debe crear el almacén PRINCIPAL antes de asignarlo")
+	}
+}
+
+// Contra una base migrada (TEST_DATABASE_URL): un usuario limitado al almacén de un lote existente lo ve
+func TestFindByProductWarehouseScopeSeesExistingStock(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL no definida")
+	}
+	db, err := sqlx.Connect("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var lot domain.Inventory
+	if err := db.Get(&lot, `SELECT * FROM inventory ORDER BY created_at LIMIT 1`); err != nil {
+		t.Skipf("sin lotes para probar: %v", err)
+	}
+
+	scope := domain.DataScope{UserID: uuid.New(), Role: domain.RoleJefeAlmacen, WarehouseIDs: []uuid.UUID{lot.WarehouseID}}
+	lots, err := NewInventoryRepository(db).FindByProduct(lot.ProductID, scope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, found := range lots {
+		if found.ID == lot.ID {
+			return
+		}
+	}
+	t.Fatalf("el lote %s del almacén %s no es visible para un usuario de ese almacén", lot.ID, lot.WarehouseID)
+}
@@ -20,13 +20,29 @@ func NewSessionRepository(db *sqlx.DB) domain.SessionRepository {
 }
 
 func (r *SessionRepositoryPostgres) Create(session *domain.Session) error {
+	return insertSession(r.db, session)
+}
+
+// sessionInserter permite crear la sesión dentro o fuera de una transacción
+type sessionInserter interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func insertSession(db sessionInserter, session *domain.Session) error {
+	if session.FamilyID == uuid.Nil {
+		session.FamilyID = uuid.New()
+	}
 	query := `
-		INSERT INTO sessions (user_id, token, ip_address, user_agent, expires_at)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, created_at
+		INSERT INTO sessions (user_id, token, ip_address, user_agent, expires_at,
+			family_id, refresh_token_hash, refresh_expires_at, password_change_required,
+			totp_enrollment_required)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at, last_activity_at
 	`
-	return r.db.QueryRow(query, session.UserID, session.Token, session.IPAddress,
-		session.UserAgent, session.ExpiresAt).Scan(&session.ID, &session.CreatedAt)
+	return db.QueryRow(query, session.UserID, session.Token, session.IPAddress,
+		session.UserAgent, session.ExpiresAt, session.FamilyID, session.RefreshTokenHash,
+		session.RefreshExpiresAt, session.PasswordChangeRequired, session.TOTPEnrollmentRequired).
+		Scan(&session.ID, &session.CreatedAt, &session.LastActivityAt)
 }
 
 func (r *SessionRepositoryPostgres) FindByToken(token string) (*domain.Session, error) {
@@ -42,18 +58,86 @@ func (r *SessionRepositoryPostgres) FindByToken(token string) (*domain.Session,
 	return &session, nil
 }
 
+func (r *SessionRepositoryPostgres) FindByRefreshTokenHash(hash string) (*domain.Session, error) {
+	var session domain.Session
+	query := `SELECT * FROM sessions WHERE refresh_token_hash = $1 AND refresh_token_hash <> ''`
+	err := r.db.Get(&session, query, hash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrInvalidToken
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *SessionRepositoryPostgres) Rotate(current *domain.Session, next *domain.Session) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	result, err := tx.Exec(`
+		UPDATE sessions SET rotated_at = $2, expires_at = LEAST(expires_at, $2)
+		WHERE id = $1 AND rotated_at IS NULL
+	`, current.ID, now)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return domain.ErrRefreshTokenReused
+	}
+
+	next.FamilyID = current.FamilyID
+	if err := insertSession(tx, next); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	current.RotatedAt = &now
+	return nil
+}
+
+func (r *SessionRepositoryPostgres) DeleteByFamily(familyID uuid.UUID) error {
+	query := `DELETE FROM sessions WHERE family_id = $1`
+	_, err := r.db.Exec(query, familyID)
+	return err
+}
+
 func (r *SessionRepositoryPostgres) Delete(token string) error {
 	query := `DELETE FROM sessions WHERE token = $1`
 	_, err := r.db.Exec(query, token)
 	return err
 }
 
+func (r *SessionRepositoryPostgres) Touch(id uuid.UUID, at time.Time) error {
+	query := `UPDATE sessions SET last_activity_at = $2 WHERE id = $1`
+	_, err := r.db.Exec(query, id, at)
+	return err
+}
+
 func (r *SessionRepositoryPostgres) DeleteExpired() error {
-	query := `DELETE FROM sessions WHERE expires_at < CURRENT_TIMESTAMP`
+	// Las sesiones rotadas se conservan hasta que vence su refresh token para detectar reutilización
+	query := `DELETE FROM sessions WHERE COALESCE(refresh_expires_at, expires_at) < CURRENT_TIMESTAMP`
 	_, err := r.db.Exec(query)
 	return err
 }
 
+func (r *SessionRepositoryPostgres) DeleteIdle(lastActivityBefore time.Time) error {
+	// La inactividad se mide en la sesión vigente de cada familia; se elimina la familia completa
+	query := `
+		DELETE FROM sessions WHERE family_id IN (
+			SELECT family_id FROM sessions WHERE rotated_at IS NULL AND last_activity_at < $1
+		)
+	`
+	_, err := r.db.Exec(query, lastActivityBefore)
+	return err
+}
+
 func (r *SessionRepositoryPostgres) DeleteByUserID(userID uuid.UUID) error {
 	query := `DELETE FROM sessions WHERE user_id = $1`
 	_, err := r.db.Exec(query, userID)
@@ -3,6 +3,9 @@ package postgres
 import (
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
@@ -18,13 +21,48 @@ func NewUserRepository(db *sqlx.DB) domain.UserRepository {
 }
 
 func (r *UserRepositoryPostgres) Create(user *domain.User) error {
+	return insertUser(r.db, user)
+}
+
+// CreateFirst bloquea la tabla para que dos registros simultáneos no creen ambos el usuario inicial
+func (r *UserRepositoryPostgres) CreateFirst(user *domain.User) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// SHARE ROW EXCLUSIVE choca consigo mismo y con las escrituras, pero permite las lecturas
+	if _, err := tx.Exec(`LOCK TABLE users IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return err
+	}
+	var count int
+	if err := tx.Get(&count, `SELECT COUNT(*) FROM users WHERE deleted_at IS NULL`); err != nil {
+		return err
+	}
+	if count > 0 {
+		return domain.ErrAlreadyExists
+	}
+	if err := insertUser(tx, user); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// userInserter permite insertar dentro o fuera de una transacción
+type userInserter interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func insertUser(db userInserter, user *domain.User) error {
 	query := `
-		INSERT INTO users (username, email, password_hash, role, status)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, created_at, updated_at
+		INSERT INTO users (username, email, password_hash, role, status, must_change_password)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at, password_changed_at
 	`
-	return r.db.QueryRow(query, user.Username, user.Email, user.PasswordHash, user.Role, user.Status).
-		Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+	return db.QueryRow(query, user.Username, user.Email, user.PasswordHash, user.Role, user.Status,
+		user.MustChangePassword).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt, &user.PasswordChangedAt)
 }
 
 func (r *UserRepositoryPostgres) FindByID(id uuid.UUID) (*domain.User, error) {
@@ -70,11 +108,15 @@ func (r *UserRepositoryPostgres) Update(user *domain.User) error {
 	query := `
 		UPDATE users
 		SET email = $1, password_hash = $2, role = $3, status = $4,
-		    failed_login_attempts = $5, last_login = $6, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $7 AND deleted_at IS NULL
+		    failed_login_attempts = $5, last_login = $6, password_changed_at = $7,
+		    must_change_password = $8, locked_until = $9, lockout_count = $10,
+		    totp_secret = $11, totp_enabled = $12, totp_last_step = $13,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $14 AND deleted_at IS NULL
 	`
 	result, err := r.db.Exec(query, user.Email, user.PasswordHash, user.Role, user.Status,
-		user.FailedLoginAttempts, user.LastLogin, user.ID)
+		user.FailedLoginAttempts, user.LastLogin, user.PasswordChangedAt, user.MustChangePassword,
+		user.LockedUntil, user.LockoutCount, user.TOTPSecret, user.TOTPEnabled, user.TOTPLastStep, user.ID)
 	if err != nil {
 		return err
 	}
@@ -110,14 +152,237 @@ func (r *UserRepositoryPostgres) Delete(id uuid.UUID) error {
 
 func (r *UserRepositoryPostgres) List(filters map[string]interface{}, limit, offset int) ([]*domain.User, error) {
 	var users []*domain.User
-	query := `SELECT * FROM users WHERE deleted_at IS NULL ORDER BY created_at DESC LIMIT $1 OFFSET $2`
-	err := r.db.Select(&users, query, limit, offset)
+	where, args := userFilters(filters)
+	query := fmt.Sprintf(`SELECT * FROM users WHERE %s ORDER BY created_at DESC LIMIT $%d OFFSET $%d`,
+		where, len(args)+1, len(args)+2)
+	err := r.db.Select(&users, query, append(args, limit, offset)...)
 	return users, err
 }
 
 func (r *UserRepositoryPostgres) Count(filters map[string]interface{}) (int, error) {
 	var count int
-	query := `SELECT COUNT(*) FROM users WHERE deleted_at IS NULL`
-	err := r.db.Get(&count, query)
+	where, args := userFilters(filters)
+	err := r.db.Get(&count, `SELECT COUNT(*) FROM users WHERE `+where, args...)
 	return count, err
 }
+
+// userFilters arma la condición parametrizada de List y Count
+func userFilters(filters map[string]interface{}) (string, []interface{}) {
+	conditions := []string{"deleted_at IS NULL"}
+	var args []interface{}
+	if role, ok := filters["role"]; ok {
+		args = append(args, role)
+		conditions = append(conditions, fmt.Sprintf("role = $%d", len(args)))
+	}
+	if status, ok := filters["status"]; ok {
+		args = append(args, status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if search, ok := filters["search"]; ok {
+		args = append(args, fmt.Sprintf("%%%v%%", search))
+		conditions = append(conditions, fmt.Sprintf("(username ILIKE $%d OR email ILIKE $%d)", len(args), len(args)))
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// PasswordHistoryRepositoryPostgres implementa el historial de contraseñas
+type PasswordHistoryRepositoryPostgres struct {
+	db *sqlx.DB
+}
+
+func NewPasswordHistoryRepository(db *sqlx.DB) domain.PasswordHistoryRepository {
+	return &PasswordHistoryRepositoryPostgres{db: db}
+}
+
+func (r *PasswordHistoryRepositoryPostgres) Create(entry *domain.PasswordHistory) error {
+	query := `
+		INSERT INTO password_history (user_id, password_hash)
+		VALUES ($1, $2)
+		RETURNING id, created_at
+	`
+	return r.db.QueryRow(query, entry.UserID, entry.PasswordHash).Scan(&entry.ID, &entry.CreatedAt)
+}
+
+func (r *PasswordHistoryRepositoryPostgres) ListRecent(userID uuid.UUID, limit int) ([]*domain.PasswordHistory, error) {
+	var entries []*domain.PasswordHistory
+	query := `SELECT * FROM password_history WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2`
+	err := r.db.Select(&entries, query, userID, limit)
+	return entries, err
+}
+
+// LoginAttemptRepositoryPostgres implementa el registro de intentos de login
+type LoginAttemptRepositoryPostgres struct {
+	db *sqlx.DB
+}
+
+func NewLoginAttemptRepository(db *sqlx.DB) domain.LoginAttemptRepository {
+	return &LoginAttemptRepositoryPostgres{db: db}
+}
+
+func (r *LoginAttemptRepositoryPostgres) Create(attempt *domain.LoginAttempt) error {
+	query := `
+		INSERT INTO login_attempts (username, ip_address, success)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`
+	return r.db.QueryRow(query, attempt.Username, attempt.IPAddress, attempt.Success).
+		Scan(&attempt.ID, &attempt.CreatedAt)
+}
+
+func (r *LoginAttemptRepositoryPostgres) CountFailuresByIP(ipAddress string, since time.Time) (int, *time.Time, error) {
+	var result struct {
+		Count  int        `db:"count"`
+		Oldest *time.Time `db:"oldest"`
+	}
+	query := `
+		SELECT COUNT(*) AS count, MIN(created_at) AS oldest
+		FROM login_attempts
+		WHERE ip_address = $1 AND NOT success AND created_at >= $2
+	`
+	if err := r.db.Get(&result, query, ipAddress, since); err != nil {
+		return 0, nil, err
+	}
+	return result.Count, result.Oldest, nil
+}
+
+func (r *LoginAttemptRepositoryPostgres) DeleteBefore(before time.Time) error {
+	query := `DELETE FROM login_attempts WHERE created_at < $1`
+	_, err := r.db.Exec(query, before)
+	return err
+}
+
+// RecoveryCodeRepositoryPostgres implementa los códigos de recuperación de 2FA
+type RecoveryCodeRepositoryPostgres struct {
+	db *sqlx.DB
+}
+
+func NewRecoveryCodeRepository(db *sqlx.DB) domain.RecoveryCodeRepository {
+	return &RecoveryCodeRepositoryPostgres{db: db}
+}
+
+func (r *RecoveryCodeRepositoryPostgres) Replace(userID uuid.UUID, codeHashes []string) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM totp_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	for _, hash := range codeHashes {
+		if _, err := tx.Exec(`INSERT INTO totp_recovery_codes (user_id, code_hash) VALUES ($1, $2)`, userID, hash); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *RecoveryCodeRepositoryPostgres) Use(userID uuid.UUID, codeHash string) (bool, error) {
+	query := `
+		UPDATE totp_recovery_codes SET used_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`
+	result, err := r.db.Exec(query, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+func (r *RecoveryCodeRepositoryPostgres) DeleteByUser(userID uuid.UUID) error {
+	_, err := r.db.Exec(`DELETE FROM totp_recovery_codes WHERE user_id = $1`, userID)
+	return err
+}
+
+// RolePermissionRepositoryPostgres implementa los permisos por rol
+type RolePermissionRepositoryPostgres struct {
+	db *sqlx.DB
+}
+
+func NewRolePermissionRepository(db *sqlx.DB) domain.RolePermissionRepository {
+	return &RolePermissionRepositoryPostgres{db: db}
+}
+
+func (r *RolePermissionRepositoryPostgres) ListAll() (map[domain.UserRole][]domain.Permission, error) {
+	var rows []struct {
+		Role       domain.UserRole   `db:"role"`
+		Permission domain.Permission `db:"permission"`
+	}
+	if err := r.db.Select(&rows, `SELECT role, permission FROM role_permissions ORDER BY role, permission`); err != nil {
+		return nil, err
+	}
+
+	permissions := make(map[domain.UserRole][]domain.Permission)
+	for _, row := range rows {
+		permissions[row.Role] = append(permissions[row.Role], row.Permission)
+	}
+	return permissions, nil
+}
+
+func (r *RolePermissionRepositoryPostgres) Replace(role domain.UserRole, permissions []domain.Permission) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM role_permissions WHERE role = $1`, role); err != nil {
+		return err
+	}
+	for _, permission := range permissions {
+		if _, err := tx.Exec(`INSERT INTO role_permissions (role, permission) VALUES ($1, $2)`, role, permission); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// UserAssignmentRepositoryPostgres implementa los almacenes y marcas asignados a cada usuario
+type UserAssignmentRepositoryPostgres struct {
+	db *sqlx.DB
+}
+
+func NewUserAssignmentRepository(db *sqlx.DB) domain.UserAssignmentRepository {
+	return &UserAssignmentRepositoryPostgres{db: db}
+}
+
+func (r *UserAssignmentRepositoryPostgres) FindByUser(userID uuid.UUID) (*domain.UserAssignments, error) {
+	assignments := &domain.UserAssignments{WarehouseIDs: []uuid.UUID{}, Brands: []domain.Brand{}}
+	if err := r.db.Select(&assignments.WarehouseIDs,
+		`SELECT warehouse_id FROM user_warehouses WHERE user_id = $1 ORDER BY warehouse_id`, userID); err != nil {
+		return nil, err
+	}
+	if err := r.db.Select(&assignments.Brands,
+		`SELECT brand FROM user_brands WHERE user_id = $1 ORDER BY brand`, userID); err != nil {
+		return nil, err
+	}
+	return assignments, nil
+}
+
+func (r *UserAssignmentRepositoryPostgres) Replace(userID uuid.UUID, assignments domain.UserAssignments) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM user_warehouses WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM user_brands WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	for _, warehouseID := range assignments.WarehouseIDs {
+		if _, err := tx.Exec(`INSERT INTO user_warehouses (user_id, warehouse_id) VALUES ($1, $2)`, userID, warehouseID); err != nil {
+			return err
+		}
+	}
+	for _, brand := range assignments.Brands {
+		if _, err := tx.Exec(`INSERT INTO user_brands (user_id, brand) VALUES ($1, $2)`, userID, brand); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/sgl-disasur/api/internal/domain"
+)
+
+// ScopeResolver arma el alcance de datos de cada petición: los roles con data.all ven todo;
+// el resto queda limitado a sus almacenes y marcas asignados y, según el rol, a sus propios registros.
+type ScopeResolver struct {
+	permissions    *PermissionService
+	assignmentRepo domain.UserAssignmentRepository
+}
+
+func NewScopeResolver(permissions *PermissionService, assignmentRepo domain.UserAssignmentRepository) *ScopeResolver {
+	return &ScopeResolver{
+		permissions:    permissions,
+		assignmentRepo: assignmentRepo,
+	}
+}
+
+func (r *ScopeResolver) Resolve(userID uuid.UUID, role domain.UserRole) (domain.DataScope, error) {
+	all, err := r.permissions.HasPermission(role, domain.PermDataAll)
+	if err != nil {
+		return domain.DataScope{}, err
+	}
+	if all {
+		return domain.DataScope{UserID: userID, Role: role, All: true}, nil
+	}
+
+	assignments, err := r.assignmentRepo.FindByUser(userID)
+	if err != nil {
+		return domain.DataScope{}, err
+	}
+	return domain.DataScope{
+		UserID:       userID,
+		Role:         role,
+		WarehouseIDs: assignments.WarehouseIDs,
+		Brands:       assignments.Brands,
+	}, nil
+}
+
+// UserAssignmentUseCase consulta y reemplaza los almacenes y marcas asignados a un usuario
+type UserAssignmentUseCase struct {
+	userRepo       domain.UserRepository
+	warehouseRepo  domain.WarehouseRepository
+	assignmentRepo domain.UserAssignmentRepository
+	auditRepo      domain.AuditRepository
+}
+
+func NewUserAssignmentUseCase(
+	userRepo domain.UserRepository,
+	warehouseRepo domain.WarehouseRepository,
+	assignmentRepo domain.UserAssignmentRepository,
+	auditRepo domain.AuditRepository,
+) *UserAssignmentUseCase {
+	return &UserAssignmentUseCase{
+		userRepo:       userRepo,
+		warehouseRepo:  warehouseRepo,
+		assignmentRepo: assignmentRepo,
+		auditRepo:      auditRepo,
+	}
+}
+
+func (uc *UserAssignmentUseCase) Get(userID uuid.UUID) (*domain.UserAssignments, error) {
+	if _, err := uc.userRepo.FindByID(userID); err != nil {
+		return nil, err
+	}
+	return uc.assignmentRepo.FindByUser(userID)
+}
+
+type UpdateAssignmentsInput struct {
+	WarehouseIDs []uuid.UUID    `json:"warehouse_ids"`
+	Brands       []domain.Brand `json:"brands"`
+	UserActionInput
+}
+
+// Update reemplaza las asignaciones del usuario. Listas vacías quitan la restricción de esa dimensión.
+func (uc *UserAssignmentUseCase) Update(input UpdateAssignmentsInput) (*domain.UserAssignments, error) {
+	if _, err := uc.userRepo.FindByID(input.TargetUserID); err != nil {
+		return nil, err
+	}
+
+	assignments := domain.UserAssignments{WarehouseIDs: []uuid.UUID{}, Brands: []domain.Brand{}}
+	seenWarehouses := make(map[uuid.UUID]bool)
+	for _, id := range input.WarehouseIDs {
+		if seenWarehouses[id] {
+			continue
+		}
+		if _, err := uc.warehouseRepo.FindByID(id); err != nil {
+			return nil, err
+		}
+		seenWarehouses[id] = true
+		assignments.WarehouseIDs = append(assignments.WarehouseIDs, id)
+	}
+	seenBrands := make(map[domain.Brand]bool)
+	for _, brand := range input.Brands {
+		if !brand.IsValid() {
+			return nil, fmt.Errorf("marca inválida: %s", brand)
+		}
+		if !seenBrands[brand] {
+			seenBrands[brand] = true
+			assignments.Brands = append(assignments.Brands, brand)
+		}
+	}
+
+	previous, err := uc.assignmentRepo.FindByUser(input.TargetUserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.assignmentRepo.Replace(input.TargetUserID, assignments); err != nil {
+		return nil, err
+	}
+
+	_ = uc.auditRepo.Log(domain.AuditLog{
+		UserID:     &input.UserID,
+		Action:     "UPDATE_USER_ASSIGNMENTS",
+		EntityType: "USER",
+		EntityID:   &input.TargetUserID,
+		OldValues:  map[string]interface{}{"warehouse_ids": previous.WarehouseIDs, "brands": previous.Brands},
+		NewValues:  map[string]interface{}{"warehouse_ids": assignments.WarehouseIDs, "brands": assignments.Brands},
+		IPAddress:  input.IPAddress,
+	})
+
+	return &assignments, nil
+}
@@ -1,33 +1,45 @@
 package auth
 
 import (
+	"fmt"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/sgl-disasur/api/internal/domain"
 	"github.com/sgl-disasur/api/internal/infrastructure/security"
 )
 
+// mfaChallengeTTL es el tiempo para ingresar el segundo factor tras validar la contraseña
+const mfaChallengeTTL = 5 * time.Minute
+
 type LoginUseCase struct {
-	userRepo    domain.UserRepository
-	sessionRepo domain.SessionRepository
-	auditRepo   domain.AuditRepository
-	secretKey   string
-	jwtExpHours int
+	userRepo     domain.UserRepository
+	sessionRepo  domain.SessionRepository
+	auditRepo    domain.AuditRepository
+	recoveryRepo domain.RecoveryCodeRepository
+	tokens       TokenConfig
+	attempts     attemptGuard
+	totpKey      string // Clave con la que se cifran los secretos TOTP
 }
 
 func NewLoginUseCase(
 	userRepo domain.UserRepository,
 	sessionRepo domain.SessionRepository,
 	auditRepo domain.AuditRepository,
-	secretKey string,
-	jwtExpHours int,
+	attemptRepo domain.LoginAttemptRepository,
+	recoveryRepo domain.RecoveryCodeRepository,
+	tokens TokenConfig,
+	lockout domain.LockoutPolicy,
+	totpKey string,
 ) *LoginUseCase {
 	return &LoginUseCase{
-		userRepo:    userRepo,
-		sessionRepo: sessionRepo,
-		auditRepo:   auditRepo,
-		secretKey:   secretKey,
-		jwtExpHours: jwtExpHours,
+		userRepo:     userRepo,
+		sessionRepo:  sessionRepo,
+		auditRepo:    auditRepo,
+		recoveryRepo: recoveryRepo,
+		tokens:       tokens,
+		attempts:     newAttemptGuard(userRepo, auditRepo, attemptRepo, lockout),
+		totpKey:      totpKey,
 	}
 }
 
@@ -39,80 +51,291 @@ type LoginInput struct {
 }
 
 type LoginOutput struct {
-	Token     string       `json:"token"`
-	User      *domain.User `json:"user"`
-	ExpiresAt time.Time    `json:"expires_at"`
+	Token        string       `json:"token,omitempty"`
+	RefreshToken string       `json:"refresh_token,omitempty"` // Opaco; se canjea una sola vez en /auth/refresh
+	User         *domain.User `json:"user,omitempty"`
+	ExpiresAt    time.Time    `json:"expires_at"`
+	// IdleExpiresAt es el vencimiento por inactividad; cada petición lo renueva (header X-Session-Idle-Expires-At)
+	IdleExpiresAt    time.Time `json:"idle_expires_at"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	// PasswordChangeRequired indica que el token solo sirve para POST /auth/change-password
+	PasswordChangeRequired bool `json:"password_change_required"`
+	// TOTPEnrollmentRequired indica que el token solo sirve para configurar la verificación en dos pasos
+	TOTPEnrollmentRequired bool `json:"totp_enrollment_required"`
+	// MFARequired indica que falta el segundo factor: no hay tokens, solo MFAToken para /auth/2fa/verify
+	MFARequired bool   `json:"mfa_required,omitempty"`
+	MFAToken    string `json:"mfa_token,omitempty"`
 }
 
 func (uc *LoginUseCase) Execute(input LoginInput) (*LoginOutput, error) {
+	now := time.Now()
+
+	// 0. Frenar la IP que acumula intentos fallidos con cualquier usuario (credential stuffing)
+	if err := uc.attempts.checkIPThrottle(input, now); err != nil {
+		return nil, err
+	}
+
 	// 1. Buscar usuario
 	user, err := uc.userRepo.FindByUsername(input.Username)
 	if err != nil {
+		uc.attempts.recordAttempt(input, false)
 		_ = uc.auditRepo.Log(domain.AuditLog{
 			Action:    "LOGIN_FAILED",
+			NewValues: map[string]interface{}{"username": input.Username, "reason": "usuario inexistente"},
 			IPAddress: input.IPAddress,
 			UserAgent: input.UserAgent,
 		})
 		return nil, domain.ErrInvalidCredentials
 	}
 
-	// 2. Verificar si está bloqueado (HU-00: 3 intentos)
-	if user.IsLocked() {
+	// 2. Verificar bloqueo (HU-00); al vencer el enfriamiento la cuenta se reactiva sola
+	if user.IsLocked(now) {
+		uc.attempts.recordAttempt(input, false)
 		_ = uc.auditRepo.Log(domain.AuditLog{
 			UserID:    &user.ID,
 			Action:    "LOGIN_BLOCKED",
+			NewValues: map[string]interface{}{"locked_until": user.LockedUntil},
 			IPAddress: input.IPAddress,
 		})
+		if user.LockedUntil != nil {
+			return nil, fmt.Errorf("%w hasta %s", domain.ErrAccountLocked, user.LockedUntil.Format("2006-01-02 15:04"))
+		}
 		return nil, domain.ErrAccountLocked
 	}
+	if user.CooldownElapsed(now) {
+		lockedUntil := user.LockedUntil
+		user.ReleaseCooldown()
+		_ = uc.userRepo.Update(user)
+		_ = uc.auditRepo.Log(domain.AuditLog{
+			UserID:     &user.ID,
+			Action:     "ACCOUNT_UNLOCKED",
+			EntityType: "USER",
+			EntityID:   &user.ID,
+			NewValues:  map[string]interface{}{"reason": "enfriamiento vencido", "locked_until": lockedUntil},
+			IPAddress:  input.IPAddress,
+		})
+	}
 
 	// 3. Verificar contraseña
 	if !security.CheckPasswordHash(input.Password, user.PasswordHash) {
-		// Incrementar intentos fallidos
-		user.IncrementFailedAttempts()
-		_ = uc.userRepo.Update(user)
+		uc.attempts.recordFailure(user, input, "LOGIN_FAILED", now)
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	// 3b. Los usuarios dados de baja no inician sesión
+	if !user.IsActive() {
 		_ = uc.auditRepo.Log(domain.AuditLog{
 			UserID:    &user.ID,
-			Action:    "LOGIN_FAILED",
+			Action:    "LOGIN_INACTIVE",
 			IPAddress: input.IPAddress,
 		})
-		return nil, domain.ErrInvalidCredentials
+		return nil, domain.ErrUserInactive
 	}
 
-	// 4. Reset intentos y actualizar last_login
-	user.ResetFailedAttempts()
+	// 4. Con 2FA activa la contraseña no basta: se emite un token de desafío y los intentos
+	// fallidos se reinician recién al completar el segundo factor
+	if user.TOTPEnabled {
+		mfaToken, err := security.GenerateChallengeToken(user.ID, user.Username, uc.tokens.Keys, mfaChallengeTTL)
+		if err != nil {
+			return nil, err
+		}
+		_ = uc.auditRepo.Log(domain.AuditLog{
+			UserID:    &user.ID,
+			Action:    "LOGIN_MFA_CHALLENGE",
+			IPAddress: input.IPAddress,
+			UserAgent: input.UserAgent,
+		})
+		return &LoginOutput{
+			ExpiresAt:   now.Add(mfaChallengeTTL),
+			MFARequired: true,
+			MFAToken:    mfaToken,
+		}, nil
+	}
+
+	return uc.finish(user, input, now, "password")
+}
+
+type VerifySecondFactorInput struct {
+	MFAToken     string `json:"mfa_token" binding:"required"`
+	Code         string `json:"code,omitempty"`          // Código de 6 dígitos de la app autenticadora
+	RecoveryCode string `json:"recovery_code,omitempty"` // Alternativa de un solo uso
+	IPAddress    string `json:"-"`
+	UserAgent    string `json:"-"`
+}
+
+// VerifySecondFactor completa el login de un usuario con 2FA y emite los tokens de la sesión.
+// Los códigos erróneos cuentan como intentos fallidos para el bloqueo de la cuenta.
+func (uc *LoginUseCase) VerifySecondFactor(input VerifySecondFactorInput) (*LoginOutput, error) {
 	now := time.Now()
+
+	claims, err := security.ValidateToken(input.MFAToken, uc.tokens.Keys)
+	if err != nil || claims.Purpose != security.PurposeMFA {
+		return nil, domain.ErrInvalidToken
+	}
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return nil, domain.ErrInvalidToken
+	}
+	user, err := uc.userRepo.FindByID(userID)
+	if err != nil || !user.TOTPEnabled {
+		return nil, domain.ErrInvalidToken
+	}
+	attempt := LoginInput{Username: user.Username, IPAddress: input.IPAddress, UserAgent: input.UserAgent}
+	if err := uc.attempts.checkIPThrottle(attempt, now); err != nil {
+		return nil, err
+	}
+	if user.IsLocked(now) {
+		return nil, domain.ErrAccountLocked
+	}
+
+	method, ok := uc.checkSecondFactor(user, input, now)
+	if !ok {
+		uc.attempts.recordFailure(user, attempt, "MFA_FAILED", now)
+		return nil, domain.ErrInvalidTOTP
+	}
+
+	return uc.finish(user, attempt, now, method)
+}
+
+// checkSecondFactor valida el código TOTP o de recuperación y retorna el método usado
+func (uc *LoginUseCase) checkSecondFactor(user *domain.User, input VerifySecondFactorInput, now time.Time) (string, bool) {
+	if input.RecoveryCode != "" {
+		used, err := uc.recoveryRepo.Use(user.ID, security.HashRecoveryCode(input.RecoveryCode))
+		return "recovery_code", err == nil && used
+	}
+
+	secret, err := security.DecryptSecret(user.TOTPSecret, uc.totpKey)
+	if err != nil {
+		return "totp", false
+	}
+	step, ok := security.ValidateTOTP(secret, input.Code, now, user.TOTPLastStep)
+	if ok {
+		// Un código aceptado no vuelve a servir aunque siga dentro de su ventana
+		user.TOTPLastStep = step
+	}
+	return "totp", ok
+}
+
+// finish reinicia los intentos fallidos, crea la sesión y audita el login exitoso
+func (uc *LoginUseCase) finish(user *domain.User, input LoginInput, now time.Time, method string) (*LoginOutput, error) {
+	// Reset intentos y actualizar last_login
+	user.ResetFailedAttempts()
 	user.LastLogin = &now
 	_ = uc.userRepo.Update(user)
+	uc.attempts.recordAttempt(input, true)
 
-	// 5. Generar JWT token
-	token, err := security.GenerateToken(user.ID, user.Username, string(user.Role), uc.secretKey, uc.jwtExpHours)
+	// Generar tokens y crear sesión (cada login inicia una familia de rotación)
+	session, output, err := uc.tokens.newSession(user, uuid.New(), input.IPAddress, input.UserAgent)
 	if err != nil {
 		return nil, err
 	}
+	// Sin sesión el token sería rechazado por el middleware
+	if err := uc.sessionRepo.Create(session); err != nil {
+		return nil, err
+	}
 
-	// 6. Crear sesión
-	expiresAt := time.Now().Add(time.Duration(uc.jwtExpHours) * time.Hour)
-	session := &domain.Session{
-		UserID:    user.ID,
-		Token:     token,
+	_ = uc.auditRepo.Log(domain.AuditLog{
+		UserID:    &user.ID,
+		Action:    "LOGIN_SUCCESS",
+		NewValues: map[string]interface{}{"method": method},
 		IPAddress: input.IPAddress,
 		UserAgent: input.UserAgent,
-		ExpiresAt: expiresAt,
+	})
+
+	output.IdleExpiresAt = session.IdleDeadline(uc.tokens.IdleTimeout, session.LastActivityAt)
+	return output, nil
+}
+
+// attemptGuard aplica el bloqueo de cuenta y el freno por IP a toda verificación de credenciales
+// (login, segundo factor y cambio de contraseña)
+type attemptGuard struct {
+	userRepo    domain.UserRepository
+	auditRepo   domain.AuditRepository
+	attemptRepo domain.LoginAttemptRepository
+	lockout     domain.LockoutPolicy
+}
+
+func newAttemptGuard(
+	userRepo domain.UserRepository,
+	auditRepo domain.AuditRepository,
+	attemptRepo domain.LoginAttemptRepository,
+	lockout domain.LockoutPolicy,
+) attemptGuard {
+	return attemptGuard{
+		userRepo:    userRepo,
+		auditRepo:   auditRepo,
+		attemptRepo: attemptRepo,
+		lockout:     lockout,
 	}
-	_ = uc.sessionRepo.Create(session)
+}
 
-	// 7. Auditar login exitoso
-	_ = uc.auditRepo.Log(domain.AuditLog{
+// recordFailure cuenta el intento fallido del usuario y de la IP; al llegar al máximo bloquea la
+// cuenta con enfriamiento creciente. action es la acción auditada (LOGIN_FAILED, MFA_FAILED...).
+func (g attemptGuard) recordFailure(user *domain.User, input LoginInput, action string, now time.Time) {
+	locked := user.IncrementFailedAttempts(g.lockout, now)
+	_ = g.userRepo.Update(user)
+	g.recordAttempt(input, false)
+	_ = g.auditRepo.Log(domain.AuditLog{
 		UserID:    &user.ID,
-		Action:    "LOGIN_SUCCESS",
+		Action:    action,
 		IPAddress: input.IPAddress,
 		UserAgent: input.UserAgent,
 	})
+	if locked {
+		_ = g.auditRepo.Log(domain.AuditLog{
+			UserID:     &user.ID,
+			Action:     "ACCOUNT_LOCKED",
+			EntityType: "USER",
+			EntityID:   &user.ID,
+			NewValues: map[string]interface{}{
+				"locked_until":  user.LockedUntil,
+				"lockout_count": user.LockoutCount,
+			},
+			IPAddress: input.IPAddress,
+		})
+	}
+}
+
+func (g attemptGuard) checkIPThrottle(input LoginInput, now time.Time) error {
+	if g.lockout.IPMaxFailures <= 0 || input.IPAddress == "" {
+		return nil
+	}
+	failures, oldest, err := g.attemptRepo.CountFailuresByIP(input.IPAddress, now.Add(-g.lockout.IPWindow))
+	if err != nil || failures < g.lockout.IPMaxFailures {
+		return err
+	}
+
+	_ = g.auditRepo.Log(domain.AuditLog{
+		Action:    "LOGIN_THROTTLED",
+		NewValues: map[string]interface{}{"username": input.Username, "failures": failures},
+		IPAddress: input.IPAddress,
+		UserAgent: input.UserAgent,
+	})
+	// La IP se libera cuando el intento más antiguo sale de la ventana
+	retryAt := now.Add(g.lockout.IPWindow)
+	if oldest != nil {
+		retryAt = oldest.Add(g.lockout.IPWindow)
+	}
+	return &ThrottleError{RetryAfter: retryAt.Sub(now)}
+}
+
+func (g attemptGuard) recordAttempt(input LoginInput, success bool) {
+	_ = g.attemptRepo.Create(&domain.LoginAttempt{
+		Username:  input.Username,
+		IPAddress: input.IPAddress,
+		Success:   success,
+	})
+}
+
+// ThrottleError indica que la IP debe esperar RetryAfter antes de reintentar
+type ThrottleError struct {
+	RetryAfter time.Duration
+}
+
+func (e *ThrottleError) Error() string {
+	return fmt.Sprintf("%s; intente en %d minutos", domain.ErrTooManyAttempts.Error(), int(e.RetryAfter.Minutes())+1)
+}
 
-	return &LoginOutput{
-		Token:     token,
-		User:      user,
-		ExpiresAt: expiresAt,
-	}, nil
+func (e *ThrottleError) Unwrap() error {
+	return domain.ErrTooManyAttempts
 }
@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sgl-disasur/api/internal/domain"
+	"github.com/sgl-disasur/api/internal/infrastructure/security"
+)
+
+// PasswordManager aplica la política de contraseñas en altas, restablecimientos y cambios
+type PasswordManager struct {
+	policy      security.PasswordPolicy
+	history     int // Contraseñas anteriores que no pueden reutilizarse (incluye la actual)
+	historyRepo domain.PasswordHistoryRepository
+}
+
+func NewPasswordManager(policy security.PasswordPolicy, history int, historyRepo domain.PasswordHistoryRepository) *PasswordManager {
+	return &PasswordManager{
+		policy:      policy,
+		history:     history,
+		historyRepo: historyRepo,
+	}
+}
+
+// Apply valida la contraseña y la asigna al usuario sin guardarlo.
+// mustChange obliga a cambiarla en el siguiente inicio de sesión.
+func (m *PasswordManager) Apply(user *domain.User, password string, mustChange bool) error {
+	if err := m.policy.Validate(password); err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrWeakPassword, err.Error())
+	}
+
+	if m.history > 0 && user.PasswordHash != "" {
+		if security.CheckPasswordHash(password, user.PasswordHash) {
+			return domain.ErrPasswordReused
+		}
+		previous, err := m.historyRepo.ListRecent(user.ID, m.history)
+		if err != nil {
+			return err
+		}
+		for _, entry := range previous {
+			if security.CheckPasswordHash(password, entry.PasswordHash) {
+				return domain.ErrPasswordReused
+			}
+		}
+	}
+
+	passwordHash, err := security.HashPassword(password)
+	if err != nil {
+		return errors.New("error al hashear contraseña")
+	}
+	user.PasswordHash = passwordHash
+	user.PasswordChangedAt = time.Now()
+	user.MustChangePassword = mustChange
+	return nil
+}
+
+// Remember agrega la contraseña vigente del usuario (ya guardado) al historial
+func (m *PasswordManager) Remember(user *domain.User) error {
+	return m.historyRepo.Create(&domain.PasswordHistory{
+		UserID:       user.ID,
+		PasswordHash: user.PasswordHash,
+	})
+}
+
+// ChangePasswordUseCase permite al usuario cambiar su propia contraseña. Cierra todas sus
+// sesiones y emite tokens nuevos para la sesión desde la que se hizo el cambio.
+type ChangePasswordUseCase struct {
+	userRepo    domain.UserRepository
+	sessionRepo domain.SessionRepository
+	auditRepo   domain.AuditRepository
+	passwords   *PasswordManager
+	tokens      TokenConfig
+	attempts    attemptGuard
+}
+
+func NewChangePasswordUseCase(
+	userRepo domain.UserRepository,
+	sessionRepo domain.SessionRepository,
+	auditRepo domain.AuditRepository,
+	attemptRepo domain.LoginAttemptRepository,
+	passwords *PasswordManager,
+	tokens TokenConfig,
+	lockout domain.LockoutPolicy,
+) *ChangePasswordUseCase {
+	return &ChangePasswordUseCase{
+		userRepo:    userRepo,
+		sessionRepo: sessionRepo,
+		auditRepo:   auditRepo,
+		passwords:   passwords,
+		tokens:      tokens,
+		attempts:    newAttemptGuard(userRepo, auditRepo, attemptRepo, lockout),
+	}
+}
+
+type ChangePasswordInput struct {
+	CurrentPassword string    `json:"current_password" binding:"required"`
+	NewPassword     string    `json:"new_password" binding:"required"`
+	UserID          uuid.UUID `json:"-"`
+	IPAddress       string    `json:"-"`
+	UserAgent       string    `json:"-"`
+}
+
+func (uc *ChangePasswordUseCase) Execute(input ChangePasswordInput) (*LoginOutput, error) {
+	now := time.Now()
+	user, err := uc.userRepo.FindByID(input.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// La contraseña actual se verifica con el mismo bloqueo y freno por IP que el login
+	attempt := LoginInput{Username: user.Username, IPAddress: input.IPAddress, UserAgent: input.UserAgent}
+	if err := uc.attempts.checkIPThrottle(attempt, now); err != nil {
+		return nil, err
+	}
+	if user.IsLocked(now) {
+		return nil, domain.ErrAccountLocked
+	}
+	if !security.CheckPasswordHash(input.CurrentPassword, user.PasswordHash) {
+		uc.attempts.recordFailure(user, attempt, "CHANGE_PASSWORD_FAILED", now)
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	if err := uc.passwords.Apply(user, input.NewPassword, false); err != nil {
+		return nil, err
+	}
+	user.ResetFailedAttempts()
+	if err := uc.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+	_ = uc.passwords.Remember(user)
+	uc.attempts.recordAttempt(attempt, true)
+
+	// Las sesiones abiertas con la contraseña anterior dejan de ser válidas
+	if err := uc.sessionRepo.DeleteByUserID(user.ID); err != nil {
+		return nil, err
+	}
+	session, output, err := uc.tokens.newSession(user, uuid.New(), input.IPAddress, input.UserAgent)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.sessionRepo.Create(session); err != nil {
+		return nil, err
+	}
+
+	_ = uc.auditRepo.Log(domain.AuditLog{
+		UserID:     &user.ID,
+		Action:     "CHANGE_PASSWORD",
+		EntityType: "USER",
+		EntityID:   &user.ID,
+		IPAddress:  input.IPAddress,
+		UserAgent:  input.UserAgent,
+	})
+
+	output.IdleExpiresAt = session.IdleDeadline(uc.tokens.IdleTimeout, session.LastActivityAt)
+	return output, nil
+}
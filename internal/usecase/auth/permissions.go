@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sgl-disasur/api/internal/domain"
+)
+
+// PermissionService resuelve los permisos de cada rol. La asignación se lee de la base de datos
+// y se mantiene en memoria hasta cacheTTL; los cambios hechos por esta instancia aplican de inmediato.
+type PermissionService struct {
+	repo      domain.RolePermissionRepository
+	auditRepo domain.AuditRepository
+	cacheTTL  time.Duration
+
+	mu       sync.RWMutex
+	byRole   map[domain.UserRole]map[domain.Permission]bool
+	loadedAt time.Time
+}
+
+func NewPermissionService(repo domain.RolePermissionRepository, auditRepo domain.AuditRepository, cacheTTL time.Duration) *PermissionService {
+	return &PermissionService{
+		repo:      repo,
+		auditRepo: auditRepo,
+		cacheTTL:  cacheTTL,
+	}
+}
+
+// HasPermission indica si el rol tiene el permiso
+func (s *PermissionService) HasPermission(role domain.UserRole, permission domain.Permission) (bool, error) {
+	byRole, err := s.load()
+	if err != nil {
+		return false, err
+	}
+	return byRole[role][permission], nil
+}
+
+// ForRole retorna los permisos efectivos del rol en el orden del catálogo
+func (s *PermissionService) ForRole(role domain.UserRole) ([]domain.Permission, error) {
+	byRole, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	permissions := make([]domain.Permission, 0, len(byRole[role]))
+	for _, info := range domain.Permissions {
+		if byRole[role][info.Name] {
+			permissions = append(permissions, info.Name)
+		}
+	}
+	return permissions, nil
+}
+
+type RolePermissions struct {
+	Role        domain.UserRole     `json:"role"`
+	Permissions []domain.Permission `json:"permissions"`
+}
+
+type PermissionMatrixOutput struct {
+	Permissions []domain.PermissionInfo `json:"permissions"` // Catálogo
+	Roles       []RolePermissions       `json:"roles"`
+}
+
+// Matrix retorna el catálogo de permisos y la asignación de todos los roles
+func (s *PermissionService) Matrix() (*PermissionMatrixOutput, error) {
+	output := &PermissionMatrixOutput{Permissions: domain.Permissions}
+	for _, role := range domain.Roles {
+		permissions, err := s.ForRole(role)
+		if err != nil {
+			return nil, err
+		}
+		output.Roles = append(output.Roles, RolePermissions{Role: role, Permissions: permissions})
+	}
+	return output, nil
+}
+
+type UpdateRolePermissionsInput struct {
+	Permissions []domain.Permission `json:"permissions" binding:"required"`
+	Role        domain.UserRole     `json:"-"`
+	UserID      uuid.UUID           `json:"-"`
+	IPAddress   string              `json:"-"`
+}
+
+// Update reemplaza los permisos del rol. ADMIN_TI conserva siempre roles.manage para
+// que el sistema no quede sin quien pueda corregir la asignación.
+func (s *PermissionService) Update(input UpdateRolePermissionsInput) (*RolePermissions, error) {
+	if !input.Role.IsValid() {
+		return nil, fmt.Errorf("%w: %s", domain.ErrInvalidRole, input.Role)
+	}
+	seen := make(map[domain.Permission]bool, len(input.Permissions))
+	permissions := make([]domain.Permission, 0, len(input.Permissions))
+	for _, permission := range input.Permissions {
+		if !permission.IsValid() {
+			return nil, fmt.Errorf("permiso inválido: %s", permission)
+		}
+		if !seen[permission] {
+			seen[permission] = true
+			permissions = append(permissions, permission)
+		}
+	}
+	if input.Role == domain.RoleAdminTI && !seen[domain.PermRolesManage] {
+		return nil, fmt.Errorf("%w: ADMIN_TI debe conservar %s", domain.ErrForbidden, domain.PermRolesManage)
+	}
+
+	previous, err := s.ForRole(input.Role)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Replace(input.Role, permissions); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+
+	_ = s.auditRepo.Log(domain.AuditLog{
+		UserID:     &input.UserID,
+		Action:     "UPDATE_ROLE_PERMISSIONS",
+		EntityType: "ROLE",
+		OldValues:  map[string]interface{}{"role": input.Role, "permissions": previous},
+		NewValues:  map[string]interface{}{"role": input.Role, "permissions": permissions},
+		IPAddress:  input.IPAddress,
+	})
+
+	current, err := s.ForRole(input.Role)
+	if err != nil {
+		return nil, err
+	}
+	return &RolePermissions{Role: input.Role, Permissions: current}, nil
+}
+
+func (s *PermissionService) load() (map[domain.UserRole]map[domain.Permission]bool, error) {
+	s.mu.RLock()
+	if s.byRole != nil && time.Since(s.loadedAt) < s.cacheTTL {
+		defer s.mu.RUnlock()
+		return s.byRole, nil
+	}
+	s.mu.RUnlock()
+
+	all, err := s.repo.ListAll()
+	if err != nil {
+		return nil, err
+	}
+	byRole := make(map[domain.UserRole]map[domain.Permission]bool, len(all))
+	for role, permissions := range all {
+		byRole[role] = make(map[domain.Permission]bool, len(permissions))
+		for _, permission := range permissions {
+			byRole[role][permission] = true
+		}
+	}
+
+	s.mu.Lock()
+	s.byRole = byRole
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+	return byRole, nil
+}
+
+func (s *PermissionService) invalidate() {
+	s.mu.Lock()
+	s.byRole = nil
+	s.mu.Unlock()
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/sgl-disasur/api/internal/domain"
+	"github.com/sgl-disasur/api/internal/infrastructure/security"
+)
+
+// RefreshTokenUseCase canjea un refresh token por un nuevo par de tokens (rotación).
+// Presentar un refresh token ya canjeado indica robo: se revoca toda la familia de sesiones.
+type RefreshTokenUseCase struct {
+	userRepo    domain.UserRepository
+	sessionRepo domain.SessionRepository
+	auditRepo   domain.AuditRepository
+	tokens      TokenConfig
+}
+
+func NewRefreshTokenUseCase(
+	userRepo domain.UserRepository,
+	sessionRepo domain.SessionRepository,
+	auditRepo domain.AuditRepository,
+	tokens TokenConfig,
+) *RefreshTokenUseCase {
+	return &RefreshTokenUseCase{
+		userRepo:    userRepo,
+		sessionRepo: sessionRepo,
+		auditRepo:   auditRepo,
+		tokens:      tokens,
+	}
+}
+
+type RefreshTokenInput struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+	IPAddress    string `json:"-"`
+	UserAgent    string `json:"-"`
+}
+
+func (uc *RefreshTokenUseCase) Execute(input RefreshTokenInput) (*LoginOutput, error) {
+	// 1. Buscar la sesión por el hash del refresh token
+	session, err := uc.sessionRepo.FindByRefreshTokenHash(security.HashRefreshToken(input.RefreshToken))
+	if err != nil {
+		return nil, domain.ErrInvalidToken
+	}
+
+	// 2. Un refresh token ya rotado solo lo tiene quien lo copió: revocar la familia
+	if session.RotatedAt != nil {
+		return nil, uc.revokeFamily(session, input)
+	}
+
+	now := time.Now()
+	if session.RefreshExpiresAt == nil || now.After(*session.RefreshExpiresAt) || session.IsIdle(uc.tokens.IdleTimeout, now) {
+		_ = uc.sessionRepo.DeleteByFamily(session.FamilyID)
+		return nil, domain.ErrSessionExpired
+	}
+
+	// 3. El usuario debe seguir activo
+	user, err := uc.userRepo.FindByID(session.UserID)
+	if err != nil || !user.IsActive() || user.IsLocked(now) {
+		_ = uc.sessionRepo.DeleteByFamily(session.FamilyID)
+		return nil, domain.ErrUnauthorized
+	}
+
+	// 4. Emitir el nuevo par y rotar
+	next, output, err := uc.tokens.newSession(user, session.FamilyID, input.IPAddress, input.UserAgent)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.sessionRepo.Rotate(session, next); err != nil {
+		// Otra petición canjeó el mismo token al mismo tiempo
+		if errors.Is(err, domain.ErrRefreshTokenReused) {
+			return nil, uc.revokeFamily(session, input)
+		}
+		return nil, err
+	}
+
+	_ = uc.auditRepo.Log(domain.AuditLog{
+		UserID:     &user.ID,
+		Action:     "REFRESH_TOKEN",
+		EntityType: "SESSION",
+		EntityID:   &next.ID,
+		IPAddress:  input.IPAddress,
+		UserAgent:  input.UserAgent,
+	})
+
+	output.IdleExpiresAt = next.IdleDeadline(uc.tokens.IdleTimeout, next.LastActivityAt)
+	return output, nil
+}
+
+func (uc *RefreshTokenUseCase) revokeFamily(session *domain.Session, input RefreshTokenInput) error {
+	if err := uc.sessionRepo.DeleteByFamily(session.FamilyID); err != nil {
+		return err
+	}
+
+	_ = uc.auditRepo.Log(domain.AuditLog{
+		UserID:     &session.UserID,
+		Action:     "REFRESH_TOKEN_REUSE",
+		EntityType: "SESSION",
+		EntityID:   &session.ID,
+		NewValues: map[string]interface{}{
+			"family_id":  session.FamilyID,
+			"rotated_at": session.RotatedAt,
+			"ip_address": session.IPAddress,
+		},
+		IPAddress: input.IPAddress,
+		UserAgent: input.UserAgent,
+	})
+
+	return domain.ErrRefreshTokenReused
+}
@@ -2,23 +2,27 @@ package auth
 
 import (
 	"errors"
+	"fmt"
 
+	"github.com/google/uuid"
 	"github.com/sgl-disasur/api/internal/domain"
-	"github.com/sgl-disasur/api/internal/infrastructure/security"
 )
 
 type RegisterUserUseCase struct {
 	userRepo  domain.UserRepository
 	auditRepo domain.AuditRepository
+	passwords *PasswordManager
 }
 
 func NewRegisterUserUseCase(
 	userRepo domain.UserRepository,
 	auditRepo domain.AuditRepository,
+	passwords *PasswordManager,
 ) *RegisterUserUseCase {
 	return &RegisterUserUseCase{
 		userRepo:  userRepo,
 		auditRepo: auditRepo,
+		passwords: passwords,
 	}
 }
 
@@ -28,6 +32,9 @@ type RegisterUserInput struct {
 	Password  string          `json:"password" binding:"required"`
 	Role      domain.UserRole `json:"role" binding:"required"`
 	IPAddress string          `json:"-"`
+	// CreatedBy es el ADMIN_TI que da de alta al usuario; vacío en el registro público,
+	// que solo crea el administrador inicial
+	CreatedBy uuid.UUID `json:"-"`
 }
 
 type RegisterUserOutput struct {
@@ -35,6 +42,24 @@ type RegisterUserOutput struct {
 }
 
 func (uc *RegisterUserUseCase) Execute(input RegisterUserInput) (*RegisterUserOutput, error) {
+	if !input.Role.IsValid() {
+		return nil, fmt.Errorf("%w: %s", domain.ErrInvalidRole, input.Role)
+	}
+
+	// 0. Sin administrador que lo autorice, solo se permite crear el primer usuario y debe ser ADMIN_TI
+	if input.CreatedBy == uuid.Nil {
+		count, err := uc.userRepo.Count(nil)
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return nil, fmt.Errorf("%w: el registro público solo crea el administrador inicial", domain.ErrForbidden)
+		}
+		if input.Role != domain.RoleAdminTI {
+			return nil, fmt.Errorf("%w: el usuario inicial debe ser ADMIN_TI", domain.ErrInvalidRole)
+		}
+	}
+
 	// 1. Verificar que el username no existe
 	existingUser, _ := uc.userRepo.FindByUsername(input.Username)
 	if existingUser != nil {
@@ -47,28 +72,38 @@ func (uc *RegisterUserUseCase) Execute(input RegisterUserInput) (*RegisterUserOu
 		return nil, domain.ErrEmailExists
 	}
 
-	// 3. Hashear la contraseña
-	passwordHash, err := security.HashPassword(input.Password)
-	if err != nil {
-		return nil, errors.New("error al hashear contraseña")
-	}
-
-	// 4. Crear el usuario
+	// 3. Validar y hashear la contraseña; la asignada por un administrador se cambia en el primer login
 	user := &domain.User{
-		Username:     input.Username,
-		Email:        input.Email,
-		PasswordHash: passwordHash,
-		Role:         input.Role,
-		Status:       domain.UserStatusActivo,
+		Username: input.Username,
+		Email:    input.Email,
+		Role:     input.Role,
+		Status:   domain.UserStatusActivo,
+	}
+	if err := uc.passwords.Apply(user, input.Password, input.CreatedBy != uuid.Nil); err != nil {
+		return nil, err
 	}
 
-	if err := uc.userRepo.Create(user); err != nil {
+	// 4. Crear el usuario; el inicial se crea con la tabla bloqueada para que no haya dos
+	if input.CreatedBy == uuid.Nil {
+		err := uc.userRepo.CreateFirst(user)
+		if errors.Is(err, domain.ErrAlreadyExists) {
+			return nil, fmt.Errorf("%w: el registro público solo crea el administrador inicial", domain.ErrForbidden)
+		}
+		if err != nil {
+			return nil, err
+		}
+	} else if err := uc.userRepo.Create(user); err != nil {
 		return nil, err
 	}
+	_ = uc.passwords.Remember(user)
 
 	// 5. Auditar
+	actor := user.ID
+	if input.CreatedBy != uuid.Nil {
+		actor = input.CreatedBy
+	}
 	_ = uc.auditRepo.Log(domain.AuditLog{
-		UserID:     &user.ID,
+		UserID:     &actor,
 		Action:     "USER_REGISTERED",
 		EntityType: "USER",
 		EntityID:   &user.ID,
@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sgl-disasur/api/internal/domain"
+)
+
+// LogoutUseCase cierra la sesión del token con el que se autenticó la petición y su refresh token
+type LogoutUseCase struct {
+	sessionRepo domain.SessionRepository
+	auditRepo   domain.AuditRepository
+}
+
+func NewLogoutUseCase(sessionRepo domain.SessionRepository, auditRepo domain.AuditRepository) *LogoutUseCase {
+	return &LogoutUseCase{
+		sessionRepo: sessionRepo,
+		auditRepo:   auditRepo,
+	}
+}
+
+type LogoutInput struct {
+	Token     string
+	UserID    uuid.UUID
+	IPAddress string
+	UserAgent string
+}
+
+func (uc *LogoutUseCase) Execute(input LogoutInput) error {
+	// Se elimina la familia completa para que el refresh token tampoco sirva
+	session, err := uc.sessionRepo.FindByToken(input.Token)
+	if err != nil {
+		return err
+	}
+	if err := uc.sessionRepo.DeleteByFamily(session.FamilyID); err != nil {
+		return err
+	}
+
+	_ = uc.auditRepo.Log(domain.AuditLog{
+		UserID:    &input.UserID,
+		Action:    "LOGOUT",
+		IPAddress: input.IPAddress,
+		UserAgent: input.UserAgent,
+	})
+
+	return nil
+}
+
+// RevokeSessionsUseCase cierra todas las sesiones de un usuario (equipo extraviado, baja, cuenta comprometida)
+type RevokeSessionsUseCase struct {
+	userRepo    domain.UserRepository
+	sessionRepo domain.SessionRepository
+	auditRepo   domain.AuditRepository
+}
+
+func NewRevokeSessionsUseCase(
+	userRepo domain.UserRepository,
+	sessionRepo domain.SessionRepository,
+	auditRepo domain.AuditRepository,
+) *RevokeSessionsUseCase {
+	return &RevokeSessionsUseCase{
+		userRepo:    userRepo,
+		sessionRepo: sessionRepo,
+		auditRepo:   auditRepo,
+	}
+}
+
+type RevokeSessionsInput struct {
+	TargetUserID uuid.UUID
+	UserID       uuid.UUID // Administrador que revoca
+	IPAddress    string
+}
+
+func (uc *RevokeSessionsUseCase) Execute(input RevokeSessionsInput) error {
+	user, err := uc.userRepo.FindByID(input.TargetUserID)
+	if err != nil {
+		return domain.ErrUserNotFound
+	}
+
+	if err := uc.sessionRepo.DeleteByUserID(user.ID); err != nil {
+		return err
+	}
+
+	_ = uc.auditRepo.Log(domain.AuditLog{
+		UserID:     &input.UserID,
+		Action:     "REVOKE_SESSIONS",
+		EntityType: "USER",
+		EntityID:   &user.ID,
+		NewValues: map[string]interface{}{
+			"username": user.Username,
+		},
+		IPAddress: input.IPAddress,
+	})
+
+	return nil
+}
+
+// SessionCleanup elimina periódicamente las sesiones expiradas o inactivas más de idleTimeout
+// y los intentos de login más antiguos que attemptRetention
+type SessionCleanup struct {
+	sessionRepo      domain.SessionRepository
+	attemptRepo      domain.LoginAttemptRepository
+	interval         time.Duration
+	idleTimeout      time.Duration
+	attemptRetention time.Duration
+}
+
+func NewSessionCleanup(
+	sessionRepo domain.SessionRepository,
+	attemptRepo domain.LoginAttemptRepository,
+	interval, idleTimeout, attemptRetention time.Duration,
+) *SessionCleanup {
+	return &SessionCleanup{
+		sessionRepo:      sessionRepo,
+		attemptRepo:      attemptRepo,
+		interval:         interval,
+		idleTimeout:      idleTimeout,
+		attemptRetention: attemptRetention,
+	}
+}
+
+// Run ejecuta la limpieza cada intervalo hasta que se cancele el contexto.
+// onError recibe los errores de cada ejecución sin detener el ciclo.
+func (j *SessionCleanup) Run(ctx context.Context, onError func(error)) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := j.sessionRepo.DeleteExpired(); err != nil && onError != nil {
+				onError(err)
+			}
+			if j.idleTimeout > 0 {
+				if err := j.sessionRepo.DeleteIdle(time.Now().Add(-j.idleTimeout)); err != nil && onError != nil {
+					onError(err)
+				}
+			}
+			if j.attemptRetention > 0 {
+				if err := j.attemptRepo.DeleteBefore(time.Now().Add(-j.attemptRetention)); err != nil && onError != nil {
+					onError(err)
+				}
+			}
+		}
+	}
+}
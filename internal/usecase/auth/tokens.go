@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sgl-disasur/api/internal/domain"
+	"github.com/sgl-disasur/api/internal/infrastructure/security"
+)
+
+// TokenConfig define la vigencia de los tokens que emite el login y la renovación
+type TokenConfig struct {
+	Keys        *security.KeySet // Llaves con las que se firman y validan los JWT
+	AccessTTL   time.Duration    // Vigencia del JWT de acceso
+	RefreshTTL  time.Duration    // Vigencia del refresh token; cada rotación emite uno nuevo
+	IdleTimeout time.Duration    // Inactividad máxima de la sesión; 0 = sin límite
+	// PasswordMaxAge es la vigencia de la contraseña; vencida, la sesión solo permite cambiarla (0 = no vence)
+	PasswordMaxAge time.Duration
+	// TOTPRequiredRoles son los roles que deben configurar la verificación en dos pasos
+	TOTPRequiredRoles map[domain.UserRole]bool
+}
+
+// newSession genera el par de tokens de una sesión sin guardarla.
+// La respuesta lleva los tokens en claro; la sesión solo guarda el hash del refresh token.
+func (cfg TokenConfig) newSession(user *domain.User, familyID uuid.UUID, ipAddress, userAgent string) (*domain.Session, *LoginOutput, error) {
+	token, err := security.GenerateToken(user.ID, user.Username, string(user.Role), cfg.Keys, cfg.AccessTTL)
+	if err != nil {
+		return nil, nil, err
+	}
+	refreshToken, err := security.GenerateRefreshToken()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	refreshExpiresAt := now.Add(cfg.RefreshTTL)
+	session := &domain.Session{
+		UserID:           user.ID,
+		Token:            token,
+		IPAddress:        ipAddress,
+		UserAgent:        userAgent,
+		ExpiresAt:        now.Add(cfg.AccessTTL),
+		FamilyID:         familyID,
+		RefreshTokenHash: security.HashRefreshToken(refreshToken),
+		RefreshExpiresAt: &refreshExpiresAt,
+		// Se recalcula en cada renovación: vencer la contraseña limita también las sesiones abiertas
+		PasswordChangeRequired: user.PasswordChangeRequired(cfg.PasswordMaxAge, now),
+		TOTPEnrollmentRequired: cfg.TOTPRequiredRoles[user.Role] && !user.TOTPEnabled,
+	}
+	output := &LoginOutput{
+		Token:                  token,
+		RefreshToken:           refreshToken,
+		User:                   user,
+		ExpiresAt:              session.ExpiresAt,
+		RefreshExpiresAt:       refreshExpiresAt,
+		PasswordChangeRequired: session.PasswordChangeRequired,
+		TOTPEnrollmentRequired: session.TOTPEnrollmentRequired,
+	}
+	return session, output, nil
+}
@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sgl-disasur/api/internal/domain"
+	"github.com/sgl-disasur/api/internal/infrastructure/security"
+)
+
+const (
+	// totpIssuer es el nombre que muestra la app autenticadora
+	totpIssuer        = "SGL-DISASUR"
+	recoveryCodeCount = 10
+)
+
+// TwoFactorUseCase gestiona el enrolamiento del usuario en la verificación en dos pasos (TOTP)
+type TwoFactorUseCase struct {
+	userRepo     domain.UserRepository
+	sessionRepo  domain.SessionRepository
+	auditRepo    domain.AuditRepository
+	recoveryRepo domain.RecoveryCodeRepository
+	tokens       TokenConfig
+	totpKey      string
+}
+
+func NewTwoFactorUseCase(
+	userRepo domain.UserRepository,
+	sessionRepo domain.SessionRepository,
+	auditRepo domain.AuditRepository,
+	recoveryRepo domain.RecoveryCodeRepository,
+	tokens TokenConfig,
+	totpKey string,
+) *TwoFactorUseCase {
+	return &TwoFactorUseCase{
+		userRepo:     userRepo,
+		sessionRepo:  sessionRepo,
+		auditRepo:    auditRepo,
+		recoveryRepo: recoveryRepo,
+		tokens:       tokens,
+		totpKey:      totpKey,
+	}
+}
+
+type TOTPEnrollOutput struct {
+	Secret string `json:"secret"` // Para ingresarlo a mano si no se puede escanear el QR
+	// ProvisioningURI es el contenido del código QR (otpauth://)
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// Enroll genera un secreto nuevo sin activarlo; se activa al confirmar un código válido.
+// Repetirlo antes de confirmar reemplaza el secreto anterior.
+func (uc *TwoFactorUseCase) Enroll(userID uuid.UUID) (*TOTPEnrollOutput, error) {
+	user, err := uc.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, errors.New("la verificación en dos pasos ya está activa")
+	}
+
+	secret, err := security.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := security.EncryptSecret(secret, uc.totpKey)
+	if err != nil {
+		return nil, err
+	}
+	user.TOTPSecret = encrypted
+	user.TOTPLastStep = 0
+	if err := uc.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+
+	return &TOTPEnrollOutput{
+		Secret:          secret,
+		ProvisioningURI: security.TOTPProvisioningURI(totpIssuer, user.Username, secret),
+	}, nil
+}
+
+type TOTPConfirmInput struct {
+	Code      string    `json:"code" binding:"required"`
+	UserID    uuid.UUID `json:"-"`
+	Token     string    `json:"-"` // Token de la sesión desde la que se confirma
+	IPAddress string    `json:"-"`
+	UserAgent string    `json:"-"`
+}
+
+type TOTPConfirmOutput struct {
+	*LoginOutput
+	// RecoveryCodes se muestran una sola vez; solo se guarda su hash
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// Confirm activa la verificación en dos pasos con el primer código de la app y genera los
+// códigos de recuperación. Reemplaza la sesión actual para levantar la restricción de enrolamiento.
+func (uc *TwoFactorUseCase) Confirm(input TOTPConfirmInput) (*TOTPConfirmOutput, error) {
+	user, err := uc.userRepo.FindByID(input.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, errors.New("la verificación en dos pasos ya está activa")
+	}
+	if user.TOTPSecret == "" {
+		return nil, errors.New("primero debe iniciar el enrolamiento")
+	}
+
+	secret, err := security.DecryptSecret(user.TOTPSecret, uc.totpKey)
+	if err != nil {
+		return nil, err
+	}
+	step, ok := security.ValidateTOTP(secret, input.Code, time.Now(), user.TOTPLastStep)
+	if !ok {
+		return nil, domain.ErrInvalidTOTP
+	}
+
+	codes, err := security.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = security.HashRecoveryCode(code)
+	}
+	if err := uc.recoveryRepo.Replace(user.ID, hashes); err != nil {
+		return nil, err
+	}
+
+	user.TOTPEnabled = true
+	user.TOTPLastStep = step
+	if err := uc.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+
+	// La sesión restringida se cambia por una completa
+	if current, err := uc.sessionRepo.FindByToken(input.Token); err == nil {
+		_ = uc.sessionRepo.DeleteByFamily(current.FamilyID)
+	}
+	session, output, err := uc.tokens.newSession(user, uuid.New(), input.IPAddress, input.UserAgent)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.sessionRepo.Create(session); err != nil {
+		return nil, err
+	}
+
+	_ = uc.auditRepo.Log(domain.AuditLog{
+		UserID:     &user.ID,
+		Action:     "ENABLE_2FA",
+		EntityType: "USER",
+		EntityID:   &user.ID,
+		IPAddress:  input.IPAddress,
+		UserAgent:  input.UserAgent,
+	})
+
+	output.IdleExpiresAt = session.IdleDeadline(uc.tokens.IdleTimeout, session.LastActivityAt)
+	return &TOTPConfirmOutput{LoginOutput: output, RecoveryCodes: codes}, nil
+}
@@ -0,0 +1,307 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sgl-disasur/api/internal/domain"
+)
+
+// ListUsersUseCase lista los usuarios con filtros y paginación
+type ListUsersUseCase struct {
+	userRepo domain.UserRepository
+}
+
+func NewListUsersUseCase(userRepo domain.UserRepository) *ListUsersUseCase {
+	return &ListUsersUseCase{userRepo: userRepo}
+}
+
+type ListUsersInput struct {
+	Role   domain.UserRole
+	Status domain.UserStatus
+	Search string // Coincidencia parcial en username o email
+	Limit  int
+	Offset int
+}
+
+type ListUsersOutput struct {
+	Users  []*domain.User `json:"users"`
+	Total  int            `json:"total"`
+	Limit  int            `json:"limit"`
+	Offset int            `json:"offset"`
+}
+
+func (uc *ListUsersUseCase) Execute(input ListUsersInput) (*ListUsersOutput, error) {
+	filters := make(map[string]interface{})
+	if input.Role != "" {
+		if !input.Role.IsValid() {
+			return nil, fmt.Errorf("%w: %s", domain.ErrInvalidRole, input.Role)
+		}
+		filters["role"] = input.Role
+	}
+	if input.Status != "" {
+		if !input.Status.IsValid() {
+			return nil, fmt.Errorf("estado inválido: %s. Use: ACTIVO, BLOQUEADO, INACTIVO", input.Status)
+		}
+		filters["status"] = input.Status
+	}
+	if search := strings.TrimSpace(input.Search); search != "" {
+		filters["search"] = search
+	}
+	if input.Limit <= 0 || input.Limit > 200 {
+		input.Limit = 50
+	}
+	if input.Offset < 0 {
+		input.Offset = 0
+	}
+
+	users, err := uc.userRepo.List(filters, input.Limit, input.Offset)
+	if err != nil {
+		return nil, err
+	}
+	total, err := uc.userRepo.Count(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListUsersOutput{Users: users, Total: total, Limit: input.Limit, Offset: input.Offset}, nil
+}
+
+// ManageUserUseCase agrupa las operaciones de administración sobre un usuario existente.
+// Los cambios que afectan el acceso (rol, baja, contraseña) cierran las sesiones del usuario.
+type ManageUserUseCase struct {
+	userRepo     domain.UserRepository
+	sessionRepo  domain.SessionRepository
+	auditRepo    domain.AuditRepository
+	recoveryRepo domain.RecoveryCodeRepository
+	passwords    *PasswordManager
+}
+
+func NewManageUserUseCase(
+	userRepo domain.UserRepository,
+	sessionRepo domain.SessionRepository,
+	auditRepo domain.AuditRepository,
+	recoveryRepo domain.RecoveryCodeRepository,
+	passwords *PasswordManager,
+) *ManageUserUseCase {
+	return &ManageUserUseCase{
+		userRepo:     userRepo,
+		sessionRepo:  sessionRepo,
+		auditRepo:    auditRepo,
+		recoveryRepo: recoveryRepo,
+		passwords:    passwords,
+	}
+}
+
+// UserActionInput identifica al usuario afectado y al administrador que actúa
+type UserActionInput struct {
+	TargetUserID uuid.UUID `json:"-"`
+	UserID       uuid.UUID `json:"-"`
+	IPAddress    string    `json:"-"`
+}
+
+type UpdateUserInput struct {
+	Email *string          `json:"email,omitempty"`
+	Role  *domain.UserRole `json:"role,omitempty"`
+	UserActionInput
+}
+
+// Update cambia el email y/o el rol del usuario
+func (uc *ManageUserUseCase) Update(input UpdateUserInput) (*domain.User, error) {
+	user, err := uc.userRepo.FindByID(input.TargetUserID)
+	if err != nil {
+		return nil, err
+	}
+	oldValues := map[string]interface{}{"email": user.Email, "role": user.Role}
+
+	if input.Email != nil {
+		email := strings.TrimSpace(*input.Email)
+		if email == "" {
+			return nil, errors.New("email no puede estar vacío")
+		}
+		if existing, _ := uc.userRepo.FindByEmail(email); existing != nil && existing.ID != user.ID {
+			return nil, domain.ErrEmailExists
+		}
+		user.Email = email
+	}
+
+	roleChanged := false
+	if input.Role != nil && *input.Role != user.Role {
+		if !input.Role.IsValid() {
+			return nil, fmt.Errorf("%w: %s", domain.ErrInvalidRole, *input.Role)
+		}
+		if user.Role == domain.RoleAdminTI {
+			if err := uc.ensureOtherAdmin(user); err != nil {
+				return nil, err
+			}
+		}
+		user.Role = *input.Role
+		roleChanged = true
+	}
+
+	if err := uc.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+	// El rol viaja en el token: obligar a iniciar sesión de nuevo
+	if roleChanged {
+		_ = uc.sessionRepo.DeleteByUserID(user.ID)
+	}
+
+	uc.audit(input.UserActionInput, "UPDATE_USER", oldValues, map[string]interface{}{"email": user.Email, "role": user.Role})
+	return user, nil
+}
+
+// Deactivate da de baja al usuario (no puede iniciar sesión) y cierra sus sesiones
+func (uc *ManageUserUseCase) Deactivate(input UserActionInput) (*domain.User, error) {
+	if input.TargetUserID == input.UserID {
+		return nil, fmt.Errorf("%w: no puede desactivar su propio usuario", domain.ErrForbidden)
+	}
+	user, err := uc.userRepo.FindByID(input.TargetUserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Status == domain.UserStatusInactivo {
+		return nil, errors.New("el usuario ya está inactivo")
+	}
+	if user.Role == domain.RoleAdminTI {
+		if err := uc.ensureOtherAdmin(user); err != nil {
+			return nil, err
+		}
+	}
+
+	oldStatus := user.Status
+	user.Status = domain.UserStatusInactivo
+	if err := uc.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+	_ = uc.sessionRepo.DeleteByUserID(user.ID)
+
+	uc.audit(input, "DEACTIVATE_USER", map[string]interface{}{"status": oldStatus}, map[string]interface{}{"status": user.Status})
+	return user, nil
+}
+
+// Reactivate vuelve a habilitar un usuario inactivo
+func (uc *ManageUserUseCase) Reactivate(input UserActionInput) (*domain.User, error) {
+	user, err := uc.userRepo.FindByID(input.TargetUserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Status != domain.UserStatusInactivo {
+		return nil, fmt.Errorf("el usuario no está inactivo (%s)", user.Status)
+	}
+
+	user.ResetFailedAttempts()
+	user.Status = domain.UserStatusActivo
+	if err := uc.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+
+	uc.audit(input, "REACTIVATE_USER", map[string]interface{}{"status": domain.UserStatusInactivo}, map[string]interface{}{"status": user.Status})
+	return user, nil
+}
+
+// Unlock desbloquea una cuenta bloqueada por intentos fallidos (HU-00)
+func (uc *ManageUserUseCase) Unlock(input UserActionInput) (*domain.User, error) {
+	user, err := uc.userRepo.FindByID(input.TargetUserID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsLocked(time.Now()) {
+		return nil, errors.New("la cuenta no está bloqueada")
+	}
+
+	oldValues := map[string]interface{}{"locked_until": user.LockedUntil, "lockout_count": user.LockoutCount}
+	user.ResetFailedAttempts()
+	if err := uc.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+
+	uc.audit(input, "UNLOCK_USER", oldValues, map[string]interface{}{"status": user.Status})
+	return user, nil
+}
+
+type ResetPasswordInput struct {
+	NewPassword string `json:"new_password" binding:"required"`
+	UserActionInput
+}
+
+// ResetPassword asigna una contraseña temporal, desbloquea la cuenta y cierra las sesiones abiertas.
+// El usuario debe cambiarla en su siguiente inicio de sesión.
+func (uc *ManageUserUseCase) ResetPassword(input ResetPasswordInput) (*domain.User, error) {
+	user, err := uc.userRepo.FindByID(input.TargetUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.passwords.Apply(user, input.NewPassword, true); err != nil {
+		return nil, err
+	}
+	user.ResetFailedAttempts()
+	if err := uc.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+	_ = uc.passwords.Remember(user)
+	_ = uc.sessionRepo.DeleteByUserID(user.ID)
+
+	// La contraseña nunca se registra en auditoría
+	uc.audit(input.UserActionInput, "RESET_PASSWORD", nil, nil)
+	return user, nil
+}
+
+// ResetTOTP desactiva la verificación en dos pasos (teléfono extraviado) y cierra las sesiones.
+// Si el rol la exige, el usuario debe volver a enrolarse en su siguiente inicio de sesión.
+func (uc *ManageUserUseCase) ResetTOTP(input UserActionInput) (*domain.User, error) {
+	user, err := uc.userRepo.FindByID(input.TargetUserID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled && user.TOTPSecret == "" {
+		return nil, errors.New("el usuario no tiene verificación en dos pasos")
+	}
+
+	wasEnabled := user.TOTPEnabled
+	user.TOTPSecret = ""
+	user.TOTPEnabled = false
+	user.TOTPLastStep = 0
+	if err := uc.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+	_ = uc.recoveryRepo.DeleteByUser(user.ID)
+	_ = uc.sessionRepo.DeleteByUserID(user.ID)
+
+	uc.audit(input, "RESET_2FA", map[string]interface{}{"totp_enabled": wasEnabled}, map[string]interface{}{"totp_enabled": false})
+	return user, nil
+}
+
+// ensureOtherAdmin impide dejar el sistema sin un ADMIN_TI activo
+func (uc *ManageUserUseCase) ensureOtherAdmin(user *domain.User) error {
+	admins, err := uc.userRepo.Count(map[string]interface{}{
+		"role":   domain.RoleAdminTI,
+		"status": domain.UserStatusActivo,
+	})
+	if err != nil {
+		return err
+	}
+	if user.Status == domain.UserStatusActivo {
+		admins--
+	}
+	if admins < 1 {
+		return domain.ErrLastAdmin
+	}
+	return nil
+}
+
+func (uc *ManageUserUseCase) audit(input UserActionInput, action string, oldValues, newValues map[string]interface{}) {
+	_ = uc.auditRepo.Log(domain.AuditLog{
+		UserID:     &input.UserID,
+		Action:     action,
+		EntityType: "USER",
+		EntityID:   &input.TargetUserID,
+		OldValues:  oldValues,
+		NewValues:  newValues,
+		IPAddress:  input.IPAddress,
+	})
+}
@@ -11,26 +11,32 @@ import (
 
 // AssignRouteUseCase implementa HU-10: Asignación inteligente de rutas
 type AssignRouteUseCase struct {
-	routeRepo   domain.RouteRepository
-	vehicleRepo domain.VehicleRepository
-	driverRepo  domain.DriverRepository
-	orderRepo   domain.OrderRepository
-	auditRepo   domain.AuditRepository
+	routeRepo    domain.RouteRepository
+	vehicleRepo  domain.VehicleRepository
+	orderRepo    domain.OrderRepository
+	customerRepo domain.CustomerRepository
+	scheduler    *DriverScheduler
+	documents    *DocumentValidator
+	auditRepo    domain.AuditRepository
 }
 
 func NewAssignRouteUseCase(
 	routeRepo domain.RouteRepository,
 	vehicleRepo domain.VehicleRepository,
-	driverRepo domain.DriverRepository,
 	orderRepo domain.OrderRepository,
+	customerRepo domain.CustomerRepository,
+	scheduler *DriverScheduler,
+	documents *DocumentValidator,
 	auditRepo domain.AuditRepository,
 ) *AssignRouteUseCase {
 	return &AssignRouteUseCase{
-		routeRepo:   routeRepo,
-		vehicleRepo: vehicleRepo,
-		driverRepo:  driverRepo,
-		orderRepo:   orderRepo,
-		auditRepo:   auditRepo,
+		routeRepo:    routeRepo,
+		vehicleRepo:  vehicleRepo,
+		orderRepo:    orderRepo,
+		customerRepo: customerRepo,
+		scheduler:    scheduler,
+		documents:    documents,
+		auditRepo:    auditRepo,
 	}
 }
 
@@ -45,10 +51,11 @@ type AssignRouteInput struct {
 }
 
 type AssignRouteOutput struct {
-	Route           *domain.Route   `json:"route"`
-	AssignedVehicle *domain.Vehicle `json:"assigned_vehicle"`
-	AssignedDriver  *domain.Driver  `json:"assigned_driver"`
-	AutoAssigned    bool            `json:"auto_assigned"`
+	Route           *domain.Route          `json:"route"`
+	AssignedVehicle *domain.Vehicle        `json:"assigned_vehicle"`
+	AssignedDriver  *domain.Driver         `json:"assigned_driver"`
+	AutoAssigned    bool                   `json:"auto_assigned"`
+	Utilization     domain.LoadUtilization `json:"utilization"` // HU-18: uso de capacidad por peso y volumen
 }
 
 func (uc *AssignRouteUseCase) Execute(input AssignRouteInput) (*AssignRouteOutput, error) {
@@ -57,29 +64,40 @@ func (uc *AssignRouteUseCase) Execute(input AssignRouteInput) (*AssignRouteOutpu
 	if err != nil {
 		return nil, errors.New("pedido no encontrado")
 	}
+	if !order.IsReadyForRoute() {
+		return nil, fmt.Errorf("%w: el pedido %s está %s; solo se asignan pedidos CONFIRMADO o EN_PREPARACION",
+			domain.ErrInvalidOrderStatus, order.OrderNumber, order.Status)
+	}
 
 	autoAssigned := false
 
+	// Mantenimiento y documentos deben estar vigentes hasta el regreso
+	routeEnd := RouteWindow{
+		RouteType: input.RouteType,
+		Departure: input.DepartureDate,
+		Arrival:   input.EstimatedArrival,
+	}.end()
+
 	// 2. HU-10: Asignación inteligente de vehículo si no se especificó
 	var vehicleID uuid.UUID
 	if input.VehicleID == nil {
-		// Buscar vehículo disponible que cumpla con el tipo sugerido
+		// Vehículo disponible más chico donde cabe el pedido por peso y volumen
 		availableVehicles, _ := uc.vehicleRepo.ListAvailable(nil)
-
-		var selectedVehicle *domain.Vehicle
-		for _, v := range availableVehicles {
-			if order.SuggestedVehicle != nil && v.VehicleType == *order.SuggestedVehicle {
-				selectedVehicle = v
-				break
-			}
+		if len(availableVehicles) == 0 {
+			return nil, domain.ErrVehicleNotAvailable
 		}
-
-		if selectedVehicle == nil && len(availableVehicles) > 0 {
-			selectedVehicle = availableVehicles[0] // Tomar el primero disponible
+		availableVehicles, err = readyVehicles(availableVehicles, uc.documents, routeEnd)
+		if err != nil {
+			return nil, err
+		}
+		if len(availableVehicles) == 0 {
+			return nil, fmt.Errorf("%w: todos los vehículos disponibles tienen mantenimiento o documentos vencidos", domain.ErrVehicleNotAvailable)
 		}
 
+		selectedVehicle := order.SuggestVehicleFrom(availableVehicles)
 		if selectedVehicle == nil {
-			return nil, domain.ErrVehicleNotAvailable
+			return nil, fmt.Errorf("%w: ningún vehículo disponible soporta %.1f kg / %.2f m³",
+				domain.ErrVehicleCapacity, order.TotalWeightKg, order.TotalVolumeM3)
 		}
 
 		vehicleID = selectedVehicle.ID
@@ -97,32 +115,41 @@ func (uc *AssignRouteUseCase) Execute(input AssignRouteInput) (*AssignRouteOutpu
 	if !vehicle.IsAvailableForRoute() {
 		return nil, domain.ErrVehicleNotAvailable
 	}
+	if err := checkVehicleReady(vehicle, uc.documents, routeEnd); err != nil {
+		return nil, err
+	}
 
-	// 3. Asignación inteligente de chofer si no se especificó
-	var driverID uuid.UUID
-	if input.DriverID == nil {
-		availableDrivers, _ := uc.driverRepo.ListAvailable()
-		if len(availableDrivers) == 0 {
-			return nil, domain.ErrDriverNotAvailable
-		}
-		driverID = availableDrivers[0].ID
-		autoAssigned = true
-	} else {
-		driverID = *input.DriverID
+	capacity := vehicle.Capacity()
+	if !capacity.Fits(order.TotalWeightKg, order.TotalVolumeM3) {
+		return nil, domain.ErrVehicleCapacity
 	}
 
-	driver, err := uc.driverRepo.FindByID(driverID)
+	// 3. Asignación de chofer por disponibilidad, licencia y horas de manejo restantes
+	driver, err := uc.scheduler.Select(input.DriverID, RouteWindow{
+		RouteType: input.RouteType,
+		Departure: input.DepartureDate,
+		Arrival:   input.EstimatedArrival,
+	})
 	if err != nil {
-		return nil, errors.New("chofer no encontrado")
+		return nil, err
 	}
-
-	if !driver.IsAvailableForRoute() {
-		return nil, domain.ErrDriverNotAvailable
+	if input.DriverID == nil {
+		autoAssigned = true
 	}
+	driverID := driver.ID
 
 	// 4. Crear la ruta
 	routeNumber := fmt.Sprintf("RTA-%s-%d", time.Now().Format("20060102"), time.Now().Unix()%10000)
 
+	// Parada única con la dirección del cliente
+	customer, err := uc.customerRepo.FindByID(order.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+	stop := newStop(order, customer)
+	stop.Sequence = 1
+	stop.EstimatedArrival = &input.EstimatedArrival
+
 	route := &domain.Route{
 		RouteNumber:      routeNumber,
 		OrderID:          input.OrderID,
@@ -135,19 +162,13 @@ func (uc *AssignRouteUseCase) Execute(input AssignRouteInput) (*AssignRouteOutpu
 		AssignedBy:       input.UserID,
 	}
 
-	if err := uc.routeRepo.Create(route); err != nil {
+	// 5. Pedido, vehículo y chofer se revalidan bloqueados y cambian de estado en la misma transacción
+	if err := uc.routeRepo.CreateAssigned(route, []*domain.RouteStop{stop}); err != nil {
 		return nil, err
 	}
-
-	// 5. Actualizar estados
 	vehicle.Status = domain.VehicleEnRuta
-	_ = uc.vehicleRepo.Update(vehicle)
-
 	driver.Status = domain.DriverEnRuta
-	_ = uc.driverRepo.Update(driver)
-
-	order.Status = domain.OrderEnRuta
-	_ = uc.orderRepo.Update(order)
+	order.Status = domain.OrderListo // EN_RUTA al iniciar la ruta
 
 	// 6. Auditar
 	_ = uc.auditRepo.Log(domain.AuditLog{
@@ -168,6 +189,7 @@ func (uc *AssignRouteUseCase) Execute(input AssignRouteInput) (*AssignRouteOutpu
 		AssignedVehicle: vehicle,
 		AssignedDriver:  driver,
 		AutoAssigned:    autoAssigned,
+		Utilization:     capacity.Utilization(order.TotalWeightKg, order.TotalVolumeM3),
 	}, nil
 }
 
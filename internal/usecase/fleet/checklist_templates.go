@@ -0,0 +1,100 @@
+package fleet
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sgl-disasur/api/internal/domain"
+)
+
+// CreateChecklistTemplateUseCase publica una nueva versión del check-list de un tipo de vehículo.
+// Los check-list ya registrados conservan la versión con la que se hicieron.
+type CreateChecklistTemplateUseCase struct {
+	templateRepo domain.ChecklistTemplateRepository
+	auditRepo    domain.AuditRepository
+}
+
+func NewCreateChecklistTemplateUseCase(templateRepo domain.ChecklistTemplateRepository, auditRepo domain.AuditRepository) *CreateChecklistTemplateUseCase {
+	return &CreateChecklistTemplateUseCase{
+		templateRepo: templateRepo,
+		auditRepo:    auditRepo,
+	}
+}
+
+type CreateChecklistTemplateInput struct {
+	VehicleType domain.VehicleType   `json:"vehicle_type"`
+	Name        string               `json:"name,omitempty"`
+	Items       []ChecklistItemInput `json:"items"` // En el orden en que se revisan
+	UserID      uuid.UUID            `json:"-"`
+}
+
+// ChecklistItemInput es un punto de la plantilla
+type ChecklistItemInput struct {
+	Code       string                     `json:"code"` // Ej. LUCES, LLANTAS, COMBUSTIBLE
+	Label      string                     `json:"label"`
+	AnswerType domain.ChecklistAnswerType `json:"answer_type"` // BOOLEANO, ESCALA, FOTO, NUMERO
+	Required   bool                       `json:"required"`
+	Blocking   bool                       `json:"blocking"`
+	MinValue   *float64                   `json:"min_value,omitempty"`
+	MaxValue   *float64                   `json:"max_value,omitempty"`
+}
+
+func (uc *CreateChecklistTemplateUseCase) Execute(input CreateChecklistTemplateInput) (*domain.ChecklistTemplate, error) {
+	if domain.ReferenceCapacity(input.VehicleType).WeightKg == 0 {
+		return nil, fmt.Errorf("tipo de vehículo inválido: %s", input.VehicleType)
+	}
+	if len(input.Items) == 0 {
+		return nil, errors.New("la plantilla requiere al menos un punto")
+	}
+
+	template := &domain.ChecklistTemplate{
+		VehicleType: input.VehicleType,
+		Name:        strings.TrimSpace(input.Name),
+		CreatedBy:   &input.UserID,
+	}
+	if template.Name == "" {
+		template.Name = fmt.Sprintf("Check-list %s", input.VehicleType)
+	}
+
+	codes := make(map[string]bool, len(input.Items))
+	for i, itemInput := range input.Items {
+		item := &domain.ChecklistItem{
+			Sequence:   i + 1,
+			Code:       strings.ToUpper(strings.TrimSpace(itemInput.Code)),
+			Label:      strings.TrimSpace(itemInput.Label),
+			AnswerType: itemInput.AnswerType,
+			Required:   itemInput.Required,
+			Blocking:   itemInput.Blocking,
+			MinValue:   itemInput.MinValue,
+			MaxValue:   itemInput.MaxValue,
+		}
+		if err := item.Validate(); err != nil {
+			return nil, err
+		}
+		if codes[item.Code] {
+			return nil, fmt.Errorf("código de punto duplicado: %s", item.Code)
+		}
+		codes[item.Code] = true
+		template.Items = append(template.Items, item)
+	}
+
+	if err := uc.templateRepo.Create(template); err != nil {
+		return nil, err
+	}
+
+	_ = uc.auditRepo.Log(domain.AuditLog{
+		UserID:     &input.UserID,
+		Action:     "CREATE_CHECKLIST_TEMPLATE",
+		EntityType: "CHECKLIST_TEMPLATE",
+		EntityID:   &template.ID,
+		NewValues: map[string]interface{}{
+			"vehicle_type": template.VehicleType,
+			"version":      template.Version,
+			"items":        len(template.Items),
+		},
+	})
+
+	return template, nil
+}
@@ -0,0 +1,393 @@
+package fleet
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sgl-disasur/api/internal/domain"
+	"github.com/sgl-disasur/api/internal/infrastructure/routing"
+)
+
+// ConsolidateRouteUseCase agrupa varios pedidos listos para ruta en una sola ruta con paradas secuenciadas
+type ConsolidateRouteUseCase struct {
+	routeRepo    domain.RouteRepository
+	vehicleRepo  domain.VehicleRepository
+	orderRepo    domain.OrderRepository
+	customerRepo domain.CustomerRepository
+	planner      *RoutePlanner
+	scheduler    *DriverScheduler
+	documents    *DocumentValidator
+	auditRepo    domain.AuditRepository
+}
+
+func NewConsolidateRouteUseCase(
+	routeRepo domain.RouteRepository,
+	vehicleRepo domain.VehicleRepository,
+	orderRepo domain.OrderRepository,
+	customerRepo domain.CustomerRepository,
+	planner *RoutePlanner,
+	scheduler *DriverScheduler,
+	documents *DocumentValidator,
+	auditRepo domain.AuditRepository,
+) *ConsolidateRouteUseCase {
+	return &ConsolidateRouteUseCase{
+		routeRepo:    routeRepo,
+		vehicleRepo:  vehicleRepo,
+		orderRepo:    orderRepo,
+		customerRepo: customerRepo,
+		planner:      planner,
+		scheduler:    scheduler,
+		documents:    documents,
+		auditRepo:    auditRepo,
+	}
+}
+
+type ConsolidateRouteInput struct {
+	OrderIDs      []uuid.UUID      `json:"order_ids,omitempty"`  // Vacío = consolidar automáticamente pedidos CONFIRMADO o EN_PREPARACION
+	VehicleID     *uuid.UUID       `json:"vehicle_id,omitempty"` // Opcional
+	DriverID      *uuid.UUID       `json:"driver_id,omitempty"`  // Opcional
+	RouteType     domain.RouteType `json:"route_type"`
+	DepartureDate time.Time        `json:"departure_date"`
+	UserID        uuid.UUID        `json:"-"`
+}
+
+type ConsolidateRouteOutput struct {
+	Route           *domain.Route          `json:"route"`
+	Stops           []*domain.RouteStop    `json:"stops"`
+	AssignedVehicle *domain.Vehicle        `json:"assigned_vehicle"`
+	AssignedDriver  *domain.Driver         `json:"assigned_driver"`
+	TotalWeightKg   float64                `json:"total_weight_kg"`
+	TotalVolumeM3   float64                `json:"total_volume_m3"`
+	TotalDistanceKm float64                `json:"total_distance_km"`
+	Utilization     domain.LoadUtilization `json:"utilization"`
+	LoadingAlert    string                 `json:"loading_alert,omitempty"`
+	PendingOrders   []uuid.UUID            `json:"pending_orders,omitempty"` // Listos para ruta que no cupieron
+	Warnings        []string               `json:"warnings"`
+}
+
+// candidate es un pedido confirmado con su cliente
+type candidate struct {
+	order    *domain.Order
+	customer *domain.Customer
+}
+
+func (uc *ConsolidateRouteUseCase) Execute(input ConsolidateRouteInput) (*ConsolidateRouteOutput, error) {
+	output := &ConsolidateRouteOutput{Warnings: []string{}}
+
+	// 1. Pedidos candidatos
+	candidates, err := uc.loadCandidates(input.OrderIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, errors.New("no hay pedidos CONFIRMADO o EN_PREPARACION para consolidar")
+	}
+
+	// 2. Vehículo: el indicado o la flota disponible. De la flota se descarta lo que ya no está vigente
+	// a la salida; la vigencia hasta el regreso se valida cuando se conoce la hora de llegada.
+	var vehicle *domain.Vehicle
+	var available []*domain.Vehicle
+	if input.VehicleID != nil {
+		vehicle, err = uc.vehicleRepo.FindByID(*input.VehicleID)
+		if err != nil {
+			return nil, errors.New("vehículo no encontrado")
+		}
+		if !vehicle.IsAvailableForRoute() {
+			return nil, domain.ErrVehicleNotAvailable
+		}
+	} else {
+		available, _ = uc.vehicleRepo.ListAvailable(nil)
+		if len(available) == 0 {
+			return nil, domain.ErrVehicleNotAvailable
+		}
+		if available, err = readyVehicles(available, uc.documents, input.DepartureDate); err != nil {
+			return nil, err
+		}
+		if len(available) == 0 {
+			return nil, fmt.Errorf("%w: todos los vehículos disponibles tienen mantenimiento o documentos vencidos", domain.ErrVehicleNotAvailable)
+		}
+	}
+
+	// 3. Selección de pedidos dentro de capacidad
+	var selected []candidate
+	if len(input.OrderIDs) > 0 {
+		selected = candidates
+	} else {
+		capacity := largestCapacity(available)
+		if vehicle != nil {
+			capacity = vehicle.Capacity()
+		}
+		var pending []candidate
+		selected, pending = uc.fillByProximity(candidates, capacity)
+		for _, c := range pending {
+			output.PendingOrders = append(output.PendingOrders, c.order.ID)
+		}
+		if len(selected) == 0 {
+			return nil, fmt.Errorf("%w: ningún pedido confirmado cabe en el vehículo", domain.ErrVehicleCapacity)
+		}
+	}
+
+	combined := &domain.Order{}
+	for _, c := range selected {
+		combined.TotalWeightKg += c.order.TotalWeightKg
+		combined.TotalVolumeM3 += c.order.TotalVolumeM3
+		combined.HasFragileItems = combined.HasFragileItems || c.order.HasFragileItems
+		combined.HasHeavyItems = combined.HasHeavyItems || c.order.HasHeavyItems
+	}
+
+	// 4. Secuenciar paradas y calcular ETA
+	var stops []*domain.RouteStop
+	for _, c := range selected {
+		stops = append(stops, newStop(c.order, c.customer))
+		if _, ok := customerPoint(c.customer); !ok {
+			output.Warnings = append(output.Warnings,
+				fmt.Sprintf("cliente %s sin coordenadas: la parada va al final sin hora estimada", c.customer.Name))
+		}
+	}
+	if uc.planner.Depot() == nil {
+		output.Warnings = append(output.Warnings, "origen (DEPOT_LATITUDE/DEPOT_LONGITUDE) no configurado: la ruta inicia en la primera parada")
+	}
+	stops, totalKm := uc.planner.Plan(stops, input.DepartureDate)
+
+	estimatedArrival := input.DepartureDate
+	for _, stop := range stops {
+		if stop.EstimatedArrival != nil && stop.EstimatedArrival.After(estimatedArrival) {
+			estimatedArrival = *stop.EstimatedArrival
+		}
+	}
+
+	window := RouteWindow{
+		RouteType: input.RouteType,
+		Departure: input.DepartureDate,
+		Arrival:   estimatedArrival,
+	}
+
+	// 5. Mantenimiento y documentos del vehículo deben estar vigentes hasta el regreso
+	routeEnd := window.end()
+	if vehicle != nil {
+		if err := checkVehicleReady(vehicle, uc.documents, routeEnd); err != nil {
+			return nil, err
+		}
+	} else {
+		if available, err = readyVehicles(available, uc.documents, routeEnd); err != nil {
+			return nil, err
+		}
+		// Vehículo disponible más chico donde cabe la carga consolidada
+		vehicle = combined.SuggestVehicleFrom(available)
+		if vehicle == nil {
+			return nil, fmt.Errorf("%w: ningún vehículo disponible y vigente hasta el regreso soporta %.1f kg / %.2f m³",
+				domain.ErrVehicleCapacity, combined.TotalWeightKg, combined.TotalVolumeM3)
+		}
+	}
+	capacity := vehicle.Capacity()
+	if !capacity.Fits(combined.TotalWeightKg, combined.TotalVolumeM3) {
+		return nil, domain.ErrVehicleCapacity
+	}
+
+	// 6. Chofer con horas suficientes para el recorrido completo
+	driver, err := uc.scheduler.Select(input.DriverID, window)
+	if err != nil {
+		return nil, err
+	}
+
+	// 7. Crear ruta y paradas y actualizar estados
+	now := time.Now()
+	route := &domain.Route{
+		RouteNumber:      fmt.Sprintf("RTA-%s-%d", now.Format("20060102"), now.Unix()%10000),
+		OrderID:          stops[0].OrderID, // Primera parada; el detalle completo está en route_stops
+		VehicleID:        vehicle.ID,
+		DriverID:         driver.ID,
+		RouteType:        input.RouteType,
+		DepartureDate:    &input.DepartureDate,
+		EstimatedArrival: &estimatedArrival,
+		Status:           domain.OrderConfirmado,
+		AssignedBy:       input.UserID,
+	}
+	// Pedidos, vehículo y chofer se revalidan bloqueados y cambian de estado en la misma transacción
+	if err := uc.routeRepo.CreateAssigned(route, stops); err != nil {
+		return nil, err
+	}
+	vehicle.Status = domain.VehicleEnRuta
+	driver.Status = domain.DriverEnRuta
+
+	orderIDs := make([]uuid.UUID, 0, len(selected))
+	for _, c := range selected {
+		c.order.Status = domain.OrderListo // EN_RUTA al iniciar la ruta
+		orderIDs = append(orderIDs, c.order.ID)
+	}
+
+	// 8. Auditar
+	_ = uc.auditRepo.Log(domain.AuditLog{
+		UserID:     &input.UserID,
+		Action:     "CONSOLIDATE_ROUTE",
+		EntityType: "ROUTE",
+		EntityID:   &route.ID,
+		NewValues: map[string]interface{}{
+			"route_number":      route.RouteNumber,
+			"vehicle_id":        vehicle.ID,
+			"driver_id":         driver.ID,
+			"order_ids":         orderIDs,
+			"total_distance_km": totalKm,
+		},
+	})
+
+	output.Route = route
+	output.Stops = stops
+	output.AssignedVehicle = vehicle
+	output.AssignedDriver = driver
+	output.TotalWeightKg = combined.TotalWeightKg
+	output.TotalVolumeM3 = combined.TotalVolumeM3
+	output.TotalDistanceKm = totalKm
+	output.Utilization = capacity.Utilization(combined.TotalWeightKg, combined.TotalVolumeM3)
+	output.LoadingAlert = combined.GenerateLoadingAlert()
+	return output, nil
+}
+
+// orderPageSize es el tamaño de página al recorrer los pedidos por rutear
+const orderPageSize = 500
+
+// listReadyOrders recorre página por página todos los pedidos CONFIRMADO o EN_PREPARACION;
+// un pedido que se repite entre páginas (por altas concurrentes) se toma una sola vez
+func listReadyOrders(repo domain.OrderRepository) ([]*domain.Order, error) {
+	filters := map[string]interface{}{"statuses": domain.RouteReadyStatuses}
+	seen := make(map[uuid.UUID]bool)
+	var orders []*domain.Order
+	for offset := 0; ; offset += orderPageSize {
+		page, err := repo.List(filters, domain.FullScope(), orderPageSize, offset)
+		if err != nil {
+			return nil, err
+		}
+		for _, order := range page {
+			if !seen[order.ID] {
+				seen[order.ID] = true
+				orders = append(orders, order)
+			}
+		}
+		if len(page) < orderPageSize {
+			return orders, nil
+		}
+	}
+}
+
+// loadCandidates obtiene los pedidos indicados o todos los listos para ruta (CONFIRMADO o EN_PREPARACION)
+func (uc *ConsolidateRouteUseCase) loadCandidates(orderIDs []uuid.UUID) ([]candidate, error) {
+	var orders []*domain.Order
+	if len(orderIDs) > 0 {
+		seen := make(map[uuid.UUID]bool)
+		for _, id := range orderIDs {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			order, err := uc.orderRepo.FindByID(id)
+			if err != nil {
+				return nil, fmt.Errorf("pedido %s no encontrado", id)
+			}
+			if !order.IsReadyForRoute() {
+				return nil, fmt.Errorf("%w: el pedido %s está %s; solo se consolidan pedidos CONFIRMADO o EN_PREPARACION",
+					domain.ErrInvalidOrderStatus, order.OrderNumber, order.Status)
+			}
+			orders = append(orders, order)
+		}
+	} else {
+		ready, err := listReadyOrders(uc.orderRepo)
+		if err != nil {
+			return nil, err
+		}
+		orders = ready
+	}
+
+	customers := make(map[uuid.UUID]*domain.Customer)
+	candidates := make([]candidate, 0, len(orders))
+	for _, order := range orders {
+		customer, ok := customers[order.CustomerID]
+		if !ok {
+			found, err := uc.customerRepo.FindByID(order.CustomerID)
+			if err != nil {
+				return nil, fmt.Errorf("cliente del pedido %s no encontrado", order.OrderNumber)
+			}
+			customer = found
+			customers[order.CustomerID] = customer
+		}
+		candidates = append(candidates, candidate{order: order, customer: customer})
+	}
+	return candidates, nil
+}
+
+// fillByProximity llena el vehículo tomando el pedido más cercano a la última parada mientras quepa.
+// Los pedidos sin coordenadas se agregan al final por antigüedad.
+func (uc *ConsolidateRouteUseCase) fillByProximity(candidates []candidate, capacity domain.VehicleCapacity) ([]candidate, []candidate) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].order.CreatedAt.Before(candidates[j].order.CreatedAt)
+	})
+
+	taken := make([]bool, len(candidates))
+	var selected []candidate
+	var weight, volume float64
+	fits := func(c candidate) bool {
+		return capacity.Fits(weight+c.order.TotalWeightKg, volume+c.order.TotalVolumeM3)
+	}
+	take := func(i int) {
+		taken[i] = true
+		weight += candidates[i].order.TotalWeightKg
+		volume += candidates[i].order.TotalVolumeM3
+		selected = append(selected, candidates[i])
+	}
+
+	current := uc.planner.Depot()
+	for {
+		next, nextDistance := -1, math.MaxFloat64
+		for i, c := range candidates {
+			point, ok := customerPoint(c.customer)
+			if taken[i] || !ok || !fits(c) {
+				continue
+			}
+			// Sin origen, la ruta arranca con el pedido más antiguo
+			distance := 0.0
+			if current != nil {
+				distance = routing.DistanceKm(*current, point)
+			}
+			if distance < nextDistance {
+				next, nextDistance = i, distance
+			}
+		}
+		if next < 0 {
+			break
+		}
+		take(next)
+		point, _ := customerPoint(candidates[next].customer)
+		current = &point
+	}
+
+	for i, c := range candidates {
+		if !taken[i] {
+			if _, ok := customerPoint(c.customer); !ok && fits(c) {
+				take(i)
+			}
+		}
+	}
+
+	var pending []candidate
+	for i, c := range candidates {
+		if !taken[i] {
+			pending = append(pending, c)
+		}
+	}
+	return selected, pending
+}
+
+func largestCapacity(vehicles []*domain.Vehicle) domain.VehicleCapacity {
+	var largest domain.VehicleCapacity
+	for _, v := range vehicles {
+		c := v.Capacity()
+		if c.VolumeM3 > largest.VolumeM3 || (c.VolumeM3 == largest.VolumeM3 && c.WeightKg > largest.WeightKg) {
+			largest = c
+		}
+	}
+	return largest
+}
@@ -0,0 +1,253 @@
+package fleet
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sgl-disasur/api/internal/domain"
+)
+
+// DocumentValidator verifica que los documentos obligatorios de choferes y vehículos sigan vigentes.
+// Un documento no registrado no bloquea la asignación; uno vencido sí.
+type DocumentValidator struct {
+	documentRepo domain.FleetDocumentRepository
+}
+
+func NewDocumentValidator(documentRepo domain.FleetDocumentRepository) *DocumentValidator {
+	return &DocumentValidator{documentRepo: documentRepo}
+}
+
+// Expired retorna los documentos obligatorios que estarán vencidos en la fecha indicada
+func (v *DocumentValidator) Expired(owner domain.DocumentOwner, ownerID uuid.UUID, at time.Time) ([]*domain.FleetDocument, error) {
+	documents, err := v.documentRepo.FindCurrent(owner, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	required := make(map[domain.DocumentType]bool)
+	for _, documentType := range domain.RequiredDocuments[owner] {
+		required[documentType] = true
+	}
+
+	var expired []*domain.FleetDocument
+	for _, document := range documents {
+		if required[document.DocumentType] && document.IsExpired(at) {
+			expired = append(expired, document)
+		}
+	}
+	return expired, nil
+}
+
+// CheckVehicle retorna ErrDocumentExpired con el detalle si algún documento del vehículo vence antes de at
+func (v *DocumentValidator) CheckVehicle(vehicle *domain.Vehicle, at time.Time) error {
+	expired, err := v.Expired(domain.OwnerVehicle, vehicle.ID, at)
+	if err != nil {
+		return err
+	}
+	if len(expired) > 0 {
+		return fmt.Errorf("%w: vehículo %s %s", domain.ErrDocumentExpired, vehicle.PlateNumber, describeExpired(expired))
+	}
+	return nil
+}
+
+// FilterVehicles descarta los vehículos con documentos vencidos en la fecha indicada
+func (v *DocumentValidator) FilterVehicles(vehicles []*domain.Vehicle, at time.Time) ([]*domain.Vehicle, error) {
+	valid := make([]*domain.Vehicle, 0, len(vehicles))
+	for _, vehicle := range vehicles {
+		expired, err := v.Expired(domain.OwnerVehicle, vehicle.ID, at)
+		if err != nil {
+			return nil, err
+		}
+		if len(expired) == 0 {
+			valid = append(valid, vehicle)
+		}
+	}
+	return valid, nil
+}
+
+func describeExpired(documents []*domain.FleetDocument) string {
+	parts := make([]string, 0, len(documents))
+	for _, document := range documents {
+		parts = append(parts, fmt.Sprintf("%s venció el %s", document.DocumentType, document.ExpiryDate.Format("2006-01-02")))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// RegisterDocumentUseCase registra un documento de chofer o vehículo con su archivo y vencimiento
+type RegisterDocumentUseCase struct {
+	documentRepo domain.FleetDocumentRepository
+	driverRepo   domain.DriverRepository
+	vehicleRepo  domain.VehicleRepository
+	auditRepo    domain.AuditRepository
+}
+
+func NewRegisterDocumentUseCase(
+	documentRepo domain.FleetDocumentRepository,
+	driverRepo domain.DriverRepository,
+	vehicleRepo domain.VehicleRepository,
+	auditRepo domain.AuditRepository,
+) *RegisterDocumentUseCase {
+	return &RegisterDocumentUseCase{
+		documentRepo: documentRepo,
+		driverRepo:   driverRepo,
+		vehicleRepo:  vehicleRepo,
+		auditRepo:    auditRepo,
+	}
+}
+
+type RegisterDocumentInput struct {
+	DocumentType   domain.DocumentType  `json:"document_type"`
+	DocumentNumber string               `json:"document_number,omitempty"`
+	FileURL        string               `json:"file_url"`             // URL retornada por /files/upload
+	IssueDate      string               `json:"issue_date,omitempty"` // YYYY-MM-DD
+	ExpiryDate     string               `json:"expiry_date"`          // YYYY-MM-DD
+	OwnerType      domain.DocumentOwner `json:"-"`
+	OwnerID        uuid.UUID            `json:"-"`
+	UserID         uuid.UUID            `json:"-"`
+}
+
+func (uc *RegisterDocumentUseCase) Execute(input RegisterDocumentInput) (*domain.FleetDocument, error) {
+	owner := input.DocumentType.Owner()
+	if owner == "" {
+		return nil, fmt.Errorf("tipo de documento inválido: %s", input.DocumentType)
+	}
+	if owner != input.OwnerType {
+		return nil, fmt.Errorf("el documento %s no corresponde a %s", input.DocumentType, input.OwnerType)
+	}
+	if input.FileURL == "" {
+		return nil, errors.New("file_url es requerido: suba el archivo en /files/upload")
+	}
+
+	expiry, err := time.ParseInLocation("2006-01-02", input.ExpiryDate, time.Local)
+	if err != nil {
+		return nil, errors.New("expiry_date inválida. Use YYYY-MM-DD")
+	}
+	document := &domain.FleetDocument{
+		OwnerType:      owner,
+		OwnerID:        input.OwnerID,
+		DocumentType:   input.DocumentType,
+		DocumentNumber: input.DocumentNumber,
+		FileURL:        input.FileURL,
+		ExpiryDate:     expiry,
+		UploadedBy:     input.UserID,
+	}
+	if input.IssueDate != "" {
+		issue, err := time.ParseInLocation("2006-01-02", input.IssueDate, time.Local)
+		if err != nil {
+			return nil, errors.New("issue_date inválida. Use YYYY-MM-DD")
+		}
+		if !issue.Before(expiry) {
+			return nil, errors.New("issue_date debe ser anterior a expiry_date")
+		}
+		document.IssueDate = &issue
+	}
+
+	// La licencia registrada actualiza la vigencia del chofer
+	var driver *domain.Driver
+	if owner == domain.OwnerDriver {
+		if driver, err = uc.driverRepo.FindByID(input.OwnerID); err != nil {
+			return nil, errors.New("chofer no encontrado")
+		}
+	} else if _, err := uc.vehicleRepo.FindByID(input.OwnerID); err != nil {
+		return nil, errors.New("vehículo no encontrado")
+	}
+
+	if err := uc.documentRepo.Create(document); err != nil {
+		return nil, err
+	}
+
+	if driver != nil && document.DocumentType == domain.DocLicencia && document.ExpiryDate.After(driver.LicenseExpiry) {
+		driver.LicenseExpiry = document.ExpiryDate
+		if document.DocumentNumber != "" {
+			driver.LicenseNumber = document.DocumentNumber
+		}
+		_ = uc.driverRepo.Update(driver)
+	}
+
+	_ = uc.auditRepo.Log(domain.AuditLog{
+		UserID:     &input.UserID,
+		Action:     "REGISTER_FLEET_DOCUMENT",
+		EntityType: string(owner),
+		EntityID:   &input.OwnerID,
+		NewValues: map[string]interface{}{
+			"document_id":   document.ID,
+			"document_type": document.DocumentType,
+			"expiry_date":   input.ExpiryDate,
+			"file_url":      document.FileURL,
+		},
+	})
+
+	return document, nil
+}
+
+// DocumentAlertsUseCase lista los documentos vencidos o por vencer
+type DocumentAlertsUseCase struct {
+	documentRepo domain.FleetDocumentRepository
+	driverRepo   domain.DriverRepository
+	vehicleRepo  domain.VehicleRepository
+	defaultDays  int
+}
+
+func NewDocumentAlertsUseCase(
+	documentRepo domain.FleetDocumentRepository,
+	driverRepo domain.DriverRepository,
+	vehicleRepo domain.VehicleRepository,
+	defaultDays int,
+) *DocumentAlertsUseCase {
+	return &DocumentAlertsUseCase{
+		documentRepo: documentRepo,
+		driverRepo:   driverRepo,
+		vehicleRepo:  vehicleRepo,
+		defaultDays:  defaultDays,
+	}
+}
+
+// DocumentAlert es un documento vencido o próximo a vencer
+type DocumentAlert struct {
+	Document *domain.FleetDocument `json:"document"`
+	Owner    string                `json:"owner"` // Placas del vehículo o licencia del chofer
+	DaysLeft int                   `json:"days_left"`
+	Expired  bool                  `json:"expired"`
+}
+
+// Execute retorna los documentos vigentes por tipo que vencen en los próximos days días (0 = valor configurado)
+func (uc *DocumentAlertsUseCase) Execute(days int) ([]*DocumentAlert, error) {
+	if days <= 0 {
+		days = uc.defaultDays
+	}
+
+	now := time.Now()
+	documents, err := uc.documentRepo.ListExpiring(now.AddDate(0, 0, days))
+	if err != nil {
+		return nil, err
+	}
+
+	owners := make(map[uuid.UUID]string)
+	alerts := make([]*DocumentAlert, 0, len(documents))
+	for _, document := range documents {
+		if _, ok := owners[document.OwnerID]; !ok {
+			owners[document.OwnerID] = uc.ownerLabel(document)
+		}
+		alerts = append(alerts, &DocumentAlert{
+			Document: document,
+			Owner:    owners[document.OwnerID],
+			DaysLeft: document.DaysToExpiry(now),
+			Expired:  document.IsExpired(now),
+		})
+	}
+	return alerts, nil
+}
+
+func (uc *DocumentAlertsUseCase) ownerLabel(document *domain.FleetDocument) string {
+	if document.OwnerType == domain.OwnerVehicle {
+		if vehicle, err := uc.vehicleRepo.FindByID(document.OwnerID); err == nil {
+			return vehicle.PlateNumber
+		}
+	} else if driver, err := uc.driverRepo.FindByID(document.OwnerID); err == nil {
+		return driver.LicenseNumber
+	}
+	return document.OwnerID.String()
+}
@@ -0,0 +1,171 @@
+package fleet
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sgl-disasur/api/internal/domain"
+)
+
+// SetDriverScheduleUseCase configura clase de licencia, turno y descanso semanal de un chofer
+type SetDriverScheduleUseCase struct {
+	driverRepo domain.DriverRepository
+	auditRepo  domain.AuditRepository
+}
+
+func NewSetDriverScheduleUseCase(driverRepo domain.DriverRepository, auditRepo domain.AuditRepository) *SetDriverScheduleUseCase {
+	return &SetDriverScheduleUseCase{
+		driverRepo: driverRepo,
+		auditRepo:  auditRepo,
+	}
+}
+
+type SetDriverScheduleInput struct {
+	LicenseClass domain.LicenseClass `json:"license_class"`
+	ShiftStart   string              `json:"shift_start,omitempty"` // HH:MM
+	ShiftEnd     string              `json:"shift_end,omitempty"`   // HH:MM
+	RestDays     []int               `json:"rest_days,omitempty"`   // 0=domingo ... 6=sábado
+	DriverID     uuid.UUID           `json:"-"`
+	UserID       uuid.UUID           `json:"-"`
+}
+
+func (uc *SetDriverScheduleUseCase) Execute(input SetDriverScheduleInput) (*domain.Driver, error) {
+	driver, err := uc.driverRepo.FindByID(input.DriverID)
+	if err != nil {
+		return nil, errors.New("chofer no encontrado")
+	}
+
+	if !input.LicenseClass.IsValid() {
+		return nil, fmt.Errorf("clase de licencia inválida: %s. Use: LOCAL, FORANEA", input.LicenseClass)
+	}
+	if (input.ShiftStart == "") != (input.ShiftEnd == "") {
+		return nil, errors.New("indique inicio y fin del turno")
+	}
+	for _, value := range []string{input.ShiftStart, input.ShiftEnd} {
+		if _, err := time.Parse("15:04", value); value != "" && err != nil {
+			return nil, fmt.Errorf("hora de turno inválida: %s. Use HH:MM", value)
+		}
+	}
+
+	days := make([]string, 0, len(input.RestDays))
+	for _, day := range input.RestDays {
+		if day < 0 || day > 6 {
+			return nil, fmt.Errorf("día de descanso inválido: %d. Use 0 (domingo) a 6 (sábado)", day)
+		}
+		days = append(days, strconv.Itoa(day))
+	}
+
+	oldValues := map[string]interface{}{
+		"license_class": driver.LicenseClass,
+		"shift_start":   driver.ShiftStart,
+		"shift_end":     driver.ShiftEnd,
+		"rest_days":     driver.RestDays,
+	}
+
+	driver.LicenseClass = input.LicenseClass
+	driver.ShiftStart = input.ShiftStart
+	driver.ShiftEnd = input.ShiftEnd
+	driver.RestDays = strings.Join(days, ",")
+
+	if err := uc.driverRepo.Update(driver); err != nil {
+		return nil, err
+	}
+
+	_ = uc.auditRepo.Log(domain.AuditLog{
+		UserID:     &input.UserID,
+		Action:     "SET_DRIVER_SCHEDULE",
+		EntityType: "DRIVER",
+		EntityID:   &driver.ID,
+		OldValues:  oldValues,
+		NewValues: map[string]interface{}{
+			"license_class": driver.LicenseClass,
+			"shift_start":   driver.ShiftStart,
+			"shift_end":     driver.ShiftEnd,
+			"rest_days":     driver.RestDays,
+		},
+	})
+
+	return driver, nil
+}
+
+// RegisterDriverAbsenceUseCase registra un descanso o licencia del chofer por rango de fechas
+type RegisterDriverAbsenceUseCase struct {
+	driverRepo  domain.DriverRepository
+	absenceRepo domain.DriverAbsenceRepository
+	auditRepo   domain.AuditRepository
+}
+
+func NewRegisterDriverAbsenceUseCase(
+	driverRepo domain.DriverRepository,
+	absenceRepo domain.DriverAbsenceRepository,
+	auditRepo domain.AuditRepository,
+) *RegisterDriverAbsenceUseCase {
+	return &RegisterDriverAbsenceUseCase{
+		driverRepo:  driverRepo,
+		absenceRepo: absenceRepo,
+		auditRepo:   auditRepo,
+	}
+}
+
+type RegisterDriverAbsenceInput struct {
+	Type      domain.DriverStatus `json:"type"`       // DESCANSO o LICENCIA
+	StartDate string              `json:"start_date"` // YYYY-MM-DD
+	EndDate   string              `json:"end_date"`   // YYYY-MM-DD, inclusive
+	Notes     string              `json:"notes,omitempty"`
+	DriverID  uuid.UUID           `json:"-"`
+	UserID    uuid.UUID           `json:"-"`
+}
+
+func (uc *RegisterDriverAbsenceUseCase) Execute(input RegisterDriverAbsenceInput) (*domain.DriverAbsence, error) {
+	driver, err := uc.driverRepo.FindByID(input.DriverID)
+	if err != nil {
+		return nil, errors.New("chofer no encontrado")
+	}
+
+	if input.Type != domain.DriverDescanso && input.Type != domain.DriverLicencia {
+		return nil, fmt.Errorf("tipo inválido: %s. Use: DESCANSO, LICENCIA", input.Type)
+	}
+
+	start, err := time.ParseInLocation("2006-01-02", input.StartDate, time.Local)
+	if err != nil {
+		return nil, errors.New("start_date inválida. Use YYYY-MM-DD")
+	}
+	end, err := time.ParseInLocation("2006-01-02", input.EndDate, time.Local)
+	if err != nil {
+		return nil, errors.New("end_date inválida. Use YYYY-MM-DD")
+	}
+	if end.Before(start) {
+		return nil, errors.New("end_date no puede ser anterior a start_date")
+	}
+
+	absence := &domain.DriverAbsence{
+		DriverID:  driver.ID,
+		Type:      input.Type,
+		StartDate: start,
+		EndDate:   end.AddDate(0, 0, 1).Add(-time.Second), // Fin del último día
+		Notes:     input.Notes,
+		CreatedBy: input.UserID,
+	}
+	if err := uc.absenceRepo.Create(absence); err != nil {
+		return nil, err
+	}
+
+	_ = uc.auditRepo.Log(domain.AuditLog{
+		UserID:     &input.UserID,
+		Action:     "REGISTER_DRIVER_ABSENCE",
+		EntityType: "DRIVER",
+		EntityID:   &driver.ID,
+		NewValues: map[string]interface{}{
+			"absence_id": absence.ID,
+			"type":       absence.Type,
+			"start_date": input.StartDate,
+			"end_date":   input.EndDate,
+		},
+	})
+
+	return absence, nil
+}
@@ -0,0 +1,252 @@
+package fleet
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sgl-disasur/api/internal/domain"
+)
+
+// DriverScheduler valida la disponibilidad de los choferes (turno, descansos, licencias,
+// documentos y horas de manejo) y los ordena por horas restantes para asignarlos a una ruta
+type DriverScheduler struct {
+	driverRepo     domain.DriverRepository
+	absenceRepo    domain.DriverAbsenceRepository
+	routeRepo      domain.RouteRepository
+	documents      *DocumentValidator
+	maxDailyHours  float64
+	maxWeeklyHours float64
+}
+
+func NewDriverScheduler(
+	driverRepo domain.DriverRepository,
+	absenceRepo domain.DriverAbsenceRepository,
+	routeRepo domain.RouteRepository,
+	documents *DocumentValidator,
+	maxDailyHours, maxWeeklyHours int,
+) *DriverScheduler {
+	return &DriverScheduler{
+		driverRepo:     driverRepo,
+		absenceRepo:    absenceRepo,
+		routeRepo:      routeRepo,
+		documents:      documents,
+		maxDailyHours:  float64(maxDailyHours),
+		maxWeeklyHours: float64(maxWeeklyHours),
+	}
+}
+
+// DriverCandidate es la evaluación de un chofer para una ruta
+type DriverCandidate struct {
+	Driver          *domain.Driver `json:"driver"`
+	Eligible        bool           `json:"eligible"`
+	Reasons         []string       `json:"reasons,omitempty"` // Motivos por los que no es elegible
+	DailyHoursUsed  float64        `json:"daily_hours_used"`
+	WeeklyHoursUsed float64        `json:"weekly_hours_used"`
+	DailyHoursLeft  float64        `json:"daily_hours_left"`  // Después de la ruta
+	WeeklyHoursLeft float64        `json:"weekly_hours_left"` // Después de la ruta
+}
+
+// RouteWindow es la salida, llegada y tipo de la ruta por asignar
+type RouteWindow struct {
+	RouteType domain.RouteType
+	Departure time.Time
+	Arrival   time.Time
+}
+
+// Hours retorna las horas en ruta entre la salida y la llegada
+func (w RouteWindow) Hours() float64 {
+	return domain.EstimatedDrivingHours(w.Departure, w.Arrival, w.RouteType)
+}
+
+// hoursByDay reparte las horas de manejo de la ruta por día calendario, con el tope diario
+func (w RouteWindow) hoursByDay(maxDaily float64) []domain.DayHours {
+	return domain.DrivingHoursByDay(w.Departure, w.Hours(), maxDaily, w.Departure.Location())
+}
+
+func (w RouteWindow) end() time.Time {
+	return w.Departure.Add(time.Duration(w.Hours() * float64(time.Hour)))
+}
+
+// Evaluate verifica si el chofer puede tomar la ruta y calcula sus horas restantes.
+// Las horas se reparten por día calendario (a lo más el tope diario por ruta y día); se revisa cada día
+// que toca la ruta y cada ventana móvil de 7 días que incluye alguno de ellos. Las horas restantes son
+// las del día y la ventana más ajustados.
+func (s *DriverScheduler) Evaluate(driver *domain.Driver, window RouteWindow) (*DriverCandidate, error) {
+	candidate := &DriverCandidate{Driver: driver}
+	reject := func(format string, args ...interface{}) {
+		candidate.Reasons = append(candidate.Reasons, fmt.Sprintf(format, args...))
+	}
+
+	if !driver.IsActive {
+		reject("chofer inactivo")
+	}
+	if driver.Status != domain.DriverDisponible {
+		reject("estado %s", driver.Status)
+	}
+	if !window.end().Before(driver.LicenseExpiry) {
+		reject("licencia vence el %s", driver.LicenseExpiry.Format("2006-01-02"))
+	}
+	if !driver.CanDriveRouteType(window.RouteType) {
+		reject("licencia %s no permite rutas %s", driver.LicenseClass, window.RouteType)
+	}
+	if driver.IsRestDay(window.Departure) {
+		reject("día de descanso semanal")
+	}
+	if !driver.InShift(window.Departure) {
+		reject("salida fuera de turno (%s-%s)", driver.ShiftStart, driver.ShiftEnd)
+	}
+
+	expired, err := s.documents.Expired(domain.OwnerDriver, driver.ID, window.end())
+	if err != nil {
+		return nil, err
+	}
+	if len(expired) > 0 {
+		reject("%s: %s", domain.ErrDocumentExpired, describeExpired(expired))
+	}
+
+	absences, err := s.absenceRepo.FindOverlapping(driver.ID, window.Departure, window.end())
+	if err != nil {
+		return nil, err
+	}
+	for _, absence := range absences {
+		reject("%s del %s al %s", absence.Type,
+			absence.StartDate.Format("2006-01-02"), absence.EndDate.Format("2006-01-02"))
+	}
+
+	loc := window.Departure.Location()
+	routeDays := window.hoursByDay(s.maxDailyHours)
+	first, last := routeDays[0].Day, routeDays[len(routeDays)-1].Day
+
+	// Rutas de las ventanas que incluyen la ruta; un día antes por las que salieron la víspera y siguen en curso
+	routes, err := s.routeRepo.ListByDriver(driver.ID, first.AddDate(0, 0, -7), last.AddDate(0, 0, 7))
+	if err != nil {
+		return nil, err
+	}
+	used := make(map[time.Time]float64)
+	for _, route := range routes {
+		for _, day := range route.DrivingHoursByDay(s.maxDailyHours, loc) {
+			used[day.Day] += day.Hours
+		}
+	}
+	planned := make(map[time.Time]float64, len(routeDays))
+	for _, day := range routeDays {
+		planned[day.Day] = day.Hours
+	}
+
+	worstDay := routeDays[0]
+	for i, day := range routeDays {
+		left := s.maxDailyHours - used[day.Day] - day.Hours
+		if i == 0 || left < candidate.DailyHoursLeft {
+			candidate.DailyHoursUsed, candidate.DailyHoursLeft = used[day.Day], left
+			worstDay = day
+		}
+	}
+	if candidate.DailyHoursLeft < 0 {
+		reject("excede %.0f h de manejo diarias el %s (%.1f h usadas + %.1f h de la ruta)",
+			s.maxDailyHours, worstDay.Day.Format("2006-01-02"), candidate.DailyHoursUsed, worstDay.Hours)
+	}
+
+	var worstWeek domain.DayHours // Inicio de la ventana y horas de la ruta dentro de ella
+	for start := first.AddDate(0, 0, -6); !start.After(last); start = start.AddDate(0, 0, 1) {
+		var usedHours, routeHours float64
+		for d := 0; d < 7; d++ {
+			day := start.AddDate(0, 0, d)
+			usedHours += used[day]
+			routeHours += planned[day]
+		}
+		left := s.maxWeeklyHours - usedHours - routeHours
+		if worstWeek.Day.IsZero() || left < candidate.WeeklyHoursLeft {
+			candidate.WeeklyHoursUsed, candidate.WeeklyHoursLeft = usedHours, left
+			worstWeek = domain.DayHours{Day: start, Hours: routeHours}
+		}
+	}
+	if candidate.WeeklyHoursLeft < 0 {
+		reject("excede %.0f h de manejo semanales del %s al %s (%.1f h usadas + %.1f h de la ruta)",
+			s.maxWeeklyHours, worstWeek.Day.Format("2006-01-02"), worstWeek.Day.AddDate(0, 0, 6).Format("2006-01-02"),
+			candidate.WeeklyHoursUsed, worstWeek.Hours)
+	}
+
+	candidate.Eligible = len(candidate.Reasons) == 0
+	return candidate, nil
+}
+
+// driverPageSize es el tamaño de página al recorrer todos los choferes
+const driverPageSize = 500
+
+// listAllDrivers recorre los choferes página por página
+func listAllDrivers(repo domain.DriverRepository) ([]*domain.Driver, error) {
+	var drivers []*domain.Driver
+	for offset := 0; ; offset += driverPageSize {
+		page, err := repo.List(nil, driverPageSize, offset)
+		if err != nil {
+			return nil, err
+		}
+		drivers = append(drivers, page...)
+		if len(page) < driverPageSize {
+			return drivers, nil
+		}
+	}
+}
+
+// Rank evalúa a los choferes activos: primero los elegibles, con más horas semanales y diarias restantes
+func (s *DriverScheduler) Rank(window RouteWindow) ([]*DriverCandidate, error) {
+	drivers, err := listAllDrivers(s.driverRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]*DriverCandidate, 0, len(drivers))
+	for _, driver := range drivers {
+		if !driver.IsActive {
+			continue
+		}
+		candidate, err := s.Evaluate(driver, window)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Eligible != b.Eligible {
+			return a.Eligible
+		}
+		if a.WeeklyHoursLeft != b.WeeklyHoursLeft {
+			return a.WeeklyHoursLeft > b.WeeklyHoursLeft
+		}
+		return a.DailyHoursLeft > b.DailyHoursLeft
+	})
+	return candidates, nil
+}
+
+// Select valida el chofer indicado o toma el elegible con más horas restantes
+func (s *DriverScheduler) Select(driverID *uuid.UUID, window RouteWindow) (*domain.Driver, error) {
+	if driverID == nil {
+		candidates, err := s.Rank(window)
+		if err != nil {
+			return nil, err
+		}
+		if len(candidates) == 0 || !candidates[0].Eligible {
+			return nil, domain.ErrDriverNotAvailable
+		}
+		return candidates[0].Driver, nil
+	}
+
+	driver, err := s.driverRepo.FindByID(*driverID)
+	if err != nil {
+		return nil, errors.New("chofer no encontrado")
+	}
+	candidate, err := s.Evaluate(driver, window)
+	if err != nil {
+		return nil, err
+	}
+	if !candidate.Eligible {
+		return nil, fmt.Errorf("%w: %s", domain.ErrDriverNotAvailable, strings.Join(candidate.Reasons, "; "))
+	}
+	return driver, nil
+}
@@ -0,0 +1,359 @@
+package fleet
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sgl-disasur/api/internal/domain"
+)
+
+// fuelHistoryLoads es el número de cargas recientes usadas para calcular el rendimiento esperado
+const fuelHistoryLoads = 10
+
+// RegisterFuelLoadUseCase registra una carga de combustible, calcula el rendimiento desde la
+// carga anterior y la marca como anómala si no es consistente con el recorrido
+type RegisterFuelLoadUseCase struct {
+	fuelRepo    domain.FuelLoadRepository
+	vehicleRepo domain.VehicleRepository
+	auditRepo   domain.AuditRepository
+}
+
+func NewRegisterFuelLoadUseCase(
+	fuelRepo domain.FuelLoadRepository,
+	vehicleRepo domain.VehicleRepository,
+	auditRepo domain.AuditRepository,
+) *RegisterFuelLoadUseCase {
+	return &RegisterFuelLoadUseCase{
+		fuelRepo:    fuelRepo,
+		vehicleRepo: vehicleRepo,
+		auditRepo:   auditRepo,
+	}
+}
+
+type RegisterFuelLoadInput struct {
+	Liters         float64    `json:"liters"`
+	TotalCost      float64    `json:"total_cost"`
+	OdometerKm     float64    `json:"odometer_km"`
+	Station        string     `json:"station,omitempty"`
+	TicketPhotoURL string     `json:"ticket_photo_url,omitempty"` // URL retornada por /files/upload
+	DriverID       *uuid.UUID `json:"driver_id,omitempty"`
+	LoadedAt       string     `json:"loaded_at,omitempty"` // RFC3339; por defecto, ahora
+	VehicleID      uuid.UUID  `json:"-"`
+	UserID         uuid.UUID  `json:"-"`
+}
+
+func (uc *RegisterFuelLoadUseCase) Execute(input RegisterFuelLoadInput) (*domain.FuelLoad, error) {
+	vehicle, err := uc.vehicleRepo.FindByID(input.VehicleID)
+	if err != nil {
+		return nil, errors.New("vehículo no encontrado")
+	}
+	if input.Liters <= 0 {
+		return nil, errors.New("liters debe ser mayor a cero")
+	}
+	if input.TotalCost < 0 {
+		return nil, errors.New("total_cost no puede ser negativo")
+	}
+	if input.OdometerKm <= 0 {
+		return nil, errors.New("odometer_km debe ser mayor a cero")
+	}
+
+	loadedAt := time.Now()
+	if input.LoadedAt != "" {
+		if loadedAt, err = time.Parse(time.RFC3339, input.LoadedAt); err != nil {
+			return nil, errors.New("loaded_at inválida. Use RFC3339")
+		}
+	}
+
+	// La carga anterior es la última por fecha, aunque la nueva se capture con fecha pasada
+	previous, err := uc.fuelRepo.FindLastBefore(vehicle.ID, loadedAt)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, err
+	}
+	history, err := uc.fuelRepo.FindByVehicle(vehicle.ID, fuelHistoryLoads, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	load := &domain.FuelLoad{
+		VehicleID:      vehicle.ID,
+		DriverID:       input.DriverID,
+		Liters:         input.Liters,
+		TotalCost:      input.TotalCost,
+		OdometerKm:     input.OdometerKm,
+		Station:        input.Station,
+		TicketPhotoURL: input.TicketPhotoURL,
+		LoadedAt:       loadedAt,
+		RecordedBy:     input.UserID,
+	}
+	if previous != nil {
+		if input.OdometerKm < previous.OdometerKm {
+			return nil, fmt.Errorf("el odómetro (%.1f km) es menor al de la carga anterior (%.1f km)",
+				input.OdometerKm, previous.OdometerKm)
+		}
+		load.DistanceKm = input.OdometerKm - previous.OdometerKm
+		efficiency := load.DistanceKm / input.Liters
+		load.EfficiencyKmL = &efficiency
+		detectFuelAnomaly(load, expectedEfficiency(vehicle, history))
+	}
+
+	reading, err := newOdometerReading(vehicle, input.OdometerKm, "COMBUSTIBLE", input.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.fuelRepo.Register(load, vehicle, reading); err != nil {
+		return nil, err
+	}
+
+	_ = uc.auditRepo.Log(domain.AuditLog{
+		UserID:     &input.UserID,
+		Action:     "REGISTER_FUEL_LOAD",
+		EntityType: "VEHICLE",
+		EntityID:   &vehicle.ID,
+		NewValues: map[string]interface{}{
+			"fuel_load_id": load.ID,
+			"liters":       load.Liters,
+			"total_cost":   load.TotalCost,
+			"odometer_km":  load.OdometerKm,
+			"is_anomaly":   load.IsAnomaly,
+		},
+	})
+
+	return load, nil
+}
+
+// expectedEfficiency promedia el rendimiento de las cargas recientes no anómalas;
+// sin historial usa el rendimiento de referencia del tipo de vehículo
+func expectedEfficiency(vehicle *domain.Vehicle, history []*domain.FuelLoad) float64 {
+	var total float64
+	var count int
+	for _, load := range history {
+		if load.EfficiencyKmL != nil && !load.IsAnomaly {
+			total += *load.EfficiencyKmL
+			count++
+		}
+	}
+	if count > 0 {
+		return total / float64(count)
+	}
+	return domain.ReferenceFuelEfficiency[vehicle.VehicleType]
+}
+
+// detectFuelAnomaly marca la carga si su rendimiento se aleja más de la tolerancia del esperado
+func detectFuelAnomaly(load *domain.FuelLoad, expected float64) {
+	if load.EfficiencyKmL == nil || expected <= 0 {
+		return
+	}
+	efficiency := *load.EfficiencyKmL
+	deviation := (efficiency - expected) / expected
+	if math.Abs(deviation) <= domain.FuelAnomalyTolerance {
+		return
+	}
+
+	load.IsAnomaly = true
+	if deviation < 0 {
+		load.AnomalyReason = fmt.Sprintf("%.1f l para %.0f km recorridos: rendimiento de %.2f km/l, %.0f%% por debajo del esperado (%.2f km/l)",
+			load.Liters, load.DistanceKm, efficiency, -deviation*100, expected)
+	} else {
+		load.AnomalyReason = fmt.Sprintf("rendimiento de %.2f km/l, %.0f%% por encima del esperado (%.2f km/l): posible carga anterior incompleta u odómetro incorrecto",
+			efficiency, deviation*100, expected)
+	}
+}
+
+// VehicleFuelUseCase consulta el historial de cargas y el rendimiento de un vehículo
+type VehicleFuelUseCase struct {
+	fuelRepo    domain.FuelLoadRepository
+	vehicleRepo domain.VehicleRepository
+}
+
+func NewVehicleFuelUseCase(fuelRepo domain.FuelLoadRepository, vehicleRepo domain.VehicleRepository) *VehicleFuelUseCase {
+	return &VehicleFuelUseCase{
+		fuelRepo:    fuelRepo,
+		vehicleRepo: vehicleRepo,
+	}
+}
+
+// VehicleFuelOutput es el historial de cargas con el rendimiento del vehículo
+type VehicleFuelOutput struct {
+	Vehicle              *domain.Vehicle    `json:"vehicle"`
+	Loads                []*domain.FuelLoad `json:"loads"`
+	AverageKmL           float64            `json:"average_km_l"`  // Promedio de las cargas no anómalas listadas
+	ExpectedKmL          float64            `json:"expected_km_l"` // Referencia para detectar anomalías
+	ReferenceKmL         float64            `json:"reference_km_l"`
+	Anomalies            int                `json:"anomalies"`
+	AveragePricePerLiter float64            `json:"average_price_per_liter"`
+}
+
+func (uc *VehicleFuelUseCase) Execute(vehicleID uuid.UUID, limit, offset int) (*VehicleFuelOutput, error) {
+	vehicle, err := uc.vehicleRepo.FindByID(vehicleID)
+	if err != nil {
+		return nil, errors.New("vehículo no encontrado")
+	}
+
+	loads, err := uc.fuelRepo.FindByVehicle(vehicleID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &VehicleFuelOutput{
+		Vehicle:      vehicle,
+		Loads:        loads,
+		ExpectedKmL:  expectedEfficiency(vehicle, loads),
+		ReferenceKmL: domain.ReferenceFuelEfficiency[vehicle.VehicleType],
+	}
+
+	var efficiencySum, liters, cost float64
+	var efficiencyCount int
+	for _, load := range loads {
+		liters += load.Liters
+		cost += load.TotalCost
+		if load.IsAnomaly {
+			output.Anomalies++
+		} else if load.EfficiencyKmL != nil {
+			efficiencySum += *load.EfficiencyKmL
+			efficiencyCount++
+		}
+	}
+	if efficiencyCount > 0 {
+		output.AverageKmL = efficiencySum / float64(efficiencyCount)
+	}
+	if liters > 0 {
+		output.AveragePricePerLiter = cost / liters
+	}
+	return output, nil
+}
+
+// FleetCostReportUseCase combina combustible y mantenimiento por vehículo en un periodo
+type FleetCostReportUseCase struct {
+	vehicleRepo     domain.VehicleRepository
+	fuelRepo        domain.FuelLoadRepository
+	maintenanceRepo domain.VehicleMaintenanceRepository
+	odometerRepo    domain.OdometerReadingRepository
+	routeRepo       domain.RouteRepository
+}
+
+func NewFleetCostReportUseCase(
+	vehicleRepo domain.VehicleRepository,
+	fuelRepo domain.FuelLoadRepository,
+	maintenanceRepo domain.VehicleMaintenanceRepository,
+	odometerRepo domain.OdometerReadingRepository,
+	routeRepo domain.RouteRepository,
+) *FleetCostReportUseCase {
+	return &FleetCostReportUseCase{
+		vehicleRepo:     vehicleRepo,
+		fuelRepo:        fuelRepo,
+		maintenanceRepo: maintenanceRepo,
+		odometerRepo:    odometerRepo,
+		routeRepo:       routeRepo,
+	}
+}
+
+// VehicleCost es el costo de operación de un vehículo en el periodo
+type VehicleCost struct {
+	VehicleID       uuid.UUID `json:"vehicle_id"`
+	PlateNumber     string    `json:"plate_number"`
+	VehicleType     string    `json:"vehicle_type"`
+	DistanceKm      float64   `json:"distance_km"`
+	Liters          float64   `json:"liters"`
+	EfficiencyKmL   float64   `json:"efficiency_km_l"`
+	FuelAnomalies   int       `json:"fuel_anomalies"`
+	FuelCost        float64   `json:"fuel_cost"`
+	MaintenanceCost float64   `json:"maintenance_cost"`
+	TotalCost       float64   `json:"total_cost"`
+	CostPerKm       float64   `json:"cost_per_km"`
+	DeliveredOrders int       `json:"delivered_orders"`
+	CostPerOrder    float64   `json:"cost_per_order"`
+}
+
+// FleetCostReport es el reporte de costos de la flotilla
+type FleetCostReport struct {
+	From     time.Time      `json:"from"`
+	To       time.Time      `json:"to"`
+	Vehicles []*VehicleCost `json:"vehicles"`
+	Total    VehicleCost    `json:"total"`
+}
+
+// Execute calcula el reporte para el periodo [from, to), con los vehículos más costosos por km primero
+func (uc *FleetCostReportUseCase) Execute(from, to time.Time) (*FleetCostReport, error) {
+	if !to.After(from) {
+		return nil, errors.New("el fin del periodo debe ser posterior al inicio")
+	}
+
+	vehicles, err := listAllVehicles(uc.vehicleRepo)
+	if err != nil {
+		return nil, err
+	}
+	fuel, err := uc.fuelRepo.SummarizeByVehicle(from, to)
+	if err != nil {
+		return nil, err
+	}
+	maintenance, err := uc.maintenanceRepo.CostByVehicle(from, to)
+	if err != nil {
+		return nil, err
+	}
+	distances, err := uc.odometerRepo.DistanceByVehicle(from, to)
+	if err != nil {
+		return nil, err
+	}
+	delivered, err := uc.routeRepo.CountDeliveredByVehicle(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	fuelByVehicle := make(map[uuid.UUID]*domain.VehicleFuelSummary, len(fuel))
+	for _, summary := range fuel {
+		fuelByVehicle[summary.VehicleID] = summary
+	}
+
+	report := &FleetCostReport{From: from, To: to, Vehicles: make([]*VehicleCost, 0, len(vehicles))}
+	for _, vehicle := range vehicles {
+		row := &VehicleCost{
+			VehicleID:       vehicle.ID,
+			PlateNumber:     vehicle.PlateNumber,
+			VehicleType:     string(vehicle.VehicleType),
+			DistanceKm:      distances[vehicle.ID],
+			MaintenanceCost: maintenance[vehicle.ID],
+			DeliveredOrders: delivered[vehicle.ID],
+		}
+		if summary, ok := fuelByVehicle[vehicle.ID]; ok {
+			row.Liters = summary.Liters
+			row.FuelCost = summary.Cost
+			row.FuelAnomalies = summary.Anomalies
+		}
+		if row.DistanceKm == 0 && row.Liters == 0 && row.MaintenanceCost == 0 && row.DeliveredOrders == 0 {
+			continue // Sin actividad en el periodo
+		}
+		row.computeRatios()
+		report.Vehicles = append(report.Vehicles, row)
+
+		report.Total.DistanceKm += row.DistanceKm
+		report.Total.Liters += row.Liters
+		report.Total.FuelAnomalies += row.FuelAnomalies
+		report.Total.FuelCost += row.FuelCost
+		report.Total.MaintenanceCost += row.MaintenanceCost
+		report.Total.DeliveredOrders += row.DeliveredOrders
+	}
+	report.Total.PlateNumber = "TOTAL"
+	report.Total.computeRatios()
+
+	sort.SliceStable(report.Vehicles, func(i, j int) bool {
+		return report.Vehicles[i].CostPerKm > report.Vehicles[j].CostPerKm
+	})
+	return report, nil
+}
+
+func (c *VehicleCost) computeRatios() {
+	c.TotalCost = c.FuelCost + c.MaintenanceCost
+	if c.Liters > 0 {
+		c.EfficiencyKmL = c.DistanceKm / c.Liters
+	}
+	if c.DistanceKm > 0 {
+		c.CostPerKm = c.TotalCost / c.DistanceKm
+	}
+	if c.DeliveredOrders > 0 {
+		c.CostPerOrder = c.TotalCost / float64(c.DeliveredOrders)
+	}
+}
@@ -0,0 +1,410 @@
+package fleet
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/sgl-disasur/api/internal/domain"
+)
+
+// maxLoadPackages limita los bultos de un plan; más bultos indica piezas sueltas sin empaque configurado
+const maxLoadPackages = 5000
+
+// LoadPlanUseCase genera el plan de carga 3D de una ruta: posición de cada bulto en la caja
+// del vehículo y orden de carga, respetando el orden de descarga por parada y las reglas de estiba
+type LoadPlanUseCase struct {
+	routeRepo     domain.RouteRepository
+	stopRepo      domain.RouteStopRepository
+	vehicleRepo   domain.VehicleRepository
+	orderRepo     domain.OrderRepository
+	orderLineRepo domain.OrderLineRepository
+	customerRepo  domain.CustomerRepository
+	productRepo   domain.ProductRepository
+	packagingRepo domain.ProductPackagingRepository
+}
+
+func NewLoadPlanUseCase(
+	routeRepo domain.RouteRepository,
+	stopRepo domain.RouteStopRepository,
+	vehicleRepo domain.VehicleRepository,
+	orderRepo domain.OrderRepository,
+	orderLineRepo domain.OrderLineRepository,
+	customerRepo domain.CustomerRepository,
+	productRepo domain.ProductRepository,
+	packagingRepo domain.ProductPackagingRepository,
+) *LoadPlanUseCase {
+	return &LoadPlanUseCase{
+		routeRepo:     routeRepo,
+		stopRepo:      stopRepo,
+		vehicleRepo:   vehicleRepo,
+		orderRepo:     orderRepo,
+		orderLineRepo: orderLineRepo,
+		customerRepo:  customerRepo,
+		productRepo:   productRepo,
+		packagingRepo: packagingRepo,
+	}
+}
+
+// LoadItem es un bulto del plan. Coordenadas en cm desde la esquina cabina-izquierda-piso:
+// X hacia la puerta, Y a lo ancho, Z hacia arriba.
+type LoadItem struct {
+	LoadSequence  int                  `json:"load_sequence,omitempty"` // Orden en que se sube al vehículo
+	StopSequence  int                  `json:"stop_sequence"`
+	OrderID       uuid.UUID            `json:"order_id"`
+	OrderNumber   string               `json:"order_number"`
+	CustomerName  string               `json:"customer_name"`
+	ProductID     uuid.UUID            `json:"product_id"`
+	SKU           string               `json:"sku"`
+	ProductName   string               `json:"product_name"`
+	Unit          domain.UnitOfMeasure `json:"unit"`
+	Pieces        int                  `json:"pieces"`
+	XCm           float64              `json:"x_cm"`
+	YCm           float64              `json:"y_cm"`
+	ZCm           float64              `json:"z_cm"`
+	LengthCm      float64              `json:"length_cm"`
+	WidthCm       float64              `json:"width_cm"`
+	HeightCm      float64              `json:"height_cm"`
+	WeightKg      float64              `json:"weight_kg"`
+	IsFragile     bool                 `json:"is_fragile"`
+	StackedOn     int                  `json:"stacked_on,omitempty"` // load_sequence del bulto de abajo
+	MaxLoadOnTop  float64              `json:"max_load_on_top_kg"`
+	LoadOnTopKg   float64              `json:"load_on_top_kg"`
+	UnplacedCause string               `json:"unplaced_cause,omitempty"`
+}
+
+type LoadPlanOutput struct {
+	RouteID           uuid.UUID              `json:"route_id"`
+	RouteNumber       string                 `json:"route_number"`
+	VehicleID         uuid.UUID              `json:"vehicle_id"`
+	PlateNumber       string                 `json:"plate_number"`
+	Cargo             domain.CargoDimensions `json:"cargo"`
+	Items             []*LoadItem            `json:"items"` // En orden de carga
+	Unplaced          []*LoadItem            `json:"unplaced"`
+	TotalWeightKg     float64                `json:"total_weight_kg"`
+	UsedLengthCm      float64                `json:"used_length_cm"`
+	VolumeUtilization float64                `json:"volume_utilization"` // % del volumen interior
+	Utilization       domain.LoadUtilization `json:"utilization"`        // Contra la capacidad del vehículo
+	Warnings          []string               `json:"warnings"`
+}
+
+// Execute arma el plan de carga de la ruta
+func (uc *LoadPlanUseCase) Execute(routeID uuid.UUID) (*LoadPlanOutput, error) {
+	route, err := uc.routeRepo.FindByID(routeID)
+	if err != nil {
+		return nil, errors.New("ruta no encontrada")
+	}
+
+	vehicle, err := uc.vehicleRepo.FindByID(route.VehicleID)
+	if err != nil {
+		return nil, errors.New("vehículo no encontrado")
+	}
+
+	cargo := vehicle.Cargo()
+	if cargo.LengthCm <= 0 || cargo.WidthCm <= 0 || cargo.HeightCm <= 0 {
+		return nil, errors.New("el vehículo no tiene dimensiones de caja de carga")
+	}
+
+	stops, err := uc.stopRepo.FindByRouteID(route.ID)
+	if err != nil {
+		return nil, err
+	}
+	// Rutas de un solo pedido anteriores a las paradas múltiples
+	if len(stops) == 0 {
+		stops = []*domain.RouteStop{{OrderID: route.OrderID, Sequence: 1}}
+	}
+
+	output := &LoadPlanOutput{
+		RouteID:     route.ID,
+		RouteNumber: route.RouteNumber,
+		VehicleID:   vehicle.ID,
+		PlateNumber: vehicle.PlateNumber,
+		Cargo:       cargo,
+		Items:       []*LoadItem{},
+		Unplaced:    []*LoadItem{},
+		Warnings:    []string{},
+	}
+
+	// La última parada se carga primero, al fondo de la caja
+	sort.Slice(stops, func(i, j int) bool { return stops[i].Sequence > stops[j].Sequence })
+
+	packer := newLoadPacker(cargo)
+	totalPackages := 0
+	for _, stop := range stops {
+		packages, err := uc.stopPackages(stop, output)
+		if err != nil {
+			return nil, err
+		}
+		totalPackages += len(packages)
+		if totalPackages > maxLoadPackages {
+			return nil, fmt.Errorf("la ruta excede %d bultos; configure cajas o tarimas para sus productos", maxLoadPackages)
+		}
+
+		// Pesados y no frágiles abajo: se cargan primero dentro de la parada
+		sort.SliceStable(packages, func(i, j int) bool {
+			a, b := packages[i], packages[j]
+			if a.IsFragile != b.IsFragile {
+				return !a.IsFragile
+			}
+			if a.WeightKg != b.WeightKg {
+				return a.WeightKg > b.WeightKg
+			}
+			return a.LengthCm*a.WidthCm > b.LengthCm*b.WidthCm
+		})
+
+		packer.startSection()
+		for _, item := range packages {
+			output.TotalWeightKg += item.WeightKg
+			if item.LengthCm <= 0 || item.WidthCm <= 0 || item.HeightCm <= 0 {
+				item.UnplacedCause = "sin dimensiones capturadas"
+				output.Unplaced = append(output.Unplaced, item)
+				continue
+			}
+			if !packer.place(item) {
+				item.UnplacedCause = "no cabe en el espacio disponible"
+				output.Unplaced = append(output.Unplaced, item)
+				continue
+			}
+			item.LoadSequence = len(output.Items) + 1
+			output.Items = append(output.Items, item)
+		}
+	}
+
+	usedVolume := 0.0
+	for _, item := range output.Items {
+		usedVolume += item.LengthCm * item.WidthCm * item.HeightCm / 1000000.0
+	}
+	output.UsedLengthCm = packer.usedLength()
+	if cargoVolume := cargo.VolumeM3(); cargoVolume > 0 {
+		output.VolumeUtilization = usedVolume / cargoVolume * 100
+	}
+
+	capacity := vehicle.Capacity()
+	output.Utilization = capacity.Utilization(output.TotalWeightKg, usedVolume)
+	if capacity.WeightKg > 0 && output.TotalWeightKg > capacity.WeightKg {
+		output.Warnings = append(output.Warnings, fmt.Sprintf(
+			"El peso total (%.2f kg) excede la capacidad del vehículo (%.2f kg)", output.TotalWeightKg, capacity.WeightKg))
+	}
+	if len(output.Unplaced) > 0 {
+		output.Warnings = append(output.Warnings, fmt.Sprintf(
+			"%d bulto(s) no pudieron acomodarse; revise dimensiones o divida la ruta", len(output.Unplaced)))
+	}
+
+	return output, nil
+}
+
+// stopPackages desglosa las líneas del pedido de la parada en bultos, usando primero el empaque más grande
+func (uc *LoadPlanUseCase) stopPackages(stop *domain.RouteStop, output *LoadPlanOutput) ([]*LoadItem, error) {
+	order, err := uc.orderRepo.FindByID(stop.OrderID)
+	if err != nil {
+		return nil, fmt.Errorf("pedido %s no encontrado", stop.OrderID)
+	}
+
+	customerName := ""
+	if customer, err := uc.customerRepo.FindByID(order.CustomerID); err == nil {
+		customerName = customer.Name
+	}
+
+	lines, err := uc.orderLineRepo.FindByOrderID(order.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var packages []*LoadItem
+	for _, line := range lines {
+		product, err := uc.productRepo.FindByID(line.ProductID)
+		if err != nil {
+			return nil, fmt.Errorf("producto %s no encontrado", line.ProductID)
+		}
+		levels, err := uc.packagingRepo.FindByProduct(product.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		// Solo se usan empaques con dimensiones; la pieza siempre queda como último recurso
+		var usable []*domain.ProductPackaging
+		for _, level := range levels {
+			if level.Unit != domain.UnitPieza && level.UnitsPerPackage > 1 && level.CalculateVolume() > 0 {
+				usable = append(usable, level)
+			}
+		}
+		sort.Slice(usable, func(i, j int) bool { return usable[i].UnitsPerPackage > usable[j].UnitsPerPackage })
+		usable = append(usable, product.BasePackaging())
+
+		remaining := line.Quantity
+		for _, level := range usable {
+			count := remaining / level.UnitsPerPackage
+			remaining -= count * level.UnitsPerPackage
+
+			weight := domain.PackagedQuantity{Product: product, Packaging: level, Quantity: 1}.WeightKg()
+			for i := 0; i < count; i++ {
+				packages = append(packages, &LoadItem{
+					StopSequence: stop.Sequence,
+					OrderID:      order.ID,
+					OrderNumber:  order.OrderNumber,
+					CustomerName: customerName,
+					ProductID:    product.ID,
+					SKU:          product.SKU,
+					ProductName:  product.Name,
+					Unit:         level.Unit,
+					Pieces:       level.UnitsPerPackage,
+					LengthCm:     level.LengthCm,
+					WidthCm:      level.WidthCm,
+					HeightCm:     level.HeightCm,
+					WeightKg:     weight,
+					IsFragile:    product.IsFragile,
+					MaxLoadOnTop: product.MaxLoadOnTopKg(weight),
+				})
+			}
+		}
+	}
+
+	if len(packages) == 0 {
+		output.Warnings = append(output.Warnings, fmt.Sprintf("El pedido %s no tiene líneas", order.OrderNumber))
+	}
+	return packages, nil
+}
+
+// loadColumn es una pila de bultos sobre la misma base
+type loadColumn struct {
+	x, y, length, width float64
+	height              float64
+	items               []*LoadItem
+}
+
+// loadPacker acomoda bultos por filas a lo ancho de la caja, de la cabina hacia la puerta.
+// Cada parada ocupa sus propias filas para descargarla sin mover bultos de otras paradas.
+type loadPacker struct {
+	cargo    domain.CargoDimensions
+	rowX     float64 // Inicio de la fila actual
+	rowDepth float64 // Largo ocupado por la fila actual
+	rowY     float64 // Ancho ocupado en la fila actual
+	columns  []*loadColumn
+}
+
+func newLoadPacker(cargo domain.CargoDimensions) *loadPacker {
+	return &loadPacker{cargo: cargo}
+}
+
+// startSection cierra la fila actual e inicia las filas de una nueva parada
+func (p *loadPacker) startSection() {
+	p.newRow()
+	p.columns = nil
+}
+
+func (p *loadPacker) newRow() {
+	p.rowX += p.rowDepth
+	p.rowDepth = 0
+	p.rowY = 0
+}
+
+func (p *loadPacker) usedLength() float64 {
+	return p.rowX + p.rowDepth
+}
+
+// place intenta estibar el bulto sobre una columna existente y, si no, abrir una nueva en el piso
+func (p *loadPacker) place(item *LoadItem) bool {
+	for _, column := range p.columns {
+		if p.stack(column, item) {
+			return true
+		}
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		for _, dims := range [][2]float64{{item.LengthCm, item.WidthCm}, {item.WidthCm, item.LengthCm}} {
+			length, width := dims[0], dims[1]
+			if item.HeightCm > p.cargo.HeightCm ||
+				p.rowY+width > p.cargo.WidthCm || p.rowX+length > p.cargo.LengthCm {
+				continue
+			}
+			column := &loadColumn{x: p.rowX, y: p.rowY, length: length, width: width}
+			p.rowY += width
+			if length > p.rowDepth {
+				p.rowDepth = length
+			}
+			p.columns = append(p.columns, column)
+			item.LengthCm, item.WidthCm = length, width
+			p.put(column, item)
+			return true
+		}
+		// El ancho de la fila está lleno: antes de abrir otra hacia la puerta se aprovecha el largo
+		// libre frente a las columnas más cortas que la fila
+		if p.rowY == 0 {
+			break
+		}
+		if p.fillRow(item) {
+			return true
+		}
+		p.newRow()
+	}
+	return false
+}
+
+// fillRow coloca el bulto en el piso frente a una columna de la fila actual, dentro del largo de la fila
+func (p *loadPacker) fillRow(item *LoadItem) bool {
+	rowEnd := p.rowX + p.rowDepth
+	for _, base := range p.columns {
+		if base.x < p.rowX || p.occupiedInFront(base) {
+			continue
+		}
+		x := base.x + base.length
+		for _, dims := range [][2]float64{{item.LengthCm, item.WidthCm}, {item.WidthCm, item.LengthCm}} {
+			length, width := dims[0], dims[1]
+			if item.HeightCm > p.cargo.HeightCm || width > base.width || x+length > rowEnd {
+				continue
+			}
+			column := &loadColumn{x: x, y: base.y, length: length, width: width}
+			p.columns = append(p.columns, column)
+			item.LengthCm, item.WidthCm = length, width
+			p.put(column, item)
+			return true
+		}
+	}
+	return false
+}
+
+// occupiedInFront indica si ya hay una columna en el piso justo frente a la columna base
+func (p *loadPacker) occupiedInFront(base *loadColumn) bool {
+	x := base.x + base.length
+	for _, column := range p.columns {
+		if column.x == x && column.y >= base.y && column.y < base.y+base.width {
+			return true
+		}
+	}
+	return false
+}
+
+// stack coloca el bulto encima de la columna si su base cabe sobre el bulto superior,
+// no rebasa la altura de la caja y ningún bulto de abajo excede el peso que soporta
+func (p *loadPacker) stack(column *loadColumn, item *LoadItem) bool {
+	if len(column.items) == 0 || column.height+item.HeightCm > p.cargo.HeightCm {
+		return false
+	}
+	top := column.items[len(column.items)-1]
+
+	for _, below := range column.items {
+		if below.LoadOnTopKg+item.WeightKg > below.MaxLoadOnTop {
+			return false
+		}
+	}
+
+	for _, dims := range [][2]float64{{item.LengthCm, item.WidthCm}, {item.WidthCm, item.LengthCm}} {
+		if dims[0] <= top.LengthCm && dims[1] <= top.WidthCm {
+			item.LengthCm, item.WidthCm = dims[0], dims[1]
+			for _, below := range column.items {
+				below.LoadOnTopKg += item.WeightKg
+			}
+			item.StackedOn = top.LoadSequence
+			p.put(column, item)
+			return true
+		}
+	}
+	return false
+}
+
+func (p *loadPacker) put(column *loadColumn, item *LoadItem) {
+	item.XCm, item.YCm, item.ZCm = column.x, column.y, column.height
+	column.height += item.HeightCm
+	column.items = append(column.items, item)
+}
@@ -2,13 +2,17 @@ package fleet
 
 import (
 	"errors"
+	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/sgl-disasur/api/internal/domain"
 )
 
-// RegisterMaintenanceUseCase implementa HU-16: Control de mantenimiento
+// RegisterMaintenanceUseCase implementa HU-16: Control de mantenimiento.
+// Abre la orden de taller; el próximo servicio se programa al cerrarla.
 type RegisterMaintenanceUseCase struct {
 	maintenanceRepo domain.VehicleMaintenanceRepository
 	vehicleRepo     domain.VehicleRepository
@@ -33,14 +37,25 @@ type RegisterMaintenanceInput struct {
 	Description     string    `json:"description"`
 	Cost            float64   `json:"cost"`
 	PerformedBy     string    `json:"performed_by"`
+	OdometerKm      float64   `json:"odometer_km,omitempty"` // Lectura al ingresar a taller
 	UserID          uuid.UUID `json:"-"`
 }
 
-func (uc *RegisterMaintenanceUseCase) Execute(input RegisterMaintenanceInput) error {
-	// 1. Verificar vehículo
+func (uc *RegisterMaintenanceUseCase) Execute(input RegisterMaintenanceInput) (*domain.VehicleMaintenance, error) {
+	// 1. Verificar vehículo; uno en ruta debe regresar (o reasignarse la ruta) antes de entrar a taller
 	vehicle, err := uc.vehicleRepo.FindByID(input.VehicleID)
 	if err != nil {
-		return errors.New("vehículo no encontrado")
+		return nil, errors.New("vehículo no encontrado")
+	}
+	if vehicle.Status == domain.VehicleEnRuta {
+		return nil, fmt.Errorf("%w: vehículo %s %s", domain.ErrVehicleNotAvailable, vehicle.PlateNumber, vehicle.Status)
+	}
+
+	var reading *domain.OdometerReading
+	if input.OdometerKm > 0 {
+		if reading, err = newOdometerReading(vehicle, input.OdometerKm, "MANTENIMIENTO", input.UserID); err != nil {
+			return nil, err
+		}
 	}
 
 	// 2. Crear registro de mantenimiento
@@ -51,22 +66,14 @@ func (uc *RegisterMaintenanceUseCase) Execute(input RegisterMaintenanceInput) er
 		Cost:            input.Cost,
 		StartDate:       time.Now(),
 		PerformedBy:     input.PerformedBy,
+		OdometerKm:      vehicle.OdometerKm,
 	}
 
-	if err := uc.maintenanceRepo.Create(maintenance); err != nil {
-		return err
-	}
-
-	// 3. Actualizar estado del vehículo
+	// 3. El vehículo queda en taller hasta cerrar el mantenimiento
 	vehicle.Status = domain.VehicleEnTaller
-	now := time.Now()
-	vehicle.LastMaintenanceDate = &now
-
-	// Programar próximo mantenimiento (ejemplo: 3 meses después)
-	nextMaintenance := now.AddDate(0, 3, 0)
-	vehicle.NextMaintenanceDate = &nextMaintenance
-
-	_ = uc.vehicleRepo.Update(vehicle)
+	if err := uc.maintenanceRepo.Open(maintenance, vehicle, reading); err != nil {
+		return nil, err
+	}
 
 	// 4. Auditar
 	_ = uc.auditRepo.Log(domain.AuditLog{
@@ -75,39 +82,50 @@ func (uc *RegisterMaintenanceUseCase) Execute(input RegisterMaintenanceInput) er
 		EntityType: "VEHICLE",
 		EntityID:   &vehicle.ID,
 		NewValues: map[string]interface{}{
-			"maintenance_type":      input.MaintenanceType,
-			"cost":                  input.Cost,
-			"next_maintenance_date": nextMaintenance,
+			"maintenance_id":   maintenance.ID,
+			"maintenance_type": input.MaintenanceType,
+			"cost":             input.Cost,
+			"odometer_km":      maintenance.OdometerKm,
 		},
 	})
 
-	return nil
+	return maintenance, nil
 }
 
-// PerformPreDepartureCheckUseCase implementa HU-17: Check-list pre-salida
+// PerformPreDepartureCheckUseCase implementa HU-17: Check-list pre-salida.
+// Usa la plantilla activa del tipo de vehículo; sin plantilla aplica las revisiones fijas.
 type PerformPreDepartureCheckUseCase struct {
-	checklistRepo domain.PreDepartureChecklistRepository
-	routeRepo     domain.RouteRepository
-	vehicleRepo   domain.VehicleRepository
-	auditRepo     domain.AuditRepository
+	checklistRepo   domain.PreDepartureChecklistRepository
+	templateRepo    domain.ChecklistTemplateRepository
+	routeRepo       domain.RouteRepository
+	vehicleRepo     domain.VehicleRepository
+	maintenanceRepo domain.VehicleMaintenanceRepository
+	auditRepo       domain.AuditRepository
 }
 
 func NewPerformPreDepartureCheckUseCase(
 	checklistRepo domain.PreDepartureChecklistRepository,
+	templateRepo domain.ChecklistTemplateRepository,
 	routeRepo domain.RouteRepository,
 	vehicleRepo domain.VehicleRepository,
+	maintenanceRepo domain.VehicleMaintenanceRepository,
 	auditRepo domain.AuditRepository,
 ) *PerformPreDepartureCheckUseCase {
 	return &PerformPreDepartureCheckUseCase{
-		checklistRepo: checklistRepo,
-		routeRepo:     routeRepo,
-		vehicleRepo:   vehicleRepo,
-		auditRepo:     auditRepo,
+		checklistRepo:   checklistRepo,
+		templateRepo:    templateRepo,
+		routeRepo:       routeRepo,
+		vehicleRepo:     vehicleRepo,
+		maintenanceRepo: maintenanceRepo,
+		auditRepo:       auditRepo,
 	}
 }
 
 type PerformCheckInput struct {
-	RouteID        uuid.UUID `json:"route_id"`
+	RouteID uuid.UUID `json:"route_id"`
+	// Respuestas a la plantilla del tipo de vehículo
+	Answers []ChecklistAnswerInput `json:"answers,omitempty"`
+	// Revisiones fijas, para vehículos sin plantilla
 	TireCondition  string    `json:"tire_condition"` // "BUENO", "REGULAR", "MALO"
 	FuelLevel      int       `json:"fuel_level"`     // 0-100%
 	OilLevel       string    `json:"oil_level"`      // "OK", "BAJO"
@@ -117,29 +135,186 @@ type PerformCheckInput struct {
 	UserID         uuid.UUID `json:"-"`
 }
 
-func (uc *PerformPreDepartureCheckUseCase) Execute(input PerformCheckInput) error {
-	// 1. Verificar ruta
+// ChecklistAnswerInput es la respuesta a un punto de la plantilla, identificado por su código
+type ChecklistAnswerInput struct {
+	Code        string   `json:"code"`
+	BoolValue   *bool    `json:"bool_value,omitempty"`
+	NumberValue *float64 `json:"number_value,omitempty"`
+	PhotoURL    string   `json:"photo_url,omitempty"` // URL retornada por /files/upload
+}
+
+// PerformCheckOutput es el check-list registrado con los puntos fallidos
+type PerformCheckOutput struct {
+	Checklist   *domain.PreDepartureChecklist `json:"checklist"`
+	Template    *domain.ChecklistTemplate     `json:"template,omitempty"`
+	Blocking    []string                      `json:"blocking,omitempty"` // Puntos bloqueantes fallidos
+	Warnings    []string                      `json:"warnings,omitempty"` // Puntos no bloqueantes fallidos
+	Maintenance *domain.VehicleMaintenance    `json:"maintenance,omitempty"`
+}
+
+// Execute registra el check-list. Si falla un punto bloqueante abre un mantenimiento correctivo
+// y retorna ErrChecklistFailed junto con el resultado.
+func (uc *PerformPreDepartureCheckUseCase) Execute(input PerformCheckInput) (*PerformCheckOutput, error) {
+	// 1. Verificar ruta y vehículo
 	route, err := uc.routeRepo.FindByID(input.RouteID)
 	if err != nil {
-		return errors.New("ruta no encontrada")
+		return nil, errors.New("ruta no encontrada")
+	}
+	vehicle, err := uc.vehicleRepo.FindByID(route.VehicleID)
+	if err != nil {
+		return nil, errors.New("vehículo no encontrado")
+	}
+
+	template, err := uc.templateRepo.FindActive(vehicle.VehicleType)
+	if errors.Is(err, domain.ErrNotFound) {
+		return uc.executeFixed(input, route)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Evaluar cada punto de la plantilla
+	answers := make(map[string]*domain.ChecklistAnswer, len(input.Answers))
+	for _, answer := range input.Answers {
+		code := strings.ToUpper(strings.TrimSpace(answer.Code))
+		if _, ok := answers[code]; ok {
+			return nil, fmt.Errorf("respuesta duplicada para %s", code)
+		}
+		answers[code] = &domain.ChecklistAnswer{
+			BoolValue:   answer.BoolValue,
+			NumberValue: answer.NumberValue,
+			PhotoURL:    answer.PhotoURL,
+		}
+	}
+
+	output := &PerformCheckOutput{Template: template}
+	checklist := &domain.PreDepartureChecklist{
+		RouteID:    route.ID,
+		VehicleID:  route.VehicleID,
+		DriverID:   route.DriverID,
+		Notes:      input.Notes,
+		CheckedAt:  time.Now(),
+		TemplateID: &template.ID,
+	}
+	for _, item := range template.Items {
+		answer, err := item.Evaluate(answers[item.Code])
+		if err != nil {
+			return nil, err
+		}
+		delete(answers, item.Code)
+		if answer == nil {
+			continue
+		}
+		checklist.Answers = append(checklist.Answers, answer)
+		if answer.Passed {
+			continue
+		}
+		if item.Blocking {
+			output.Blocking = append(output.Blocking, answer.FailureReason)
+		} else {
+			output.Warnings = append(output.Warnings, answer.FailureReason)
+		}
+	}
+	if len(answers) > 0 {
+		unknown := make([]string, 0, len(answers))
+		for code := range answers {
+			unknown = append(unknown, code)
+		}
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("puntos inexistentes en la plantilla %s v%d: %s",
+			template.Name, template.Version, strings.Join(unknown, ", "))
+	}
+	checklist.Passed = len(output.Blocking) == 0
+
+	// 3. Un punto bloqueante fallido manda el vehículo a taller
+	if checklist.Passed {
+		err = uc.checklistRepo.Create(checklist)
+	} else {
+		output.Maintenance, err = uc.sendToWorkshop(checklist, route, vehicle, output.Blocking, input.UserID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	output.Checklist = checklist
+
+	// 4. Auditar
+	_ = uc.auditRepo.Log(domain.AuditLog{
+		UserID:     &input.UserID,
+		Action:     "PRE_DEPARTURE_CHECK",
+		EntityType: "ROUTE",
+		EntityID:   &route.ID,
+		NewValues: map[string]interface{}{
+			"checklist_id":     checklist.ID,
+			"template_id":      template.ID,
+			"template_version": template.Version,
+			"passed":           checklist.Passed,
+			"blocking":         output.Blocking,
+			"maintenance_id":   checklist.MaintenanceID,
+		},
+	})
+
+	if !checklist.Passed {
+		return output, fmt.Errorf("%w: %s", domain.ErrChecklistFailed, strings.Join(output.Blocking, "; "))
+	}
+	return output, nil
+}
+
+// sendToWorkshop guarda el check-list no aprobado ligado a un mantenimiento correctivo. Si el vehículo
+// ya tiene uno abierto (por ejemplo de un intento anterior) se reutiliza en lugar de abrir otro.
+func (uc *PerformPreDepartureCheckUseCase) sendToWorkshop(checklist *domain.PreDepartureChecklist, route *domain.Route, vehicle *domain.Vehicle, blocking []string, userID uuid.UUID) (*domain.VehicleMaintenance, error) {
+	open, err := uc.maintenanceRepo.FindOpenByType(vehicle.ID, domain.MaintenanceCorrectivo)
+	if err == nil {
+		checklist.MaintenanceID = &open.ID
+		return open, uc.checklistRepo.Create(checklist)
+	}
+	if !errors.Is(err, domain.ErrNotFound) {
+		return nil, err
 	}
 
-	// 2. Validaciones de seguridad (HU-17)
+	maintenance := &domain.VehicleMaintenance{
+		VehicleID:       vehicle.ID,
+		MaintenanceType: domain.MaintenanceCorrectivo,
+		Description:     fmt.Sprintf("Check-list pre-salida ruta %s: %s", route.RouteNumber, strings.Join(blocking, "; ")),
+		StartDate:       time.Now(),
+		OdometerKm:      vehicle.OdometerKm,
+	}
+	vehicle.Status = domain.VehicleEnTaller
+	if err := uc.checklistRepo.CreateWithMaintenance(checklist, maintenance, vehicle); err != nil {
+		return nil, err
+	}
+
+	_ = uc.auditRepo.Log(domain.AuditLog{
+		UserID:     &userID,
+		Action:     "REGISTER_MAINTENANCE",
+		EntityType: "VEHICLE",
+		EntityID:   &vehicle.ID,
+		NewValues: map[string]interface{}{
+			"maintenance_id":   maintenance.ID,
+			"maintenance_type": maintenance.MaintenanceType,
+			"odometer_km":      maintenance.OdometerKm,
+			"route_id":         route.ID,
+		},
+	})
+	return maintenance, nil
+}
+
+// executeFixed aplica las revisiones fijas de HU-17 (combustible, llantas y luces)
+func (uc *PerformPreDepartureCheckUseCase) executeFixed(input PerformCheckInput, route *domain.Route) (*PerformCheckOutput, error) {
 	if input.FuelLevel < 25 {
-		return errors.New("nivel de combustible insuficiente (<25%). Cargar combustible antes de partir")
+		return nil, errors.New("nivel de combustible insuficiente (<25%). Cargar combustible antes de partir")
 	}
 
 	if input.TireCondition == "MALO" {
-		return errors.New("condición de llantas MALA. Cambiar llantas antes de partir")
+		return nil, errors.New("condición de llantas MALA. Cambiar llantas antes de partir")
 	}
 
 	if !input.LightsOk {
-		return errors.New("luces no funcionan correctamente. Reparar antes de partir")
+		return nil, errors.New("luces no funcionan correctamente. Reparar antes de partir")
 	}
 
-	// 3. Crear check-list
 	checklist := &domain.PreDepartureChecklist{
 		RouteID:        input.RouteID,
+		VehicleID:      route.VehicleID,
 		DriverID:       route.DriverID,
 		TireCondition:  input.TireCondition,
 		FuelLevel:      input.FuelLevel,
@@ -148,13 +323,13 @@ func (uc *PerformPreDepartureCheckUseCase) Execute(input PerformCheckInput) erro
 		DamagePhotoURL: input.DamagePhotoURL,
 		Notes:          input.Notes,
 		CheckedAt:      time.Now(),
+		Passed:         true,
 	}
 
 	if err := uc.checklistRepo.Create(checklist); err != nil {
-		return err
+		return nil, err
 	}
 
-	// 4. Auditar
 	_ = uc.auditRepo.Log(domain.AuditLog{
 		UserID:     &input.UserID,
 		Action:     "PRE_DEPARTURE_CHECK",
@@ -167,5 +342,5 @@ func (uc *PerformPreDepartureCheckUseCase) Execute(input PerformCheckInput) erro
 		},
 	})
 
-	return nil
+	return &PerformCheckOutput{Checklist: checklist}, nil
 }
@@ -0,0 +1,354 @@
+package fleet
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sgl-disasur/api/internal/domain"
+)
+
+// recordOdometer guarda la lectura y actualiza el odómetro del vehículo (sin persistir el vehículo)
+func recordOdometer(repo domain.OdometerReadingRepository, vehicle *domain.Vehicle, readingKm float64, source string, userID uuid.UUID) error {
+	reading, err := newOdometerReading(vehicle, readingKm, source, userID)
+	if err != nil {
+		return err
+	}
+	return repo.Create(reading)
+}
+
+// newOdometerReading valida la lectura y actualiza el odómetro del vehículo; la guarda quien la llama
+func newOdometerReading(vehicle *domain.Vehicle, readingKm float64, source string, userID uuid.UUID) (*domain.OdometerReading, error) {
+	if readingKm < vehicle.OdometerKm {
+		return nil, fmt.Errorf("la lectura (%.1f km) es menor al odómetro registrado (%.1f km)", readingKm, vehicle.OdometerKm)
+	}
+
+	vehicle.OdometerKm = readingKm
+	return &domain.OdometerReading{
+		VehicleID:  vehicle.ID,
+		ReadingKm:  readingKm,
+		Source:     source,
+		RecordedBy: userID,
+	}, nil
+}
+
+// vehiclePageSize es el tamaño de página al recorrer toda la flota
+const vehiclePageSize = 500
+
+// listAllVehicles recorre la flota completa página por página
+func listAllVehicles(repo domain.VehicleRepository) ([]*domain.Vehicle, error) {
+	var vehicles []*domain.Vehicle
+	for offset := 0; ; offset += vehiclePageSize {
+		page, err := repo.List(nil, vehiclePageSize, offset)
+		if err != nil {
+			return nil, err
+		}
+		vehicles = append(vehicles, page...)
+		if len(page) < vehiclePageSize {
+			return vehicles, nil
+		}
+	}
+}
+
+// readyVehicles descarta los vehículos con mantenimiento o documentos vencidos en la fecha de la ruta
+func readyVehicles(vehicles []*domain.Vehicle, documents *DocumentValidator, at time.Time) ([]*domain.Vehicle, error) {
+	var serviced []*domain.Vehicle
+	for _, vehicle := range vehicles {
+		if !vehicle.MaintenanceOverdue(at) {
+			serviced = append(serviced, vehicle)
+		}
+	}
+	return documents.FilterVehicles(serviced, at)
+}
+
+// checkVehicleReady valida mantenimiento y documentos del vehículo indicado manualmente
+func checkVehicleReady(vehicle *domain.Vehicle, documents *DocumentValidator, at time.Time) error {
+	if vehicle.MaintenanceOverdue(at) {
+		return fmt.Errorf("%w: vehículo %s", domain.ErrMaintenanceOverdue, vehicle.PlateNumber)
+	}
+	return documents.CheckVehicle(vehicle, at)
+}
+
+// CloseMaintenanceUseCase cierra la orden de taller, programa el próximo servicio y libera el vehículo
+type CloseMaintenanceUseCase struct {
+	maintenanceRepo domain.VehicleMaintenanceRepository
+	vehicleRepo     domain.VehicleRepository
+	routeRepo       domain.RouteRepository
+	planRepo        domain.MaintenancePlanRepository
+	auditRepo       domain.AuditRepository
+}
+
+func NewCloseMaintenanceUseCase(
+	maintenanceRepo domain.VehicleMaintenanceRepository,
+	vehicleRepo domain.VehicleRepository,
+	routeRepo domain.RouteRepository,
+	planRepo domain.MaintenancePlanRepository,
+	auditRepo domain.AuditRepository,
+) *CloseMaintenanceUseCase {
+	return &CloseMaintenanceUseCase{
+		maintenanceRepo: maintenanceRepo,
+		vehicleRepo:     vehicleRepo,
+		routeRepo:       routeRepo,
+		planRepo:        planRepo,
+		auditRepo:       auditRepo,
+	}
+}
+
+type CloseMaintenanceInput struct {
+	Cost          *float64  `json:"cost,omitempty"` // Costo final; si no se envía se conserva el registrado
+	OdometerKm    float64   `json:"odometer_km,omitempty"`
+	Notes         string    `json:"notes,omitempty"`
+	MaintenanceID uuid.UUID `json:"-"`
+	UserID        uuid.UUID `json:"-"`
+}
+
+type CloseMaintenanceOutput struct {
+	Maintenance *domain.VehicleMaintenance `json:"maintenance"`
+	Vehicle     *domain.Vehicle            `json:"vehicle"`
+}
+
+func (uc *CloseMaintenanceUseCase) Execute(input CloseMaintenanceInput) (*CloseMaintenanceOutput, error) {
+	maintenance, err := uc.maintenanceRepo.FindByID(input.MaintenanceID)
+	if err != nil {
+		return nil, errors.New("mantenimiento no encontrado")
+	}
+	if maintenance.EndDate != nil {
+		return nil, errors.New("el mantenimiento ya está cerrado")
+	}
+
+	vehicle, err := uc.vehicleRepo.FindByID(maintenance.VehicleID)
+	if err != nil {
+		return nil, errors.New("vehículo no encontrado")
+	}
+
+	if input.Cost != nil && *input.Cost < 0 {
+		return nil, errors.New("el costo no puede ser negativo")
+	}
+
+	var reading *domain.OdometerReading
+	if input.OdometerKm > 0 && input.OdometerKm != vehicle.OdometerKm {
+		if reading, err = newOdometerReading(vehicle, input.OdometerKm, "MANTENIMIENTO", input.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	maintenance.EndDate = &now
+	maintenance.OdometerKm = vehicle.OdometerKm
+	if input.Cost != nil {
+		maintenance.Cost = *input.Cost
+	}
+	if input.Notes != "" {
+		maintenance.Description = strings.TrimSpace(maintenance.Description + "\n" + input.Notes)
+	}
+
+	// El servicio preventivo reinicia el plan; uno correctivo solo programa si no había nada pendiente
+	if maintenance.MaintenanceType == domain.MaintenancePreventivo || (vehicle.NextMaintenanceDate == nil && vehicle.NextMaintenanceKm == nil) {
+		plan := domain.DefaultMaintenancePlan
+		if configured, err := uc.planRepo.FindByVehicleType(vehicle.VehicleType); err == nil {
+			plan = *configured
+		}
+		vehicle.LastMaintenanceDate = &now
+		vehicle.LastMaintenanceKm = vehicle.OdometerKm
+		vehicle.NextMaintenanceDate, vehicle.NextMaintenanceKm = plan.Schedule(now, vehicle.OdometerKm)
+	}
+
+	// Sin otras órdenes abiertas el vehículo sale de taller: regresa EN_RUTA si sigue asignado a una
+	// ruta confirmada o en curso (p. ej. tras un check-list fallido) y DISPONIBLE si no.
+	// La orden que se está cerrando todavía cuenta como abierta.
+	if vehicle.Status == domain.VehicleEnTaller {
+		open, err := uc.maintenanceRepo.CountOpen(vehicle.ID)
+		if err != nil {
+			return nil, err
+		}
+		if open <= 1 {
+			active, err := uc.routeRepo.CountActiveByVehicle(vehicle.ID)
+			if err != nil {
+				return nil, err
+			}
+			vehicle.Status = domain.VehicleDisponible
+			if active > 0 {
+				vehicle.Status = domain.VehicleEnRuta
+			}
+		}
+	}
+	if err := uc.maintenanceRepo.Close(maintenance, vehicle, reading); err != nil {
+		return nil, err
+	}
+
+	_ = uc.auditRepo.Log(domain.AuditLog{
+		UserID:     &input.UserID,
+		Action:     "CLOSE_MAINTENANCE",
+		EntityType: "VEHICLE",
+		EntityID:   &vehicle.ID,
+		NewValues: map[string]interface{}{
+			"maintenance_id":        maintenance.ID,
+			"cost":                  maintenance.Cost,
+			"odometer_km":           vehicle.OdometerKm,
+			"next_maintenance_date": vehicle.NextMaintenanceDate,
+			"next_maintenance_km":   vehicle.NextMaintenanceKm,
+			"status":                vehicle.Status,
+		},
+	})
+
+	return &CloseMaintenanceOutput{Maintenance: maintenance, Vehicle: vehicle}, nil
+}
+
+// RecordOdometerUseCase registra una lectura manual del odómetro
+type RecordOdometerUseCase struct {
+	vehicleRepo  domain.VehicleRepository
+	odometerRepo domain.OdometerReadingRepository
+}
+
+func NewRecordOdometerUseCase(vehicleRepo domain.VehicleRepository, odometerRepo domain.OdometerReadingRepository) *RecordOdometerUseCase {
+	return &RecordOdometerUseCase{
+		vehicleRepo:  vehicleRepo,
+		odometerRepo: odometerRepo,
+	}
+}
+
+type RecordOdometerInput struct {
+	ReadingKm float64   `json:"reading_km"`
+	VehicleID uuid.UUID `json:"-"`
+	UserID    uuid.UUID `json:"-"`
+}
+
+func (uc *RecordOdometerUseCase) Execute(input RecordOdometerInput) (*domain.Vehicle, error) {
+	vehicle, err := uc.vehicleRepo.FindByID(input.VehicleID)
+	if err != nil {
+		return nil, errors.New("vehículo no encontrado")
+	}
+	if input.ReadingKm <= 0 {
+		return nil, errors.New("reading_km debe ser mayor a cero")
+	}
+
+	if err := recordOdometer(uc.odometerRepo, vehicle, input.ReadingKm, "MANUAL", input.UserID); err != nil {
+		return nil, err
+	}
+	if err := uc.vehicleRepo.Update(vehicle); err != nil {
+		return nil, err
+	}
+	return vehicle, nil
+}
+
+// SetMaintenancePlanUseCase configura el plan preventivo de un tipo de vehículo
+type SetMaintenancePlanUseCase struct {
+	planRepo  domain.MaintenancePlanRepository
+	auditRepo domain.AuditRepository
+}
+
+func NewSetMaintenancePlanUseCase(planRepo domain.MaintenancePlanRepository, auditRepo domain.AuditRepository) *SetMaintenancePlanUseCase {
+	return &SetMaintenancePlanUseCase{
+		planRepo:  planRepo,
+		auditRepo: auditRepo,
+	}
+}
+
+type SetMaintenancePlanInput struct {
+	VehicleType    domain.VehicleType `json:"vehicle_type"`
+	IntervalKm     int                `json:"interval_km"`
+	IntervalMonths int                `json:"interval_months"`
+	UserID         uuid.UUID          `json:"-"`
+}
+
+func (uc *SetMaintenancePlanUseCase) Execute(input SetMaintenancePlanInput) (*domain.MaintenancePlan, error) {
+	if domain.ReferenceCapacity(input.VehicleType).WeightKg == 0 {
+		return nil, fmt.Errorf("tipo de vehículo inválido: %s", input.VehicleType)
+	}
+	if input.IntervalKm < 0 || input.IntervalMonths < 0 {
+		return nil, errors.New("los intervalos no pueden ser negativos")
+	}
+	if input.IntervalKm == 0 && input.IntervalMonths == 0 {
+		return nil, errors.New("indique interval_km, interval_months o ambos")
+	}
+
+	plan := &domain.MaintenancePlan{
+		VehicleType:    input.VehicleType,
+		IntervalKm:     input.IntervalKm,
+		IntervalMonths: input.IntervalMonths,
+	}
+	if err := uc.planRepo.Upsert(plan); err != nil {
+		return nil, err
+	}
+
+	_ = uc.auditRepo.Log(domain.AuditLog{
+		UserID:     &input.UserID,
+		Action:     "SET_MAINTENANCE_PLAN",
+		EntityType: "MAINTENANCE_PLAN",
+		EntityID:   &plan.ID,
+		NewValues: map[string]interface{}{
+			"vehicle_type":    plan.VehicleType,
+			"interval_km":     plan.IntervalKm,
+			"interval_months": plan.IntervalMonths,
+		},
+	})
+
+	return plan, nil
+}
+
+// UpcomingMaintenanceUseCase lista los vehículos con servicio vencido o próximo
+type UpcomingMaintenanceUseCase struct {
+	vehicleRepo domain.VehicleRepository
+}
+
+func NewUpcomingMaintenanceUseCase(vehicleRepo domain.VehicleRepository) *UpcomingMaintenanceUseCase {
+	return &UpcomingMaintenanceUseCase{vehicleRepo: vehicleRepo}
+}
+
+// UpcomingMaintenance es el estado de mantenimiento de un vehículo
+type UpcomingMaintenance struct {
+	Vehicle  *domain.Vehicle `json:"vehicle"`
+	DaysLeft *int            `json:"days_left,omitempty"`
+	KmLeft   *float64        `json:"km_left,omitempty"`
+	Overdue  bool            `json:"overdue"` // Bloquea la asignación de rutas
+}
+
+// Execute retorna los vehículos activos cuyo servicio vence en days días o km kilómetros
+func (uc *UpcomingMaintenanceUseCase) Execute(days int, km float64) ([]*UpcomingMaintenance, error) {
+	vehicles, err := listAllVehicles(uc.vehicleRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var upcoming []*UpcomingMaintenance
+	for _, vehicle := range vehicles {
+		if !vehicle.IsActive {
+			continue
+		}
+
+		item := &UpcomingMaintenance{Vehicle: vehicle, Overdue: vehicle.MaintenanceOverdue(now)}
+		due := item.Overdue
+		if vehicle.NextMaintenanceDate != nil {
+			daysLeft := int(vehicle.NextMaintenanceDate.Sub(now).Hours() / 24)
+			item.DaysLeft = &daysLeft
+			due = due || daysLeft <= days
+		}
+		if vehicle.NextMaintenanceKm != nil {
+			kmLeft := *vehicle.NextMaintenanceKm - vehicle.OdometerKm
+			item.KmLeft = &kmLeft
+			due = due || kmLeft <= km
+		}
+		if due {
+			upcoming = append(upcoming, item)
+		}
+	}
+
+	sort.SliceStable(upcoming, func(i, j int) bool {
+		if upcoming[i].Overdue != upcoming[j].Overdue {
+			return upcoming[i].Overdue
+		}
+		return remainingDays(upcoming[i]) < remainingDays(upcoming[j])
+	})
+	return upcoming, nil
+}
+
+func remainingDays(item *UpcomingMaintenance) int {
+	if item.DaysLeft == nil {
+		return int(^uint(0) >> 1)
+	}
+	return *item.DaysLeft
+}
@@ -0,0 +1,222 @@
+package fleet
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sgl-disasur/api/internal/domain"
+	"github.com/sgl-disasur/api/internal/infrastructure/routing"
+)
+
+// RoutePlanner secuencia las paradas de una ruta y calcula la hora estimada de llegada a cada una
+type RoutePlanner struct {
+	depot          *routing.Point
+	avgSpeedKmh    float64
+	serviceMinutes int
+}
+
+// NewRoutePlanner crea el planificador; con depot 0,0 las rutas inician en la primera parada
+func NewRoutePlanner(depotLat, depotLng float64, avgSpeedKmh, serviceMinutes int) *RoutePlanner {
+	planner := &RoutePlanner{
+		avgSpeedKmh:    float64(avgSpeedKmh),
+		serviceMinutes: serviceMinutes,
+	}
+	if depotLat != 0 || depotLng != 0 {
+		planner.depot = &routing.Point{Lat: depotLat, Lng: depotLng}
+	}
+	if planner.avgSpeedKmh <= 0 {
+		planner.avgSpeedKmh = 40
+	}
+	return planner
+}
+
+// Depot retorna el origen de las rutas (nil si no está configurado)
+func (p *RoutePlanner) Depot() *routing.Point {
+	return p.depot
+}
+
+// Plan ordena las paradas (vecino más cercano + 2-opt) y asigna secuencia, distancia y ETA.
+// Las paradas sin coordenadas quedan al final, sin ETA. Retorna las paradas ordenadas y los km totales.
+func (p *RoutePlanner) Plan(stops []*domain.RouteStop, departure time.Time) ([]*domain.RouteStop, float64) {
+	var geocoded, pending []*domain.RouteStop
+	var points []routing.Point
+	for _, stop := range stops {
+		if point, ok := stopPoint(stop); ok {
+			geocoded = append(geocoded, stop)
+			points = append(points, point)
+		} else {
+			pending = append(pending, stop)
+		}
+	}
+
+	ordered := make([]*domain.RouteStop, 0, len(stops))
+	totalKm := 0.0
+	current := p.depot
+	clock := departure
+
+	for _, idx := range routing.Sequence(p.depot, points) {
+		stop := geocoded[idx]
+		point := points[idx]
+
+		stop.DistanceKm = 0
+		if current != nil {
+			stop.DistanceKm = routing.DistanceKm(*current, point)
+		}
+		totalKm += stop.DistanceKm
+
+		clock = clock.Add(time.Duration(stop.DistanceKm / p.avgSpeedKmh * float64(time.Hour)))
+		eta := clock
+		stop.EstimatedArrival = &eta
+		clock = clock.Add(time.Duration(p.serviceMinutes) * time.Minute)
+
+		current = &point
+		ordered = append(ordered, stop)
+	}
+
+	for _, stop := range pending {
+		stop.DistanceKm = 0
+		stop.EstimatedArrival = nil
+		ordered = append(ordered, stop)
+	}
+
+	for i, stop := range ordered {
+		stop.Sequence = i + 1
+	}
+	return ordered, totalKm
+}
+
+// StopETA es la llegada recalculada a una parada pendiente
+type StopETA struct {
+	StopID         uuid.UUID  `json:"stop_id"`
+	Sequence       int        `json:"sequence"`
+	Address        string     `json:"address,omitempty"`
+	DistanceKm     float64    `json:"distance_km"`               // Desde la posición actual, siguiendo la secuencia
+	PlannedArrival *time.Time `json:"planned_arrival,omitempty"` // ETA calculada al planear la ruta
+	RevisedArrival *time.Time `json:"revised_arrival,omitempty"` // Nil si la parada no tiene coordenadas
+}
+
+// RouteProgress es el avance de la ruta respecto a la última posición
+type RouteProgress struct {
+	TotalKm        float64    `json:"total_km"`
+	RemainingKm    float64    `json:"remaining_km"`
+	ProgressPct    float64    `json:"progress_pct"`
+	DeliveredStops int        `json:"delivered_stops"`
+	PendingStops   int        `json:"pending_stops"`
+	UnlocatedStops int        `json:"unlocated_stops"` // Pendientes sin coordenadas: sin ETA ni km restantes
+	NextStop       *StopETA   `json:"next_stop,omitempty"`
+	Stops          []*StopETA `json:"stops"`
+	PlannedArrival *time.Time `json:"planned_arrival,omitempty"` // EstimatedArrival de la ruta
+	RevisedArrival *time.Time `json:"revised_arrival,omitempty"`
+	DelayMinutes   int        `json:"delay_minutes"` // Positivo = retraso respecto a lo planeado
+}
+
+// Progress recalcula km restantes y ETA de las paradas pendientes desde la posición indicada,
+// con la velocidad promedio y el tiempo de descarga configurados.
+// El avance pondera por km las paradas con coordenadas y cuenta las demás solo al entregarse.
+func (p *RoutePlanner) Progress(route *domain.Route, stops []*domain.RouteStop, position routing.Point, at time.Time) *RouteProgress {
+	progress := &RouteProgress{PlannedArrival: route.EstimatedArrival, Stops: []*StopETA{}}
+
+	current := position
+	clock := at
+	var located, deliveredLocated, deliveredUnlocated int
+	for _, stop := range stops {
+		progress.TotalKm += stop.DistanceKm
+		_, hasPoint := stopPoint(stop)
+		if hasPoint {
+			located++
+		}
+		if stop.Status != domain.StopPendiente {
+			progress.DeliveredStops++
+			if hasPoint {
+				deliveredLocated++
+			} else {
+				deliveredUnlocated++
+			}
+			continue
+		}
+		progress.PendingStops++
+		if !hasPoint {
+			progress.UnlocatedStops++
+		}
+
+		eta := &StopETA{
+			StopID:         stop.ID,
+			Sequence:       stop.Sequence,
+			Address:        stop.Address,
+			PlannedArrival: stop.EstimatedArrival,
+		}
+		if point, ok := stopPoint(stop); ok {
+			leg := routing.DistanceKm(current, point)
+			progress.RemainingKm += leg
+			clock = clock.Add(time.Duration(leg / p.avgSpeedKmh * float64(time.Hour)))
+			arrival := clock
+			eta.DistanceKm = progress.RemainingKm
+			eta.RevisedArrival = &arrival
+			progress.RevisedArrival = &arrival
+			clock = clock.Add(time.Duration(p.serviceMinutes) * time.Minute)
+			current = point
+		}
+		if progress.NextStop == nil {
+			progress.NextStop = eta
+		}
+		progress.Stops = append(progress.Stops, eta)
+	}
+
+	if len(stops) == 0 {
+		progress.ProgressPct = 100
+	} else {
+		// Fracción recorrida de las paradas con coordenadas
+		var locatedDone float64
+		if progress.TotalKm > 0 {
+			locatedDone = math.Max(0, math.Min(1, 1-progress.RemainingKm/progress.TotalKm)) * float64(located)
+		} else {
+			locatedDone = float64(deliveredLocated)
+		}
+		progress.ProgressPct = (locatedDone + float64(deliveredUnlocated)) / float64(len(stops)) * 100
+	}
+	if progress.RevisedArrival != nil && route.EstimatedArrival != nil {
+		progress.DelayMinutes = int(math.Round(progress.RevisedArrival.Sub(*route.EstimatedArrival).Minutes()))
+	}
+	return progress
+}
+
+// newStop crea la parada de un pedido con la dirección y coordenadas del cliente
+func newStop(order *domain.Order, customer *domain.Customer) *domain.RouteStop {
+	return &domain.RouteStop{
+		OrderID:    order.ID,
+		CustomerID: customer.ID,
+		Address:    customerAddress(customer),
+		Latitude:   customer.Latitude,
+		Longitude:  customer.Longitude,
+		Status:     domain.StopPendiente,
+	}
+}
+
+func customerAddress(c *domain.Customer) string {
+	address := c.Address
+	for _, part := range []string{c.City, c.State, c.PostalCode} {
+		if part == "" {
+			continue
+		}
+		if address != "" {
+			address += ", "
+		}
+		address += part
+	}
+	return address
+}
+
+func stopPoint(stop *domain.RouteStop) (routing.Point, bool) {
+	if stop.Latitude == nil || stop.Longitude == nil {
+		return routing.Point{}, false
+	}
+	return routing.Point{Lat: *stop.Latitude, Lng: *stop.Longitude}, true
+}
+
+func customerPoint(c *domain.Customer) (routing.Point, bool) {
+	if c.Latitude == nil || c.Longitude == nil {
+		return routing.Point{}, false
+	}
+	return routing.Point{Lat: *c.Latitude, Lng: *c.Longitude}, true
+}
@@ -0,0 +1,158 @@
+package fleet
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sgl-disasur/api/internal/domain"
+)
+
+// StartRouteUseCase registra la salida real de la ruta. Requiere que el chofer asignado tenga
+// un check-list pre-salida aprobado para la ruta y el vehículo.
+type StartRouteUseCase struct {
+	routeRepo     domain.RouteRepository
+	stopRepo      domain.RouteStopRepository
+	orderRepo     domain.OrderRepository
+	checklistRepo domain.PreDepartureChecklistRepository
+	vehicleRepo   domain.VehicleRepository
+	driverRepo    domain.DriverRepository
+	odometerRepo  domain.OdometerReadingRepository
+	auditRepo     domain.AuditRepository
+}
+
+func NewStartRouteUseCase(
+	routeRepo domain.RouteRepository,
+	stopRepo domain.RouteStopRepository,
+	orderRepo domain.OrderRepository,
+	checklistRepo domain.PreDepartureChecklistRepository,
+	vehicleRepo domain.VehicleRepository,
+	driverRepo domain.DriverRepository,
+	odometerRepo domain.OdometerReadingRepository,
+	auditRepo domain.AuditRepository,
+) *StartRouteUseCase {
+	return &StartRouteUseCase{
+		routeRepo:     routeRepo,
+		stopRepo:      stopRepo,
+		orderRepo:     orderRepo,
+		checklistRepo: checklistRepo,
+		vehicleRepo:   vehicleRepo,
+		driverRepo:    driverRepo,
+		odometerRepo:  odometerRepo,
+		auditRepo:     auditRepo,
+	}
+}
+
+type StartRouteInput struct {
+	OdometerKm float64   `json:"odometer_km"` // Lectura al salir
+	RouteID    uuid.UUID `json:"-"`
+	UserID     uuid.UUID `json:"-"`
+}
+
+type StartRouteOutput struct {
+	Route     *domain.Route                 `json:"route"`
+	Checklist *domain.PreDepartureChecklist `json:"checklist"`
+	OrderIDs  []uuid.UUID                   `json:"order_ids"` // Pedidos que pasaron a EN_RUTA
+}
+
+func (uc *StartRouteUseCase) Execute(input StartRouteInput) (*StartRouteOutput, error) {
+	// 1. Verificar ruta y que el usuario sea el chofer asignado
+	route, err := uc.routeRepo.FindByID(input.RouteID)
+	if err != nil {
+		return nil, errors.New("ruta no encontrada")
+	}
+	if route.Status != domain.OrderConfirmado {
+		return nil, fmt.Errorf("la ruta %s no puede iniciarse en estado %s", route.RouteNumber, route.Status)
+	}
+
+	driver, err := uc.driverRepo.FindByUserID(input.UserID)
+	if err != nil || driver.ID != route.DriverID {
+		return nil, fmt.Errorf("%w: la ruta %s está asignada a otro chofer", domain.ErrForbidden, route.RouteNumber)
+	}
+
+	if input.OdometerKm <= 0 {
+		return nil, errors.New("odometer_km debe ser mayor a cero")
+	}
+
+	// 2. El último check-list de la ruta debe estar aprobado para el mismo vehículo
+	checklist, err := uc.checklistRepo.FindByRouteID(route.ID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, domain.ErrChecklistRequired
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !checklist.Passed {
+		return nil, fmt.Errorf("%w: el último check-list no fue aprobado", domain.ErrChecklistRequired)
+	}
+	if checklist.VehicleID != route.VehicleID {
+		return nil, fmt.Errorf("%w: el check-list corresponde a otro vehículo", domain.ErrChecklistRequired)
+	}
+
+	vehicle, err := uc.vehicleRepo.FindByID(route.VehicleID)
+	if err != nil {
+		return nil, errors.New("vehículo no encontrado")
+	}
+	if vehicle.Status == domain.VehicleEnTaller || vehicle.Status == domain.VehicleFueraServicio {
+		return nil, fmt.Errorf("%w: vehículo %s %s", domain.ErrVehicleNotAvailable, vehicle.PlateNumber, vehicle.Status)
+	}
+
+	// 3. Registrar salida real y odómetro
+	if err := recordOdometer(uc.odometerRepo, vehicle, input.OdometerKm, "SALIDA_RUTA", input.UserID); err != nil {
+		return nil, err
+	}
+	vehicle.Status = domain.VehicleEnRuta
+	if err := uc.vehicleRepo.Update(vehicle); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	route.ActualDeparture = &now
+	route.DepartureOdometerKm = &input.OdometerKm
+	route.Status = domain.OrderEnRuta
+	if err := uc.routeRepo.Update(route); err != nil {
+		return nil, err
+	}
+
+	driver.Status = domain.DriverEnRuta
+	_ = uc.driverRepo.Update(driver)
+
+	// 4. Los pedidos LISTO de la ruta pasan a EN_RUTA; los cancelados mientras tanto se quedan igual
+	routeOrderIDs := []uuid.UUID{route.OrderID}
+	if stops, err := uc.stopRepo.FindByRouteID(route.ID); err == nil {
+		for _, stop := range stops {
+			if stop.OrderID != route.OrderID {
+				routeOrderIDs = append(routeOrderIDs, stop.OrderID)
+			}
+		}
+	}
+	orderIDs := make([]uuid.UUID, 0, len(routeOrderIDs))
+	for _, orderID := range routeOrderIDs {
+		order, err := uc.orderRepo.FindByID(orderID)
+		if err != nil || order.Status != domain.OrderListo {
+			continue
+		}
+		order.Status = domain.OrderEnRuta
+		if err := uc.orderRepo.Update(order); err != nil {
+			return nil, err
+		}
+		orderIDs = append(orderIDs, orderID)
+	}
+
+	// 5. Auditar
+	_ = uc.auditRepo.Log(domain.AuditLog{
+		UserID:     &input.UserID,
+		Action:     "START_ROUTE",
+		EntityType: "ROUTE",
+		EntityID:   &route.ID,
+		NewValues: map[string]interface{}{
+			"actual_departure": now,
+			"odometer_km":      input.OdometerKm,
+			"checklist_id":     checklist.ID,
+			"order_ids":        orderIDs,
+		},
+	})
+
+	return &StartRouteOutput{Route: route, Checklist: checklist, OrderIDs: orderIDs}, nil
+}
@@ -0,0 +1,252 @@
+package fleet
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sgl-disasur/api/internal/domain"
+	"github.com/sgl-disasur/api/internal/infrastructure/routing"
+)
+
+const (
+	// maxPositionBatch es el máximo de posiciones por envío (un dispositivo sin señal envía su acumulado)
+	maxPositionBatch = 500
+	// maxPositionClockSkew es la tolerancia para posiciones con hora adelantada del dispositivo
+	maxPositionClockSkew = 5 * time.Minute
+)
+
+// RecordPositionsUseCase recibe las posiciones GPS de una ruta EN_RUTA desde la app del chofer
+// o un dispositivo de telemetría y retorna el avance recalculado
+type RecordPositionsUseCase struct {
+	routeRepo    domain.RouteRepository
+	driverRepo   domain.DriverRepository
+	positionRepo domain.VehiclePositionRepository
+	tracking     *RouteTrackingUseCase
+}
+
+func NewRecordPositionsUseCase(
+	routeRepo domain.RouteRepository,
+	driverRepo domain.DriverRepository,
+	positionRepo domain.VehiclePositionRepository,
+	tracking *RouteTrackingUseCase,
+) *RecordPositionsUseCase {
+	return &RecordPositionsUseCase{
+		routeRepo:    routeRepo,
+		driverRepo:   driverRepo,
+		positionRepo: positionRepo,
+		tracking:     tracking,
+	}
+}
+
+type RecordPositionsInput struct {
+	Source    domain.PositionSource `json:"source,omitempty"` // TELEFONO (default) o TELEMATICA
+	Positions []PositionInput       `json:"positions"`
+	RouteID   uuid.UUID             `json:"-"`
+	UserID    uuid.UUID             `json:"-"`
+	Role      domain.UserRole       `json:"-"`
+}
+
+// PositionInput es una lectura GPS
+type PositionInput struct {
+	Latitude   float64  `json:"latitude"`
+	Longitude  float64  `json:"longitude"`
+	SpeedKmh   *float64 `json:"speed_kmh,omitempty"`
+	Heading    *float64 `json:"heading,omitempty"`
+	AccuracyM  *float64 `json:"accuracy_m,omitempty"`
+	RecordedAt string   `json:"recorded_at,omitempty"` // RFC3339; por defecto, la hora de recepción
+}
+
+func (uc *RecordPositionsUseCase) Execute(input RecordPositionsInput) (*RouteTracking, error) {
+	route, err := uc.routeRepo.FindByID(input.RouteID)
+	if err != nil {
+		return nil, errors.New("ruta no encontrada")
+	}
+	if route.Status != domain.OrderEnRuta {
+		return nil, fmt.Errorf("la ruta %s no está EN_RUTA (%s)", route.RouteNumber, route.Status)
+	}
+	// Un chofer solo reporta su propia ruta; los dispositivos usan un usuario de flota
+	driver, err := uc.driverRepo.FindByUserID(input.UserID)
+	switch {
+	case err == nil && driver.ID != route.DriverID:
+		return nil, fmt.Errorf("%w: la ruta %s está asignada a otro chofer", domain.ErrForbidden, route.RouteNumber)
+	case errors.Is(err, domain.ErrNotFound) && input.Role == domain.RoleChofer:
+		return nil, fmt.Errorf("%w: el usuario no tiene registro de chofer", domain.ErrForbidden)
+	case err != nil && !errors.Is(err, domain.ErrNotFound):
+		return nil, err
+	}
+
+	if input.Source == "" {
+		input.Source = domain.PositionTelefono
+	}
+	if input.Source != domain.PositionTelefono && input.Source != domain.PositionTelematica {
+		return nil, fmt.Errorf("source inválido: %s. Use: TELEFONO, TELEMATICA", input.Source)
+	}
+	if len(input.Positions) == 0 {
+		return nil, errors.New("positions es requerido")
+	}
+	if len(input.Positions) > maxPositionBatch {
+		return nil, fmt.Errorf("máximo %d posiciones por envío", maxPositionBatch)
+	}
+
+	now := time.Now()
+	positions := make([]*domain.VehiclePosition, 0, len(input.Positions))
+	for i, ping := range input.Positions {
+		if ping.Latitude < -90 || ping.Latitude > 90 || ping.Longitude < -180 || ping.Longitude > 180 {
+			return nil, fmt.Errorf("posición %d: coordenadas fuera de rango", i+1)
+		}
+		recordedAt := now
+		if ping.RecordedAt != "" {
+			if recordedAt, err = time.Parse(time.RFC3339, ping.RecordedAt); err != nil {
+				return nil, fmt.Errorf("posición %d: recorded_at inválida. Use RFC3339", i+1)
+			}
+			if recordedAt.After(now.Add(maxPositionClockSkew)) {
+				return nil, fmt.Errorf("posición %d: recorded_at en el futuro", i+1)
+			}
+		}
+		positions = append(positions, &domain.VehiclePosition{
+			RouteID:    route.ID,
+			VehicleID:  route.VehicleID,
+			Latitude:   ping.Latitude,
+			Longitude:  ping.Longitude,
+			SpeedKmh:   ping.SpeedKmh,
+			Heading:    ping.Heading,
+			AccuracyM:  ping.AccuracyM,
+			Source:     input.Source,
+			RecordedAt: recordedAt,
+		})
+	}
+
+	if err := uc.positionRepo.CreateBatch(positions); err != nil {
+		return nil, err
+	}
+
+	return uc.tracking.Execute(route.ID, 0)
+}
+
+// RouteTrackingUseCase calcula la posición actual, el recorrido y la ETA revisada de las rutas
+type RouteTrackingUseCase struct {
+	routeRepo    domain.RouteRepository
+	stopRepo     domain.RouteStopRepository
+	vehicleRepo  domain.VehicleRepository
+	positionRepo domain.VehiclePositionRepository
+	planner      *RoutePlanner
+}
+
+func NewRouteTrackingUseCase(
+	routeRepo domain.RouteRepository,
+	stopRepo domain.RouteStopRepository,
+	vehicleRepo domain.VehicleRepository,
+	positionRepo domain.VehiclePositionRepository,
+	planner *RoutePlanner,
+) *RouteTrackingUseCase {
+	return &RouteTrackingUseCase{
+		routeRepo:    routeRepo,
+		stopRepo:     stopRepo,
+		vehicleRepo:  vehicleRepo,
+		positionRepo: positionRepo,
+		planner:      planner,
+	}
+}
+
+// RouteTracking es el seguimiento de una ruta
+type RouteTracking struct {
+	Route              *domain.Route             `json:"route"`
+	PlateNumber        string                    `json:"plate_number"`
+	Position           *domain.VehiclePosition   `json:"position,omitempty"` // Nil si aún no reporta
+	PositionAgeMinutes int                       `json:"position_age_minutes"`
+	Progress           *RouteProgress            `json:"progress,omitempty"`
+	Trail              []*domain.VehiclePosition `json:"trail,omitempty"`
+}
+
+// Execute retorna el seguimiento de la ruta con las últimas trailLimit posiciones (0 = sin recorrido)
+func (uc *RouteTrackingUseCase) Execute(routeID uuid.UUID, trailLimit int) (*RouteTracking, error) {
+	route, err := uc.routeRepo.FindByID(routeID)
+	if err != nil {
+		return nil, errors.New("ruta no encontrada")
+	}
+
+	limit := trailLimit
+	if limit <= 0 {
+		limit = 1
+	}
+	positions, err := uc.positionRepo.FindByRoute(route.ID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var latest *domain.VehiclePosition
+	if len(positions) > 0 {
+		latest = positions[len(positions)-1]
+	}
+	tracking, err := uc.build(route, latest)
+	if err != nil {
+		return nil, err
+	}
+	if trailLimit > 0 {
+		tracking.Trail = positions
+	}
+	return tracking, nil
+}
+
+// FleetPositions retorna la última posición y ETA de todos los vehículos EN_RUTA, los más retrasados primero
+func (uc *RouteTrackingUseCase) FleetPositions() ([]*RouteTracking, error) {
+	routes, err := uc.routeRepo.ListByStatus(domain.OrderEnRuta)
+	if err != nil {
+		return nil, err
+	}
+	latest, err := uc.positionRepo.LatestEnRuta()
+	if err != nil {
+		return nil, err
+	}
+
+	byRoute := make(map[uuid.UUID]*domain.VehiclePosition, len(latest))
+	for _, position := range latest {
+		byRoute[position.RouteID] = position
+	}
+
+	trackings := make([]*RouteTracking, 0, len(routes))
+	for _, route := range routes {
+		tracking, err := uc.build(route, byRoute[route.ID])
+		if err != nil {
+			return nil, err
+		}
+		trackings = append(trackings, tracking)
+	}
+
+	sort.SliceStable(trackings, func(i, j int) bool {
+		return delayOf(trackings[i]) > delayOf(trackings[j])
+	})
+	return trackings, nil
+}
+
+func (uc *RouteTrackingUseCase) build(route *domain.Route, position *domain.VehiclePosition) (*RouteTracking, error) {
+	tracking := &RouteTracking{Route: route, Position: position}
+	if vehicle, err := uc.vehicleRepo.FindByID(route.VehicleID); err == nil {
+		tracking.PlateNumber = vehicle.PlateNumber
+	}
+	if position == nil {
+		return tracking, nil
+	}
+
+	now := time.Now()
+	tracking.PositionAgeMinutes = int(now.Sub(position.RecordedAt).Minutes())
+
+	stops, err := uc.stopRepo.FindByRouteID(route.ID)
+	if err != nil {
+		return nil, err
+	}
+	// La ETA parte de ahora: si la última posición es vieja, el vehículo no ha avanzado desde entonces
+	point := routing.Point{Lat: position.Latitude, Lng: position.Longitude}
+	tracking.Progress = uc.planner.Progress(route, stops, point, now)
+	return tracking, nil
+}
+
+func delayOf(tracking *RouteTracking) int {
+	if tracking.Progress == nil {
+		return 0
+	}
+	return tracking.Progress.DelayMinutes
+}
@@ -2,6 +2,7 @@ package inventory
 
 import (
 	"errors"
+	"fmt"
 	"math/rand"
 	"time"
 
@@ -15,6 +16,7 @@ type PerformCycleCountUseCase struct {
 	inventoryRepo  domain.InventoryRepository
 	movementRepo   domain.InventoryMovementRepository
 	productRepo    domain.ProductRepository
+	packagingRepo  domain.ProductPackagingRepository
 	auditRepo      domain.AuditRepository
 }
 
@@ -23,6 +25,7 @@ func NewPerformCycleCountUseCase(
 	inventoryRepo domain.InventoryRepository,
 	movementRepo domain.InventoryMovementRepository,
 	productRepo domain.ProductRepository,
+	packagingRepo domain.ProductPackagingRepository,
 	auditRepo domain.AuditRepository,
 ) *PerformCycleCountUseCase {
 	return &PerformCycleCountUseCase{
@@ -30,6 +33,7 @@ func NewPerformCycleCountUseCase(
 		inventoryRepo:  inventoryRepo,
 		movementRepo:   movementRepo,
 		productRepo:    productRepo,
+		packagingRepo:  packagingRepo,
 		auditRepo:      auditRepo,
 	}
 }
@@ -86,9 +90,11 @@ func (uc *PerformCycleCountUseCase) GenerateDailyCycleCounts() ([]*domain.CycleC
 }
 
 type PerformCountInput struct {
-	CountID         uuid.UUID `json:"count_id"`
-	CountedQuantity int       `json:"counted_quantity"`
-	UserID          uuid.UUID `json:"-"`
+	CountID         uuid.UUID            `json:"count_id"`
+	CountedQuantity int                  `json:"counted_quantity"`
+	Unit            domain.UnitOfMeasure `json:"unit,omitempty"` // PIEZA (default), CAJA o TARIMA
+	UserID          uuid.UUID            `json:"-"`
+	Scope           domain.DataScope     `json:"-"` // Alcance del usuario
 }
 
 // PerformCount registra el conteo y ajusta inventario si hay varianza
@@ -103,9 +109,36 @@ func (uc *PerformCycleCountUseCase) PerformCount(input PerformCountInput) error
 		return errors.New("el conteo ya fue realizado")
 	}
 
-	// 2. Registrar el conteo
+	// 2. Solo se cuentan productos de sus marcas y con lotes en sus almacenes; el ajuste usa esos lotes
+	product, err := uc.productRepo.FindByID(count.ProductID)
+	if err != nil {
+		return errors.New("producto no encontrado")
+	}
+	if !input.Scope.AllowsBrand(product.Brand) {
+		return fmt.Errorf("%w: la marca %s no está asignada al usuario", domain.ErrForbidden, product.Brand)
+	}
+	inventories, err := uc.inventoryRepo.FindByProduct(count.ProductID, input.Scope)
+	if err != nil {
+		return err
+	}
+	if input.Scope.ByWarehouse() && len(inventories) == 0 {
+		return fmt.Errorf("%w: el producto %s no tiene lotes en sus almacenes", domain.ErrForbidden, product.SKU)
+	}
+
+	// 3. Convertir el conteo a piezas
+	counted := input.CountedQuantity
+	if input.Unit != "" && input.Unit != domain.UnitPieza {
+		levels, _ := uc.packagingRepo.FindByProduct(product.ID)
+		packaging, err := product.FindPackaging(levels, input.Unit)
+		if err != nil {
+			return err
+		}
+		counted *= packaging.UnitsPerPackage
+	}
+
+	// 4. Registrar el conteo
 	now := time.Now()
-	count.CountedQuantity = &input.CountedQuantity
+	count.CountedQuantity = &counted
 	count.CountedBy = &input.UserID
 	count.CountedAt = &now
 	count.Status = "COMPLETADO"
@@ -114,16 +147,13 @@ func (uc *PerformCycleCountUseCase) PerformCount(input PerformCountInput) error
 		return err
 	}
 
-	// 3. Si hay varianza, crear ajuste de inventario
+	// 5. Si hay varianza, crear ajuste de inventario
 	if count.Variance != nil && *count.Variance != 0 {
-		// Obtener inventarios del producto
-		inventories, _ := uc.inventoryRepo.FindByProduct(count.ProductID)
-
 		if len(inventories) > 0 {
 			// Ajustar el primer inventario disponible (simplificado)
 			inventory := inventories[0]
 			previousQty := inventory.Quantity
-			inventory.Quantity = input.CountedQuantity
+			inventory.Quantity = counted
 
 			movement := &domain.InventoryMovement{
 				InventoryID:      inventory.ID,
@@ -142,7 +172,7 @@ func (uc *PerformCycleCountUseCase) PerformCount(input PerformCountInput) error
 		}
 	}
 
-	// 4. Auditar
+	// 6. Auditar
 	_ = uc.auditRepo.Log(domain.AuditLog{
 		UserID:     &input.UserID,
 		Action:     "CYCLE_COUNT",
@@ -150,7 +180,7 @@ func (uc *PerformCycleCountUseCase) PerformCount(input PerformCountInput) error
 		EntityID:   &count.ID,
 		NewValues: map[string]interface{}{
 			"expected": count.ExpectedQuantity,
-			"counted":  input.CountedQuantity,
+			"counted":  counted,
 			"variance": count.Variance,
 		},
 	})
@@ -35,7 +35,14 @@ type StockItem struct {
 	Lots              []*domain.Inventory `json:"lots,omitempty"`
 }
 
-func (uc *GetStockUseCase) Execute(brand *domain.Brand, category *string) ([]*StockItem, error) {
+func (uc *GetStockUseCase) Execute(brand *domain.Brand, category *string, scope domain.DataScope) ([]*StockItem, error) {
+	return uc.ExecutePage(brand, category, scope, 100, 0)
+}
+
+// ExecutePage obtiene el stock de una página de productos (usado por las exportaciones).
+// El alcance se aplica en la consulta paginada: solo productos de las marcas del usuario con lotes
+// en sus almacenes, y de cada uno solo esos lotes.
+func (uc *GetStockUseCase) ExecutePage(brand *domain.Brand, category *string, scope domain.DataScope, limit, offset int) ([]*StockItem, error) {
 	// Obtener productos filtrados
 	filters := make(map[string]interface{})
 	if brand != nil {
@@ -45,7 +52,7 @@ func (uc *GetStockUseCase) Execute(brand *domain.Brand, category *string) ([]*St
 		filters["category"] = *category
 	}
 
-	products, err := uc.productRepo.List(filters, 100, 0)
+	products, err := uc.productRepo.ListInScope(filters, scope, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -53,7 +60,7 @@ func (uc *GetStockUseCase) Execute(brand *domain.Brand, category *string) ([]*St
 	var stockItems []*StockItem
 	for _, product := range products {
 		// Obtener inventario del producto
-		lots, _ := uc.inventoryRepo.FindByProduct(product.ID)
+		lots, _ := uc.inventoryRepo.FindByProduct(product.ID, scope)
 
 		totalStock := 0
 		availableStock := 0
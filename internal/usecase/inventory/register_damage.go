@@ -2,6 +2,7 @@ package inventory
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -28,11 +29,12 @@ func NewRegisterDamageUseCase(
 }
 
 type RegisterDamageInput struct {
-	InventoryID      uuid.UUID `json:"inventory_id" binding:"required"`
-	Quantity         int       `json:"quantity" binding:"required,min=1"`
-	Reason           string    `json:"reason" binding:"required"`
-	EvidencePhotoURL string    `json:"evidence_photo_url" binding:"required"` // HU-13: OBLIGATORIO
-	UserID           uuid.UUID `json:"-"`
+	InventoryID      uuid.UUID        `json:"inventory_id" binding:"required"`
+	Quantity         int              `json:"quantity" binding:"required,min=1"`
+	Reason           string           `json:"reason" binding:"required"`
+	EvidencePhotoURL string           `json:"evidence_photo_url" binding:"required"` // HU-13: OBLIGATORIO
+	UserID           uuid.UUID        `json:"-"`
+	Scope            domain.DataScope `json:"-"` // Alcance del usuario
 }
 
 type RegisterDamageOutput struct {
@@ -52,6 +54,13 @@ func (uc *RegisterDamageUseCase) Execute(input RegisterDamageInput) (*RegisterDa
 	if err != nil {
 		return nil, errors.New("inventario no encontrado")
 	}
+	visible, err := uc.inventoryRepo.Visible(inventory.ID, input.Scope)
+	if err != nil {
+		return nil, err
+	}
+	if !visible {
+		return nil, fmt.Errorf("%w: el lote %s está fuera de sus almacenes o marcas", domain.ErrForbidden, inventory.LotNumber)
+	}
 
 	// Validar cantidad
 	if inventory.Quantity < input.Quantity {
@@ -117,9 +126,9 @@ type FEFOLot struct {
 	ExpirationAlert   string     `json:"expiration_alert,omitempty"`
 }
 
-func (uc *GetFEFOLotsUseCase) Execute(productID uuid.UUID) ([]*FEFOLot, error) {
-	// Obtener lotes ordenados por FEFO
-	lots, err := uc.inventoryRepo.FindByProductFEFO(productID)
+func (uc *GetFEFOLotsUseCase) Execute(productID uuid.UUID, scope domain.DataScope) ([]*FEFOLot, error) {
+	// Obtener lotes ordenados por FEFO (solo los almacenes y marcas del usuario)
+	lots, err := uc.inventoryRepo.FindByProductFEFO(productID, scope)
 	if err != nil {
 		return nil, err
 	}
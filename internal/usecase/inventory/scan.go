@@ -0,0 +1,299 @@
+package inventory
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sgl-disasur/api/internal/domain"
+	"github.com/sgl-disasur/api/internal/infrastructure/barcode"
+)
+
+// ScanTask indica la tarea que el auxiliar está realizando con el lector
+type ScanTask string
+
+const (
+	ScanTaskBlindCount ScanTask = "BLIND_COUNT"
+	ScanTaskCycleCount ScanTask = "CYCLE_COUNT"
+	ScanTaskPicking    ScanTask = "PICKING"
+)
+
+// ScanUseCase resuelve lecturas de escáner (EAN/UPC/GS1-128) a producto, lote y tarea en curso
+type ScanUseCase struct {
+	productRepo        domain.ProductRepository
+	inventoryRepo      domain.InventoryRepository
+	receptionOrderRepo domain.ReceptionOrderRepository
+	receptionLineRepo  domain.ReceptionLineRepository
+	cycleCountRepo     domain.CycleCountRepository
+	orderRepo          domain.OrderRepository
+	orderLineRepo      domain.OrderLineRepository
+}
+
+func NewScanUseCase(
+	productRepo domain.ProductRepository,
+	inventoryRepo domain.InventoryRepository,
+	receptionOrderRepo domain.ReceptionOrderRepository,
+	receptionLineRepo domain.ReceptionLineRepository,
+	cycleCountRepo domain.CycleCountRepository,
+	orderRepo domain.OrderRepository,
+	orderLineRepo domain.OrderLineRepository,
+) *ScanUseCase {
+	return &ScanUseCase{
+		productRepo:        productRepo,
+		inventoryRepo:      inventoryRepo,
+		receptionOrderRepo: receptionOrderRepo,
+		receptionLineRepo:  receptionLineRepo,
+		cycleCountRepo:     cycleCountRepo,
+		orderRepo:          orderRepo,
+		orderLineRepo:      orderLineRepo,
+	}
+}
+
+type ScanInput struct {
+	Code        string           `json:"code" binding:"required"` // Lectura cruda del escáner
+	Task        ScanTask         `json:"task,omitempty"`          // BLIND_COUNT, CYCLE_COUNT o PICKING
+	ReferenceID *uuid.UUID       `json:"reference_id,omitempty"`  // Orden de recepción, conteo cíclico o pedido
+	Scope       domain.DataScope `json:"-"`                       // Alcance del usuario
+}
+
+// ScanBlindCountLine es la línea de recepción a contar (sin cantidad esperada, conteo ciego)
+type ScanBlindCountLine struct {
+	LineID          uuid.UUID `json:"line_id"`
+	LotNumber       string    `json:"lot_number,omitempty"`
+	CountedQuantity *int      `json:"counted_quantity,omitempty"`
+}
+
+// ScanCycleCount es el conteo cíclico pendiente del producto (sin cantidad esperada)
+type ScanCycleCount struct {
+	CountID  uuid.UUID `json:"count_id"`
+	Location string    `json:"location,omitempty"`
+	Status   string    `json:"status"`
+}
+
+// ScanPickingLine es la línea del pedido a surtir con el lote asignado por FEFO
+type ScanPickingLine struct {
+	LineID      uuid.UUID  `json:"line_id"`
+	Quantity    int        `json:"quantity"`
+	InventoryID *uuid.UUID `json:"inventory_id,omitempty"`
+	LotMatches  *bool      `json:"lot_matches,omitempty"` // El lote leído es el asignado
+}
+
+type ScanOutput struct {
+	Code           *barcode.Code       `json:"code"`
+	Product        *domain.Product     `json:"product"`
+	Lot            *domain.Inventory   `json:"lot,omitempty"`
+	Task           ScanTask            `json:"task,omitempty"`
+	BlindCountLine *ScanBlindCountLine `json:"blind_count_line,omitempty"`
+	CycleCount     *ScanCycleCount     `json:"cycle_count,omitempty"`
+	PickingLine    *ScanPickingLine    `json:"picking_line,omitempty"`
+	Warnings       []string            `json:"warnings"`
+}
+
+func (uc *ScanUseCase) Execute(input ScanInput) (*ScanOutput, error) {
+	// 1. Interpretar la lectura
+	code, err := barcode.Parse(input.Code)
+	if errors.Is(err, barcode.ErrCheckDigit) {
+		// Un SKU numérico de 8, 12, 13 o 14 dígitos parece un GTIN; solo es error si tampoco es un SKU
+		if _, skuErr := uc.productRepo.FindBySKU(strings.TrimSpace(input.Code)); skuErr == nil {
+			code, err = &barcode.Code{Raw: input.Code, Symbology: barcode.SymbologyOther}, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Resolver producto por código de barras (o SKU si es un código interno)
+	product, err := uc.resolveProduct(code)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &ScanOutput{Code: code, Product: product, Task: input.Task, Warnings: []string{}}
+	if !product.IsActive {
+		output.Warnings = append(output.Warnings, "producto inactivo")
+	}
+
+	// 3. Resolver lote (solo entre los de sus almacenes y marcas)
+	if code.LotNumber != "" {
+		lots, _ := uc.inventoryRepo.FindByProduct(product.ID, input.Scope)
+		for _, lot := range lots {
+			if strings.EqualFold(lot.LotNumber, code.LotNumber) {
+				output.Lot = lot
+				break
+			}
+		}
+		if output.Lot == nil {
+			output.Warnings = append(output.Warnings, fmt.Sprintf("lote %s sin existencias registradas", code.LotNumber))
+		} else if output.Lot.IsExpired() {
+			output.Warnings = append(output.Warnings, fmt.Sprintf("lote %s caducado", code.LotNumber))
+		}
+	}
+
+	// 4. Contexto de la tarea en curso
+	switch input.Task {
+	case "":
+		return output, nil
+	case ScanTaskBlindCount:
+		err = uc.blindCountContext(output, input.ReferenceID, input.Scope)
+	case ScanTaskCycleCount:
+		err = uc.cycleCountContext(output, input.ReferenceID)
+	case ScanTaskPicking:
+		err = uc.pickingContext(output, input.ReferenceID, input.Scope)
+	default:
+		return nil, errors.New("tarea inválida. Use: BLIND_COUNT, CYCLE_COUNT, PICKING")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Los conteos son ciegos: no se muestra la existencia registrada del lote
+	if output.Lot != nil && (input.Task == ScanTaskBlindCount || input.Task == ScanTaskCycleCount) {
+		lot := *output.Lot
+		lot.Quantity = 0
+		output.Lot = &lot
+	}
+
+	return output, nil
+}
+
+func (uc *ScanUseCase) resolveProduct(code *barcode.Code) (*domain.Product, error) {
+	for _, candidate := range code.Candidates() {
+		if product, err := uc.productRepo.FindByBarcode(candidate); err == nil {
+			return product, nil
+		}
+	}
+	// Un SKU interno puede tener forma de EAN/UPC válido: si ningún código de barras coincide, se busca como SKU
+	if product, err := uc.productRepo.FindBySKU(strings.TrimSpace(code.Raw)); err == nil {
+		return product, nil
+	}
+	return nil, errors.New("producto no encontrado para el código leído")
+}
+
+// blindCountContext ubica la línea de la orden de recepción del producto leído
+func (uc *ScanUseCase) blindCountContext(output *ScanOutput, referenceID *uuid.UUID, scope domain.DataScope) error {
+	if referenceID == nil {
+		return errors.New("reference_id (orden de recepción) es obligatorio")
+	}
+	visible, err := uc.receptionOrderRepo.Visible(*referenceID, scope)
+	if err != nil {
+		return err
+	}
+	if !visible {
+		return fmt.Errorf("%w: la orden de recepción está fuera de sus almacenes o marcas", domain.ErrForbidden)
+	}
+	lines, err := uc.receptionLineRepo.FindByOrderID(*referenceID)
+	if err != nil {
+		return errors.New("orden de recepción no encontrada")
+	}
+
+	var match *domain.ReceptionLine
+	for _, line := range lines {
+		if line.ProductID != output.Product.ID {
+			continue
+		}
+		// Si la orden trae el lote, preferir la línea de ese lote
+		if match == nil || (output.Code.LotNumber != "" && strings.EqualFold(line.LotNumber, output.Code.LotNumber)) {
+			match = line
+		}
+	}
+	if match == nil {
+		return errors.New("el producto no pertenece a esta orden de recepción")
+	}
+
+	output.BlindCountLine = &ScanBlindCountLine{
+		LineID:          match.ID,
+		LotNumber:       match.LotNumber,
+		CountedQuantity: match.CountedQuantity,
+	}
+	if match.CountedQuantity != nil {
+		output.Warnings = append(output.Warnings, "la línea ya fue contada")
+	}
+	return nil
+}
+
+// cycleCountContext ubica el conteo cíclico pendiente del producto
+func (uc *ScanUseCase) cycleCountContext(output *ScanOutput, referenceID *uuid.UUID) error {
+	var count *domain.CycleCount
+	if referenceID != nil {
+		found, err := uc.cycleCountRepo.FindByID(*referenceID)
+		if err != nil {
+			return errors.New("conteo cíclico no encontrado")
+		}
+		if found.ProductID != output.Product.ID {
+			return errors.New("el producto leído no corresponde al conteo cíclico")
+		}
+		count = found
+	} else {
+		pending, err := uc.cycleCountRepo.ListPending(100, 0)
+		if err != nil {
+			return err
+		}
+		for _, c := range pending {
+			if c.ProductID == output.Product.ID {
+				count = c
+				break
+			}
+		}
+		if count == nil {
+			return errors.New("no hay conteo cíclico pendiente para este producto")
+		}
+	}
+
+	output.CycleCount = &ScanCycleCount{
+		CountID:  count.ID,
+		Location: count.Location,
+		Status:   count.Status,
+	}
+	if count.Status != "PENDIENTE" {
+		output.Warnings = append(output.Warnings, "el conteo ya fue realizado")
+	}
+	return nil
+}
+
+// pickingContext ubica la línea del pedido y verifica que el lote leído sea el asignado por FEFO
+func (uc *ScanUseCase) pickingContext(output *ScanOutput, referenceID *uuid.UUID, scope domain.DataScope) error {
+	if referenceID == nil {
+		return errors.New("reference_id (pedido) es obligatorio")
+	}
+	visible, err := uc.orderRepo.Visible(*referenceID, scope)
+	if err != nil {
+		return err
+	}
+	if !visible {
+		return fmt.Errorf("%w: el pedido está fuera de su alcance", domain.ErrForbidden)
+	}
+	lines, err := uc.orderLineRepo.FindByOrderID(*referenceID)
+	if err != nil {
+		return errors.New("pedido no encontrado")
+	}
+
+	var match *domain.OrderLine
+	for _, line := range lines {
+		if line.ProductID == output.Product.ID {
+			match = line
+			break
+		}
+	}
+	if match == nil {
+		return errors.New("el producto no pertenece a este pedido")
+	}
+
+	picking := &ScanPickingLine{
+		LineID:      match.ID,
+		Quantity:    match.Quantity,
+		InventoryID: match.InventoryID,
+	}
+	if output.Lot != nil && match.InventoryID != nil {
+		matches := output.Lot.ID == *match.InventoryID
+		picking.LotMatches = &matches
+		if !matches {
+			output.Warnings = append(output.Warnings, "el lote leído no es el asignado por FEFO")
+		}
+	}
+	if output.Code.Quantity != nil && *output.Code.Quantity > match.Quantity {
+		output.Warnings = append(output.Warnings, fmt.Sprintf("la cantidad leída (%d) excede la del pedido (%d)", *output.Code.Quantity, match.Quantity))
+	}
+	output.PickingLine = picking
+	return nil
+}
@@ -0,0 +1,53 @@
+package inventory
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sgl-disasur/api/internal/domain"
+)
+
+// CreateWarehouseUseCase da de alta un almacén para asignarlo a lotes, recepciones y usuarios
+type CreateWarehouseUseCase struct {
+	warehouseRepo domain.WarehouseRepository
+	auditRepo     domain.AuditRepository
+}
+
+func NewCreateWarehouseUseCase(warehouseRepo domain.WarehouseRepository, auditRepo domain.AuditRepository) *CreateWarehouseUseCase {
+	return &CreateWarehouseUseCase{
+		warehouseRepo: warehouseRepo,
+		auditRepo:     auditRepo,
+	}
+}
+
+type CreateWarehouseInput struct {
+	Code      string    `json:"code" binding:"required"`
+	Name      string    `json:"name" binding:"required"`
+	UserID    uuid.UUID `json:"-"`
+	IPAddress string    `json:"-"`
+}
+
+func (uc *CreateWarehouseUseCase) Execute(input CreateWarehouseInput) (*domain.Warehouse, error) {
+	code := strings.ToUpper(strings.TrimSpace(input.Code))
+	name := strings.TrimSpace(input.Name)
+	if code == "" || name == "" {
+		return nil, errors.New("código y nombre son obligatorios")
+	}
+
+	warehouse := &domain.Warehouse{Code: code, Name: name, IsActive: true}
+	if err := uc.warehouseRepo.Create(warehouse); err != nil {
+		return nil, err
+	}
+
+	_ = uc.auditRepo.Log(domain.AuditLog{
+		UserID:     &input.UserID,
+		Action:     "CREATE_WAREHOUSE",
+		EntityType: "WAREHOUSE",
+		EntityID:   &warehouse.ID,
+		NewValues:  map[string]interface{}{"code": warehouse.Code, "name": warehouse.Name},
+		IPAddress:  input.IPAddress,
+	})
+
+	return warehouse, nil
+}
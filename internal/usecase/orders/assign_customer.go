@@ -0,0 +1,74 @@
+package orders
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/sgl-disasur/api/internal/domain"
+)
+
+// AssignCustomerUseCase asigna o reasigna el vendedor que atiende a un cliente.
+// El vendedor asignado es quien ve al cliente y sus pedidos.
+type AssignCustomerUseCase struct {
+	customerRepo domain.CustomerRepository
+	userRepo     domain.UserRepository
+	auditRepo    domain.AuditRepository
+}
+
+func NewAssignCustomerUseCase(
+	customerRepo domain.CustomerRepository,
+	userRepo domain.UserRepository,
+	auditRepo domain.AuditRepository,
+) *AssignCustomerUseCase {
+	return &AssignCustomerUseCase{
+		customerRepo: customerRepo,
+		userRepo:     userRepo,
+		auditRepo:    auditRepo,
+	}
+}
+
+type AssignCustomerInput struct {
+	AssignedTo *uuid.UUID `json:"assigned_to"` // null quita la asignación
+	CustomerID uuid.UUID  `json:"-"`
+	UserID     uuid.UUID  `json:"-"`
+	IPAddress  string     `json:"-"`
+}
+
+func (uc *AssignCustomerUseCase) Execute(input AssignCustomerInput) (*domain.Customer, error) {
+	customer, err := uc.customerRepo.FindByID(input.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.AssignedTo != nil {
+		seller, err := uc.userRepo.FindByID(*input.AssignedTo)
+		if err != nil {
+			return nil, err
+		}
+		if seller.Role != domain.RoleVendedor {
+			return nil, fmt.Errorf("el usuario %s no es VENDEDOR (%s)", seller.Username, seller.Role)
+		}
+		if seller.Status == domain.UserStatusInactivo {
+			return nil, errors.New("el vendedor está inactivo")
+		}
+	}
+
+	previous := customer.AssignedTo
+	customer.AssignedTo = input.AssignedTo
+	if err := uc.customerRepo.Update(customer); err != nil {
+		return nil, err
+	}
+
+	_ = uc.auditRepo.Log(domain.AuditLog{
+		UserID:     &input.UserID,
+		Action:     "ASSIGN_CUSTOMER",
+		EntityType: "CUSTOMER",
+		EntityID:   &customer.ID,
+		OldValues:  map[string]interface{}{"assigned_to": previous},
+		NewValues:  map[string]interface{}{"assigned_to": customer.AssignedTo},
+		IPAddress:  input.IPAddress,
+	})
+
+	return customer, nil
+}
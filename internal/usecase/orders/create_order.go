@@ -3,6 +3,7 @@ package orders
 import (
 	"errors"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,7 +16,10 @@ type CreateOrderUseCase struct {
 	orderLineRepo domain.OrderLineRepository
 	customerRepo  domain.CustomerRepository
 	productRepo   domain.ProductRepository
+	priceRepo     domain.ProductPriceRepository
+	packagingRepo domain.ProductPackagingRepository
 	inventoryRepo domain.InventoryRepository
+	vehicleRepo   domain.VehicleRepository
 	auditRepo     domain.AuditRepository
 }
 
@@ -24,7 +28,10 @@ func NewCreateOrderUseCase(
 	orderLineRepo domain.OrderLineRepository,
 	customerRepo domain.CustomerRepository,
 	productRepo domain.ProductRepository,
+	priceRepo domain.ProductPriceRepository,
+	packagingRepo domain.ProductPackagingRepository,
 	inventoryRepo domain.InventoryRepository,
+	vehicleRepo domain.VehicleRepository,
 	auditRepo domain.AuditRepository,
 ) *CreateOrderUseCase {
 	return &CreateOrderUseCase{
@@ -32,29 +39,36 @@ func NewCreateOrderUseCase(
 		orderLineRepo: orderLineRepo,
 		customerRepo:  customerRepo,
 		productRepo:   productRepo,
+		priceRepo:     priceRepo,
+		packagingRepo: packagingRepo,
 		inventoryRepo: inventoryRepo,
+		vehicleRepo:   vehicleRepo,
 		auditRepo:     auditRepo,
 	}
 }
 
 type OrderLineInput struct {
-	ProductID uuid.UUID `json:"product_id"`
-	Quantity  int       `json:"quantity"`
+	ProductID uuid.UUID            `json:"product_id"`
+	Quantity  int                  `json:"quantity"`
+	Unit      domain.UnitOfMeasure `json:"unit,omitempty"` // PIEZA (default), CAJA o TARIMA
 }
 
 type CreateOrderInput struct {
 	CustomerID uuid.UUID        `json:"customer_id"`
 	Lines      []OrderLineInput `json:"lines"`
 	UserID     uuid.UUID        `json:"-"`
+	Scope      domain.DataScope `json:"-"` // Alcance del usuario
 }
 
 type CreateOrderOutput struct {
-	Order             *domain.Order       `json:"order"`
-	Lines             []*domain.OrderLine `json:"lines"`
-	SuggestedVehicle  domain.VehicleType  `json:"suggested_vehicle"`
-	LoadingAlert      string              `json:"loading_alert,omitempty"`
-	LoadingEfficiency float64             `json:"loading_efficiency"` // HU-18
-	BrandsMixed       []domain.Brand      `json:"brands_mixed"`       // HU-07
+	Order              *domain.Order          `json:"order"`
+	Lines              []*domain.OrderLine    `json:"lines"`
+	SuggestedVehicle   domain.VehicleType     `json:"suggested_vehicle"`
+	SuggestedVehicleID *uuid.UUID             `json:"suggested_vehicle_id,omitempty"` // Vehículo de la flota donde cabe
+	LoadingAlert       string                 `json:"loading_alert,omitempty"`
+	LoadingEfficiency  float64                `json:"loading_efficiency"` // HU-18: la mayor entre peso y volumen
+	Utilization        domain.LoadUtilization `json:"utilization"`        // HU-18
+	BrandsMixed        []domain.Brand         `json:"brands_mixed"`       // HU-07
 }
 
 func (uc *CreateOrderUseCase) Execute(input CreateOrderInput) (*CreateOrderOutput, error) {
@@ -67,9 +81,14 @@ func (uc *CreateOrderUseCase) Execute(input CreateOrderInput) (*CreateOrderOutpu
 	if !customer.IsActive {
 		return nil, errors.New("cliente inactivo")
 	}
+	// El vendedor solo captura pedidos de los clientes que tiene asignados
+	if input.Scope.OwnCustomersOnly() && (customer.AssignedTo == nil || *customer.AssignedTo != input.Scope.UserID) {
+		return nil, fmt.Errorf("%w: el cliente %s no está asignado al vendedor", domain.ErrForbidden, customer.Name)
+	}
 
 	// 2. Generar número de pedido
-	orderNumber := fmt.Sprintf("ORD-%s-%d", time.Now().Format("20060102"), time.Now().Unix()%10000)
+	now := time.Now()
+	orderNumber := fmt.Sprintf("ORD-%s-%d", now.Format("20060102"), now.Unix()%10000)
 
 	// 3. Procesar líneas y calcular métricas
 	var orderLines []*domain.OrderLine
@@ -86,14 +105,32 @@ func (uc *CreateOrderUseCase) Execute(input CreateOrderInput) (*CreateOrderOutpu
 		if err != nil {
 			return nil, fmt.Errorf("producto %s no encontrado", lineInput.ProductID)
 		}
+		if !product.IsActive {
+			return nil, fmt.Errorf("producto %s inactivo", product.SKU)
+		}
+
+		// Precio vigente a la fecha del pedido (historial de precios)
+		unitPrice := product.UnitPrice
+		if price, err := uc.priceRepo.FindEffective(product.ID, now); err == nil {
+			unitPrice = price.UnitPrice
+		}
+
+		// Convertir a piezas según el nivel de empaque
+		levels, _ := uc.packagingRepo.FindByProduct(product.ID)
+		packaging, err := product.FindPackaging(levels, lineInput.Unit)
+		if err != nil {
+			return nil, err
+		}
+		packaged := domain.PackagedQuantity{Product: product, Packaging: packaging, Quantity: lineInput.Quantity}
+		quantity := packaged.BaseUnits()
 
 		// HU-07: Detectar mezcla de marcas
 		brandMap[product.Brand] = true
 
-		// Calcular pesos y volúmenes
-		lineWeight := product.WeightKg * float64(lineInput.Quantity)
-		lineVolume := product.CalculateVolume() * float64(lineInput.Quantity)
-		subtotal := product.UnitPrice * float64(lineInput.Quantity)
+		// Calcular pesos y volúmenes (con dimensiones de caja/tarima si están capturadas)
+		lineWeight := packaged.WeightKg()
+		lineVolume := packaged.VolumeM3()
+		subtotal := unitPrice * float64(quantity)
 
 		totalWeightKg += lineWeight
 		totalVolumeM3 += lineVolume
@@ -108,9 +145,9 @@ func (uc *CreateOrderUseCase) Execute(input CreateOrderInput) (*CreateOrderOutpu
 		}
 
 		// Reservar inventario FEFO
-		lots, _ := uc.inventoryRepo.FindByProductFEFO(product.ID)
+		lots, _ := uc.inventoryRepo.FindByProductFEFO(product.ID, domain.FullScope())
 		var inventoryID *uuid.UUID
-		remainingQty := lineInput.Quantity
+		remainingQty := quantity
 
 		for _, lot := range lots {
 			if lot.Quantity >= remainingQty {
@@ -122,8 +159,8 @@ func (uc *CreateOrderUseCase) Execute(input CreateOrderInput) (*CreateOrderOutpu
 		orderLine := &domain.OrderLine{
 			ProductID:   product.ID,
 			InventoryID: inventoryID,
-			Quantity:    lineInput.Quantity,
-			UnitPrice:   product.UnitPrice,
+			Quantity:    quantity,
+			UnitPrice:   unitPrice,
 			Subtotal:    subtotal,
 		}
 		orderLines = append(orderLines, orderLine)
@@ -148,12 +185,30 @@ func (uc *CreateOrderUseCase) Execute(input CreateOrderInput) (*CreateOrderOutpu
 		CreatedBy:       input.UserID,
 	}
 
-	// HU-08: Sugerencia automática de vehículo
+	// HU-08: Sugerencia automática de vehículo por peso y volumen contra la flota registrada
+	vehicle := uc.suggestFleetVehicle(order)
+	var suggestedVehicleID *uuid.UUID
+	var capacity domain.VehicleCapacity
 	suggestedVehicle := order.SuggestVehicle()
+	if vehicle != nil {
+		suggestedVehicle = vehicle.VehicleType
+		suggestedVehicleID = &vehicle.ID
+		capacity = vehicle.Capacity()
+	} else {
+		capacity = domain.ReferenceCapacity(suggestedVehicle)
+	}
 	order.SuggestedVehicle = &suggestedVehicle
 
 	// HU-09: Generar alerta de estiba
 	loadingAlert := order.GenerateLoadingAlert()
+	if !capacity.Fits(totalWeightKg, totalVolumeM3) {
+		capacityAlert := "ADVERTENCIA: El pedido excede la capacidad del vehículo más grande. Dividir en varias rutas."
+		if loadingAlert != "" {
+			loadingAlert += " " + capacityAlert
+		} else {
+			loadingAlert = capacityAlert
+		}
+	}
 	order.LoadingAlert = loadingAlert
 
 	if err := uc.orderRepo.Create(order); err != nil {
@@ -168,20 +223,9 @@ func (uc *CreateOrderUseCase) Execute(input CreateOrderInput) (*CreateOrderOutpu
 		return nil, err
 	}
 
-	// HU-18: Calcular eficiencia de carga
-	var vehicleCapacity float64
-	switch suggestedVehicle {
-	case domain.VehicleVan:
-		vehicleCapacity = 10.0
-	case domain.VehicleCamion35:
-		vehicleCapacity = 20.0
-	case domain.VehicleTorton:
-		vehicleCapacity = 40.0
-	default:
-		vehicleCapacity = 10.0
-	}
-
-	loadingEfficiency := (totalVolumeM3 / vehicleCapacity) * 100
+	// HU-18: Calcular eficiencia de carga (peso y volumen)
+	utilization := capacity.Utilization(totalWeightKg, totalVolumeM3)
+	loadingEfficiency := math.Max(utilization.WeightPct, utilization.VolumePct)
 	if loadingEfficiency > 100 {
 		loadingEfficiency = 100
 	}
@@ -194,6 +238,7 @@ func (uc *CreateOrderUseCase) Execute(input CreateOrderInput) (*CreateOrderOutpu
 		EntityID:   &order.ID,
 		NewValues: map[string]interface{}{
 			"order_number":       orderNumber,
+			"total_weight_kg":    totalWeightKg,
 			"total_volume_m3":    totalVolumeM3,
 			"suggested_vehicle":  suggestedVehicle,
 			"loading_efficiency": loadingEfficiency,
@@ -202,11 +247,31 @@ func (uc *CreateOrderUseCase) Execute(input CreateOrderInput) (*CreateOrderOutpu
 	})
 
 	return &CreateOrderOutput{
-		Order:             order,
-		Lines:             orderLines,
-		SuggestedVehicle:  suggestedVehicle,
-		LoadingAlert:      loadingAlert,
-		LoadingEfficiency: loadingEfficiency,
-		BrandsMixed:       brands,
+		Order:              order,
+		Lines:              orderLines,
+		SuggestedVehicle:   suggestedVehicle,
+		SuggestedVehicleID: suggestedVehicleID,
+		LoadingAlert:       loadingAlert,
+		LoadingEfficiency:  loadingEfficiency,
+		Utilization:        utilization,
+		BrandsMixed:        brands,
 	}, nil
 }
+
+// suggestFleetVehicle busca el vehículo más chico donde cabe el pedido: primero entre los
+// disponibles y, si ninguno alcanza, en toda la flota activa (para sugerir el tipo)
+func (uc *CreateOrderUseCase) suggestFleetVehicle(order *domain.Order) *domain.Vehicle {
+	available, _ := uc.vehicleRepo.ListAvailable(nil)
+	if vehicle := order.SuggestVehicleFrom(available); vehicle != nil {
+		return vehicle
+	}
+
+	fleet, _ := uc.vehicleRepo.List(nil, 500, 0)
+	var active []*domain.Vehicle
+	for _, v := range fleet {
+		if v.IsActive {
+			active = append(active, v)
+		}
+	}
+	return order.SuggestVehicleFrom(active)
+}
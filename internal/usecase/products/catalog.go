@@ -0,0 +1,44 @@
+package products
+
+import (
+	"github.com/sgl-disasur/api/internal/domain"
+	"github.com/sgl-disasur/api/internal/infrastructure/export"
+)
+
+// CatalogColumns define el layout del catálogo; la importación y la exportación usan el mismo formato
+var CatalogColumns = []export.Column{
+	{Header: "SKU", Width: 12},
+	{Header: "Nombre", Width: 30},
+	{Header: "Marca", Width: 12},
+	{Header: "Categoría", Width: 14},
+	{Header: "Código de barras", Width: 16},
+	{Header: "Peso (kg)", Width: 9},
+	{Header: "Largo (cm)", Width: 9},
+	{Header: "Ancho (cm)", Width: 9},
+	{Header: "Alto (cm)", Width: 9},
+	{Header: "Frágil", Width: 7},
+	{Header: "Precio unitario", Width: 11},
+}
+
+// Índices de CatalogColumns
+const (
+	colSKU = iota
+	colName
+	colBrand
+	colCategory
+	colBarcode
+	colWeight
+	colLength
+	colWidth
+	colHeight
+	colFragile
+	colPrice
+)
+
+// CatalogRow convierte un producto en una fila del catálogo
+func CatalogRow(p *domain.Product) []interface{} {
+	return []interface{}{
+		p.SKU, p.Name, p.Brand, p.Category, p.Barcode, p.WeightKg,
+		p.LengthCm, p.WidthCm, p.HeightCm, p.IsFragile, p.UnitPrice,
+	}
+}
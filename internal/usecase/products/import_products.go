@@ -0,0 +1,321 @@
+package products
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sgl-disasur/api/internal/domain"
+)
+
+// ImportProductsUseCase carga listas de precios/catálogos de proveedores (upsert por SKU)
+type ImportProductsUseCase struct {
+	productRepo domain.ProductRepository
+	auditRepo   domain.AuditRepository
+}
+
+func NewImportProductsUseCase(
+	productRepo domain.ProductRepository,
+	auditRepo domain.AuditRepository,
+) *ImportProductsUseCase {
+	return &ImportProductsUseCase{
+		productRepo: productRepo,
+		auditRepo:   auditRepo,
+	}
+}
+
+type ImportProductsInput struct {
+	Rows          [][]string // Filas del archivo, la primera con encabezados
+	DecimalComma  bool       // CSV separado por ';': la coma separa decimales y el punto miles
+	DryRun        bool
+	EffectiveFrom *time.Time // Vigencia de los precios de la lista (default: ahora)
+	UserID        uuid.UUID
+}
+
+// ImportRowError describe un error de validación de una fila del archivo
+type ImportRowError struct {
+	Row     int    `json:"row"` // Número de fila en el archivo (1 = encabezados)
+	SKU     string `json:"sku,omitempty"`
+	Message string `json:"message"`
+}
+
+type ImportProductsOutput struct {
+	DryRun    bool             `json:"dry_run"`
+	Applied   bool             `json:"applied"`
+	TotalRows int              `json:"total_rows"`
+	Created   int              `json:"created"`
+	Updated   int              `json:"updated"`
+	Repriced  int              `json:"repriced"`
+	Errors    []ImportRowError `json:"errors"`
+}
+
+// importRow es una fila validada lista para aplicarse
+type importRow struct {
+	product  *domain.Product
+	existing bool
+	newPrice *float64 // Precio distinto al actual de un producto existente
+}
+
+// Execute valida todas las filas y, si no hay errores y no es dry-run, aplica el upsert.
+// La importación es todo o nada: con un solo error de fila no se modifica el catálogo, y las filas
+// válidas se guardan en una sola transacción.
+func (uc *ImportProductsUseCase) Execute(input ImportProductsInput) (*ImportProductsOutput, error) {
+	if len(input.Rows) < 2 {
+		return nil, errors.New("el archivo no contiene productos")
+	}
+
+	index, err := headerIndex(input.Rows[0])
+	if err != nil {
+		return nil, err
+	}
+
+	output := &ImportProductsOutput{DryRun: input.DryRun, Errors: []ImportRowError{}}
+	var valid []importRow
+	seenSKU := make(map[string]int)
+	seenBarcode := make(map[string]int)
+
+	for i, record := range input.Rows[1:] {
+		rowNumber := i + 2
+		if isBlank(record) {
+			continue
+		}
+		output.TotalRows++
+
+		row, rowErrs := uc.parseRow(record, index, input.DecimalComma)
+		sku := cell(record, index[colSKU])
+
+		if first, dup := seenSKU[sku]; dup && sku != "" {
+			rowErrs = append(rowErrs, fmt.Sprintf("SKU duplicado en el archivo (fila %d)", first))
+		} else {
+			seenSKU[sku] = rowNumber
+		}
+
+		if barcode := cell(record, index[colBarcode]); barcode != "" {
+			if first, dup := seenBarcode[barcode]; dup {
+				rowErrs = append(rowErrs, fmt.Sprintf("código de barras duplicado en el archivo (fila %d)", first))
+			} else {
+				seenBarcode[barcode] = rowNumber
+			}
+		}
+
+		for _, msg := range rowErrs {
+			output.Errors = append(output.Errors, ImportRowError{Row: rowNumber, SKU: sku, Message: msg})
+		}
+		if len(rowErrs) == 0 {
+			valid = append(valid, *row)
+			if row.existing {
+				output.Updated++
+			} else {
+				output.Created++
+			}
+			if row.newPrice != nil {
+				output.Repriced++
+			}
+		}
+	}
+
+	if input.DryRun || len(output.Errors) > 0 {
+		return output, nil
+	}
+
+	// Todas las filas se aplican en una sola transacción
+	items := make([]*domain.ProductImport, 0, len(valid))
+	for _, row := range valid {
+		item := &domain.ProductImport{Product: row.product, Existing: row.existing}
+		if row.newPrice != nil {
+			item.Price = applyPriceChange(row.product, *row.newPrice, input.EffectiveFrom, input.UserID)
+		}
+		items = append(items, item)
+	}
+	if err := uc.productRepo.Import(items); err != nil {
+		return nil, err
+	}
+	output.Applied = true
+
+	_ = uc.auditRepo.Log(domain.AuditLog{
+		UserID:     &input.UserID,
+		Action:     "IMPORT_PRODUCTS",
+		EntityType: "PRODUCT",
+		NewValues: map[string]interface{}{
+			"total_rows": output.TotalRows,
+			"created":    output.Created,
+			"updated":    output.Updated,
+			"repriced":   output.Repriced,
+		},
+	})
+
+	return output, nil
+}
+
+// parseRow valida una fila y la combina con el producto existente (si el SKU ya existe)
+func (uc *ImportProductsUseCase) parseRow(record []string, index []int, decimalComma bool) (*importRow, []string) {
+	var errs []string
+
+	sku := cell(record, index[colSKU])
+	if sku == "" {
+		return nil, []string{"SKU es obligatorio"}
+	}
+
+	product, err := uc.productRepo.FindBySKU(sku)
+	existing := err == nil
+	if !existing {
+		if !errors.Is(err, domain.ErrNotFound) {
+			return nil, []string{err.Error()}
+		}
+		product = &domain.Product{SKU: sku, IsActive: true}
+	}
+
+	// Columnas de texto: vacío conserva el valor actual
+	if name := cell(record, index[colName]); name != "" {
+		product.Name = name
+	} else if !existing {
+		errs = append(errs, "Nombre es obligatorio para productos nuevos")
+	}
+
+	if brand := cell(record, index[colBrand]); brand != "" {
+		b := domain.Brand(strings.ToUpper(brand))
+		if !b.IsValid() {
+			errs = append(errs, fmt.Sprintf("marca inválida: %s", brand))
+		}
+		product.Brand = b
+	} else if !existing {
+		errs = append(errs, "Marca es obligatoria para productos nuevos")
+	}
+
+	if category := cell(record, index[colCategory]); category != "" {
+		product.Category = category
+	}
+
+	if barcode := cell(record, index[colBarcode]); barcode != "" {
+		if other, err := uc.productRepo.FindByBarcode(barcode); err == nil && other.SKU != sku {
+			errs = append(errs, fmt.Sprintf("código de barras %s ya asignado al SKU %s", barcode, other.SKU))
+		}
+		product.Barcode = barcode
+	}
+
+	// Columnas numéricas; el precio de productos existentes pasa por el historial de precios
+	var price float64
+	numeric := []struct {
+		col    int
+		target *float64
+	}{
+		{colWeight, &product.WeightKg},
+		{colLength, &product.LengthCm},
+		{colWidth, &product.WidthCm},
+		{colHeight, &product.HeightCm},
+		{colPrice, &price},
+	}
+	for _, n := range numeric {
+		raw := cell(record, index[n.col])
+		if raw == "" {
+			continue
+		}
+		value, err := parseNumber(raw, decimalComma)
+		if err != nil || value < 0 {
+			errs = append(errs, fmt.Sprintf("%s inválido: %s", CatalogColumns[n.col].Header, raw))
+			continue
+		}
+		*n.target = value
+	}
+
+	row := &importRow{product: product, existing: existing}
+	if cell(record, index[colPrice]) != "" {
+		if existing && price != product.UnitPrice {
+			row.newPrice = &price
+		} else if !existing {
+			product.UnitPrice = price
+		}
+	}
+
+	if raw := cell(record, index[colFragile]); raw != "" {
+		fragile, err := parseBool(raw)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("Frágil inválido: %s (usar Sí/No)", raw))
+		}
+		product.IsFragile = fragile
+	}
+
+	return row, errs
+}
+
+// headerIndex ubica cada columna del catálogo por su encabezado (sin importar orden ni mayúsculas)
+func headerIndex(headers []string) ([]int, error) {
+	positions := make(map[string]int)
+	for i, h := range headers {
+		positions[normalizeHeader(h)] = i
+	}
+
+	index := make([]int, len(CatalogColumns))
+	for i, col := range CatalogColumns {
+		pos, ok := positions[normalizeHeader(col.Header)]
+		if !ok {
+			if i == colSKU {
+				return nil, errors.New("el archivo debe incluir la columna SKU")
+			}
+			pos = -1
+		}
+		index[i] = pos
+	}
+	return index, nil
+}
+
+func normalizeHeader(h string) string {
+	h = strings.ToLower(strings.TrimSpace(h))
+	replacer := strings.NewReplacer("á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u")
+	return replacer.Replace(h)
+}
+
+func cell(record []string, pos int) string {
+	if pos < 0 || pos >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[pos])
+}
+
+func isBlank(record []string) bool {
+	for _, v := range record {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+var (
+	// Punto decimal; la coma solo se acepta como separador de miles bien agrupado (1,234.50)
+	pointDecimal = regexp.MustCompile(`^-?(\d{1,3}(,\d{3})+|\d+)(\.\d+)?$`)
+	// Coma decimal; el punto solo se acepta como separador de miles bien agrupado (1.234,50)
+	commaDecimal = regexp.MustCompile(`^-?(\d{1,3}(\.\d{3})+|\d+)(,\d+)?$`)
+)
+
+// parseNumber interpreta montos con el separador decimal del archivo. Un valor que no sigue ese
+// formato (por ejemplo "1,5" con punto decimal) se rechaza en lugar de adivinar su magnitud.
+func parseNumber(raw string, decimalComma bool) (float64, error) {
+	raw = strings.NewReplacer("$", "", " ", "").Replace(raw)
+	if decimalComma {
+		if !commaDecimal.MatchString(raw) {
+			return 0, errors.New("número ambiguo o inválido")
+		}
+		raw = strings.NewReplacer(".", "", ",", ".").Replace(raw)
+	} else {
+		if !pointDecimal.MatchString(raw) {
+			return 0, errors.New("número ambiguo o inválido")
+		}
+		raw = strings.ReplaceAll(raw, ",", "")
+	}
+	return strconv.ParseFloat(raw, 64)
+}
+
+func parseBool(raw string) (bool, error) {
+	switch strings.ToLower(raw) {
+	case "si", "sí", "s", "true", "1", "x":
+		return true, nil
+	case "no", "n", "false", "0":
+		return false, nil
+	}
+	return false, errors.New("valor booleano inválido")
+}
@@ -0,0 +1,104 @@
+package products
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/sgl-disasur/api/internal/domain"
+)
+
+// SetPackagingUseCase configura los niveles de empaque (pieza, caja, tarima) de un producto
+type SetPackagingUseCase struct {
+	productRepo   domain.ProductRepository
+	packagingRepo domain.ProductPackagingRepository
+	auditRepo     domain.AuditRepository
+}
+
+func NewSetPackagingUseCase(
+	productRepo domain.ProductRepository,
+	packagingRepo domain.ProductPackagingRepository,
+	auditRepo domain.AuditRepository,
+) *SetPackagingUseCase {
+	return &SetPackagingUseCase{
+		productRepo:   productRepo,
+		packagingRepo: packagingRepo,
+		auditRepo:     auditRepo,
+	}
+}
+
+type PackagingLevelInput struct {
+	Unit            domain.UnitOfMeasure `json:"unit"`
+	UnitsPerPackage int                  `json:"units_per_package"` // Piezas por empaque
+	WeightKg        float64              `json:"weight_kg"`
+	LengthCm        float64              `json:"length_cm"`
+	WidthCm         float64              `json:"width_cm"`
+	HeightCm        float64              `json:"height_cm"`
+}
+
+type SetPackagingInput struct {
+	Levels    []PackagingLevelInput `json:"levels"`
+	ProductID uuid.UUID             `json:"-"`
+	UserID    uuid.UUID             `json:"-"`
+}
+
+// Execute reemplaza los niveles de empaque del producto
+func (uc *SetPackagingUseCase) Execute(input SetPackagingInput) ([]*domain.ProductPackaging, error) {
+	product, err := uc.productRepo.FindByID(input.ProductID)
+	if err != nil {
+		return nil, errors.New("producto no encontrado")
+	}
+
+	seen := make(map[domain.UnitOfMeasure]int)
+	var levels []*domain.ProductPackaging
+	for _, l := range input.Levels {
+		if !l.Unit.IsValid() {
+			return nil, fmt.Errorf("unidad inválida: %s. Use: PIEZA, CAJA, TARIMA", l.Unit)
+		}
+		if _, dup := seen[l.Unit]; dup {
+			return nil, fmt.Errorf("unidad %s duplicada", l.Unit)
+		}
+		if l.UnitsPerPackage < 1 {
+			return nil, fmt.Errorf("units_per_package de %s debe ser mayor a cero", l.Unit)
+		}
+		if l.Unit == domain.UnitPieza && l.UnitsPerPackage != 1 {
+			return nil, errors.New("la pieza es la unidad base: units_per_package debe ser 1")
+		}
+		if l.WeightKg < 0 || l.LengthCm < 0 || l.WidthCm < 0 || l.HeightCm < 0 {
+			return nil, errors.New("peso y dimensiones no pueden ser negativos")
+		}
+		seen[l.Unit] = l.UnitsPerPackage
+
+		levels = append(levels, &domain.ProductPackaging{
+			Unit:            l.Unit,
+			UnitsPerPackage: l.UnitsPerPackage,
+			WeightKg:        l.WeightKg,
+			LengthCm:        l.LengthCm,
+			WidthCm:         l.WidthCm,
+			HeightCm:        l.HeightCm,
+		})
+	}
+
+	// Una tarima contiene cajas completas
+	if box, ok := seen[domain.UnitCaja]; ok {
+		if pallet, ok := seen[domain.UnitTarima]; ok && (pallet <= box || pallet%box != 0) {
+			return nil, errors.New("las piezas por tarima deben ser múltiplo de las piezas por caja")
+		}
+	}
+
+	if err := uc.packagingRepo.ReplaceForProduct(product.ID, levels); err != nil {
+		return nil, err
+	}
+
+	_ = uc.auditRepo.Log(domain.AuditLog{
+		UserID:     &input.UserID,
+		Action:     "SET_PRODUCT_PACKAGING",
+		EntityType: "PRODUCT",
+		EntityID:   &product.ID,
+		NewValues: map[string]interface{}{
+			"levels": seen,
+		},
+	})
+
+	return levels, nil
+}
@@ -0,0 +1,216 @@
+package products
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sgl-disasur/api/internal/domain"
+)
+
+// UpdateProductUseCase actualiza datos del catálogo y registra cambios de precio con vigencia
+type UpdateProductUseCase struct {
+	productRepo domain.ProductRepository
+	auditRepo   domain.AuditRepository
+}
+
+func NewUpdateProductUseCase(
+	productRepo domain.ProductRepository,
+	auditRepo domain.AuditRepository,
+) *UpdateProductUseCase {
+	return &UpdateProductUseCase{
+		productRepo: productRepo,
+		auditRepo:   auditRepo,
+	}
+}
+
+// UpdateProductInput usa punteros para distinguir campos omitidos (PATCH) de valores vacíos.
+// El SKU no se puede modificar.
+type UpdateProductInput struct {
+	Name          *string       `json:"name,omitempty"`
+	Brand         *domain.Brand `json:"brand,omitempty"`
+	Category      *string       `json:"category,omitempty"`
+	Barcode       *string       `json:"barcode,omitempty"`
+	WeightKg      *float64      `json:"weight_kg,omitempty"`
+	LengthCm      *float64      `json:"length_cm,omitempty"`
+	WidthCm       *float64      `json:"width_cm,omitempty"`
+	HeightCm      *float64      `json:"height_cm,omitempty"`
+	IsFragile     *bool         `json:"is_fragile,omitempty"`
+	UnitPrice     *float64      `json:"unit_price,omitempty"`
+	IsActive      *bool         `json:"is_active,omitempty"`
+	EffectiveFrom *time.Time    `json:"effective_from,omitempty"` // Vigencia del nuevo precio (default: ahora)
+	ProductID     uuid.UUID     `json:"-"`
+	Replace       bool          `json:"-"` // PUT: exige los campos obligatorios
+	UserID        uuid.UUID     `json:"-"`
+}
+
+type UpdateProductOutput struct {
+	Product     *domain.Product      `json:"product"`
+	PriceChange *domain.ProductPrice `json:"price_change,omitempty"`
+}
+
+func (uc *UpdateProductUseCase) Execute(input UpdateProductInput) (*UpdateProductOutput, error) {
+	if input.Replace && (input.Name == nil || input.Brand == nil || input.UnitPrice == nil) {
+		return nil, errors.New("name, brand y unit_price son obligatorios")
+	}
+
+	// 1. Obtener producto
+	product, err := uc.productRepo.FindByID(input.ProductID)
+	if err != nil {
+		return nil, errors.New("producto no encontrado")
+	}
+	before := *product
+
+	// 2. Aplicar cambios
+	if input.Name != nil {
+		if *input.Name == "" {
+			return nil, errors.New("el nombre no puede estar vacío")
+		}
+		product.Name = *input.Name
+	}
+	if input.Brand != nil {
+		if !input.Brand.IsValid() {
+			return nil, errors.New("marca inválida")
+		}
+		product.Brand = *input.Brand
+	}
+	if input.Category != nil {
+		product.Category = *input.Category
+	}
+	if input.Barcode != nil && *input.Barcode != product.Barcode {
+		if other, err := uc.productRepo.FindByBarcode(*input.Barcode); err == nil && other.ID != product.ID {
+			return nil, errors.New("el código de barras ya está asignado a otro producto")
+		}
+		product.Barcode = *input.Barcode
+	}
+	for _, dim := range []struct {
+		value  *float64
+		target *float64
+	}{
+		{input.WeightKg, &product.WeightKg},
+		{input.LengthCm, &product.LengthCm},
+		{input.WidthCm, &product.WidthCm},
+		{input.HeightCm, &product.HeightCm},
+	} {
+		if dim.value == nil {
+			continue
+		}
+		if *dim.value < 0 {
+			return nil, errors.New("peso y dimensiones no pueden ser negativos")
+		}
+		*dim.target = *dim.value
+	}
+	if input.IsFragile != nil {
+		product.IsFragile = *input.IsFragile
+	}
+	if input.IsActive != nil {
+		product.IsActive = *input.IsActive
+	}
+
+	// 3. Cambio de precio con vigencia
+	var priceChange *domain.ProductPrice
+	if input.UnitPrice != nil && *input.UnitPrice != product.UnitPrice {
+		if *input.UnitPrice < 0 {
+			return nil, errors.New("el precio no puede ser negativo")
+		}
+		priceChange = applyPriceChange(product, *input.UnitPrice, input.EffectiveFrom, input.UserID)
+	}
+
+	// Producto e historial de precios se guardan en una sola transacción
+	if err := uc.productRepo.UpdateWithPrice(product, priceChange); err != nil {
+		return nil, err
+	}
+
+	// 4. Auditar
+	_ = uc.auditRepo.Log(domain.AuditLog{
+		UserID:     &input.UserID,
+		Action:     "UPDATE_PRODUCT",
+		EntityType: "PRODUCT",
+		EntityID:   &product.ID,
+		OldValues: map[string]interface{}{
+			"name":       before.Name,
+			"brand":      before.Brand,
+			"unit_price": before.UnitPrice,
+			"is_active":  before.IsActive,
+		},
+		NewValues: map[string]interface{}{
+			"name":       product.Name,
+			"brand":      product.Brand,
+			"unit_price": product.UnitPrice,
+			"is_active":  product.IsActive,
+		},
+	})
+
+	return &UpdateProductOutput{
+		Product:     product,
+		PriceChange: priceChange,
+	}, nil
+}
+
+// applyPriceChange arma el registro del historial para el nuevo precio. Si la vigencia ya inició,
+// también actualiza el precio de lista del producto (el llamador persiste ambos en una transacción).
+func applyPriceChange(product *domain.Product, newPrice float64, effectiveFrom *time.Time, userID uuid.UUID) *domain.ProductPrice {
+	price := newPriceChange(product, newPrice, effectiveFrom, userID)
+	if !price.EffectiveFrom.After(time.Now()) {
+		product.UnitPrice = newPrice
+	}
+	return price
+}
+
+// newPriceChange arma el registro del historial para el cambio de precio (vigente desde ahora si no se indica)
+func newPriceChange(product *domain.Product, newPrice float64, effectiveFrom *time.Time, userID uuid.UUID) *domain.ProductPrice {
+	effective := time.Now()
+	if effectiveFrom != nil {
+		effective = *effectiveFrom
+	}
+
+	previous := product.UnitPrice
+	return &domain.ProductPrice{
+		ProductID:     product.ID,
+		UnitPrice:     newPrice,
+		PreviousPrice: &previous,
+		EffectiveFrom: effective,
+		ChangedBy:     &userID,
+	}
+}
+
+// DeactivateProductUseCase da de baja (soft-delete) un producto del catálogo
+type DeactivateProductUseCase struct {
+	productRepo domain.ProductRepository
+	auditRepo   domain.AuditRepository
+}
+
+func NewDeactivateProductUseCase(
+	productRepo domain.ProductRepository,
+	auditRepo domain.AuditRepository,
+) *DeactivateProductUseCase {
+	return &DeactivateProductUseCase{
+		productRepo: productRepo,
+		auditRepo:   auditRepo,
+	}
+}
+
+func (uc *DeactivateProductUseCase) Execute(productID, userID uuid.UUID) error {
+	product, err := uc.productRepo.FindByID(productID)
+	if err != nil {
+		return errors.New("producto no encontrado")
+	}
+
+	// Se marca inactivo junto con el soft-delete para que los listados históricos lo reflejen
+	if err := uc.productRepo.Deactivate(product); err != nil {
+		return err
+	}
+
+	_ = uc.auditRepo.Log(domain.AuditLog{
+		UserID:     &userID,
+		Action:     "DELETE_PRODUCT",
+		EntityType: "PRODUCT",
+		EntityID:   &product.ID,
+		OldValues: map[string]interface{}{
+			"sku":  product.SKU,
+			"name": product.Name,
+		},
+	})
+
+	return nil
+}
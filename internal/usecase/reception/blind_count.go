@@ -2,6 +2,7 @@ package reception
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,6 +14,8 @@ type BlindCountUseCase struct {
 	receptionOrderRepo domain.ReceptionOrderRepository
 	receptionLineRepo  domain.ReceptionLineRepository
 	discrepancyRepo    domain.ReceptionDiscrepancyRepository
+	productRepo        domain.ProductRepository
+	packagingRepo      domain.ProductPackagingRepository
 	auditRepo          domain.AuditRepository
 }
 
@@ -20,12 +23,16 @@ func NewBlindCountUseCase(
 	receptionOrderRepo domain.ReceptionOrderRepository,
 	receptionLineRepo domain.ReceptionLineRepository,
 	discrepancyRepo domain.ReceptionDiscrepancyRepository,
+	productRepo domain.ProductRepository,
+	packagingRepo domain.ProductPackagingRepository,
 	auditRepo domain.AuditRepository,
 ) *BlindCountUseCase {
 	return &BlindCountUseCase{
 		receptionOrderRepo: receptionOrderRepo,
 		receptionLineRepo:  receptionLineRepo,
 		discrepancyRepo:    discrepancyRepo,
+		productRepo:        productRepo,
+		packagingRepo:      packagingRepo,
 		auditRepo:          auditRepo,
 	}
 }
@@ -33,6 +40,7 @@ func NewBlindCountUseCase(
 type BlindCountLineInput struct {
 	LineID          uuid.UUID               `json:"line_id"`
 	CountedQuantity int                     `json:"counted_quantity"`
+	Unit            domain.UnitOfMeasure    `json:"unit,omitempty"` // PIEZA (default), CAJA o TARIMA
 	Condition       domain.ProductCondition `json:"condition,omitempty"`
 }
 
@@ -40,6 +48,7 @@ type BlindCountInput struct {
 	ReceptionOrderID uuid.UUID             `json:"reception_order_id"`
 	Lines            []BlindCountLineInput `json:"lines"`
 	UserID           uuid.UUID             `json:"-"` // Del contexto (AUXILIAR o RECEPCIONISTA)
+	Scope            domain.DataScope      `json:"-"` // Alcance del usuario
 }
 
 func (uc *BlindCountUseCase) Execute(input BlindCountInput) error {
@@ -48,6 +57,13 @@ func (uc *BlindCountUseCase) Execute(input BlindCountInput) error {
 	if err != nil {
 		return errors.New("orden de recepción no encontrada")
 	}
+	visible, err := uc.receptionOrderRepo.Visible(order.ID, input.Scope)
+	if err != nil {
+		return err
+	}
+	if !visible {
+		return fmt.Errorf("%w: la orden %s está fuera de sus almacenes o marcas", domain.ErrForbidden, order.OrderNumber)
+	}
 
 	if order.Status != domain.ReceptionPendiente {
 		return errors.New("la orden ya fue contada o validada")
@@ -68,8 +84,23 @@ func (uc *BlindCountUseCase) Execute(input BlindCountInput) error {
 			return errors.New("línea no pertenece a esta orden")
 		}
 
+		// Convertir el conteo a piezas
+		counted := countInput.CountedQuantity
+		if countInput.Unit != "" && countInput.Unit != domain.UnitPieza {
+			product, err := uc.productRepo.FindByID(line.ProductID)
+			if err != nil {
+				return err
+			}
+			levels, _ := uc.packagingRepo.FindByProduct(product.ID)
+			packaging, err := product.FindPackaging(levels, countInput.Unit)
+			if err != nil {
+				return err
+			}
+			counted *= packaging.UnitsPerPackage
+		}
+
 		// Actualizar con el conteo
-		line.CountedQuantity = &countInput.CountedQuantity
+		line.CountedQuantity = &counted
 		line.CountedBy = &input.UserID
 		line.CountedAt = &now
 		if countInput.Condition != "" {
@@ -15,6 +15,8 @@ type CreateReceptionOrderUseCase struct {
 	receptionLineRepo  domain.ReceptionLineRepository
 	supplierRepo       domain.SupplierRepository
 	productRepo        domain.ProductRepository
+	packagingRepo      domain.ProductPackagingRepository
+	warehouseRepo      domain.WarehouseRepository
 	auditRepo          domain.AuditRepository
 }
 
@@ -23,6 +25,8 @@ func NewCreateReceptionOrderUseCase(
 	receptionLineRepo domain.ReceptionLineRepository,
 	supplierRepo domain.SupplierRepository,
 	productRepo domain.ProductRepository,
+	packagingRepo domain.ProductPackagingRepository,
+	warehouseRepo domain.WarehouseRepository,
 	auditRepo domain.AuditRepository,
 ) *CreateReceptionOrderUseCase {
 	return &CreateReceptionOrderUseCase{
@@ -30,15 +34,18 @@ func NewCreateReceptionOrderUseCase(
 		receptionLineRepo:  receptionLineRepo,
 		supplierRepo:       supplierRepo,
 		productRepo:        productRepo,
+		packagingRepo:      packagingRepo,
+		warehouseRepo:      warehouseRepo,
 		auditRepo:          auditRepo,
 	}
 }
 
 type ReceptionLineInput struct {
-	ProductID        uuid.UUID  `json:"product_id"`
-	ExpectedQuantity int        `json:"expected_quantity"`
-	LotNumber        string     `json:"lot_number"`
-	ExpirationDate   *time.Time `json:"expiration_date,omitempty"`
+	ProductID        uuid.UUID            `json:"product_id"`
+	ExpectedQuantity int                  `json:"expected_quantity"`
+	Unit             domain.UnitOfMeasure `json:"unit,omitempty"` // PIEZA (default), CAJA o TARIMA
+	LotNumber        string               `json:"lot_number"`
+	ExpirationDate   *time.Time           `json:"expiration_date,omitempty"`
 }
 
 type CreateReceptionOrderInput struct {
@@ -46,8 +53,10 @@ type CreateReceptionOrderInput struct {
 	InvoiceNumber  string               `json:"invoice_number"`
 	InvoiceFileURL string               `json:"invoice_file_url,omitempty"`
 	Notes          string               `json:"notes,omitempty"`
+	WarehouseID    *uuid.UUID           `json:"warehouse_id,omitempty"` // Almacén que recibe
 	Lines          []ReceptionLineInput `json:"lines"`
 	UserID         uuid.UUID            `json:"-"` // Del contexto
+	Scope          domain.DataScope     `json:"-"` // Alcance del usuario
 }
 
 func (uc *CreateReceptionOrderUseCase) Execute(input CreateReceptionOrderInput) (*domain.ReceptionOrder, error) {
@@ -61,6 +70,21 @@ func (uc *CreateReceptionOrderUseCase) Execute(input CreateReceptionOrderInput)
 	if len(input.Lines) == 0 {
 		return nil, errors.New("la orden debe tener al menos una línea")
 	}
+	// Quien no ve todo debe indicar un almacén que tenga asignado; si no, no vería la orden que crea
+	if input.WarehouseID == nil && !input.Scope.All {
+		return nil, errors.New("el almacén que recibe es obligatorio")
+	}
+	if input.WarehouseID != nil {
+		if !input.Scope.AllowsWarehouse(*input.WarehouseID) {
+			return nil, fmt.Errorf("%w: el almacén no está asignado al usuario", domain.ErrForbidden)
+		}
+		if _, err := uc.warehouseRepo.FindByID(*input.WarehouseID); err != nil {
+			return nil, err
+		}
+	}
+	if !input.Scope.AllowsBrand(supplier.Brand) {
+		return nil, fmt.Errorf("%w: la marca %s no está asignada al usuario", domain.ErrForbidden, supplier.Brand)
+	}
 
 	// 3. Generar número de orden único
 	orderNumber := fmt.Sprintf("REC-%s-%d", time.Now().Format("20060102"), time.Now().Unix()%10000)
@@ -74,6 +98,7 @@ func (uc *CreateReceptionOrderUseCase) Execute(input CreateReceptionOrderInput)
 		InvoiceFileURL: input.InvoiceFileURL,
 		Status:         domain.ReceptionPendiente,
 		Notes:          input.Notes,
+		WarehouseID:    input.WarehouseID,
 	}
 
 	if err := uc.receptionOrderRepo.Create(order); err != nil {
@@ -84,15 +109,22 @@ func (uc *CreateReceptionOrderUseCase) Execute(input CreateReceptionOrderInput)
 	var lines []*domain.ReceptionLine
 	for _, lineInput := range input.Lines {
 		// Verificar que el producto existe
-		_, err := uc.productRepo.FindByID(lineInput.ProductID)
+		product, err := uc.productRepo.FindByID(lineInput.ProductID)
 		if err != nil {
 			return nil, fmt.Errorf("producto %s no encontrado", lineInput.ProductID)
 		}
 
+		// Las cantidades se guardan en piezas
+		levels, _ := uc.packagingRepo.FindByProduct(product.ID)
+		packaging, err := product.FindPackaging(levels, lineInput.Unit)
+		if err != nil {
+			return nil, err
+		}
+
 		line := &domain.ReceptionLine{
 			ReceptionOrderID: order.ID,
 			ProductID:        lineInput.ProductID,
-			ExpectedQuantity: lineInput.ExpectedQuantity,
+			ExpectedQuantity: lineInput.ExpectedQuantity * packaging.UnitsPerPackage,
 			LotNumber:        lineInput.LotNumber,
 			ExpirationDate:   lineInput.ExpirationDate,
 			Condition:        domain.ConditionApto,
@@ -0,0 +1,15 @@
+-- Historial de precios de lista con fecha de vigencia
+CREATE TABLE IF NOT EXISTS product_price_history (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id     UUID NOT NULL REFERENCES products(id),
+    unit_price     NUMERIC(12, 2) NOT NULL CHECK (unit_price >= 0),
+    previous_price NUMERIC(12, 2),
+    effective_from TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    changed_by     UUID REFERENCES users(id),
+    created_at     TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_price_history_product
+    ON product_price_history (product_id, effective_from DESC);
+CREATE INDEX IF NOT EXISTS idx_product_price_history_effective
+    ON product_price_history (effective_from);
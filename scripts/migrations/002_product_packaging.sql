@@ -0,0 +1,14 @@
+-- Niveles de empaque por producto (pieza, caja, tarima) con factor de conversión a piezas
+CREATE TABLE IF NOT EXISTS product_packaging (
+    id                UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id        UUID NOT NULL REFERENCES products(id),
+    unit              VARCHAR(10) NOT NULL CHECK (unit IN ('PIEZA', 'CAJA', 'TARIMA')),
+    units_per_package INTEGER NOT NULL CHECK (units_per_package > 0),
+    weight_kg         NUMERIC(10, 3) NOT NULL DEFAULT 0,
+    length_cm         NUMERIC(10, 2) NOT NULL DEFAULT 0,
+    width_cm          NUMERIC(10, 2) NOT NULL DEFAULT 0,
+    height_cm         NUMERIC(10, 2) NOT NULL DEFAULT 0,
+    created_at        TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at        TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (product_id, unit)
+);
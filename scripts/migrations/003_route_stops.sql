@@ -0,0 +1,25 @@
+-- Coordenadas de clientes para secuenciar paradas
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS latitude NUMERIC(9, 6);
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS longitude NUMERIC(9, 6);
+
+-- Paradas de rutas con varios pedidos
+CREATE TABLE IF NOT EXISTS route_stops (
+    id                UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    route_id          UUID NOT NULL REFERENCES routes(id),
+    order_id          UUID NOT NULL REFERENCES orders(id),
+    customer_id       UUID NOT NULL REFERENCES customers(id),
+    sequence          INTEGER NOT NULL,
+    address           TEXT,
+    latitude          NUMERIC(9, 6),
+    longitude         NUMERIC(9, 6),
+    distance_km       NUMERIC(10, 2) NOT NULL DEFAULT 0,
+    estimated_arrival TIMESTAMP,
+    actual_arrival    TIMESTAMP,
+    status            VARCHAR(20) NOT NULL DEFAULT 'PENDIENTE',
+    created_at        TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at        TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (route_id, sequence)
+);
+
+CREATE INDEX IF NOT EXISTS idx_route_stops_route ON route_stops (route_id);
+CREATE INDEX IF NOT EXISTS idx_route_stops_order ON route_stops (order_id);
@@ -0,0 +1,4 @@
+-- Dimensiones interiores de la caja de carga para el plan de carga
+ALTER TABLE vehicles ADD COLUMN IF NOT EXISTS cargo_length_cm NUMERIC(10, 2) NOT NULL DEFAULT 0;
+ALTER TABLE vehicles ADD COLUMN IF NOT EXISTS cargo_width_cm NUMERIC(10, 2) NOT NULL DEFAULT 0;
+ALTER TABLE vehicles ADD COLUMN IF NOT EXISTS cargo_height_cm NUMERIC(10, 2) NOT NULL DEFAULT 0;
@@ -0,0 +1,21 @@
+-- Clase de licencia, turno y descanso semanal de choferes
+ALTER TABLE drivers ADD COLUMN IF NOT EXISTS license_class VARCHAR(10) NOT NULL DEFAULT 'LOCAL';
+ALTER TABLE drivers ADD COLUMN IF NOT EXISTS shift_start VARCHAR(5) NOT NULL DEFAULT '';
+ALTER TABLE drivers ADD COLUMN IF NOT EXISTS shift_end VARCHAR(5) NOT NULL DEFAULT '';
+ALTER TABLE drivers ADD COLUMN IF NOT EXISTS rest_days VARCHAR(20) NOT NULL DEFAULT '';
+
+-- Descansos y licencias por rango de fechas
+CREATE TABLE IF NOT EXISTS driver_absences (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    driver_id    UUID NOT NULL REFERENCES drivers(id),
+    absence_type VARCHAR(20) NOT NULL,
+    start_date   TIMESTAMP NOT NULL,
+    end_date     TIMESTAMP NOT NULL,
+    notes        TEXT NOT NULL DEFAULT '',
+    created_by   UUID NOT NULL REFERENCES users(id),
+    created_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (end_date >= start_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_driver_absences_driver ON driver_absences (driver_id, start_date);
+CREATE INDEX IF NOT EXISTS idx_routes_driver_departure ON routes (driver_id, departure_date);
@@ -0,0 +1,16 @@
+-- Documentos con vigencia de choferes y vehículos (licencia, certificado médico, póliza,
+-- tarjeta de circulación, verificación)
+CREATE TABLE IF NOT EXISTS fleet_documents (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    owner_type      VARCHAR(10) NOT NULL,
+    owner_id        UUID NOT NULL,
+    document_type   VARCHAR(30) NOT NULL,
+    document_number VARCHAR(50) NOT NULL DEFAULT '',
+    file_url        TEXT NOT NULL,
+    issue_date      TIMESTAMP,
+    expiry_date     TIMESTAMP NOT NULL,
+    uploaded_by     UUID NOT NULL REFERENCES users(id),
+    created_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_fleet_documents_owner ON fleet_documents (owner_type, owner_id, document_type, expiry_date DESC);
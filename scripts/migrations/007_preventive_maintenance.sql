@@ -0,0 +1,27 @@
+-- Odómetro y próximo servicio por kilometraje
+ALTER TABLE vehicles ADD COLUMN IF NOT EXISTS odometer_km NUMERIC(12, 1) NOT NULL DEFAULT 0;
+ALTER TABLE vehicles ADD COLUMN IF NOT EXISTS last_maintenance_km NUMERIC(12, 1) NOT NULL DEFAULT 0;
+ALTER TABLE vehicles ADD COLUMN IF NOT EXISTS next_maintenance_km NUMERIC(12, 1);
+ALTER TABLE vehicle_maintenance ADD COLUMN IF NOT EXISTS odometer_km NUMERIC(12, 1) NOT NULL DEFAULT 0;
+
+-- Planes de mantenimiento preventivo por tipo de vehículo (km o meses, lo que ocurra primero)
+CREATE TABLE IF NOT EXISTS maintenance_plans (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    vehicle_type    VARCHAR(20) NOT NULL UNIQUE,
+    interval_km     INTEGER NOT NULL DEFAULT 0,
+    interval_months INTEGER NOT NULL DEFAULT 0,
+    created_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Lecturas de odómetro
+CREATE TABLE IF NOT EXISTS odometer_readings (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    vehicle_id  UUID NOT NULL REFERENCES vehicles(id),
+    reading_km  NUMERIC(12, 1) NOT NULL,
+    source      VARCHAR(20) NOT NULL DEFAULT 'MANUAL',
+    recorded_by UUID NOT NULL REFERENCES users(id),
+    recorded_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_odometer_readings_vehicle ON odometer_readings (vehicle_id, recorded_at DESC);
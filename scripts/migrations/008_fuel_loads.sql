@@ -0,0 +1,21 @@
+-- Cargas de combustible con rendimiento y detección de anomalías
+CREATE TABLE IF NOT EXISTS fuel_loads (
+    id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    vehicle_id       UUID NOT NULL REFERENCES vehicles(id),
+    driver_id        UUID REFERENCES drivers(id),
+    liters           NUMERIC(10, 2) NOT NULL,
+    total_cost       NUMERIC(12, 2) NOT NULL,
+    odometer_km      NUMERIC(12, 1) NOT NULL,
+    station          VARCHAR(150) NOT NULL DEFAULT '',
+    ticket_photo_url TEXT NOT NULL DEFAULT '',
+    distance_km      NUMERIC(12, 1) NOT NULL DEFAULT 0,
+    efficiency_km_l  NUMERIC(8, 2),
+    is_anomaly       BOOLEAN NOT NULL DEFAULT false,
+    anomaly_reason   TEXT NOT NULL DEFAULT '',
+    loaded_at        TIMESTAMP NOT NULL,
+    recorded_by      UUID NOT NULL REFERENCES users(id),
+    created_at       TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_fuel_loads_vehicle ON fuel_loads (vehicle_id, odometer_km DESC);
+CREATE INDEX IF NOT EXISTS idx_fuel_loads_loaded_at ON fuel_loads (loaded_at);
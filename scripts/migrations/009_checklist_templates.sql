@@ -0,0 +1,48 @@
+-- Plantillas versionadas de check-list pre-salida por tipo de vehículo
+CREATE TABLE IF NOT EXISTS checklist_templates (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    vehicle_type VARCHAR(20) NOT NULL,
+    version      INTEGER NOT NULL,
+    name         VARCHAR(150) NOT NULL,
+    is_active    BOOLEAN NOT NULL DEFAULT true,
+    created_by   UUID REFERENCES users(id),
+    created_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (vehicle_type, version)
+);
+
+-- Una sola versión activa por tipo de vehículo
+CREATE UNIQUE INDEX IF NOT EXISTS idx_checklist_templates_active
+    ON checklist_templates (vehicle_type) WHERE is_active;
+
+CREATE TABLE IF NOT EXISTS checklist_template_items (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    template_id UUID NOT NULL REFERENCES checklist_templates(id) ON DELETE CASCADE,
+    sequence    INTEGER NOT NULL,
+    code        VARCHAR(50) NOT NULL,
+    label       VARCHAR(200) NOT NULL,
+    answer_type VARCHAR(10) NOT NULL CHECK (answer_type IN ('BOOLEANO', 'ESCALA', 'FOTO', 'NUMERO')),
+    required    BOOLEAN NOT NULL DEFAULT true,
+    blocking    BOOLEAN NOT NULL DEFAULT false,
+    min_value   NUMERIC(12, 2),
+    max_value   NUMERIC(12, 2),
+    UNIQUE (template_id, code)
+);
+
+-- Check-list con plantilla: versión usada, resultado y mantenimiento correctivo abierto
+ALTER TABLE pre_departure_checklist ADD COLUMN IF NOT EXISTS template_id UUID REFERENCES checklist_templates(id);
+ALTER TABLE pre_departure_checklist ADD COLUMN IF NOT EXISTS passed BOOLEAN NOT NULL DEFAULT true;
+ALTER TABLE pre_departure_checklist ADD COLUMN IF NOT EXISTS maintenance_id UUID REFERENCES vehicle_maintenance(id);
+
+CREATE TABLE IF NOT EXISTS checklist_answers (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    checklist_id   UUID NOT NULL REFERENCES pre_departure_checklist(id) ON DELETE CASCADE,
+    item_id        UUID NOT NULL REFERENCES checklist_template_items(id),
+    bool_value     BOOLEAN,
+    number_value   NUMERIC(12, 2),
+    photo_url      TEXT NOT NULL DEFAULT '',
+    passed         BOOLEAN NOT NULL,
+    failure_reason TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_checklist_answers_checklist ON checklist_answers (checklist_id);
+CREATE INDEX IF NOT EXISTS idx_pre_departure_checklist_route ON pre_departure_checklist (route_id, checked_at DESC);
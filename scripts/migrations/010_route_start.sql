@@ -0,0 +1,9 @@
+-- Salida real de la ruta registrada por el chofer
+ALTER TABLE routes ADD COLUMN IF NOT EXISTS actual_departure TIMESTAMP;
+ALTER TABLE routes ADD COLUMN IF NOT EXISTS departure_odometer_km NUMERIC(12, 1);
+
+-- Vehículo revisado en el check-list pre-salida
+ALTER TABLE pre_departure_checklist ADD COLUMN IF NOT EXISTS vehicle_id UUID REFERENCES vehicles(id);
+UPDATE pre_departure_checklist c SET vehicle_id = r.vehicle_id
+FROM routes r WHERE r.id = c.route_id AND c.vehicle_id IS NULL;
+ALTER TABLE pre_departure_checklist ALTER COLUMN vehicle_id SET NOT NULL;
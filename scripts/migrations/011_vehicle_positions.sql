@@ -0,0 +1,16 @@
+-- Posiciones GPS de los vehículos durante la ruta
+CREATE TABLE IF NOT EXISTS vehicle_positions (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    route_id    UUID NOT NULL REFERENCES routes(id),
+    vehicle_id  UUID NOT NULL REFERENCES vehicles(id),
+    latitude    DOUBLE PRECISION NOT NULL,
+    longitude   DOUBLE PRECISION NOT NULL,
+    speed_kmh   DOUBLE PRECISION,
+    heading     DOUBLE PRECISION,
+    accuracy_m  DOUBLE PRECISION,
+    source      VARCHAR(20) NOT NULL DEFAULT 'TELEFONO',
+    recorded_at TIMESTAMP NOT NULL,
+    received_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_vehicle_positions_route ON vehicle_positions (route_id, recorded_at DESC);
@@ -0,0 +1,3 @@
+-- Última actividad de la sesión para el vencimiento por inactividad (SESSION_TIMEOUT_MINUTES)
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS last_activity_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+CREATE INDEX IF NOT EXISTS idx_sessions_last_activity ON sessions(last_activity_at);
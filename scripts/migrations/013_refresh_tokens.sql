@@ -0,0 +1,12 @@
+-- Refresh tokens con rotación: cada login inicia una familia de sesiones y cada renovación agrega una
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS family_id UUID;
+UPDATE sessions SET family_id = id WHERE family_id IS NULL;
+ALTER TABLE sessions ALTER COLUMN family_id SET NOT NULL;
+
+-- Solo se guarda el hash SHA-256 del refresh token
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS refresh_token_hash VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS refresh_expires_at TIMESTAMP;
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS rotated_at TIMESTAMP;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sessions_refresh_token_hash ON sessions(refresh_token_hash) WHERE refresh_token_hash <> '';
+CREATE INDEX IF NOT EXISTS idx_sessions_family ON sessions(family_id);
@@ -0,0 +1,16 @@
+-- Vigencia de la contraseña y cambio obligatorio (alta por administrador o restablecimiento)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS password_changed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS must_change_password BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Sesión limitada a cambiar la contraseña
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS password_change_required BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Contraseñas anteriores (hash bcrypt) para impedir su reutilización
+CREATE TABLE IF NOT EXISTS password_history (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id       UUID NOT NULL REFERENCES users(id),
+    password_hash VARCHAR(255) NOT NULL,
+    created_at    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_history_user ON password_history(user_id, created_at DESC);
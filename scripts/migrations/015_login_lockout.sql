@@ -0,0 +1,14 @@
+-- Bloqueo temporal con enfriamiento exponencial
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS lockout_count INTEGER NOT NULL DEFAULT 0;
+
+-- Intentos de login por IP, incluidos los de usuarios inexistentes
+CREATE TABLE IF NOT EXISTS login_attempts (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    username   VARCHAR(100) NOT NULL DEFAULT '',
+    ip_address VARCHAR(45) NOT NULL,
+    success    BOOLEAN NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_attempts_ip ON login_attempts(ip_address, created_at);
@@ -0,0 +1,18 @@
+-- Verificación en dos pasos (TOTP, RFC 6238); el secreto se guarda cifrado
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_secret TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_last_step BIGINT NOT NULL DEFAULT 0;
+
+-- Sesión limitada a configurar 2FA (roles que la requieren)
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS totp_enrollment_required BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Códigos de recuperación de un solo uso (hash SHA-256)
+CREATE TABLE IF NOT EXISTS totp_recovery_codes (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id    UUID NOT NULL REFERENCES users(id),
+    code_hash  VARCHAR(64) NOT NULL,
+    used_at    TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_totp_recovery_codes_user ON totp_recovery_codes(user_id);
@@ -0,0 +1,72 @@
+-- Permisos por rol (HU-19); los edita ADMIN_TI en PUT /api/v1/roles/{role}/permissions
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role       VARCHAR(50) NOT NULL,
+    permission VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (role, permission)
+);
+
+-- Asignación inicial: equivale a las listas de roles que tenía cada ruta
+INSERT INTO role_permissions (role, permission) VALUES
+    ('ADMIN_TI', 'users.read'),
+    ('GERENTE', 'users.read'),
+    ('ADMIN_TI', 'users.manage'),
+    ('ADMIN_TI', 'roles.manage'),
+    ('ADMIN_TI', 'products.manage'),
+    ('JEFE_ALMACEN', 'products.manage'),
+    ('ADMIN_TI', 'products.import'),
+    ('JEFE_ALMACEN', 'products.import'),
+    ('JEFE_ALMACEN', 'reception.order.create'),
+    ('SUPERVISOR', 'reception.order.create'),
+    ('AUXILIAR', 'reception.blind_count.perform'),
+    ('RECEPCIONISTA', 'reception.blind_count.perform'),
+    ('RECEPCIONISTA', 'reception.return.create'),
+    ('JEFE_ALMACEN', 'reception.return.create'),
+    ('ADMIN_TI', 'reception.return.create'),
+    ('JEFE_ALMACEN', 'inventory.damage.create'),
+    ('SUPERVISOR', 'inventory.damage.create'),
+    ('JEFE_ALMACEN', 'inventory.cycle_count.generate'),
+    ('AUXILIAR', 'inventory.cycle_count.perform'),
+    ('MONTACARGUISTA', 'inventory.cycle_count.perform'),
+    ('VENDEDOR', 'orders.create'),
+    ('JEFE_TRAFICO', 'orders.create'),
+    ('ADMIN_TI', 'customers.create'),
+    ('JEFE_TRAFICO', 'customers.create'),
+    ('VENDEDOR', 'customers.create'),
+    ('FLOTA', 'fleet.maintenance.manage'),
+    ('JEFE_TRAFICO', 'fleet.maintenance.manage'),
+    ('FLOTA', 'fleet.maintenance_plan.manage'),
+    ('ADMIN_TI', 'fleet.maintenance_plan.manage'),
+    ('FLOTA', 'fleet.odometer.record'),
+    ('JEFE_TRAFICO', 'fleet.odometer.record'),
+    ('CHOFER', 'fleet.odometer.record'),
+    ('FLOTA', 'fleet.fuel.record'),
+    ('JEFE_TRAFICO', 'fleet.fuel.record'),
+    ('CHOFER', 'fleet.fuel.record'),
+    ('FLOTA', 'fleet.document.create'),
+    ('JEFE_TRAFICO', 'fleet.document.create'),
+    ('FLOTA', 'fleet.document.alerts'),
+    ('JEFE_TRAFICO', 'fleet.document.alerts'),
+    ('GERENTE', 'fleet.document.alerts'),
+    ('JEFE_TRAFICO', 'fleet.driver.availability'),
+    ('FLOTA', 'fleet.driver.availability'),
+    ('JEFE_TRAFICO', 'fleet.driver.schedule'),
+    ('ADMIN_TI', 'fleet.driver.schedule'),
+    ('JEFE_TRAFICO', 'fleet.route.assign'),
+    ('JEFE_TRAFICO', 'fleet.route.load_plan'),
+    ('JEFE_ALMACEN', 'fleet.route.load_plan'),
+    ('CARGADOR', 'fleet.route.load_plan'),
+    ('MONTACARGUISTA', 'fleet.route.load_plan'),
+    ('JEFE_TRAFICO', 'fleet.route.invoice'),
+    ('VENDEDOR', 'fleet.route.invoice'),
+    ('CHOFER', 'fleet.route.depart'),
+    ('CHOFER', 'fleet.tracking.record'),
+    ('FLOTA', 'fleet.tracking.record'),
+    ('JEFE_TRAFICO', 'fleet.tracking.view'),
+    ('FLOTA', 'fleet.tracking.view'),
+    ('GERENTE', 'fleet.tracking.view'),
+    ('FLOTA', 'fleet.checklist_template.manage'),
+    ('ADMIN_TI', 'fleet.checklist_template.manage'),
+    ('GERENTE', 'reports.view'),
+    ('ADMIN_TI', 'reports.view')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,76 @@
+-- Alcance de datos por almacén y marca (HU-19); ADMIN_TI asigna en PUT /api/v1/users/{id}/assignments
+CREATE TABLE IF NOT EXISTS warehouses (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code       VARCHAR(20) NOT NULL UNIQUE,
+    name       VARCHAR(200) NOT NULL,
+    is_active  BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Almacén principal: recibe los lotes cuya ubicación no corresponde a ningún almacén dado de alta
+INSERT INTO warehouses (code, name) VALUES ('PRINCIPAL', 'Almacén principal')
+ON CONFLICT (code) DO NOTHING;
+
+-- Todo lote pertenece a un almacén; el existente se asigna por el código con que empieza su ubicación
+-- (p. ej. CEDIS-MTY-A-01; gana el código más largo) y el resto al almacén principal
+ALTER TABLE inventory ADD COLUMN IF NOT EXISTS warehouse_id UUID REFERENCES warehouses(id);
+UPDATE inventory i
+SET warehouse_id = m.warehouse_id
+FROM (
+    SELECT DISTINCT ON (inv.id) inv.id, w.id AS warehouse_id
+    FROM inventory inv
+    JOIN warehouses w ON UPPER(TRIM(inv.warehouse_location)) = UPPER(w.code)
+                      OR UPPER(TRIM(inv.warehouse_location)) LIKE UPPER(w.code) || '-%'
+    WHERE inv.warehouse_id IS NULL
+    ORDER BY inv.id, LENGTH(w.code) DESC
+) m
+WHERE m.id = i.id;
+UPDATE inventory
+SET warehouse_id = (SELECT id FROM warehouses WHERE code = 'PRINCIPAL')
+WHERE warehouse_id IS NULL;
+ALTER TABLE inventory ALTER COLUMN warehouse_id SET NOT NULL;
+
+-- Las órdenes de recepción sin almacén solo las ven los usuarios sin almacenes asignados
+ALTER TABLE reception_orders ADD COLUMN IF NOT EXISTS warehouse_id UUID REFERENCES warehouses(id);
+CREATE INDEX IF NOT EXISTS idx_inventory_warehouse ON inventory(warehouse_id);
+CREATE INDEX IF NOT EXISTS idx_reception_orders_warehouse ON reception_orders(warehouse_id);
+
+-- Vendedor responsable del cliente
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS assigned_to UUID REFERENCES users(id);
+CREATE INDEX IF NOT EXISTS idx_customers_assigned_to ON customers(assigned_to);
+
+-- Cada cliente existente queda con el vendedor que más pedidos le ha capturado (el más reciente en empate);
+-- los que no tienen pedidos de un vendedor se asignan con PUT /api/v1/customers/{id}/assignment
+UPDATE customers cu
+SET assigned_to = s.created_by
+FROM (
+    SELECT DISTINCT ON (o.customer_id) o.customer_id, o.created_by
+    FROM orders o
+    JOIN users u ON u.id = o.created_by
+    WHERE u.role = 'VENDEDOR' AND o.deleted_at IS NULL
+    GROUP BY o.customer_id, o.created_by
+    ORDER BY o.customer_id, COUNT(*) DESC, MAX(o.created_at) DESC
+) s
+WHERE s.customer_id = cu.id AND cu.assigned_to IS NULL;
+
+CREATE TABLE IF NOT EXISTS user_warehouses (
+    user_id      UUID NOT NULL REFERENCES users(id),
+    warehouse_id UUID NOT NULL REFERENCES warehouses(id),
+    PRIMARY KEY (user_id, warehouse_id)
+);
+
+CREATE TABLE IF NOT EXISTS user_brands (
+    user_id UUID NOT NULL REFERENCES users(id),
+    brand   VARCHAR(50) NOT NULL,
+    PRIMARY KEY (user_id, brand)
+);
+
+-- Roles que ven todos los registros sin importar sus asignaciones
+INSERT INTO role_permissions (role, permission) VALUES
+    ('ADMIN_TI', 'data.all'),
+    ('GERENTE', 'data.all'),
+    ('AUDITOR', 'data.all'),
+    ('ADMIN_TI', 'customers.assign'),
+    ('GERENTE', 'customers.assign'),
+    ('JEFE_TRAFICO', 'customers.assign')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,9 @@
+-- Las fechas de sesión se guardan con zona horaria: la API escribe su hora local y al leer una
+-- columna TIMESTAMP el driver la interpreta como UTC, corriendo la expiración y la inactividad.
+-- Los valores existentes se interpretan en la zona horaria de la base (la misma que la API).
+ALTER TABLE sessions
+    ALTER COLUMN expires_at         TYPE TIMESTAMPTZ USING expires_at         AT TIME ZONE current_setting('TimeZone'),
+    ALTER COLUMN created_at         TYPE TIMESTAMPTZ USING created_at         AT TIME ZONE current_setting('TimeZone'),
+    ALTER COLUMN last_activity_at   TYPE TIMESTAMPTZ USING last_activity_at   AT TIME ZONE current_setting('TimeZone'),
+    ALTER COLUMN refresh_expires_at TYPE TIMESTAMPTZ USING refresh_expires_at AT TIME ZONE current_setting('TimeZone'),
+    ALTER COLUMN rotated_at         TYPE TIMESTAMPTZ USING rotated_at         AT TIME ZONE current_setting('TimeZone');
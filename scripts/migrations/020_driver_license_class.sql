@@ -0,0 +1,14 @@
+-- La clase de licencia usa los mismos valores que el tipo de ruta (LOCAL/FORANEA)
+UPDATE drivers SET license_class = 'FORANEA' WHERE license_class = 'FEDERAL';
+
+-- 005 dejó a todos los choferes en LOCAL: quien ya manejó rutas foráneas conserva ese alcance.
+-- El resto se reclasifica con PUT /api/v1/fleet/drivers/{id}/schedule.
+UPDATE drivers d
+SET license_class = 'FORANEA'
+WHERE d.license_class = 'LOCAL'
+  AND EXISTS (
+      SELECT 1 FROM routes r
+      WHERE r.driver_id = d.id
+        AND r.route_type = 'FORANEA'
+        AND r.status <> 'CANCELADO'
+  );
@@ -0,0 +1,9 @@
+-- Lectura de escáneres (POST /api/v1/inventory/scan): roles que cuentan, reciben y surten en almacén
+INSERT INTO role_permissions (role, permission) VALUES
+    ('JEFE_ALMACEN', 'inventory.scan'),
+    ('SUPERVISOR', 'inventory.scan'),
+    ('AUXILIAR', 'inventory.scan'),
+    ('RECEPCIONISTA', 'inventory.scan'),
+    ('MONTACARGUISTA', 'inventory.scan'),
+    ('CARGADOR', 'inventory.scan')
+ON CONFLICT DO NOTHING;
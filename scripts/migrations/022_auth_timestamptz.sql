@@ -0,0 +1,10 @@
+-- Las fechas de bloqueo, vigencia de contraseña e intentos de login se guardan con zona horaria,
+-- igual que las de sesión (019): con TIMESTAMP el driver lee la hora local de la API como UTC,
+-- corriendo el fin del bloqueo, la expiración de la contraseña y la ventana de intentos por IP.
+-- Los valores existentes se interpretan en la zona horaria de la base (la misma que la API).
+ALTER TABLE users
+    ALTER COLUMN locked_until        TYPE TIMESTAMPTZ USING locked_until        AT TIME ZONE current_setting('TimeZone'),
+    ALTER COLUMN password_changed_at TYPE TIMESTAMPTZ USING password_changed_at AT TIME ZONE current_setting('TimeZone');
+
+ALTER TABLE login_attempts
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE current_setting('TimeZone');
@@ -1,5 +1,6 @@
 # Test completo de TODOS los endpoints de la API SGL-DISASUR
 # Ejecutar: powershell -ExecutionPolicy Bypass -File test_all_endpoints.ps1
+# Requiere la API con TOTP_REQUIRED_ROLES vacío (el login del admin no pasa por 2FA)
 
 $baseUrl = "http://localhost:8080"
 $ErrorActionPreference = "Continue"
@@ -46,7 +47,7 @@ $token = $null
 Test-Endpoint "Login admin" {
     $loginBody = @{
         username = "admin"
-        password = "password123"
+        password = "Disasur.2024"
     } | ConvertTo-Json
     
     $loginResponse = Invoke-RestMethod -Uri "$baseUrl/api/v1/auth/login" -Method Post -Body $loginBody -ContentType "application/json"
@@ -73,7 +74,7 @@ Test-Endpoint "Register new user" {
         role = "AUXILIAR"
     } | ConvertTo-Json
     
-    $result = Invoke-RestMethod -Uri "$baseUrl/api/v1/auth/register" -Method Post -Body $newUser -ContentType "application/json"
+    $result = Invoke-RestMethod -Uri "$baseUrl/api/v1/users" -Method Post -Body $newUser -Headers $headers
     Write-Host "[OK] Usuario creado: $($result.user.username)" -ForegroundColor Green
 }
 